@@ -0,0 +1,438 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioanalysis
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const dataplanePackagePath = "github.com/v3io/v3io-go/pkg/dataplane"
+
+// Analyzer flags common misuse of the v3io dataplane API
+var Analyzer = &analysis.Analyzer{
+	Name: "v3io",
+	Doc: `check for common misuse of the v3io dataplane API
+
+Reports:
+- responses which are never released
+- PutItemsOutput whose per item Errors are never checked
+- unchecked type assertions of the Output of asynchronous responses
+- asynchronous requests whose response channel is never received from`,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(node ast.Node) {
+		funcDecl := node.(*ast.FuncDecl)
+		if funcDecl.Body == nil {
+			return
+		}
+
+		newFunctionChecker(pass, funcDecl.Body).check()
+	})
+
+	return nil, nil
+}
+
+// checks a single function body (including the closures in it)
+type functionChecker struct {
+	pass    *analysis.Pass
+	body    *ast.BlockStmt
+	parents map[ast.Node]ast.Node
+
+	// uses of each variable in the body
+	uses map[types.Object][]*ast.Ident
+
+	// variables holding responses received from a channel
+	receivedResponses map[types.Object]bool
+}
+
+func newFunctionChecker(pass *analysis.Pass, body *ast.BlockStmt) *functionChecker {
+	fc := functionChecker{
+		pass:              pass,
+		body:              body,
+		parents:           map[ast.Node]ast.Node{},
+		uses:              map[types.Object][]*ast.Ident{},
+		receivedResponses: map[types.Object]bool{},
+	}
+
+	var stack []ast.Node
+	ast.Inspect(body, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		if len(stack) > 0 {
+			fc.parents[node] = stack[len(stack)-1]
+		}
+
+		stack = append(stack, node)
+
+		if ident, isIdent := node.(*ast.Ident); isIdent {
+			if object := pass.TypesInfo.Uses[ident]; object != nil {
+				fc.uses[object] = append(fc.uses[object], ident)
+			}
+		}
+
+		return true
+	})
+
+	return &fc
+}
+
+func (fc *functionChecker) check() {
+	ast.Inspect(fc.body, func(node ast.Node) bool {
+		switch typedNode := node.(type) {
+		case *ast.AssignStmt:
+			fc.recordReceivedResponses(typedNode.Lhs, typedNode.Rhs)
+			fc.checkResponseResults(typedNode.Lhs, typedNode.Rhs)
+		case *ast.ValueSpec:
+			var lhs []ast.Expr
+			for _, name := range typedNode.Names {
+				lhs = append(lhs, name)
+			}
+
+			fc.checkResponseResults(lhs, typedNode.Values)
+		case *ast.RangeStmt:
+			if rangeType := fc.pass.TypesInfo.TypeOf(typedNode.X); rangeType != nil {
+				if _, isChan := rangeType.Underlying().(*types.Chan); isChan {
+					fc.recordReceivedResponse(typedNode.Key)
+				}
+			}
+		case *ast.ExprStmt:
+			if call, isCall := typedNode.X.(*ast.CallExpr); isCall && fc.responseResultIndex(call) != -1 {
+				fc.pass.Reportf(call.Pos(), "response returned by %s is discarded without being released",
+					fc.calleeName(call))
+			}
+		case *ast.TypeAssertExpr:
+			fc.checkPutItemsOutput(typedNode)
+			fc.checkOutputTypeAssertion(typedNode)
+		case *ast.CallExpr:
+			fc.checkAsyncCall(typedNode)
+		}
+
+		return true
+	})
+}
+
+// reports responses which are discarded or never released
+func (fc *functionChecker) checkResponseResults(lhs []ast.Expr, rhs []ast.Expr) {
+	if len(rhs) != 1 {
+		return
+	}
+
+	call, isCall := rhs[0].(*ast.CallExpr)
+	if !isCall {
+		return
+	}
+
+	resultIndex := fc.responseResultIndex(call)
+	if resultIndex == -1 || resultIndex >= len(lhs) {
+		return
+	}
+
+	ident, isIdent := lhs[resultIndex].(*ast.Ident)
+	if !isIdent {
+		return
+	}
+
+	if ident.Name == "_" {
+		fc.pass.Reportf(ident.Pos(), "response returned by %s is discarded without being released",
+			fc.calleeName(call))
+		return
+	}
+
+	object := fc.objectOf(ident)
+	if object == nil {
+		return
+	}
+
+	for _, use := range fc.uses[object] {
+		if fc.isReleased(use) || fc.escapes(use) {
+			return
+		}
+	}
+
+	fc.pass.Reportf(ident.Pos(), "response returned by %s is never released", fc.calleeName(call))
+}
+
+// reports PutItemsOutputs whose Errors (or Success, or Err()) are never checked
+func (fc *functionChecker) checkPutItemsOutput(typeAssert *ast.TypeAssertExpr) {
+	if typeAssert.Type == nil || !isDataplaneType(fc.pass.TypesInfo.TypeOf(typeAssert.Type), "PutItemsOutput") {
+		return
+	}
+
+	if isResultChecked(fc.parents[typeAssert]) {
+		return
+	}
+
+	assign, isAssign := fc.parents[typeAssert].(*ast.AssignStmt)
+	if !isAssign || len(assign.Lhs) == 0 {
+		return
+	}
+
+	ident, isIdent := assign.Lhs[0].(*ast.Ident)
+	if !isIdent || ident.Name == "_" {
+		return
+	}
+
+	object := fc.objectOf(ident)
+	if object == nil {
+		return
+	}
+
+	for _, use := range fc.uses[object] {
+		if isResultChecked(fc.parents[use]) || fc.escapes(use) {
+			return
+		}
+	}
+
+	fc.pass.Reportf(typeAssert.Pos(), "PutItemsOutput.Errors is never checked - items may have failed")
+}
+
+// reports single value type assertions of the Output of responses received from a channel, whose
+// type depends on the request
+func (fc *functionChecker) checkOutputTypeAssertion(typeAssert *ast.TypeAssertExpr) {
+	if typeAssert.Type == nil || fc.isCommaOk(typeAssert) {
+		return
+	}
+
+	selector, isSelector := typeAssert.X.(*ast.SelectorExpr)
+	if !isSelector || selector.Sel.Name != "Output" {
+		return
+	}
+
+	ident, isIdent := selector.X.(*ast.Ident)
+	if !isIdent || !fc.receivedResponses[fc.objectOf(ident)] {
+		return
+	}
+
+	fc.pass.Reportf(typeAssert.Pos(),
+		"unchecked type assertion of an asynchronous response's Output - use the comma-ok form")
+}
+
+// reports asynchronous requests whose response channel is never received from
+func (fc *functionChecker) checkAsyncCall(call *ast.CallExpr) {
+	if !fc.isAsyncCall(call) {
+		return
+	}
+
+	responseChan := call.Args[len(call.Args)-1]
+
+	switch typedResponseChan := responseChan.(type) {
+	case *ast.CallExpr:
+		fc.pass.Reportf(responseChan.Pos(), "responses of %s are sent to a channel which is never received from",
+			fc.calleeName(call))
+	case *ast.Ident:
+		object := fc.objectOf(typedResponseChan)
+		if object == nil || object.Parent() == nil || object.Parent() == fc.pass.Pkg.Scope() {
+			return
+		}
+
+		// parameters and channels defined outside the function may be drained elsewhere
+		if !fc.isDefinedInBody(object) {
+			return
+		}
+
+		for _, use := range fc.uses[object] {
+			if fc.isReceivedFrom(use) {
+				return
+			}
+
+			if parentCall, isParentCall := fc.parents[use].(*ast.CallExpr); isParentCall && fc.isAsyncCall(parentCall) {
+				continue
+			}
+
+			if fc.escapes(use) {
+				return
+			}
+		}
+
+		fc.pass.Reportf(responseChan.Pos(), "responses of %s are sent to a channel which is never received from",
+			fc.calleeName(call))
+	}
+}
+
+func (fc *functionChecker) recordReceivedResponses(lhs []ast.Expr, rhs []ast.Expr) {
+	if len(rhs) != 1 || len(lhs) == 0 {
+		return
+	}
+
+	if unary, isUnary := rhs[0].(*ast.UnaryExpr); isUnary && unary.Op == token.ARROW {
+		fc.recordReceivedResponse(lhs[0])
+	}
+}
+
+func (fc *functionChecker) recordReceivedResponse(expr ast.Expr) {
+	ident, isIdent := expr.(*ast.Ident)
+	if !isIdent {
+		return
+	}
+
+	object := fc.objectOf(ident)
+	if object != nil && isDataplaneType(object.Type(), "Response") {
+		fc.receivedResponses[object] = true
+	}
+}
+
+// returns the index of the *v3io.Response result of a call, or -1 if it has none
+func (fc *functionChecker) responseResultIndex(call *ast.CallExpr) int {
+	switch typedResult := fc.pass.TypesInfo.TypeOf(call).(type) {
+	case *types.Tuple:
+		for resultIndex := 0; resultIndex < typedResult.Len(); resultIndex++ {
+			if isDataplaneType(typedResult.At(resultIndex).Type(), "Response") {
+				return resultIndex
+			}
+		}
+	case nil:
+	default:
+		if isDataplaneType(typedResult, "Response") {
+			return 0
+		}
+	}
+
+	return -1
+}
+
+// returns whether a call is an asynchronous request, i.e. returns (*v3io.Request, error) and takes a
+// response channel as its last argument
+func (fc *functionChecker) isAsyncCall(call *ast.CallExpr) bool {
+	signature, isSignature := fc.pass.TypesInfo.TypeOf(call.Fun).(*types.Signature)
+	if !isSignature || signature.Params().Len() == 0 || len(call.Args) != signature.Params().Len() {
+		return false
+	}
+
+	if signature.Results().Len() != 2 || !isDataplaneType(signature.Results().At(0).Type(), "Request") {
+		return false
+	}
+
+	chanType, isChan := signature.Params().At(signature.Params().Len() - 1).Type().(*types.Chan)
+
+	return isChan && isDataplaneType(chanType.Elem(), "Response")
+}
+
+func (fc *functionChecker) isReleased(use *ast.Ident) bool {
+	selector, isSelector := fc.parents[use].(*ast.SelectorExpr)
+	return isSelector && selector.Sel.Name == "Release"
+}
+
+func (fc *functionChecker) isReceivedFrom(use *ast.Ident) bool {
+	switch typedParent := fc.parents[use].(type) {
+	case *ast.UnaryExpr:
+		return typedParent.Op == token.ARROW
+	case *ast.RangeStmt:
+		return typedParent.X == use
+	}
+
+	return false
+}
+
+// returns whether a use of a variable hands it to code we can't follow
+func (fc *functionChecker) escapes(use *ast.Ident) bool {
+	switch typedParent := fc.parents[use].(type) {
+	case *ast.ReturnStmt, *ast.CompositeLit, *ast.KeyValueExpr, *ast.SendStmt:
+		return true
+	case *ast.CallExpr:
+		return typedParent.Fun != use
+	case *ast.AssignStmt:
+		for _, rhs := range typedParent.Rhs {
+			if rhs == use {
+				return true
+			}
+		}
+	case *ast.ValueSpec:
+		for _, value := range typedParent.Values {
+			if value == use {
+				return true
+			}
+		}
+	case *ast.UnaryExpr:
+		return typedParent.Op == token.AND
+	}
+
+	return false
+}
+
+func (fc *functionChecker) isCommaOk(typeAssert *ast.TypeAssertExpr) bool {
+	switch typedParent := fc.parents[typeAssert].(type) {
+	case *ast.AssignStmt:
+		return len(typedParent.Lhs) == 2
+	case *ast.ValueSpec:
+		return len(typedParent.Names) == 2
+	}
+
+	return false
+}
+
+func (fc *functionChecker) isDefinedInBody(object types.Object) bool {
+	return object.Pos() >= fc.body.Pos() && object.Pos() < fc.body.End()
+}
+
+func (fc *functionChecker) objectOf(ident *ast.Ident) types.Object {
+	return fc.pass.TypesInfo.ObjectOf(ident)
+}
+
+func (fc *functionChecker) calleeName(call *ast.CallExpr) string {
+	switch typedFun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		return typedFun.Sel.Name
+	case *ast.Ident:
+		return typedFun.Name
+	default:
+		return "call"
+	}
+}
+
+// returns whether a node selects the Errors or Success fields (or the Err method) of a PutItemsOutput
+func isResultChecked(node ast.Node) bool {
+	selector, isSelector := node.(*ast.SelectorExpr)
+	return isSelector && (selector.Sel.Name == "Errors" || selector.Sel.Name == "Success" || selector.Sel.Name == "Err")
+}
+
+// returns whether a type is (a pointer to) the named type of the dataplane package
+func isDataplaneType(typ types.Type, name string) bool {
+	if typ == nil {
+		return false
+	}
+
+	if pointer, isPointer := typ.(*types.Pointer); isPointer {
+		typ = pointer.Elem()
+	}
+
+	named, isNamed := typ.(*types.Named)
+	if !isNamed {
+		return false
+	}
+
+	object := named.Obj()
+
+	return object.Pkg() != nil && object.Pkg().Path() == dataplanePackagePath && object.Name() == name
+}
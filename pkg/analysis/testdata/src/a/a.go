@@ -0,0 +1,89 @@
+package a
+
+import (
+	"github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+func released(container v3io.Container) {
+	response, err := container.GetItemSync(nil)
+	if err != nil {
+		return
+	}
+
+	defer response.Release()
+}
+
+func returned(container v3io.Container) (*v3io.Response, error) {
+	response, err := container.GetItemSync(nil)
+	return response, err
+}
+
+func notReleased(container v3io.Container) {
+	response, _ := container.GetItemSync(nil) // want `response returned by GetItemSync is never released`
+	_ = response.Output
+}
+
+func discarded(container v3io.Container) {
+	_, _ = container.GetItemSync(nil) // want `response returned by GetItemSync is discarded without being released`
+	container.GetItemSync(nil)        // want `response returned by GetItemSync is discarded without being released`
+}
+
+func putItemsChecked(container v3io.Container) {
+	response, _ := container.PutItemsSync(nil)
+	defer response.Release()
+
+	output := response.Output.(*v3io.PutItemsOutput)
+	if !output.Success {
+		return
+	}
+}
+
+func putItemsErrChecked(container v3io.Container) error {
+	response, _ := container.PutItemsSync(nil)
+	defer response.Release()
+
+	output := response.Output.(*v3io.PutItemsOutput)
+	return output.Err()
+}
+
+func putItemsNotChecked(container v3io.Container) {
+	response, _ := container.PutItemsSync(nil)
+	defer response.Release()
+
+	output := response.Output.(*v3io.PutItemsOutput) // want `PutItemsOutput.Errors is never checked`
+	if output == nil {
+		return
+	}
+}
+
+func asyncDrained(container v3io.Container) {
+	responseChan := make(chan *v3io.Response, 1)
+	container.GetItem(nil, nil, responseChan) // nolint: errcheck
+
+	response := <-responseChan
+	if output, ok := response.Output.(*v3io.GetItemOutput); ok {
+		_ = output
+	}
+
+	response.Release()
+}
+
+func asyncUncheckedOutput(container v3io.Container) {
+	responseChan := make(chan *v3io.Response, 1)
+	container.GetItem(nil, nil, responseChan) // nolint: errcheck
+
+	for response := range responseChan {
+		_ = response.Output.(*v3io.GetItemOutput) // want `unchecked type assertion`
+		response.Release()
+	}
+}
+
+func asyncNotDrained(container v3io.Container) {
+	responseChan := make(chan *v3io.Response, 1)
+	container.GetItem(nil, nil, responseChan)                 // want `responses of GetItem are sent to a channel which is never received from`
+	container.GetItem(nil, nil, make(chan *v3io.Response, 1)) // want `responses of GetItem are sent to a channel which is never received from`
+}
+
+func asyncDrainedElsewhere(container v3io.Container, responseChan chan *v3io.Response) {
+	container.GetItem(nil, nil, responseChan)
+}
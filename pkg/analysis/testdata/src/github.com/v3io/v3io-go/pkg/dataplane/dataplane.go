@@ -0,0 +1,27 @@
+// a minimal stand in for the dataplane package
+package v3io
+
+type Request struct {
+	ID uint64
+}
+
+type Response struct {
+	Output interface{}
+}
+
+func (r *Response) Release() {}
+
+type PutItemsOutput struct {
+	Success bool
+	Errors  map[string]error
+}
+
+func (o *PutItemsOutput) Err() error { return nil }
+
+type GetItemOutput struct{}
+
+type Container interface {
+	GetItem(input interface{}, context interface{}, responseChan chan *Response) (*Request, error)
+	GetItemSync(input interface{}) (*Response, error)
+	PutItemsSync(input interface{}) (*Response, error)
+}
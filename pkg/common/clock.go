@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package common
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of time, so that tests can control it. it is a subset of
+// github.com/benbjohnson/clock's Clock, so clocks of that package may be used as well
+type Clock interface {
+	Now() time.Time
+	Since(time.Time) time.Duration
+	After(time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// NewClock returns a clock backed by the time package
+func NewClock() Clock {
+	return &realClock{}
+}
+
+func (rc *realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (rc *realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (rc *realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// MockClock is a clock whose time only moves when told to, firing the timers which expire on the way
+type MockClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*mockTimer
+}
+
+type mockTimer struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// NewMockClock returns a mock clock set to the given time
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+func (mc *MockClock) Now() time.Time {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	return mc.now
+}
+
+func (mc *MockClock) Since(t time.Time) time.Duration {
+	return mc.Now().Sub(t)
+}
+
+func (mc *MockClock) After(d time.Duration) <-chan time.Time {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	timer := &mockTimer{
+		deadline: mc.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		timer.c <- mc.now
+		return timer.c
+	}
+
+	mc.timers = append(mc.timers, timer)
+
+	return timer.c
+}
+
+// Add moves the clock forward, firing the timers which expire by the new time in order of expiry
+func (mc *MockClock) Add(d time.Duration) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	mc.now = mc.now.Add(d)
+
+	sort.SliceStable(mc.timers, func(i, j int) bool {
+		return mc.timers[i].deadline.Before(mc.timers[j].deadline)
+	})
+
+	var pendingTimers []*mockTimer
+	for _, timer := range mc.timers {
+		if timer.deadline.After(mc.now) {
+			pendingTimers = append(pendingTimers, timer)
+			continue
+		}
+
+		timer.c <- mc.now
+	}
+
+	mc.timers = pendingTimers
+}
+
+// NumTimers returns the number of timers which haven't fired yet (including those no longer waited on),
+// letting tests wait for goroutines to start waiting before moving the clock
+func (mc *MockClock) NumTimers() int {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	return len(mc.timers)
+}
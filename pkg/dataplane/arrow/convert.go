@@ -0,0 +1,279 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioarrow
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+// InferSchema returns a field per attribute of the items, the item name first and the rest in order of their
+// names. ints and floats of the same attribute are widened to floats, other mixed types fail the inference
+func InferSchema(items []v3io.Item) (*Schema, error) {
+	fieldIndexes := map[string]int{}
+	numItemsWithField := map[string]int{}
+	var fields []Field
+
+	for _, item := range items {
+		for attributeName, attributeValue := range item {
+			if attributeValue == nil {
+				continue
+			}
+
+			attributeType, err := getValueType(attributeValue)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to infer the type of attribute %s", attributeName)
+			}
+
+			numItemsWithField[attributeName]++
+
+			fieldIdx, found := fieldIndexes[attributeName]
+			if !found {
+				fieldIndexes[attributeName] = len(fields)
+				fields = append(fields, Field{Name: attributeName, Type: attributeType})
+				continue
+			}
+
+			fieldType, err := widenType(fields[fieldIdx].Type, attributeType)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to infer the type of attribute %s", attributeName)
+			}
+
+			fields[fieldIdx].Type = fieldType
+		}
+	}
+
+	for fieldIdx := range fields {
+		fields[fieldIdx].Nullable = numItemsWithField[fields[fieldIdx].Name] < len(items)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Name == "__name" || fields[j].Name == "__name" {
+			return fields[i].Name == "__name"
+		}
+
+		return fields[i].Name < fields[j].Name
+	})
+
+	return &Schema{Fields: fields}, nil
+}
+
+// NewRecordBatch converts the items to a record batch with the schema. attributes which aren't in the schema
+// are ignored
+func NewRecordBatch(schema *Schema, items []v3io.Item) (*RecordBatch, error) {
+	recordBatch := RecordBatch{
+		Schema:  schema,
+		NumRows: len(items),
+		Columns: make([]*Column, len(schema.Fields)),
+	}
+
+	for fieldIdx, field := range schema.Fields {
+		column, err := newColumn(field, items)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to convert attribute %s", field.Name)
+		}
+
+		recordBatch.Columns[fieldIdx] = column
+	}
+
+	return &recordBatch, nil
+}
+
+// ConvertGetItemsOutput converts a page of a scan to a record batch. the schema is inferred from the page if
+// nil, so pass the schema of the first page when converting the following ones to get batches of one schema
+func ConvertGetItemsOutput(getItemsOutput *v3io.GetItemsOutput, schema *Schema) (*RecordBatch, error) {
+	if schema == nil {
+		var err error
+
+		schema, err = InferSchema(getItemsOutput.Items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewRecordBatch(schema, getItemsOutput.Items)
+}
+
+func newColumn(field Field, items []v3io.Item) (*Column, error) {
+	column := Column{
+		Field:  field,
+		Length: len(items),
+	}
+
+	validity := make([]byte, (len(items)+7)/8)
+
+	switch field.Type {
+	case TypeInt64, TypeFloat64, TypeTimestamp:
+		column.Values = make([]byte, 8*len(items))
+	case TypeBoolean:
+		column.Values = make([]byte, (len(items)+7)/8)
+	case TypeUTF8, TypeBinary:
+		column.Offsets = make([]byte, 4*(len(items)+1))
+	}
+
+	for itemIdx, item := range items {
+		value := item[field.Name]
+		if value == nil {
+			column.NullCount++
+		} else {
+			validity[itemIdx/8] |= 1 << uint(itemIdx%8)
+
+			if err := column.setValue(itemIdx, value); err != nil {
+				return nil, errors.Wrapf(err, "Failed to convert the value of item %d", itemIdx)
+			}
+		}
+
+		// variable length values end where the next one starts, or where the previous one ended if null
+		if column.Offsets != nil {
+			binary.LittleEndian.PutUint32(column.Offsets[4*(itemIdx+1):], uint32(len(column.Values)))
+		}
+	}
+
+	if column.NullCount > 0 {
+		if !field.Nullable {
+			return nil, errors.Errorf("%d items have no value for non nullable attribute", column.NullCount)
+		}
+
+		column.Validity = validity
+	}
+
+	return &column, nil
+}
+
+func (c *Column) setValue(itemIdx int, value interface{}) error {
+	valueType, err := getValueType(value)
+	if err != nil {
+		return err
+	}
+
+	if widenedType, err := widenType(c.Type, valueType); err != nil || widenedType != c.Type {
+		return errors.Errorf("Expected %s, got %T", c.Type, value)
+	}
+
+	switch c.Type {
+	case TypeInt64:
+		intValue, _ := toInt64(value)
+		binary.LittleEndian.PutUint64(c.Values[8*itemIdx:], uint64(intValue))
+	case TypeFloat64:
+		floatValue, isFloat := value.(float64)
+		if !isFloat {
+			intValue, _ := toInt64(value)
+			floatValue = float64(intValue)
+		}
+
+		binary.LittleEndian.PutUint64(c.Values[8*itemIdx:], math.Float64bits(floatValue))
+	case TypeTimestamp:
+		binary.LittleEndian.PutUint64(c.Values[8*itemIdx:], uint64(value.(time.Time).UnixNano()))
+	case TypeBoolean:
+		if value.(bool) {
+			c.Values[itemIdx/8] |= 1 << uint(itemIdx%8)
+		}
+	case TypeUTF8:
+		c.Values = append(c.Values, value.(string)...)
+	case TypeBinary:
+		c.Values = append(c.Values, value.([]byte)...)
+	}
+
+	return nil
+}
+
+// IsNull returns whether the value at the index is null
+func (c *Column) IsNull(idx int) bool {
+	return c.Validity != nil && c.Validity[idx/8]&(1<<uint(idx%8)) == 0
+}
+
+// Int64 returns the value at the index of an int64 or timestamp column
+func (c *Column) Int64(idx int) int64 {
+	return int64(binary.LittleEndian.Uint64(c.Values[8*idx:]))
+}
+
+// Float64 returns the value at the index of a float64 column
+func (c *Column) Float64(idx int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(c.Values[8*idx:]))
+}
+
+// Bool returns the value at the index of a boolean column
+func (c *Column) Bool(idx int) bool {
+	return c.Values[idx/8]&(1<<uint(idx%8)) != 0
+}
+
+// Bytes returns the value at the index of a UTF8 or binary column, referencing the column's values
+func (c *Column) Bytes(idx int) []byte {
+	start := binary.LittleEndian.Uint32(c.Offsets[4*idx:])
+	end := binary.LittleEndian.Uint32(c.Offsets[4*(idx+1):])
+
+	return c.Values[start:end:end]
+}
+
+func getValueType(value interface{}) (Type, error) {
+	switch value.(type) {
+	case int, int64, int32, uint32, uint64:
+		return TypeInt64, nil
+	case float64:
+		return TypeFloat64, nil
+	case bool:
+		return TypeBoolean, nil
+	case string:
+		return TypeUTF8, nil
+	case []byte:
+		return TypeBinary, nil
+	case time.Time:
+		return TypeTimestamp, nil
+	default:
+		return 0, errors.Errorf("Unsupported attribute type %T", value)
+	}
+}
+
+// returns the type holding values of both types
+func widenType(fieldType Type, valueType Type) (Type, error) {
+	if fieldType == valueType {
+		return fieldType, nil
+	}
+
+	if (fieldType == TypeInt64 && valueType == TypeFloat64) || (fieldType == TypeFloat64 && valueType == TypeInt64) {
+		return TypeFloat64, nil
+	}
+
+	return 0, errors.Errorf("Mixed types %s and %s", fieldType, valueType)
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch typedValue := value.(type) {
+	case int:
+		return int64(typedValue), true
+	case int64:
+		return typedValue, true
+	case int32:
+		return int64(typedValue), true
+	case uint32:
+		return int64(typedValue), true
+	case uint64:
+		return int64(typedValue), true
+	default:
+		return 0, false
+	}
+}
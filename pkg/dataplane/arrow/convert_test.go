@@ -0,0 +1,115 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioarrow
+
+import (
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type convertSuite struct {
+	suite.Suite
+}
+
+func (suite *convertSuite) TestConvertGetItemsOutput() {
+	timestamp := time.Unix(100, 5)
+
+	getItemsOutput := v3io.GetItemsOutput{
+		Items: []v3io.Item{
+			{"__name": "a", "count": 1, "score": 1, "ok": true, "blob": []byte("x"), "time": timestamp},
+			{"__name": "b", "count": 2, "score": 2.5, "ok": false},
+			{"__name": "c", "count": 3, "ok": true, "blob": []byte("yz")},
+		},
+	}
+
+	recordBatch, err := ConvertGetItemsOutput(&getItemsOutput, nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, recordBatch.NumRows)
+
+	suite.Require().Equal([]Field{
+		{Name: "__name", Type: TypeUTF8},
+		{Name: "blob", Type: TypeBinary, Nullable: true},
+		{Name: "count", Type: TypeInt64},
+		{Name: "ok", Type: TypeBoolean},
+		{Name: "score", Type: TypeFloat64, Nullable: true},
+		{Name: "time", Type: TypeTimestamp, Nullable: true},
+	}, recordBatch.Schema.Fields)
+
+	names, blobs, counts, oks, scores, times := recordBatch.Columns[0], recordBatch.Columns[1],
+		recordBatch.Columns[2], recordBatch.Columns[3], recordBatch.Columns[4], recordBatch.Columns[5]
+
+	suite.Require().Nil(names.Validity)
+	suite.Require().Equal("abc", string(names.Values))
+	suite.Require().Equal("b", string(names.Bytes(1)))
+
+	suite.Require().Equal(1, blobs.NullCount)
+	suite.Require().Equal([]byte{0x5}, blobs.Validity)
+	suite.Require().Equal("x", string(blobs.Bytes(0)))
+	suite.Require().True(blobs.IsNull(1))
+	suite.Require().Empty(blobs.Bytes(1))
+	suite.Require().Equal("yz", string(blobs.Bytes(2)))
+
+	suite.Require().Equal(int64(3), counts.Int64(2))
+
+	suite.Require().True(oks.Bool(0))
+	suite.Require().False(oks.Bool(1))
+	suite.Require().True(oks.Bool(2))
+
+	// ints and floats are widened to floats
+	suite.Require().Equal(1.0, scores.Float64(0))
+	suite.Require().Equal(2.5, scores.Float64(1))
+	suite.Require().True(scores.IsNull(2))
+
+	suite.Require().Equal(timestamp.UnixNano(), times.Int64(0))
+	suite.Require().Equal(2, times.NullCount)
+
+	// the following pages are converted with the schema of the first
+	recordBatch, err = ConvertGetItemsOutput(&v3io.GetItemsOutput{
+		Items: []v3io.Item{{"__name": "d", "count": 4, "ok": false, "other": 1}},
+	}, recordBatch.Schema)
+	suite.Require().NoError(err)
+	suite.Require().Len(recordBatch.Columns, 6)
+	suite.Require().True(recordBatch.Columns[4].IsNull(0))
+
+	// but fail on values which don't fit it
+	_, err = ConvertGetItemsOutput(&v3io.GetItemsOutput{
+		Items: []v3io.Item{{"__name": "e", "count": 1.5, "ok": false}},
+	}, recordBatch.Schema)
+	suite.Require().Error(err)
+
+	_, err = ConvertGetItemsOutput(&v3io.GetItemsOutput{
+		Items: []v3io.Item{{"__name": "e", "count": 1}},
+	}, recordBatch.Schema)
+	suite.Require().Error(err)
+}
+
+func (suite *convertSuite) TestInferMixedTypes() {
+	_, err := InferSchema([]v3io.Item{{"value": 1}, {"value": "1"}})
+	suite.Require().Error(err)
+}
+
+func TestConvertSuite(t *testing.T) {
+	suite.Run(t, new(convertSuite))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+// Package v3ioarrow converts scan results to columnar record batches laid out as Apache Arrow arrays. the
+// buffers of each column follow the Arrow columnar format, so that they can be wrapped by Arrow arrays (e.g.
+// with array.NewData and memory.NewBufferBytes) without copying, without v3io-go depending on Arrow
+package v3ioarrow
+
+import (
+	"fmt"
+)
+
+// Type is the Arrow type of a column
+type Type int
+
+const (
+	TypeInt64     Type = iota // int attributes
+	TypeFloat64               // float attributes, and number attributes holding both ints and floats
+	TypeBoolean               // bool attributes
+	TypeUTF8                  // string attributes
+	TypeBinary                // blob attributes
+	TypeTimestamp             // time attributes, as nanoseconds since the epoch in UTC
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeInt64:
+		return "int64"
+	case TypeFloat64:
+		return "float64"
+	case TypeBoolean:
+		return "bool"
+	case TypeUTF8:
+		return "utf8"
+	case TypeBinary:
+		return "binary"
+	case TypeTimestamp:
+		return "timestamp[ns, tz=UTC]"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool // set if some of the items don't have the attribute
+}
+
+type Schema struct {
+	Fields []Field
+}
+
+// Column is an Arrow array of a record batch
+type Column struct {
+	Field
+	Length    int
+	NullCount int
+
+	// a bit per value, least significant bit first, set for values which aren't null. nil if there are none
+	Validity []byte
+
+	// Length + 1 little endian int32 offsets into Values, for UTF8 and binary columns
+	Offsets []byte
+
+	// little endian 64 bit values, a bit per boolean value or the concatenated UTF8 and binary values
+	Values []byte
+}
+
+// RecordBatch is a page of items as columns, one per field of the schema
+type RecordBatch struct {
+	Schema  *Schema
+	NumRows int
+	Columns []*Column
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// Capabilities describes which optional APIs a backend supports. operations of unsupported APIs fail
+// with v3ioerrors.ErrNotSupported
+type Capabilities struct {
+	Streams    bool
+	Chunks     bool // PutChunk
+	OOSObjects bool // PutOOSObject and GetOOSObject
+
+	// whether GetItems responses can be encoded with capnp rather than json (see
+	// GetItemsInput.RequestJSONResponse)
+	CapnpGetItems bool
+}
+
+// ProbeCapabilities checks which optional APIs the backend of the container supports, by describing a
+// stream which doesn't exist. chunk and OOS operations can't be probed without writing, and are reported
+// as supported (see Context.Capabilities for what was learned from attempting them)
+func ProbeCapabilities(container Container) (*Capabilities, error) {
+	capabilities := Capabilities{
+		Chunks:        true,
+		OOSObjects:    true,
+		CapnpGetItems: true,
+	}
+
+	response, err := container.DescribeStreamSync(&DescribeStreamInput{
+		Path: fmt.Sprintf(".v3io-probe-%d/", time.Now().UnixNano()),
+	})
+
+	switch {
+	case err == nil:
+		response.Release()
+		capabilities.Streams = true
+	case errors.Cause(err) == v3ioerrors.ErrNotSupported:
+		capabilities.Streams = false
+	default:
+		statusCode, errHasStatusCode := v3ioerrors.GetStatusCode(err)
+		if !errHasStatusCode || v3ioerrors.IsAuth(err) {
+			return nil, errors.Wrap(err, "Failed to probe streams API")
+		}
+
+		// a not found means the streams API reported that the stream doesn't exist
+		capabilities.Streams = statusCode == http.StatusNotFound
+	}
+
+	return &capabilities, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type describeStreamContainer struct {
+	Container
+	err error
+}
+
+func (dsc *describeStreamContainer) DescribeStreamSync(*DescribeStreamInput) (*Response, error) {
+	return nil, dsc.err
+}
+
+type capabilitiesSuite struct {
+	suite.Suite
+}
+
+func (suite *capabilitiesSuite) TestProbe() {
+	for _, testCase := range []struct {
+		name            string
+		err             error
+		expectedStreams bool
+		expectedErr     bool
+	}{
+		{
+			name:            "stream not found",
+			err:             v3ioerrors.NewErrorWithStatusCode(errors.New("not found"), 404),
+			expectedStreams: true,
+		},
+		{
+			name: "not supported",
+			err:  errors.Wrap(v3ioerrors.ErrNotSupported, "DescribeStream is not supported"),
+		},
+		{
+			name: "bad request",
+			err:  v3ioerrors.NewErrorWithStatusCode(errors.New("bad request"), 400),
+		},
+		{
+			name:        "unauthorized",
+			err:         v3ioerrors.NewErrorWithStatusCode(errors.New("unauthorized"), 401),
+			expectedErr: true,
+		},
+		{
+			name:        "connection error",
+			err:         errors.New("connection refused"),
+			expectedErr: true,
+		},
+	} {
+		capabilities, err := ProbeCapabilities(&describeStreamContainer{err: testCase.err})
+		if testCase.expectedErr {
+			suite.Require().Error(err, testCase.name)
+			continue
+		}
+
+		suite.Require().NoError(err, testCase.name)
+		suite.Require().Equal(testCase.expectedStreams, capabilities.Streams, testCase.name)
+	}
+}
+
+func TestCapabilitiesSuite(t *testing.T) {
+	suite.Run(t, new(capabilitiesSuite))
+}
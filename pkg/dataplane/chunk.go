@@ -0,0 +1,257 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// GetItemChunksInput addresses a stream shard, as <stream path>/<shard ID>
+type GetItemChunksInput struct {
+	DataPlaneInput
+	Path string
+}
+
+type GetItemChunksOutput struct {
+
+	// the shard's chunks by sequence number. the data of each chunk is sorted by offset
+	Chunks map[int]*ItemChunk
+
+	// the metadata of the chunk currently written to
+	CurrentChunkMetadata *ItemCurrentChunkMetadata
+}
+
+// PutChunkFromReaderInput writes the data read from Reader to a chunk, starting at Offset. the data is written
+// in pieces of up to PieceSize bytes and the metadata, if any, is written along with the last piece
+type PutChunkFromReaderInput struct {
+	DataPlaneInput
+	Path                 string
+	ChunkSeqNumber       int
+	Offset               uint64
+	Reader               io.Reader
+	PieceSize            int
+	ChunksMetadata       []*ChunkMetadata
+	CurrentChunkMetadata *CurrentChunkMetadata
+}
+
+type GetChunkInput struct {
+	DataPlaneInput
+	Path           string
+	ChunkSeqNumber int
+}
+
+const defaultPutChunkPieceSize = 512 * 1024
+
+// GetItemChunks reads the chunks of a stream shard - their metadata and data - so that they can be inspected,
+// compacted or written back with PutChunk. all of the shard's data is read, so shards should be read one at a time
+func GetItemChunks(container Container, getItemChunksInput *GetItemChunksInput) (*GetItemChunksOutput, error) {
+	shardPath := path.Clean(getItemChunksInput.Path)
+
+	getItemsInput := GetItemsInput{
+		DataPlaneInput:     getItemChunksInput.DataPlaneInput,
+		Path:               path.Dir(shardPath) + "/",
+		Filter:             fmt.Sprintf("__name == '%s'", path.Base(shardPath)),
+		AttributeNames:     []string{"**"},
+		AllowObjectScatter: ScatterAllowed,
+		ReturnData:         ReturnDataEnabled,
+	}
+
+	itemsCursor, err := NewItemsCursor(container, &getItemsInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create items cursor")
+	}
+
+	defer itemsCursor.Release()
+
+	items, err := itemsCursor.AllSync()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get shard items")
+	}
+
+	if len(items) == 0 {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.Wrapf(v3ioerrors.ErrNotFound, "Shard not found: %s", shardPath),
+			http.StatusNotFound)
+	}
+
+	getItemChunksOutput := GetItemChunksOutput{
+		Chunks: map[int]*ItemChunk{},
+	}
+
+	// large shards are scattered across several items, each holding some of the chunks' attributes
+	for _, item := range items {
+		chunks, currentChunkMetadata, err := item.GetShard()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode shard chunks")
+		}
+
+		for chunkSeqNumber, chunk := range chunks {
+			existingChunk, found := getItemChunksOutput.Chunks[chunkSeqNumber]
+			if !found {
+				existingChunk = &ItemChunk{}
+				getItemChunksOutput.Chunks[chunkSeqNumber] = existingChunk
+			}
+
+			if chunk.Metadata != nil {
+				existingChunk.Metadata = chunk.Metadata
+			}
+
+			existingChunk.Data = append(existingChunk.Data, chunk.Data...)
+		}
+
+		// items which don't hold the current chunk's metadata return it zeroed
+		if currentChunkMetadata != nil && *currentChunkMetadata != (ItemCurrentChunkMetadata{}) {
+			getItemChunksOutput.CurrentChunkMetadata = currentChunkMetadata
+		}
+	}
+
+	for _, chunk := range getItemChunksOutput.Chunks {
+		sort.Slice(chunk.Data, func(i, j int) bool {
+			return chunk.Data[i].Offset < chunk.Data[j].Offset
+		})
+	}
+
+	return &getItemChunksOutput, nil
+}
+
+// GetChunkSync reads a single chunk of a stream shard. like GetItemChunks, it reads all of the shard's data
+func GetChunkSync(container Container, getChunkInput *GetChunkInput) (*ItemChunk, error) {
+	getItemChunksOutput, err := GetItemChunks(container, &GetItemChunksInput{
+		DataPlaneInput: getChunkInput.DataPlaneInput,
+		Path:           getChunkInput.Path,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, found := getItemChunksOutput.Chunks[getChunkInput.ChunkSeqNumber]
+	if !found {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.Wrapf(v3ioerrors.ErrNotFound,
+			"Chunk %d not found in shard: %s", getChunkInput.ChunkSeqNumber, getChunkInput.Path), http.StatusNotFound)
+	}
+
+	return chunk, nil
+}
+
+// PutChunkFromReaderSync writes a chunk from a reader without holding all of it in memory. the next piece is read
+// while the previous one is being written, but pieces are written one at a time and in order. returns the number
+// of bytes written, which is valid also when an error is returned
+func PutChunkFromReaderSync(container Container, putChunkFromReaderInput *PutChunkFromReaderInput) (uint64, error) {
+	pieceSize := putChunkFromReaderInput.PieceSize
+	if pieceSize <= 0 {
+		pieceSize = defaultPutChunkPieceSize
+	}
+
+	responseChan := make(chan *Response, 1)
+
+	// the size of the piece being written, or -1 if none is
+	numPendingBytes := -1
+	numBytesWritten := uint64(0)
+	offset := putChunkFromReaderInput.Offset
+
+	// waits for the piece being written, if any
+	waitForPendingPiece := func() error {
+		if numPendingBytes < 0 {
+			return nil
+		}
+
+		response := <-responseChan
+		defer response.Release()
+
+		if response.Error != nil {
+			return errors.Wrapf(response.Error, "Failed to put chunk piece at offset %d", offset-uint64(numPendingBytes))
+		}
+
+		numBytesWritten += uint64(numPendingBytes)
+		numPendingBytes = -1
+
+		return nil
+	}
+
+	piece, last, err := readChunkPiece(putChunkFromReaderInput.Reader, pieceSize)
+	for {
+		if err != nil {
+			if waitErr := waitForPendingPiece(); waitErr != nil {
+				return numBytesWritten, waitErr
+			}
+
+			return numBytesWritten, errors.Wrapf(err, "Failed to read chunk piece at offset %d", offset)
+		}
+
+		putChunkInput := PutChunkInput{
+			DataPlaneInput: putChunkFromReaderInput.DataPlaneInput,
+			Path:           putChunkFromReaderInput.Path,
+			ChunkSeqNumber: putChunkFromReaderInput.ChunkSeqNumber,
+			Offset:         offset,
+			Data:           piece,
+		}
+
+		if last {
+			putChunkInput.ChunksMetadata = putChunkFromReaderInput.ChunksMetadata
+			putChunkInput.CurrentChunkMetadata = putChunkFromReaderInput.CurrentChunkMetadata
+		}
+
+		if err := waitForPendingPiece(); err != nil {
+			return numBytesWritten, err
+		}
+
+		// don't write an empty last piece unless it carries metadata
+		if len(piece) == 0 && putChunkInput.ChunksMetadata == nil && putChunkInput.CurrentChunkMetadata == nil {
+			return numBytesWritten, nil
+		}
+
+		if _, err := container.PutChunk(&putChunkInput, nil, responseChan); err != nil {
+			return numBytesWritten, errors.Wrapf(err, "Failed to put chunk piece at offset %d", offset)
+		}
+
+		numPendingBytes = len(piece)
+		offset += uint64(len(piece))
+
+		if last {
+			return numBytesWritten, waitForPendingPiece()
+		}
+
+		// read the next piece while this one is being written
+		piece, last, err = readChunkPiece(putChunkFromReaderInput.Reader, pieceSize)
+	}
+}
+
+// readChunkPiece reads up to pieceSize bytes, returning whether the reader was exhausted
+func readChunkPiece(reader io.Reader, pieceSize int) ([]byte, bool, error) {
+	piece := make([]byte, pieceSize)
+
+	numBytesRead, err := io.ReadFull(reader, piece)
+	switch err {
+	case nil:
+		return piece, false, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return piece[:numBytesRead], true, nil
+	default:
+		return nil, false, err
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// returns the given items in a single page
+type fakeChunksContainer struct {
+	Container
+	items         []Item
+	getItemsInput *GetItemsInput
+}
+
+func (fcc *fakeChunksContainer) GetItemsSync(getItemsInput *GetItemsInput) (*Response, error) {
+	fcc.getItemsInput = getItemsInput
+
+	return &Response{
+		Output: &GetItemsOutput{Last: true, Items: fcc.items},
+	}, nil
+}
+
+// records the chunk pieces it's given, failing the piece at failOffset if set
+type fakePutChunkContainer struct {
+	Container
+	putChunkInputs []*PutChunkInput
+	failOffset     uint64
+}
+
+func (fpcc *fakePutChunkContainer) PutChunk(putChunkInput *PutChunkInput,
+	context interface{},
+	responseChan chan *Response) (*Request, error) {
+	response := &Response{Context: context}
+	if fpcc.failOffset != 0 && putChunkInput.Offset == fpcc.failOffset {
+		response.Error = v3ioerrors.NewErrorWithStatusCode(errors.New("Internal error"),
+			http.StatusInternalServerError)
+	}
+
+	fpcc.putChunkInputs = append(fpcc.putChunkInputs, putChunkInput)
+	responseChan <- response
+
+	return &Request{Input: putChunkInput, Context: context}, nil
+}
+
+type chunkSuite struct {
+	suite.Suite
+}
+
+func (suite *chunkSuite) TestGetItemChunksMergesScatteredItems() {
+	container := &fakeChunksContainer{
+		items: []Item{
+			{
+				"__name":                                "1",
+				"__data_stream[0001][0000000000000010]": []byte("second"),
+			},
+			{
+				"__name":                                "1",
+				"__data_stream[0001][0000000000000000]": []byte("first"),
+				"__data_stream[0002][0000000000000000]": []byte("other"),
+			},
+		},
+	}
+
+	getItemChunksOutput, err := GetItemChunks(container, &GetItemChunksInput{Path: "/stream/1"})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal("/stream/", container.getItemsInput.Path)
+	suite.Require().Equal("__name == '1'", container.getItemsInput.Filter)
+	suite.Require().Len(getItemChunksOutput.Chunks, 2)
+
+	chunk := getItemChunksOutput.Chunks[1]
+	suite.Require().Len(chunk.Data, 2)
+	suite.Require().Equal(uint64(0), chunk.Data[0].Offset)
+	suite.Require().Equal("first", string(*chunk.Data[0].Data))
+	suite.Require().Equal(uint64(0x10), chunk.Data[1].Offset)
+	suite.Require().Equal("second", string(*chunk.Data[1].Data))
+
+	chunk, err = GetChunkSync(container, &GetChunkInput{Path: "/stream/1", ChunkSeqNumber: 2})
+	suite.Require().NoError(err)
+	suite.Require().Equal("other", string(*chunk.Data[0].Data))
+
+	_, err = GetChunkSync(container, &GetChunkInput{Path: "/stream/1", ChunkSeqNumber: 3})
+	suite.Require().Equal(http.StatusNotFound, err.(v3ioerrors.ErrorWithStatusCode).StatusCode())
+}
+
+func (suite *chunkSuite) TestGetItemChunksShardNotFound() {
+	_, err := GetItemChunks(&fakeChunksContainer{}, &GetItemChunksInput{Path: "/stream/1"})
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusNotFound, err.(v3ioerrors.ErrorWithStatusCode).StatusCode())
+}
+
+func (suite *chunkSuite) TestPutChunkFromReaderSync() {
+	container := &fakePutChunkContainer{}
+	currentChunkMetadata := &CurrentChunkMetadata{ChunkSeqNumber: 2}
+
+	numBytesWritten, err := PutChunkFromReaderSync(container, &PutChunkFromReaderInput{
+		Path:                 "/stream/1",
+		ChunkSeqNumber:       2,
+		Offset:               100,
+		Reader:               bytes.NewReader([]byte("0123456789")),
+		PieceSize:            4,
+		CurrentChunkMetadata: currentChunkMetadata,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(10), numBytesWritten)
+	suite.Require().Len(container.putChunkInputs, 3)
+
+	for pieceIndex, expectedPiece := range []struct {
+		offset uint64
+		data   string
+	}{
+		{100, "0123"},
+		{104, "4567"},
+		{108, "89"},
+	} {
+		putChunkInput := container.putChunkInputs[pieceIndex]
+		suite.Require().Equal(2, putChunkInput.ChunkSeqNumber)
+		suite.Require().Equal(expectedPiece.offset, putChunkInput.Offset)
+		suite.Require().Equal(expectedPiece.data, string(putChunkInput.Data))
+	}
+
+	// only the last piece carries the metadata
+	suite.Require().Nil(container.putChunkInputs[0].CurrentChunkMetadata)
+	suite.Require().Equal(currentChunkMetadata, container.putChunkInputs[2].CurrentChunkMetadata)
+
+	// a failed piece stops the write
+	container = &fakePutChunkContainer{failOffset: 4}
+	numBytesWritten, err = PutChunkFromReaderSync(container, &PutChunkFromReaderInput{
+		Path:      "/stream/1",
+		Reader:    bytes.NewReader([]byte("0123456789")),
+		PieceSize: 4,
+	})
+	suite.Require().Error(err)
+	suite.Require().Equal(uint64(4), numBytesWritten)
+	suite.Require().Len(container.putChunkInputs, 2)
+}
+
+func TestChunkSuite(t *testing.T) {
+	suite.Run(t, new(chunkSuite))
+}
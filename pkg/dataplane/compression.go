@@ -0,0 +1,124 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/nuclio/errors"
+)
+
+// RecordCompression is the compression of stream record data. empty means uncompressed
+type RecordCompression string
+
+const (
+	RecordCompressionGzip   RecordCompression = "gzip"
+	RecordCompressionSnappy RecordCompression = "snappy"
+)
+
+func (rc RecordCompression) Validate() error {
+	switch rc {
+	case "", RecordCompressionGzip, RecordCompressionSnappy:
+		return nil
+	default:
+		return errors.Errorf("Invalid record compression: %s", rc)
+	}
+}
+
+// compressed data is wrapped in an envelope - a marker followed by a byte identifying the compression. the
+// marker starts with a zero byte, which text payloads such as JSON never do
+var recordCompressionMarker = []byte{0, 'v', '3', 'z'}
+
+// the byte identifying each compression in the envelope
+var recordCompressionIDs = map[RecordCompression]byte{
+	RecordCompressionGzip:   1,
+	RecordCompressionSnappy: 2,
+}
+
+// CompressRecordData compresses the data and wraps it in an envelope which DecompressRecordData recognizes
+func CompressRecordData(data []byte, compression RecordCompression) ([]byte, error) {
+	if err := compression.Validate(); err != nil {
+		return nil, err
+	}
+
+	if compression == "" {
+		return data, nil
+	}
+
+	compressedData := bytes.NewBuffer(make([]byte, 0, len(recordCompressionMarker)+1+len(data)/2))
+	compressedData.Write(recordCompressionMarker)
+	compressedData.WriteByte(recordCompressionIDs[compression])
+
+	switch compression {
+	case RecordCompressionGzip:
+		gzipWriter := gzip.NewWriter(compressedData)
+
+		if _, err := gzipWriter.Write(data); err != nil {
+			return nil, errors.Wrap(err, "Failed to compress record data")
+		}
+
+		if err := gzipWriter.Close(); err != nil {
+			return nil, errors.Wrap(err, "Failed to compress record data")
+		}
+	case RecordCompressionSnappy:
+		compressedData.Write(snappy.Encode(nil, data))
+	}
+
+	return compressedData.Bytes(), nil
+}
+
+// DecompressRecordData returns the decompressed data of a record compressed with CompressRecordData, or the
+// data as is if it isn't compressed
+func DecompressRecordData(data []byte) ([]byte, error) {
+	if len(data) <= len(recordCompressionMarker) || !bytes.HasPrefix(data, recordCompressionMarker) {
+		return data, nil
+	}
+
+	compressionID := data[len(recordCompressionMarker)]
+	compressedData := data[len(recordCompressionMarker)+1:]
+
+	switch compressionID {
+	case recordCompressionIDs[RecordCompressionGzip]:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(compressedData))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decompress gzip record data")
+		}
+
+		decompressedData, err := ioutil.ReadAll(gzipReader)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decompress gzip record data")
+		}
+
+		return decompressedData, nil
+	case recordCompressionIDs[RecordCompressionSnappy]:
+		decompressedData, err := snappy.Decode(nil, compressedData)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decompress snappy record data")
+		}
+
+		return decompressedData, nil
+	default:
+		return nil, errors.Errorf("Unknown record compression: %d", compressionID)
+	}
+}
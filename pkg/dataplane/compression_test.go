@@ -0,0 +1,67 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type recordCompressionSuite struct {
+	suite.Suite
+}
+
+func (suite *recordCompressionSuite) TestRoundTrip() {
+	data := bytes.Repeat([]byte(`{"event": "some event"}`), 100)
+
+	for _, compression := range []RecordCompression{"", RecordCompressionGzip, RecordCompressionSnappy} {
+		compressedData, err := CompressRecordData(data, compression)
+		suite.Require().NoError(err)
+
+		if compression != "" {
+			suite.Require().True(len(compressedData) < len(data), "compression %s", compression)
+		}
+
+		decompressedData, err := DecompressRecordData(compressedData)
+		suite.Require().NoError(err)
+		suite.Require().Equal(data, decompressedData)
+	}
+}
+
+func (suite *recordCompressionSuite) TestInvalid() {
+	_, err := CompressRecordData([]byte("data"), "lz4")
+	suite.Require().Error(err)
+
+	// an envelope with an unknown compression
+	_, err = DecompressRecordData(append(append([]byte(nil), recordCompressionMarker...), 9, 1, 2))
+	suite.Require().Error(err)
+
+	// uncompressed data is returned as is
+	decompressedData, err := DecompressRecordData([]byte("data"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("data", string(decompressedData))
+}
+
+func TestRecordCompressionSuite(t *testing.T) {
+	suite.Run(t, new(recordCompressionSuite))
+}
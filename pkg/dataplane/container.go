@@ -160,3 +160,33 @@ type Container interface {
 	// PutOOSObjectSync
 	PutOOSObjectSync(*PutOOSObjectInput) error
 }
+
+// the following are implemented by containers which support them. they aren't part of Container, so that
+// existing implementations and wrappers of Container keep compiling - check for them with a type assertion
+
+// ObjectPresigner is a container which can presign object requests
+type ObjectPresigner interface {
+
+	// PresignObject signs a URL for the object, to be accessed without credentials until it expires
+	PresignObject(*PresignObjectInput) (*PresignObjectOutput, error)
+}
+
+// StreamUpdater is a container which can change the shard count and retention of existing streams
+type StreamUpdater interface {
+
+	// UpdateStream
+	UpdateStream(*UpdateStreamInput, interface{}, chan *Response) (*Request, error)
+
+	// UpdateStreamSync
+	UpdateStreamSync(*UpdateStreamInput) error
+}
+
+// OOSObjectGetter is a container which can read back the objects written with PutOOSObject
+type OOSObjectGetter interface {
+
+	// GetOOSObject
+	GetOOSObject(*GetOOSObjectInput, interface{}, chan *Response) (*Request, error)
+
+	// GetOOSObjectSync
+	GetOOSObjectSync(*GetOOSObjectInput) (*Response, error)
+}
@@ -22,3 +22,46 @@ type Context interface {
 	// create a new session
 	NewSession(*NewSessionInput) (Session, error)
 }
+
+// the following are implemented by contexts which support them. they aren't part of Context, so that
+// existing implementations of Context keep compiling - check for them with a type assertion
+
+// StatsContext is a context which reports its runtime statistics
+type StatsContext interface {
+
+	// Stats returns a snapshot of the context's runtime statistics
+	Stats() *ContextStats
+}
+
+// PingContext is a context which can check that a container is reachable
+type PingContext interface {
+
+	// Ping sends a cheap authenticated request to the container of the input (a HEAD of its root), e.g. for
+	// readiness probes. the output is returned even if the ping failed, to tell unreachable servers
+	// from rejected requests
+	Ping(*PingInput) (*PingOutput, error)
+}
+
+// CapabilitiesContext is a context which tracks the optional APIs the server supports
+type CapabilitiesContext interface {
+
+	// Capabilities returns the optional APIs the server supports, as learned from the responses to
+	// the context's requests. APIs which weren't attempted yet are reported as supported
+	Capabilities() *Capabilities
+}
+
+// ClosableContext is a context which holds resources (e.g. workers) that must be released
+type ClosableContext interface {
+
+	// Close stops the context's workers. The context must not be used afterwards
+	Close() error
+}
+
+// CloseContext closes the context if it's closable, and does nothing otherwise
+func CloseContext(context Context) error {
+	if closableContext, ok := context.(ClosableContext); ok {
+		return closableContext.Close()
+	}
+
+	return nil
+}
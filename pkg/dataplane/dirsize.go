@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"sync"
+
+	"github.com/nuclio/errors"
+)
+
+type ComputeDirSizeInput struct {
+	DataPlaneInput
+	Path string
+
+	// the maximum number of directories listed concurrently (defaults to 8)
+	Parallelism int
+
+	// if set, called after each directory listing
+	Progress func(*DirSize)
+}
+
+type DirSize struct {
+	NumBytes int64
+	NumFiles int
+	NumDirs  int // not including the directory itself
+}
+
+type ComputeDirSizeOutput struct {
+	DirSize
+}
+
+// ComputeDirSize walks the directory tree under the path, listing directories concurrently, and sums the
+// sizes of the files in it
+func ComputeDirSize(container Container, computeDirSizeInput *ComputeDirSizeInput) (*ComputeDirSizeOutput, error) {
+	parallelism := computeDirSizeInput.Parallelism
+	if parallelism <= 0 {
+		parallelism = 8
+	}
+
+	dirSizeComputer := dirSizeComputer{
+		container:           container,
+		computeDirSizeInput: computeDirSizeInput,
+		semaphore:           make(chan struct{}, parallelism),
+	}
+
+	dirSizeComputer.walkDir(DirectoryPath(computeDirSizeInput.Path))
+	dirSizeComputer.waitGroup.Wait()
+
+	if dirSizeComputer.err != nil {
+		return nil, dirSizeComputer.err
+	}
+
+	return &ComputeDirSizeOutput{DirSize: dirSizeComputer.dirSize}, nil
+}
+
+type dirSizeComputer struct {
+	container           Container
+	computeDirSizeInput *ComputeDirSizeInput
+	semaphore           chan struct{}
+	waitGroup           sync.WaitGroup
+	lock                sync.Mutex
+	dirSize             DirSize
+	err                 error
+}
+
+// lists the directory in the background, walking its sub directories once it's listed. the semaphore is
+// only held while listing, so that walks don't wait on each other
+func (dsc *dirSizeComputer) walkDir(dirPath string) {
+	dsc.waitGroup.Add(1)
+
+	go func() {
+		defer dsc.waitGroup.Done()
+
+		dsc.semaphore <- struct{}{}
+		subDirPaths, dirSize, err := dsc.listDir(dirPath)
+		<-dsc.semaphore
+
+		dsc.lock.Lock()
+
+		if err != nil && dsc.err == nil {
+			dsc.err = err
+		}
+
+		// stop walking once a listing failed
+		if dsc.err != nil {
+			dsc.lock.Unlock()
+			return
+		}
+
+		dsc.dirSize.NumBytes += dirSize.NumBytes
+		dsc.dirSize.NumFiles += dirSize.NumFiles
+		dsc.dirSize.NumDirs += dirSize.NumDirs
+
+		if dsc.computeDirSizeInput.Progress != nil {
+			progress := dsc.dirSize
+			dsc.computeDirSizeInput.Progress(&progress)
+		}
+
+		dsc.lock.Unlock()
+
+		for _, subDirPath := range subDirPaths {
+			dsc.walkDir(subDirPath)
+		}
+	}()
+}
+
+func (dsc *dirSizeComputer) listDir(dirPath string) ([]string, *DirSize, error) {
+	var subDirPaths []string
+	dirSize := DirSize{}
+	marker := ""
+
+	for {
+		response, err := dsc.container.GetContainerContentsSync(&GetContainerContentsInput{
+			DataPlaneInput: dsc.computeDirSizeInput.DataPlaneInput,
+			Path:           dirPath,
+			Marker:         marker,
+		})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed to list %s", dirPath)
+		}
+
+		getContainerContentsOutput := response.Output.(*GetContainerContentsOutput)
+
+		for _, content := range getContainerContentsOutput.Contents {
+			if content.Size != nil {
+				dirSize.NumBytes += int64(*content.Size)
+			}
+
+			dirSize.NumFiles++
+		}
+
+		for _, commonPrefix := range getContainerContentsOutput.CommonPrefixes {
+			subDirPaths = append(subDirPaths, DirectoryPath(commonPrefix.Prefix))
+			dirSize.NumDirs++
+		}
+
+		isTruncated := getContainerContentsOutput.IsTruncated
+		marker = getContainerContentsOutput.NextMarker
+
+		response.Release()
+
+		if !isTruncated || marker == "" {
+			return subDirPaths, &dirSize, nil
+		}
+	}
+}
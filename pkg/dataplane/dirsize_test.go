@@ -0,0 +1,126 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// a directory tree listed one entry per page, to exercise paging
+type fakeDirTreeContainer struct {
+	Container
+	lock    sync.Mutex
+	dirs    map[string]*GetContainerContentsOutput
+	listed  []string
+	failDir string
+}
+
+func (fdtc *fakeDirTreeContainer) GetContainerContentsSync(getContainerContentsInput *GetContainerContentsInput) (*Response, error) {
+	fdtc.lock.Lock()
+	fdtc.listed = append(fdtc.listed, getContainerContentsInput.Path)
+	fdtc.lock.Unlock()
+
+	if getContainerContentsInput.Path == fdtc.failDir {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.New("Forbidden"), http.StatusForbidden)
+	}
+
+	dir := fdtc.dirs[getContainerContentsInput.Path]
+	if getContainerContentsInput.Marker == "" && len(dir.Contents) > 1 {
+		return &Response{Output: &GetContainerContentsOutput{
+			Contents:    dir.Contents[:1],
+			IsTruncated: true,
+			NextMarker:  dir.Contents[0].Key,
+		}}, nil
+	}
+
+	getContainerContentsOutput := *dir
+	if getContainerContentsInput.Marker != "" {
+		getContainerContentsOutput.Contents = dir.Contents[1:]
+	}
+
+	return &Response{Output: &getContainerContentsOutput}, nil
+}
+
+type dirSizeSuite struct {
+	suite.Suite
+	container *fakeDirTreeContainer
+}
+
+func (suite *dirSizeSuite) SetupTest() {
+	size := func(size int) *int { return &size }
+
+	suite.container = &fakeDirTreeContainer{
+		dirs: map[string]*GetContainerContentsOutput{
+			"root/": {
+				Contents:       []Content{{Key: "root/a", Size: size(10)}, {Key: "root/b", Size: size(20)}},
+				CommonPrefixes: []CommonPrefix{{Prefix: "root/x"}, {Prefix: "root/y/"}},
+			},
+			"root/x/": {
+				Contents: []Content{{Key: "root/x/c", Size: size(30)}},
+			},
+			"root/y/": {
+				CommonPrefixes: []CommonPrefix{{Prefix: "root/y/z/"}},
+			},
+			"root/y/z/": {
+				Contents: []Content{{Key: "root/y/z/d", Size: size(40)}, {Key: "root/y/z/e"}},
+			},
+		},
+	}
+}
+
+func (suite *dirSizeSuite) TestComputeDirSize() {
+	var progresses []DirSize
+
+	computeDirSizeOutput, err := ComputeDirSize(suite.container, &ComputeDirSizeInput{
+		Path:        "root",
+		Parallelism: 2,
+		Progress: func(progress *DirSize) {
+			progresses = append(progresses, *progress)
+		},
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(DirSize{NumBytes: 100, NumFiles: 5, NumDirs: 3}, computeDirSizeOutput.DirSize)
+	suite.Require().Len(progresses, 4)
+	suite.Require().Equal(computeDirSizeOutput.DirSize, progresses[3])
+}
+
+func (suite *dirSizeSuite) TestListingFailed() {
+	suite.container.failDir = "root/y/"
+
+	_, err := ComputeDirSize(suite.container, &ComputeDirSizeInput{Path: "root/"})
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusForbidden, errors.Cause(err).(v3ioerrors.ErrorWithStatusCode).StatusCode())
+
+	// the failed directory isn't walked
+	suite.Require().NotContains(suite.container.listed, "root/y/z/")
+}
+
+func TestDirSizeSuite(t *testing.T) {
+	suite.Run(t, new(dirSizeSuite))
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"sync"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type DirChangeType string
+
+const (
+	DirChangeTypeAdded    DirChangeType = "added"
+	DirChangeTypeModified DirChangeType = "modified"
+	DirChangeTypeDeleted  DirChangeType = "deleted"
+)
+
+// DirChange is a file which was added, modified or deleted since the previous listing. for deleted files,
+// Size and LastModified are those of the last listing the file was seen in
+type DirChange struct {
+	Type         DirChangeType
+	Key          string
+	Size         int
+	LastModified string
+}
+
+type NewDirWatcherInput struct {
+	DataPlaneInput
+	Container Container
+	Path      string
+
+	// watches the files in sub directories as well
+	Recursive bool
+
+	// reports the files which exist when the watcher starts as added. otherwise, the first listing is
+	// only the baseline changes are detected against
+	ReportExisting bool
+
+	// the time between listings (defaults to 1s)
+	PollInterval time.Duration
+
+	// called with the errors of failed listings, which are retried on the next poll
+	OnError func(error)
+
+	// defaults to the wall clock
+	Clock common.Clock
+}
+
+type dirWatcherEntry struct {
+	size         int
+	lastModified string
+}
+
+// DirWatcher reports the files of a directory which are added, modified or deleted, by listing the
+// directory periodically and diffing the keys, sizes and modification times against the previous listing
+type DirWatcher struct {
+	input       NewDirWatcherInput
+	changesChan chan *DirChange
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+	entries     map[string]dirWatcherEntry
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	started     bool
+}
+
+func NewDirWatcher(newDirWatcherInput *NewDirWatcherInput) (*DirWatcher, error) {
+	if newDirWatcherInput.Container == nil {
+		return nil, errors.New("Container must be set")
+	}
+
+	newDirWatcher := DirWatcher{
+		input:       *newDirWatcherInput,
+		changesChan: make(chan *DirChange),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+
+	if newDirWatcher.input.PollInterval <= 0 {
+		newDirWatcher.input.PollInterval = time.Second
+	}
+
+	if newDirWatcher.input.Clock == nil {
+		newDirWatcher.input.Clock = common.NewClock()
+	}
+
+	if newDirWatcher.input.ReportExisting {
+		newDirWatcher.entries = map[string]dirWatcherEntry{}
+	}
+
+	return &newDirWatcher, nil
+}
+
+// Start starts listing the directory
+func (dw *DirWatcher) Start() error {
+	dw.startOnce.Do(func() {
+		dw.started = true
+		go dw.pollPeriodically()
+	})
+
+	return nil
+}
+
+// Changes returns the channel changes are delivered on. it's closed once the watcher stops
+func (dw *DirWatcher) Changes() <-chan *DirChange {
+	return dw.changesChan
+}
+
+// Stop stops listing, waiting for the current listing to end. changes which weren't received are dropped
+func (dw *DirWatcher) Stop() error {
+	dw.stopOnce.Do(func() {
+		close(dw.stopChan)
+	})
+
+	if dw.started {
+		<-dw.stoppedChan
+	}
+
+	return nil
+}
+
+func (dw *DirWatcher) pollPeriodically() {
+	defer close(dw.stoppedChan)
+	defer close(dw.changesChan)
+
+	for {
+		if err := dw.poll(); err != nil {
+			if errors.Cause(err) == v3ioerrors.ErrStopped {
+				return
+			}
+
+			if dw.input.OnError != nil {
+				dw.input.OnError(err)
+			}
+		}
+
+		select {
+		case <-dw.input.Clock.After(dw.input.PollInterval):
+		case <-dw.stopChan:
+			return
+		}
+	}
+}
+
+// lists the directory and reports the differences from the previous listing
+func (dw *DirWatcher) poll() error {
+	entries := map[string]dirWatcherEntry{}
+
+	if err := dw.listDir(DirectoryPath(dw.input.Path), entries); err != nil {
+		return errors.Wrapf(err, "Failed to list %s", dw.input.Path)
+	}
+
+	// the first listing is the baseline
+	if dw.entries == nil {
+		dw.entries = entries
+		return nil
+	}
+
+	var changes []*DirChange
+
+	for key, entry := range entries {
+		previousEntry, exists := dw.entries[key]
+		switch {
+		case !exists:
+			changes = append(changes, newDirChange(DirChangeTypeAdded, key, entry))
+		case previousEntry != entry:
+			changes = append(changes, newDirChange(DirChangeTypeModified, key, entry))
+		}
+	}
+
+	for key, previousEntry := range dw.entries {
+		if _, exists := entries[key]; !exists {
+			changes = append(changes, newDirChange(DirChangeTypeDeleted, key, previousEntry))
+		}
+	}
+
+	// the listing is only taken as the baseline once all its changes were delivered, so that changes
+	// which weren't are reported again
+	for _, change := range changes {
+		select {
+		case dw.changesChan <- change:
+		case <-dw.stopChan:
+			return v3ioerrors.ErrStopped
+		}
+	}
+
+	dw.entries = entries
+
+	return nil
+}
+
+func (dw *DirWatcher) listDir(dirPath string, entries map[string]dirWatcherEntry) error {
+	marker := ""
+
+	for {
+		response, err := dw.input.Container.GetContainerContentsSync(&GetContainerContentsInput{
+			DataPlaneInput: dw.input.DataPlaneInput,
+			Path:           dirPath,
+			Marker:         marker,
+		})
+		if err != nil {
+			return err
+		}
+
+		getContainerContentsOutput := response.Output.(*GetContainerContentsOutput)
+
+		for _, content := range getContainerContentsOutput.Contents {
+			entry := dirWatcherEntry{lastModified: content.LastModified}
+			if content.Size != nil {
+				entry.size = *content.Size
+			}
+
+			entries[content.Key] = entry
+		}
+
+		var subDirPaths []string
+		if dw.input.Recursive {
+			for _, commonPrefix := range getContainerContentsOutput.CommonPrefixes {
+				subDirPaths = append(subDirPaths, DirectoryPath(commonPrefix.Prefix))
+			}
+		}
+
+		isTruncated := getContainerContentsOutput.IsTruncated
+		marker = getContainerContentsOutput.NextMarker
+
+		response.Release()
+
+		for _, subDirPath := range subDirPaths {
+			if err := dw.listDir(subDirPath, entries); err != nil {
+				return err
+			}
+		}
+
+		if !isTruncated || marker == "" {
+			return nil
+		}
+	}
+}
+
+func newDirChange(changeType DirChangeType, key string, entry dirWatcherEntry) *DirChange {
+	return &DirChange{
+		Type:         changeType,
+		Key:          key,
+		Size:         entry.size,
+		LastModified: entry.lastModified,
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeListedFile struct {
+	size         int
+	lastModified string
+}
+
+// lists files by path, two entries per page
+type fakeListingContainer struct {
+	Container
+	lock        sync.Mutex
+	files       map[string]fakeListedFile
+	listErr     error
+	numReleased int
+	numListings int
+}
+
+func (flc *fakeListingContainer) GetContainerContentsSync(getContainerContentsInput *GetContainerContentsInput) (*Response, error) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	if flc.listErr != nil {
+		return nil, flc.listErr
+	}
+
+	flc.numListings++
+
+	var keys []string
+	subDirPaths := map[string]bool{}
+
+	for key := range flc.files {
+		if !strings.HasPrefix(key, getContainerContentsInput.Path) {
+			continue
+		}
+
+		relativeKey := strings.TrimPrefix(key, getContainerContentsInput.Path)
+		if slashIndex := strings.Index(relativeKey, "/"); slashIndex != -1 {
+			subDirPaths[getContainerContentsInput.Path+relativeKey[:slashIndex+1]] = true
+			continue
+		}
+
+		if key > getContainerContentsInput.Marker {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	getContainerContentsOutput := GetContainerContentsOutput{}
+
+	if getContainerContentsInput.Marker == "" {
+		for subDirPath := range subDirPaths {
+			getContainerContentsOutput.CommonPrefixes = append(getContainerContentsOutput.CommonPrefixes,
+				CommonPrefix{Prefix: subDirPath})
+		}
+	}
+
+	if len(keys) > 2 {
+		keys = keys[:2]
+		getContainerContentsOutput.IsTruncated = true
+		getContainerContentsOutput.NextMarker = keys[1]
+	}
+
+	for _, key := range keys {
+		size := flc.files[key].size
+		getContainerContentsOutput.Contents = append(getContainerContentsOutput.Contents, Content{
+			Key:          key,
+			Size:         &size,
+			LastModified: flc.files[key].lastModified,
+		})
+	}
+
+	return &Response{
+		Output: &getContainerContentsOutput,
+		OnRelease: func() {
+			flc.lock.Lock()
+			flc.numReleased++
+			flc.lock.Unlock()
+		},
+	}, nil
+}
+
+func (flc *fakeListingContainer) setFile(key string, size int, lastModified string) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	flc.files[key] = fakeListedFile{size: size, lastModified: lastModified}
+}
+
+func (flc *fakeListingContainer) deleteFile(key string) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	delete(flc.files, key)
+}
+
+func (flc *fakeListingContainer) setListErr(err error) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	flc.listErr = err
+}
+
+type dirWatcherSuite struct {
+	suite.Suite
+	container  *fakeListingContainer
+	clock      *common.MockClock
+	errChan    chan error
+	dirWatcher *DirWatcher
+}
+
+func (suite *dirWatcherSuite) SetupTest() {
+	suite.container = &fakeListingContainer{
+		files: map[string]fakeListedFile{
+			"dir/a":     {size: 1, lastModified: "2019-06-02T14:30:39.18Z"},
+			"dir/b":     {size: 2, lastModified: "2019-06-02T14:30:39.18Z"},
+			"dir/c":     {size: 3, lastModified: "2019-06-02T14:30:39.18Z"},
+			"dir/sub/d": {size: 4, lastModified: "2019-06-02T14:30:39.18Z"},
+			"other/e":   {size: 5, lastModified: "2019-06-02T14:30:39.18Z"},
+		},
+	}
+
+	suite.clock = common.NewMockClock(time.Now())
+	suite.errChan = make(chan error, 1)
+}
+
+func (suite *dirWatcherSuite) TearDownTest() {
+	if suite.dirWatcher != nil {
+		suite.Require().NoError(suite.dirWatcher.Stop())
+	}
+}
+
+func (suite *dirWatcherSuite) TestChanges() {
+	suite.startDirWatcher(false, false)
+
+	suite.container.setFile("dir/b", 20, "2019-06-02T14:31:00.00Z")
+	suite.container.deleteFile("dir/c")
+	suite.container.setFile("dir/f", 6, "2019-06-02T14:31:00.00Z")
+	suite.container.setFile("dir/sub/g", 7, "2019-06-02T14:31:00.00Z")
+	suite.container.setFile("other/h", 8, "2019-06-02T14:31:00.00Z")
+
+	// files in sub directories and outside the directory aren't watched
+	suite.Require().Equal([]*DirChange{
+		{Type: DirChangeTypeModified, Key: "dir/b", Size: 20, LastModified: "2019-06-02T14:31:00.00Z"},
+		{Type: DirChangeTypeDeleted, Key: "dir/c", Size: 3, LastModified: "2019-06-02T14:30:39.18Z"},
+		{Type: DirChangeTypeAdded, Key: "dir/f", Size: 6, LastModified: "2019-06-02T14:31:00.00Z"},
+	}, suite.poll(3))
+
+	// nothing changed since
+	suite.Require().Empty(suite.poll(0))
+	suite.requireAllResponsesReleased()
+}
+
+func (suite *dirWatcherSuite) TestRecursive() {
+	suite.startDirWatcher(true, false)
+
+	suite.container.setFile("dir/sub/d", 40, "2019-06-02T14:31:00.00Z")
+	suite.container.setFile("dir/sub/sub/g", 7, "2019-06-02T14:31:00.00Z")
+
+	suite.Require().Equal([]*DirChange{
+		{Type: DirChangeTypeModified, Key: "dir/sub/d", Size: 40, LastModified: "2019-06-02T14:31:00.00Z"},
+		{Type: DirChangeTypeAdded, Key: "dir/sub/sub/g", Size: 7, LastModified: "2019-06-02T14:31:00.00Z"},
+	}, suite.poll(2))
+}
+
+func (suite *dirWatcherSuite) TestReportExisting() {
+	suite.startDirWatcher(false, true)
+
+	changes := suite.readChanges(3)
+	suite.Require().Equal([]*DirChange{
+		{Type: DirChangeTypeAdded, Key: "dir/a", Size: 1, LastModified: "2019-06-02T14:30:39.18Z"},
+		{Type: DirChangeTypeAdded, Key: "dir/b", Size: 2, LastModified: "2019-06-02T14:30:39.18Z"},
+		{Type: DirChangeTypeAdded, Key: "dir/c", Size: 3, LastModified: "2019-06-02T14:30:39.18Z"},
+	}, changes)
+}
+
+func (suite *dirWatcherSuite) TestListingError() {
+	suite.startDirWatcher(false, false)
+
+	listErr := errors.New("Listing failed")
+	suite.container.setListErr(listErr)
+
+	suite.Require().Empty(suite.poll(0))
+
+	select {
+	case err := <-suite.errChan:
+		suite.Require().Equal(listErr, errors.RootCause(err))
+	case <-time.After(5 * time.Second):
+		suite.FailNow("Listing error wasn't reported")
+	}
+
+	// once listing succeeds again, changes are detected against the last successful listing
+	suite.container.setListErr(nil)
+	suite.container.deleteFile("dir/a")
+
+	suite.Require().Equal([]*DirChange{
+		{Type: DirChangeTypeDeleted, Key: "dir/a", Size: 1, LastModified: "2019-06-02T14:30:39.18Z"},
+	}, suite.poll(1))
+}
+
+func (suite *dirWatcherSuite) startDirWatcher(recursive bool, reportExisting bool) {
+	var err error
+
+	suite.dirWatcher, err = NewDirWatcher(&NewDirWatcherInput{
+		Container:      suite.container,
+		Path:           "dir",
+		Recursive:      recursive,
+		ReportExisting: reportExisting,
+		PollInterval:   time.Second,
+		Clock:          suite.clock,
+		OnError: func(err error) {
+			suite.errChan <- err
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.dirWatcher.Start())
+
+	if !reportExisting {
+		suite.waitForPollToEnd()
+	}
+}
+
+// triggers a listing and returns the changes it reported, sorted by key
+func (suite *dirWatcherSuite) poll(numChanges int) []*DirChange {
+	suite.waitForPollToEnd()
+	suite.clock.Add(time.Second)
+
+	changes := suite.readChanges(numChanges)
+	suite.waitForPollToEnd()
+
+	select {
+	case change := <-suite.dirWatcher.Changes():
+		suite.FailNow("Unexpected change", "%+v", change)
+	default:
+	}
+
+	return changes
+}
+
+func (suite *dirWatcherSuite) readChanges(numChanges int) []*DirChange {
+	changes := []*DirChange{}
+
+	for changeIdx := 0; changeIdx < numChanges; changeIdx++ {
+		select {
+		case change := <-suite.dirWatcher.Changes():
+			changes = append(changes, change)
+		case <-time.After(5 * time.Second):
+			suite.FailNow("Change wasn't reported", "%d/%d changes", changeIdx, numChanges)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// the watcher waits on the clock between listings
+func (suite *dirWatcherSuite) waitForPollToEnd() {
+	for deadline := time.Now().Add(5 * time.Second); suite.clock.NumTimers() == 0; {
+		suite.Require().True(time.Now().Before(deadline), "Timed out waiting for listing")
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (suite *dirWatcherSuite) requireAllResponsesReleased() {
+	suite.container.lock.Lock()
+	defer suite.container.lock.Unlock()
+
+	suite.Require().Equal(suite.container.numListings, suite.container.numReleased)
+}
+
+func TestDirWatcherSuite(t *testing.T) {
+	suite.Run(t, new(dirWatcherSuite))
+}
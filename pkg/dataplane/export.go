@@ -0,0 +1,177 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+type ExportTableInput struct {
+	DataPlaneInput
+	Path           string
+	AttributeNames []string // defaults to all attributes, along with the name and mtime (see RestoreTable)
+	Filter         string   // exports only the items matching the filter
+
+	// the point in time the export is consistent with, compared against the mtimes stamped by the server.
+	// defaults to the server's time when the export starts, taken from the mtime of a fence item written to
+	// (and then deleted from) the table, so that the export isn't skewed by the local clock
+	SnapshotTime time.Time
+}
+
+type ExportTableOutput struct {
+	SnapshotTime time.Time
+	NumItems     int
+
+	// the names of the items which were skipped since they were modified after the snapshot time. items
+	// deleted during the export can't be detected, and may or may not have been exported
+	ModifiedItemNames []string
+}
+
+// ExportTable passes each item of the table which wasn't modified since the snapshot time to handler, so
+// that a backup of a live table holds the items as of the snapshot time. items modified during the export
+// are reported in the output, so they can be handled separately (e.g. exported again after the fact)
+func ExportTable(container Container,
+	exportTableInput *ExportTableInput,
+	handler func(Item) error) (*ExportTableOutput, error) {
+
+	exportTableOutput := ExportTableOutput{
+		SnapshotTime: exportTableInput.SnapshotTime,
+	}
+
+	if exportTableOutput.SnapshotTime.IsZero() {
+		snapshotTime, err := getServerTime(container, exportTableInput)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get the snapshot time of table %s", exportTableInput.Path)
+		}
+
+		exportTableOutput.SnapshotTime = snapshotTime
+	}
+
+	unmodifiedFilter := getUnmodifiedSinceFilter(exportTableOutput.SnapshotTime)
+
+	attributeNames := exportTableInput.AttributeNames
+	if len(attributeNames) == 0 {
+		attributeNames = []string{"__name", "__mtime_secs", "__mtime_nsecs", "*"}
+	}
+
+	// export the items which weren't modified since the snapshot time
+	err := scanTable(container, &GetItemsInput{
+		DataPlaneInput: exportTableInput.DataPlaneInput,
+		Path:           exportTableInput.Path,
+		AttributeNames: attributeNames,
+		Filter:         combineFilters(exportTableInput.Filter, unmodifiedFilter),
+	}, func(item Item) error {
+		exportTableOutput.NumItems++
+		return handler(item)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to export table %s", exportTableInput.Path)
+	}
+
+	// collect the items which were modified since
+	err = scanTable(container, &GetItemsInput{
+		DataPlaneInput: exportTableInput.DataPlaneInput,
+		Path:           exportTableInput.Path,
+		AttributeNames: []string{"__name"},
+		Filter:         combineFilters(exportTableInput.Filter, fmt.Sprintf("not(%s)", unmodifiedFilter)),
+	}, func(item Item) error {
+		itemName, err := item.GetFieldString("__name")
+		if err != nil {
+			return errors.Wrap(err, "Failed to get item name")
+		}
+
+		exportTableOutput.ModifiedItemNames = append(exportTableOutput.ModifiedItemNames, itemName)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get items modified during the export of table %s", exportTableInput.Path)
+	}
+
+	return &exportTableOutput, nil
+}
+
+// writes a fence item to the table and returns its mtime, as stamped by the server. the item is deleted
+// before returning, so it's not exported
+func getServerTime(container Container, exportTableInput *ExportTableInput) (time.Time, error) {
+	fenceItemPath := DirectoryPath(exportTableInput.Path) + fmt.Sprintf(".export-fence-%d", time.Now().UnixNano())
+
+	response, err := container.UpdateItemSync(&UpdateItemInput{
+		DataPlaneInput: exportTableInput.DataPlaneInput,
+		Path:           fenceItemPath,
+		Attributes:     map[string]interface{}{"export_fence": 1},
+	})
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Failed to write fence item")
+	}
+
+	updateItemOutput := response.Output.(*UpdateItemOutput)
+	mtimeSecs, mtimeNSecs := updateItemOutput.MtimeSecs, updateItemOutput.MtimeNSecs
+	response.Release()
+
+	if err := container.DeleteObjectSync(&DeleteObjectInput{
+		DataPlaneInput: exportTableInput.DataPlaneInput,
+		Path:           fenceItemPath,
+	}); err != nil {
+		return time.Time{}, errors.Wrap(err, "Failed to delete fence item")
+	}
+
+	if mtimeSecs == 0 {
+		return time.Time{}, errors.New("Server didn't return the mtime of the fence item")
+	}
+
+	return time.Unix(int64(mtimeSecs), int64(mtimeNSecs)), nil
+}
+
+func scanTable(container Container, getItemsInput *GetItemsInput, handler func(Item) error) error {
+	itemsCursor, err := NewItemsCursor(container, getItemsInput)
+	if err != nil {
+		return err
+	}
+
+	defer itemsCursor.Release()
+
+	for itemsCursor.NextSync() {
+		if err := handler(itemsCursor.GetItem()); err != nil {
+			return err
+		}
+	}
+
+	return itemsCursor.Err()
+}
+
+// returns a filter matching items which weren't modified after the given time
+func getUnmodifiedSinceFilter(snapshotTime time.Time) string {
+	return fmt.Sprintf("(__mtime_secs < %d) OR ((__mtime_secs == %d) AND (__mtime_nsecs <= %d))",
+		snapshotTime.Unix(),
+		snapshotTime.Unix(),
+		snapshotTime.Nanosecond())
+}
+
+func combineFilters(filter string, otherFilter string) string {
+	if filter == "" {
+		return otherFilter
+	}
+
+	return fmt.Sprintf("(%s) AND (%s)", filter, otherFilter)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var unmodifiedSinceFilterRegexp = regexp.MustCompile(`__mtime_secs < (\d+)\) OR .* AND \(__mtime_nsecs <= (\d+)\)`)
+
+type fakeExportedItem struct {
+	attributes map[string]interface{}
+	mtime      time.Time
+}
+
+// a table whose items are stamped with the mtime of a server whose clock is offset from the local one
+type fakeExportContainer struct {
+	Container
+	serverClockOffset time.Duration
+	items             map[string]*fakeExportedItem
+	deletedPaths      []string
+
+	// called before each scan, to simulate concurrent writers
+	beforeScan func()
+}
+
+func (fec *fakeExportContainer) UpdateItemSync(updateItemInput *UpdateItemInput) (*Response, error) {
+	mtime := fec.write(strings.TrimPrefix(updateItemInput.Path, "table/"), updateItemInput.Attributes)
+
+	return &Response{
+		Output: &UpdateItemOutput{MtimeSecs: int(mtime.Unix()), MtimeNSecs: mtime.Nanosecond()},
+	}, nil
+}
+
+func (fec *fakeExportContainer) DeleteObjectSync(deleteObjectInput *DeleteObjectInput) error {
+	delete(fec.items, strings.TrimPrefix(deleteObjectInput.Path, "table/"))
+	fec.deletedPaths = append(fec.deletedPaths, deleteObjectInput.Path)
+
+	return nil
+}
+
+func (fec *fakeExportContainer) GetItemsSync(getItemsInput *GetItemsInput) (*Response, error) {
+	if fec.beforeScan != nil {
+		fec.beforeScan()
+	}
+
+	match := unmodifiedSinceFilterRegexp.FindStringSubmatch(getItemsInput.Filter)
+	snapshotSecs, _ := strconv.ParseInt(match[1], 10, 64)
+	snapshotNSecs, _ := strconv.ParseInt(match[2], 10, 64)
+	snapshotTime := time.Unix(snapshotSecs, snapshotNSecs)
+	modified := strings.HasPrefix(getItemsInput.Filter, "not(")
+
+	var itemNames []string
+	for itemName := range fec.items {
+		itemNames = append(itemNames, itemName)
+	}
+
+	sort.Strings(itemNames)
+
+	items := []Item{}
+	for _, itemName := range itemNames {
+		if fec.items[itemName].mtime.After(snapshotTime) != modified {
+			continue
+		}
+
+		item := Item{"__name": itemName}
+		for attributeName, attributeValue := range fec.items[itemName].attributes {
+			item[attributeName] = attributeValue
+		}
+
+		items = append(items, item)
+	}
+
+	return &Response{Output: &GetItemsOutput{Items: items, Last: true}}, nil
+}
+
+func (fec *fakeExportContainer) write(itemName string, attributes map[string]interface{}) time.Time {
+	mtime := time.Now().Add(fec.serverClockOffset)
+	fec.items[itemName] = &fakeExportedItem{attributes: attributes, mtime: mtime}
+
+	// let the server clock advance between writes
+	time.Sleep(time.Millisecond)
+
+	return mtime
+}
+
+type exportTableSuite struct {
+	suite.Suite
+	container *fakeExportContainer
+}
+
+func (suite *exportTableSuite) SetupTest() {
+	suite.container = &fakeExportContainer{
+		items: map[string]*fakeExportedItem{},
+	}
+}
+
+func (suite *exportTableSuite) TestServerClockAhead() {
+
+	// items written just before the export are stamped later than the local time, and must still be exported
+	suite.container.serverClockOffset = time.Hour
+	suite.container.write("a", map[string]interface{}{"value": 1})
+	suite.container.write("b", map[string]interface{}{"value": 2})
+
+	exportTableOutput, exportedItemNames := suite.exportTable()
+	suite.Require().Equal([]string{"a", "b"}, exportedItemNames)
+	suite.Require().Empty(exportTableOutput.ModifiedItemNames)
+	suite.Require().True(exportTableOutput.SnapshotTime.After(time.Now().Add(30 * time.Minute)))
+
+	// the fence item was deleted rather than exported
+	suite.Require().Len(suite.container.deletedPaths, 1)
+	suite.Require().True(strings.HasPrefix(suite.container.deletedPaths[0], "table/.export-fence-"))
+	suite.Require().Len(suite.container.items, 2)
+}
+
+func (suite *exportTableSuite) TestItemsModifiedDuringScan() {
+	suite.container.serverClockOffset = -time.Hour
+	suite.container.write("a", map[string]interface{}{"value": 1})
+	suite.container.write("b", map[string]interface{}{"value": 2})
+	suite.container.write("c", map[string]interface{}{"value": 3})
+
+	// b is modified and d is created once the export started
+	suite.container.beforeScan = func() {
+		suite.container.beforeScan = nil
+		suite.container.write("b", map[string]interface{}{"value": 20})
+		suite.container.write("d", map[string]interface{}{"value": 4})
+	}
+
+	exportTableOutput, exportedItemNames := suite.exportTable()
+	suite.Require().Equal([]string{"a", "c"}, exportedItemNames)
+	suite.Require().Equal(2, exportTableOutput.NumItems)
+	suite.Require().Equal([]string{"b", "d"}, exportTableOutput.ModifiedItemNames)
+}
+
+func (suite *exportTableSuite) exportTable() (*ExportTableOutput, []string) {
+	var exportedItemNames []string
+
+	exportTableOutput, err := ExportTable(suite.container, &ExportTableInput{Path: "table/"}, func(item Item) error {
+		itemName, err := item.GetFieldString("__name")
+		suite.Require().NoError(err)
+
+		exportedItemNames = append(exportedItemNames, itemName)
+		return nil
+	})
+	suite.Require().NoError(err)
+
+	return exportTableOutput, exportedItemNames
+}
+
+func TestExportTableSuite(t *testing.T) {
+	suite.Run(t, new(exportTableSuite))
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"strings"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+)
+
+// the longest attribute name the platform accepts, in bytes
+const maxAttributeNameLength = 255
+
+// words of the expression language, which can't be used as bare attribute names in expressions
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "between": true, "by": true, "case": true, "cast": true,
+	"contains": true, "delete": true, "distinct": true, "else": true, "end": true, "exists": true,
+	"false": true, "from": true, "if_exists": true, "if_not_exists": true, "in": true, "init_array": true,
+	"is": true, "length": true, "max": true, "min": true, "not": true, "null": true, "or": true,
+	"remove": true, "select": true, "set": true, "then": true, "true": true, "when": true, "where": true,
+}
+
+// ValidateAttributeName fails with ErrInvalidInput, describing the problem, unless the name can be used
+// as is in expressions: it must start with a letter or an underscore, contain only letters, digits and
+// underscores, and not be a reserved word. names which fail may still be referenced through
+// QuoteAttributeName
+func ValidateAttributeName(attributeName string) error {
+	if err := validateAttributeNameLength(attributeName); err != nil {
+		return err
+	}
+
+	for charIdx := 0; charIdx < len(attributeName); charIdx++ {
+		char := attributeName[charIdx]
+		if !isIdentifierChar(char, charIdx == 0) {
+			return v3ioerrors.NewErrorWithField("AttributeName",
+				"must start with a letter or an underscore and contain only letters, digits and underscores "+
+					"(found "+strings.TrimSpace(string(char))+" in "+attributeName+")")
+		}
+	}
+
+	if reservedWords[strings.ToLower(attributeName)] {
+		return v3ioerrors.NewErrorWithField("AttributeName", "must not be a reserved word ("+attributeName+")")
+	}
+
+	return nil
+}
+
+// QuoteAttributeName returns the name for use in expressions - as is if it's valid (see
+// ValidateAttributeName), or quoted in backticks otherwise
+func QuoteAttributeName(attributeName string) (string, error) {
+	if ValidateAttributeName(attributeName) == nil {
+		return attributeName, nil
+	}
+
+	if err := validateAttributeNameLength(attributeName); err != nil {
+		return "", err
+	}
+
+	if strings.ContainsAny(attributeName, "`\x00") {
+		return "", v3ioerrors.NewErrorWithField("AttributeName", "must not contain backticks or null characters")
+	}
+
+	return "`" + attributeName + "`", nil
+}
+
+// QuoteString returns the value as a string literal for use in expressions. values containing both
+// single and double quotes can't be expressed
+func QuoteString(value string) (string, error) {
+	switch {
+	case !strings.Contains(value, "'"):
+		return "'" + value + "'", nil
+	case !strings.Contains(value, `"`):
+		return `"` + value + `"`, nil
+	default:
+		return "", v3ioerrors.NewErrorWithField("Value", "must not contain both single and double quotes")
+	}
+}
+
+func validateAttributeNameLength(attributeName string) error {
+	if attributeName == "" {
+		return v3ioerrors.NewErrorWithField("AttributeName", "must not be empty")
+	}
+
+	if len(attributeName) > maxAttributeNameLength {
+		return v3ioerrors.NewErrorWithField("AttributeName", "must not be longer than 255 bytes")
+	}
+
+	return nil
+}
+
+func isIdentifierChar(char byte, first bool) bool {
+	if 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || char == '_' {
+		return true
+	}
+
+	return !first && '0' <= char && char <= '9'
+}
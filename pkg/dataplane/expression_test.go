@@ -0,0 +1,78 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"errors"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type expressionSuite struct {
+	suite.Suite
+}
+
+func (suite *expressionSuite) TestValidateAttributeName() {
+	for _, attributeName := range []string{"a", "_a", "a1", "__mtime_secs", "Value_2"} {
+		suite.Require().NoError(ValidateAttributeName(attributeName), attributeName)
+	}
+
+	for _, attributeName := range []string{"", "1a", "a-b", "a b", "a.b", "and", "EXISTS"} {
+		err := ValidateAttributeName(attributeName)
+		suite.Require().True(errors.Is(err, v3ioerrors.ErrInvalidInput), attributeName)
+	}
+}
+
+func (suite *expressionSuite) TestQuoteAttributeName() {
+	for attributeName, expectedQuotedName := range map[string]string{
+		"a":   "a",
+		"a-b": "`a-b`",
+		"and": "`and`",
+	} {
+		quotedName, err := QuoteAttributeName(attributeName)
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedQuotedName, quotedName)
+	}
+
+	_, err := QuoteAttributeName("a`b")
+	suite.Require().Error(err)
+}
+
+func (suite *expressionSuite) TestQuoteString() {
+	for value, expectedQuotedValue := range map[string]string{
+		"a":   "'a'",
+		"a'b": `"a'b"`,
+	} {
+		quotedValue, err := QuoteString(value)
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedQuotedValue, quotedValue)
+	}
+
+	_, err := QuoteString(`a'b"c`)
+	suite.Require().Error(err)
+}
+
+func TestExpressionSuite(t *testing.T) {
+	suite.Run(t, new(expressionSuite))
+}
@@ -0,0 +1,493 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iofake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	node_common_capnp "github.com/v3io/v3io-go/pkg/dataplane/schemas/node/common"
+	capnp "zombiezen.com/go/capnproto2"
+)
+
+// the body of put and update item requests
+type putItemBody struct {
+	Item                map[string]map[string]interface{}
+	UpdateMode          v3io.UpdateMode
+	UpdateExpression    *string
+	ConditionExpression string
+}
+
+// the body of get items requests
+type getItemsBody struct {
+	AttributesToGet   string
+	FilterExpression  string
+	Marker            string
+	ShardingKey       string
+	Limit             int
+	TotalSegment      int
+	Segment           int
+	SortKeyRangeStart string
+	SortKeyRangeEnd   string
+}
+
+func (s *Server) putItem(parsedRequest *request) error {
+	body := putItemBody{}
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	attributes, err := decodeTypedAttributes(body.Item)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.context.PutItemSync(&v3io.PutItemInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+		Attributes:     attributes,
+		Condition:      body.ConditionExpression,
+		UpdateMode:     body.UpdateMode,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	putItemOutput := response.Output.(*v3io.PutItemOutput)
+
+	return s.writeMtime(parsedRequest, putItemOutput.MtimeSecs, putItemOutput.MtimeNSecs)
+}
+
+func (s *Server) updateItem(parsedRequest *request) error {
+	body := putItemBody{}
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	response, err := s.context.UpdateItemSync(&v3io.UpdateItemInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+		Expression:     body.UpdateExpression,
+		Condition:      body.ConditionExpression,
+		UpdateMode:     body.UpdateMode,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	updateItemOutput := response.Output.(*v3io.UpdateItemOutput)
+
+	return s.writeMtime(parsedRequest, updateItemOutput.MtimeSecs, updateItemOutput.MtimeNSecs)
+}
+
+func (s *Server) getItem(parsedRequest *request) error {
+	body := struct {
+		AttributesToGet string
+	}{}
+
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	response, err := s.context.GetItemSync(&v3io.GetItemInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+		AttributeNames: splitAttributeNames(body.AttributesToGet),
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	typedItem, err := encodeTypedAttributes(response.Output.(*v3io.GetItemOutput).Item)
+	if err != nil {
+		return err
+	}
+
+	return s.writeJSON(parsedRequest, map[string]interface{}{"Item": typedItem})
+}
+
+func (s *Server) getItems(parsedRequest *request) error {
+	body := getItemsBody{}
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	attributeNames := splitAttributeNames(body.AttributesToGet)
+	capnpResponse := parsedRequest.httpRequest.Header.Get("X-v3io-response-content-type") == "capnp"
+
+	// capnp responses carry the name of each item apart from its attributes
+	nameRequested := contains(attributeNames, "__name")
+	if capnpResponse && !nameRequested {
+		if len(attributeNames) == 0 {
+			attributeNames = []string{"*"}
+		}
+
+		attributeNames = append(attributeNames, "__name")
+	}
+
+	response, err := s.context.GetItemsSync(&v3io.GetItemsInput{
+		DataPlaneInput:    parsedRequest.dataPlaneInput,
+		Path:              parsedRequest.path,
+		AttributeNames:    attributeNames,
+		Filter:            body.FilterExpression,
+		Marker:            body.Marker,
+		ShardingKey:       body.ShardingKey,
+		Limit:             body.Limit,
+		Segment:           body.Segment,
+		TotalSegments:     body.TotalSegment,
+		SortKeyRangeStart: body.SortKeyRangeStart,
+		SortKeyRangeEnd:   body.SortKeyRangeEnd,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	getItemsOutput := response.Output.(*v3io.GetItemsOutput)
+
+	if capnpResponse {
+		encodedItems, err := encodeCapnpItems(getItemsOutput.Items, nameRequested)
+		if err != nil {
+			return err
+		}
+
+		parsedRequest.responseWriter.Header().Set("X-v3io-cookie", getItemsOutput.NextMarker)
+
+		return s.write(parsedRequest, "application/octet-capnp", encodedItems)
+	}
+
+	getItemsResponse := struct {
+		Items            []map[string]map[string]interface{}
+		NextMarker       string
+		LastItemIncluded string
+	}{
+		Items:            []map[string]map[string]interface{}{},
+		NextMarker:       getItemsOutput.NextMarker,
+		LastItemIncluded: strings.ToUpper(strconv.FormatBool(getItemsOutput.Last)),
+	}
+
+	for _, item := range getItemsOutput.Items {
+		typedItem, err := encodeTypedAttributes(item)
+		if err != nil {
+			return err
+		}
+
+		getItemsResponse.Items = append(getItemsResponse.Items, typedItem)
+	}
+
+	return s.writeJSON(parsedRequest, &getItemsResponse)
+}
+
+func (s *Server) writeMtime(parsedRequest *request, mtimeSecs int, mtimeNSecs int) error {
+	parsedRequest.responseWriter.Header().Set("X-v3io-transaction-verifier",
+		fmt.Sprintf("__mtime_secs==%d and __mtime_nsecs==%d", mtimeSecs, mtimeNSecs))
+	parsedRequest.responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+// encodes items as the platform does - a header section, followed by a section holding the attribute names,
+// values and items. the attribute values are held in a single list which the items index into
+func encodeCapnpItems(items []v3io.Item, nameRequested bool) ([]byte, error) {
+	var encodedItems bytes.Buffer
+	encoder := capnp.NewEncoder(&encodedItems)
+
+	// the header section isn't read by the SDK
+	headerMessage, headerSegment, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create header message")
+	}
+
+	header, err := node_common_capnp.NewRootVnObjectItemsGetResponseHeader(headerSegment)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create header")
+	}
+
+	header.SetNumItems(uint64(len(items)))
+
+	if err := encoder.Encode(headerMessage); err != nil {
+		return nil, errors.Wrap(err, "Failed to encode header")
+	}
+
+	metadataMessage, metadataSegment, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create metadata message")
+	}
+
+	metadataPayload, err := node_common_capnp.NewRootVnObjectItemsGetResponseMetadataPayload(metadataSegment)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create metadata payload")
+	}
+
+	// collect the attribute names and count the values
+	attributeNameIndexes := map[string]int{}
+	var attributeNames []string
+	numValues := 0
+
+	for _, item := range items {
+		for attributeName := range item {
+			if attributeName == "__name" && !nameRequested {
+				continue
+			}
+
+			if _, found := attributeNameIndexes[attributeName]; !found {
+				attributeNameIndexes[attributeName] = len(attributeNames)
+				attributeNames = append(attributeNames, attributeName)
+			}
+
+			numValues++
+		}
+	}
+
+	keyMap, err := metadataPayload.NewKeyMap()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create key map")
+	}
+
+	keyMapNames, err := keyMap.NewNames()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create key map names")
+	}
+
+	keyMapNamesArr, err := keyMapNames.NewArr(int32(len(attributeNames)))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create key map names array")
+	}
+
+	for attributeNameIdx, attributeName := range attributeNames {
+		if err := keyMapNamesArr.At(attributeNameIdx).SetStr(attributeName); err != nil {
+			return nil, errors.Wrap(err, "Failed to set attribute name")
+		}
+	}
+
+	valueMap, err := metadataPayload.NewValueMap()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create value map")
+	}
+
+	values, err := valueMap.NewValues(int32(numValues))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create values")
+	}
+
+	capnpItems, err := metadataPayload.NewItems(int32(len(items)))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create items")
+	}
+
+	valueIdx := 0
+
+	for itemIdx, item := range items {
+		capnpItem, err := capnpItems.At(itemIdx).NewItem()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create item")
+		}
+
+		itemName, _ := item["__name"].(string)
+		if err := capnpItem.SetName(itemName); err != nil {
+			return nil, errors.Wrap(err, "Failed to set item name")
+		}
+
+		// encode the attributes in a stable order
+		var itemAttributeNames []string
+		for attributeName := range item {
+			if attributeName != "__name" || nameRequested {
+				itemAttributeNames = append(itemAttributeNames, attributeName)
+			}
+		}
+
+		sort.Strings(itemAttributeNames)
+
+		itemAttributes, err := capnpItem.NewAttrs(int32(len(itemAttributeNames)))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create item attributes")
+		}
+
+		for itemAttributeIdx, attributeName := range itemAttributeNames {
+			value, err := values.At(valueIdx).NewValue()
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to create value")
+			}
+
+			if err := encodeCapnpValue(value, item[attributeName]); err != nil {
+				return nil, errors.Wrapf(err, "Failed to encode attribute %s", attributeName)
+			}
+
+			itemAttributes.At(itemAttributeIdx).SetKeyMapIndex(uint64(attributeNameIndexes[attributeName]))
+			itemAttributes.At(itemAttributeIdx).SetValueMapIndex(uint64(valueIdx))
+
+			valueIdx++
+		}
+	}
+
+	if err := encoder.Encode(metadataMessage); err != nil {
+		return nil, errors.Wrap(err, "Failed to encode metadata")
+	}
+
+	return encodedItems.Bytes(), nil
+}
+
+func encodeCapnpValue(value node_common_capnp.ExtAttrValue, attributeValue interface{}) error {
+	switch typedAttributeValue := attributeValue.(type) {
+	case int:
+		value.SetQword(int64(typedAttributeValue))
+	case float64:
+		value.SetDfloat(typedAttributeValue)
+	case string:
+		return value.SetStr(typedAttributeValue)
+	case []byte:
+		return value.SetBlob(typedAttributeValue)
+	case bool:
+		value.SetBoolean(typedAttributeValue)
+	case time.Time:
+		timeSpec, err := value.NewTime()
+		if err != nil {
+			return err
+		}
+
+		timeSpec.SetTvSec(typedAttributeValue.Unix())
+		timeSpec.SetTvNsec(int64(typedAttributeValue.Nanosecond()))
+	default:
+		return errors.Errorf("Unexpected attribute type: %T", attributeValue)
+	}
+
+	return nil
+}
+
+// {"age": 30, "name": "foo"} -> {"age": {"N": "30"}, "name": {"S": "foo"}}
+func encodeTypedAttributes(attributes map[string]interface{}) (map[string]map[string]interface{}, error) {
+	typedAttributes := map[string]map[string]interface{}{}
+
+	for attributeName, attributeValue := range attributes {
+		switch value := attributeValue.(type) {
+		case int:
+			typedAttributes[attributeName] = map[string]interface{}{"N": strconv.Itoa(value)}
+		case float64:
+
+			// formatted with an exponent so that whole numbers aren't decoded as ints
+			typedAttributes[attributeName] = map[string]interface{}{"N": strconv.FormatFloat(value, 'E', -1, 64)}
+		case string:
+			typedAttributes[attributeName] = map[string]interface{}{"S": value}
+		case []byte:
+			typedAttributes[attributeName] = map[string]interface{}{"B": base64.StdEncoding.EncodeToString(value)}
+		case bool:
+			typedAttributes[attributeName] = map[string]interface{}{"BOOL": value}
+		case time.Time:
+			typedAttributes[attributeName] = map[string]interface{}{
+				"TS": fmt.Sprintf("%d:%d", value.Unix(), value.Nanosecond()),
+			}
+		default:
+			return nil, errors.Errorf("Unexpected attribute type for %s: %T", attributeName, attributeValue)
+		}
+	}
+
+	return typedAttributes, nil
+}
+
+// {"age": {"N": "30"}, "name": {"S": "foo"}} -> {"age": 30, "name": "foo"}
+func decodeTypedAttributes(typedAttributes map[string]map[string]interface{}) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+
+	for attributeName, typedAttributeValue := range typedAttributes {
+		for attributeType, value := range typedAttributeValue {
+			decodedValue, err := decodeTypedValue(attributeType, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to decode attribute %s", attributeName)
+			}
+
+			attributes[attributeName] = decodedValue
+		}
+	}
+
+	return attributes, nil
+}
+
+func decodeTypedValue(attributeType string, value interface{}) (interface{}, error) {
+	if attributeType == "BOOL" {
+		boolValue, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("Expected a bool, got %T", value)
+		}
+
+		return boolValue, nil
+	}
+
+	stringValue, ok := value.(string)
+	if !ok {
+		return nil, errors.Errorf("Expected a string for type %s, got %T", attributeType, value)
+	}
+
+	switch attributeType {
+	case "N":
+		if intValue, err := strconv.Atoi(stringValue); err == nil {
+			return intValue, nil
+		}
+
+		return strconv.ParseFloat(stringValue, 64)
+	case "S":
+		return stringValue, nil
+	case "B":
+		return base64.StdEncoding.DecodeString(stringValue)
+	case "TS":
+		var seconds, nanoseconds int64
+		if _, err := fmt.Sscanf(stringValue, "%d:%d", &seconds, &nanoseconds); err != nil {
+			return nil, errors.Wrapf(err, "Invalid timestamp: %s", stringValue)
+		}
+
+		return time.Unix(seconds, nanoseconds), nil
+	default:
+		return nil, errors.Errorf("Unexpected attribute type: %s", attributeType)
+	}
+}
+
+func splitAttributeNames(attributesToGet string) []string {
+	if attributesToGet == "" {
+		return nil
+	}
+
+	return strings.Split(attributesToGet, ",")
+}
+
+func contains(values []string, value string) bool {
+	for _, candidateValue := range values {
+		if candidateValue == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iofake
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nuclio/errors"
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iomock "github.com/v3io/v3io-go/pkg/dataplane/mock"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+)
+
+type NewServerInput struct {
+
+	// if set, requests must carry it as their access key
+	AccessKey string
+}
+
+// Server is a local v3io server, speaking the subset of the protocol used by the SDK. its state is held by a mock
+// context, so that tests can seed and inspect it directly
+type Server struct {
+	*httptest.Server
+	context       *v3iomock.Context
+	accessKey     string
+	lastRequestID uint64
+}
+
+// a request to the server, parsed
+type request struct {
+	responseWriter http.ResponseWriter
+	httpRequest    *http.Request
+	dataPlaneInput v3io.DataPlaneInput
+	path           string
+	body           []byte
+}
+
+// NewServer starts a server listening on a local port. the server must be closed when done
+func NewServer(newServerInput *NewServerInput) *Server {
+	newServer := &Server{
+		context:   v3iomock.NewContext(),
+		accessKey: newServerInput.AccessKey,
+	}
+
+	newServer.Server = httptest.NewServer(newServer)
+
+	return newServer
+}
+
+// Context returns the context holding the server's state
+func (s *Server) Context() *v3iomock.Context {
+	return s.context
+}
+
+// ServeHTTP handles a single request
+func (s *Server) ServeHTTP(responseWriter http.ResponseWriter, httpRequest *http.Request) {
+	if err := s.handleRequest(responseWriter, httpRequest); err != nil {
+		s.writeError(responseWriter, httpRequest, err)
+	}
+}
+
+func (s *Server) handleRequest(responseWriter http.ResponseWriter, httpRequest *http.Request) error {
+	if s.accessKey != "" && httpRequest.Header.Get("X-v3io-session-key") != s.accessKey {
+		return v3ioerrors.NewErrorWithStatusCode(errors.New("Invalid access key"), http.StatusUnauthorized)
+	}
+
+	body, err := ioutil.ReadAll(httpRequest.Body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read request body")
+	}
+
+	// the path is /<container>/<path>, with the trailing slash of directory paths retained
+	pathParts := strings.SplitN(strings.TrimPrefix(httpRequest.URL.Path, "/"), "/", 2)
+	if pathParts[0] == "" {
+		return errors.New("Container name must not be empty")
+	}
+
+	parsedRequest := &request{
+		responseWriter: responseWriter,
+		httpRequest:    httpRequest,
+		dataPlaneInput: v3io.DataPlaneInput{ContainerName: pathParts[0]},
+		body:           body,
+	}
+
+	if len(pathParts) > 1 {
+		parsedRequest.path = pathParts[1]
+	}
+
+	switch functionName := httpRequest.Header.Get("X-v3io-function"); functionName {
+	case "":
+		return s.handleObjectRequest(parsedRequest)
+	case "GetClusterMD":
+		return s.getClusterMD(parsedRequest)
+	case "DirSetAttr":
+		return s.updateObject(parsedRequest)
+	case "PutItem":
+		return s.putItem(parsedRequest)
+	case "UpdateItem":
+		return s.updateItem(parsedRequest)
+	case "GetItem":
+		return s.getItem(parsedRequest)
+	case "GetItems":
+		return s.getItems(parsedRequest)
+	case "CreateStream":
+		return s.createStream(parsedRequest)
+	case "DescribeStream":
+		return s.describeStream(parsedRequest)
+	case "UpdateStream":
+		return s.updateStream(parsedRequest)
+	case "PutRecords":
+		return s.putRecords(parsedRequest)
+	case "SeekShard":
+		return s.seekShard(parsedRequest)
+	case "GetRecords":
+		return s.getRecords(parsedRequest)
+	default:
+		return errors.Wrapf(v3ioerrors.ErrNotSupported, "Function %s is not supported", functionName)
+	}
+}
+
+func (s *Server) handleObjectRequest(parsedRequest *request) error {
+	isDirectory := strings.HasSuffix(parsedRequest.path, "/")
+
+	switch parsedRequest.httpRequest.Method {
+	case http.MethodGet:
+
+		// requests to the root of the container list it
+		if parsedRequest.path == "" {
+			return s.getContainerContents(parsedRequest)
+		}
+
+		return s.getObject(parsedRequest)
+	case http.MethodPut:
+		return s.context.PutObjectSync(&v3io.PutObjectInput{
+			DataPlaneInput: parsedRequest.dataPlaneInput,
+			Path:           parsedRequest.path,
+			Body:           parsedRequest.body,
+			Append:         parsedRequest.httpRequest.Header.Get("Range") == "-1",
+			IsDirectory:    isDirectory,
+		})
+	case http.MethodHead:
+		return s.context.CheckPathExistsSync(&v3io.CheckPathExistsInput{
+			DataPlaneInput: parsedRequest.dataPlaneInput,
+			Path:           parsedRequest.path,
+			IsDirectory:    isDirectory,
+		})
+	case http.MethodDelete:
+		return s.context.DeleteObjectSync(&v3io.DeleteObjectInput{
+			DataPlaneInput: parsedRequest.dataPlaneInput,
+			Path:           parsedRequest.path,
+			IsDirectory:    isDirectory,
+		})
+	default:
+		return v3ioerrors.NewErrorWithStatusCode(errors.Errorf("Method %s is not allowed", parsedRequest.httpRequest.Method),
+			http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) getObject(parsedRequest *request) error {
+	getObjectInput := v3io.GetObjectInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+	}
+
+	// ranges are of the form bytes=<first>-<last>, inclusive
+	if rangeHeader := parsedRequest.httpRequest.Header.Get("Range"); rangeHeader != "" {
+		var firstByte, lastByte int
+		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &firstByte, &lastByte); err != nil {
+			return errors.Wrapf(err, "Invalid range: %s", rangeHeader)
+		}
+
+		getObjectInput.Offset = firstByte
+		getObjectInput.NumBytes = lastByte - firstByte + 1
+	}
+
+	response, err := s.context.GetObjectSync(&getObjectInput)
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.write(parsedRequest, "application/octet-stream", response.Body())
+}
+
+func (s *Server) getContainerContents(parsedRequest *request) error {
+	query, err := url.ParseQuery(parsedRequest.httpRequest.URL.RawQuery)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse query")
+	}
+
+	getContainerContentsInput := v3io.GetContainerContentsInput{
+		DataPlaneInput:   parsedRequest.dataPlaneInput,
+		Path:             query.Get("prefix"),
+		GetAllAttributes: query.Get("prefix-info") == "1",
+		DirectoriesOnly:  query.Get("prefix-only") == "1",
+		Marker:           query.Get("marker"),
+	}
+
+	if maxKeys := query.Get("max-keys"); maxKeys != "" {
+		if getContainerContentsInput.Limit, err = strconv.Atoi(maxKeys); err != nil {
+			return errors.Wrapf(err, "Invalid max-keys: %s", maxKeys)
+		}
+	}
+
+	response, err := s.context.GetContainerContentsSync(&getContainerContentsInput)
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	listBucketResult := struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		*v3io.GetContainerContentsOutput
+		Buckets *v3io.Containers `xml:"Buckets,omitempty"`
+	}{
+		GetContainerContentsOutput: response.Output.(*v3io.GetContainerContentsOutput),
+	}
+
+	// listing the container root without a query is indistinguishable from listing the containers, so
+	// answer both
+	if parsedRequest.httpRequest.URL.RawQuery == "" {
+		getContainersResponse, err := s.context.GetContainersSync(&v3io.GetContainersInput{})
+		if err != nil {
+			return err
+		}
+
+		defer getContainersResponse.Release()
+
+		listBucketResult.Buckets = &getContainersResponse.Output.(*v3io.GetContainersOutput).Results
+	}
+
+	encodedListBucketResult, err := xml.Marshal(&listBucketResult)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode container contents")
+	}
+
+	return s.write(parsedRequest, "application/xml", encodedListBucketResult)
+}
+
+func (s *Server) updateObject(parsedRequest *request) error {
+	dirAttributes := v3io.DirAttributes{}
+	if err := json.Unmarshal(parsedRequest.body, &dirAttributes); err != nil {
+		return errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	return s.context.UpdateObjectSync(&v3io.UpdateObjectInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+		DirAttributes:  &dirAttributes,
+		IsDirectory:    strings.HasSuffix(parsedRequest.path, "/"),
+	})
+}
+
+func (s *Server) getClusterMD(parsedRequest *request) error {
+	response, err := s.context.GetClusterMDSync(&v3io.GetClusterMDInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.writeJSON(parsedRequest, response.Output)
+}
+
+func (s *Server) writeJSON(parsedRequest *request, output interface{}) error {
+	encodedOutput, err := json.Marshal(output)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode response")
+	}
+
+	return s.write(parsedRequest, "application/json", encodedOutput)
+}
+
+func (s *Server) write(parsedRequest *request, contentType string, body []byte) error {
+	parsedRequest.responseWriter.Header().Set("Content-Type", contentType)
+	parsedRequest.responseWriter.WriteHeader(http.StatusOK)
+
+	_, err := parsedRequest.responseWriter.Write(body)
+	return err
+}
+
+// writes the error the way the platform does - errors carrying a status code respond with it, unsupported
+// features with 501 and anything else with 400
+func (s *Server) writeError(responseWriter http.ResponseWriter, httpRequest *http.Request, err error) {
+	statusCode := http.StatusBadRequest
+
+	if errorWithStatusCode, ok := err.(interface{ StatusCode() int }); ok {
+		statusCode = errorWithStatusCode.StatusCode()
+	} else if errors.RootCause(err) == v3ioerrors.ErrNotSupported {
+		statusCode = http.StatusNotImplemented
+	}
+
+	encodedErrorBody, _ := json.Marshal(map[string]string{
+		"ErrorMessage": err.Error(),
+		"Resource":     httpRequest.URL.Path,
+		"RequestId":    strconv.FormatUint(atomic.AddUint64(&s.lastRequestID, 1), 10),
+	})
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	responseWriter.Write(encodedErrorBody) // nolint: errcheck
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iofake
+
+import (
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iohttp "github.com/v3io/v3io-go/pkg/dataplane/http"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type serverTestSuite struct {
+	suite.Suite
+	logger    logger.Logger
+	server    *Server
+	context   v3io.Context
+	container v3io.Container
+}
+
+func (suite *serverTestSuite) SetupTest() {
+	var err error
+
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	suite.server = NewServer(&NewServerInput{AccessKey: "some-access-key"})
+
+	suite.context, err = v3iohttp.NewContext(suite.logger, &v3iohttp.NewContextInput{})
+	suite.Require().NoError(err)
+
+	session, err := suite.context.NewSession(&v3io.NewSessionInput{
+		URL:       suite.server.URL,
+		AccessKey: "some-access-key",
+	})
+	suite.Require().NoError(err)
+
+	suite.container, err = session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+}
+
+func (suite *serverTestSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+	suite.server.Close()
+}
+
+func (suite *serverTestSuite) TestObjects() {
+	err := suite.container.PutObjectSync(&v3io.PutObjectInput{Path: "/dir/a", Body: []byte("hello")})
+	suite.Require().NoError(err)
+
+	err = suite.container.PutObjectSync(&v3io.PutObjectInput{Path: "/dir/a", Body: []byte(" world"), Append: true})
+	suite.Require().NoError(err)
+
+	response, err := suite.container.GetObjectSync(&v3io.GetObjectInput{Path: "/dir/a", Offset: 1, NumBytes: 4})
+	suite.Require().NoError(err)
+	suite.Require().Equal("ello", string(response.Body()))
+	response.Release()
+
+	response, err = suite.container.GetContainerContentsSync(&v3io.GetContainerContentsInput{Path: "dir/"})
+	suite.Require().NoError(err)
+	suite.Require().Len(response.Output.(*v3io.GetContainerContentsOutput).Contents, 1)
+	response.Release()
+
+	response, err = suite.context.GetContainersSync(&v3io.GetContainersInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: suite.server.URL, ContainerName: "bigdata", AccessKey: "some-access-key"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("bigdata", response.Output.(*v3io.GetContainersOutput).Results.Containers[0].Name)
+	response.Release()
+
+	err = suite.container.DeleteObjectSync(&v3io.DeleteObjectInput{Path: "/dir/a"})
+	suite.Require().NoError(err)
+
+	// the status code of errors is kept
+	err = suite.container.CheckPathExistsSync(&v3io.CheckPathExistsInput{Path: "/dir/a"})
+	suite.Require().Error(err)
+	suite.Require().Equal(404, err.(v3ioerrors.ErrorWithStatusCode).StatusCode())
+}
+
+func (suite *serverTestSuite) TestItems() {
+	for _, itemName := range []string{"c", "a", "b"} {
+		_, err := suite.container.PutItemSync(&v3io.PutItemInput{
+			Path:       "/table/" + itemName,
+			Attributes: map[string]interface{}{"name": itemName, "value": 1, "ratio": 2.0},
+		})
+		suite.Require().NoError(err)
+	}
+
+	response, err := suite.container.UpdateItemSync(&v3io.UpdateItemInput{
+		Path:       "/table/a",
+		Attributes: map[string]interface{}{"value": 2},
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotZero(response.Output.(*v3io.UpdateItemOutput).MtimeSecs)
+	response.Release()
+
+	response, err = suite.container.GetItemSync(&v3io.GetItemInput{
+		Path:           "/table/a",
+		AttributeNames: []string{"*"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(v3io.Item{"name": "a", "value": 2, "ratio": 2.0}, response.Output.(*v3io.GetItemOutput).Item)
+	response.Release()
+
+	// read the table in pages, through both response encodings
+	for _, requestJSONResponse := range []bool{false, true} {
+		var itemNames []string
+
+		getItemsInput := v3io.GetItemsInput{
+			Path:                "/table/",
+			AttributeNames:      []string{"__name", "value"},
+			Limit:               2,
+			RequestJSONResponse: requestJSONResponse,
+		}
+
+		for {
+			response, err := suite.container.GetItemsSync(&getItemsInput)
+			suite.Require().NoError(err)
+
+			getItemsOutput := response.Output.(*v3io.GetItemsOutput)
+			for _, item := range getItemsOutput.Items {
+				itemNames = append(itemNames, item["__name"].(string))
+				suite.Require().Contains(item, "value")
+			}
+
+			response.Release()
+
+			if getItemsOutput.Last {
+				break
+			}
+
+			getItemsInput.Marker = getItemsOutput.NextMarker
+		}
+
+		suite.Require().Equal([]string{"a", "b", "c"}, itemNames)
+	}
+
+	// the names are returned without being requested as an attribute
+	for _, requestJSONResponse := range []bool{false, true} {
+		response, err := suite.container.GetItemsSync(&v3io.GetItemsInput{
+			Path:                "/table/",
+			AttributeNames:      []string{"value"},
+			ReturnItemName:      true,
+			RequestJSONResponse: requestJSONResponse,
+		})
+		suite.Require().NoError(err)
+		suite.Require().Len(response.Output.(*v3io.GetItemsOutput).Items, 3)
+
+		for _, item := range response.Output.(*v3io.GetItemsOutput).Items {
+			itemName, err := item.GetName()
+			suite.Require().NoError(err)
+			suite.Require().Contains([]string{"a", "b", "c"}, itemName)
+		}
+
+		response.Release()
+	}
+}
+
+func (suite *serverTestSuite) TestStreams() {
+	err := suite.container.CreateStreamSync(&v3io.CreateStreamInput{
+		Path:                 "/stream/",
+		ShardCount:           2,
+		RetentionPeriodHours: 1,
+	})
+	suite.Require().NoError(err)
+
+	shardID := 1
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{Data: []byte("first"), ShardID: &shardID},
+			{Data: []byte("second"), ShardID: &shardID},
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Zero(response.Output.(*v3io.PutRecordsOutput).FailedRecordCount)
+	response.Release()
+
+	response, err = suite.container.SeekShardSync(&v3io.SeekShardInput{
+		Path:                   "/stream/1",
+		Type:                   v3io.SeekShardInputTypeSequence,
+		StartingSequenceNumber: 2,
+	})
+	suite.Require().NoError(err)
+	location := response.Output.(*v3io.SeekShardOutput).Location
+	response.Release()
+
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/1", Location: location, Limit: 10})
+	suite.Require().NoError(err)
+	records := response.Output.(*v3io.GetRecordsOutput).Records
+	suite.Require().Len(records, 1)
+	suite.Require().Equal("second", string(records[0].Data))
+	response.Release()
+
+	response, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/", IncludeShards: true})
+	suite.Require().NoError(err)
+	shards := response.Output.(*v3io.DescribeStreamOutput).Shards
+	suite.Require().Len(shards, 2)
+	suite.Require().Equal(v3io.ShardDescription{}, shards[0])
+	suite.Require().Equal(1, shards[1].ShardID)
+	suite.Require().Equal(uint64(2), shards[1].Length)
+	suite.Require().Equal(uint64(1), shards[1].EarliestSequenceNumber)
+	suite.Require().Equal(uint64(2), shards[1].LatestSequenceNumber)
+	suite.Require().WithinDuration(time.Now(), shards[1].LastWriteTime, time.Minute)
+	response.Release()
+
+	err = suite.container.(v3io.StreamUpdater).UpdateStreamSync(&v3io.UpdateStreamInput{Path: "/stream/", ShardCount: 4})
+	suite.Require().NoError(err)
+
+	response, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(4, response.Output.(*v3io.DescribeStreamOutput).ShardCount)
+	suite.Require().Equal(1, response.Output.(*v3io.DescribeStreamOutput).RetentionPeriodHours)
+	response.Release()
+
+	// shards can't be removed
+	err = suite.container.(v3io.StreamUpdater).UpdateStreamSync(&v3io.UpdateStreamInput{Path: "/stream/", ShardCount: 3})
+	suite.Require().Error(err)
+
+	err = suite.container.DeleteStreamSync(&v3io.DeleteStreamInput{Path: "/stream/"})
+	suite.Require().NoError(err)
+
+	_, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/"})
+	suite.Require().Error(err)
+}
+
+func (suite *serverTestSuite) TestUnsupportedAndUnauthorized() {
+	err := suite.container.PutChunkSync(&v3io.PutChunkInput{Path: "/stream/0"})
+	suite.Require().Equal(v3ioerrors.ErrNotSupported, errors.RootCause(err))
+
+	err = suite.context.CheckPathExistsSync(&v3io.CheckPathExistsInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: suite.server.URL, ContainerName: "bigdata"},
+		Path:           "/",
+	})
+	suite.Require().Error(err)
+	suite.Require().Equal(401, err.(v3ioerrors.ErrorWithStatusCode).StatusCode())
+}
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(serverTestSuite))
+}
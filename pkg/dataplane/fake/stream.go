@@ -0,0 +1,188 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iofake
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nuclio/errors"
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// map between encoded seek types and SeekShardInputType
+var seekShardInputTypes = map[string]v3io.SeekShardInputType{
+	"TIME":     v3io.SeekShardInputTypeTime,
+	"SEQUENCE": v3io.SeekShardInputTypeSequence,
+	"LATEST":   v3io.SeekShardInputTypeLatest,
+	"EARLIEST": v3io.SeekShardInputTypeEarliest,
+}
+
+func (s *Server) createStream(parsedRequest *request) error {
+	body := struct {
+		ShardCount           int
+		RetentionPeriodHours int
+		RetentionPeriodSec   int
+	}{}
+
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	createStreamInput := v3io.CreateStreamInput{
+		DataPlaneInput:         parsedRequest.dataPlaneInput,
+		Path:                   parsedRequest.path,
+		ShardCount:             body.ShardCount,
+		RetentionPeriodHours:   body.RetentionPeriodHours,
+		RetentionPeriodSeconds: body.RetentionPeriodSec,
+	}
+
+	return s.context.CreateStreamSync(&createStreamInput)
+}
+
+func (s *Server) updateStream(parsedRequest *request) error {
+	updateStreamInput := v3io.UpdateStreamInput{}
+	if err := json.Unmarshal(parsedRequest.body, &updateStreamInput); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	updateStreamInput.DataPlaneInput = parsedRequest.dataPlaneInput
+	updateStreamInput.Path = parsedRequest.path
+
+	return s.context.UpdateStreamSync(&updateStreamInput)
+}
+
+func (s *Server) describeStream(parsedRequest *request) error {
+	response, err := s.context.DescribeStreamSync(&v3io.DescribeStreamInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.writeJSON(parsedRequest, response.Output)
+}
+
+func (s *Server) putRecords(parsedRequest *request) error {
+	body := struct {
+		Records []struct {
+			Data         []byte
+			ClientInfo   []byte
+			ShardID      *int `json:"ShardId"`
+			PartitionKey string
+		}
+	}{}
+
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	putRecordsInput := v3io.PutRecordsInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+	}
+
+	for _, record := range body.Records {
+		putRecordsInput.Records = append(putRecordsInput.Records, &v3io.StreamRecord{
+			Data:         record.Data,
+			ClientInfo:   record.ClientInfo,
+			ShardID:      record.ShardID,
+			PartitionKey: record.PartitionKey,
+		})
+	}
+
+	response, err := s.context.PutRecordsSync(&putRecordsInput)
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.writeJSON(parsedRequest, response.Output)
+}
+
+func (s *Server) seekShard(parsedRequest *request) error {
+	body := struct {
+		Type                   string
+		StartingSequenceNumber uint64
+		TimestampSec           int
+		TimestampNSec          int
+	}{}
+
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	seekShardInputType, found := seekShardInputTypes[body.Type]
+	if !found {
+		return errors.Errorf("Invalid seek type: %s", body.Type)
+	}
+
+	response, err := s.context.SeekShardSync(&v3io.SeekShardInput{
+		DataPlaneInput:         parsedRequest.dataPlaneInput,
+		Path:                   parsedRequest.path,
+		Type:                   seekShardInputType,
+		StartingSequenceNumber: body.StartingSequenceNumber,
+		Timestamp:              body.TimestampSec,
+		TimestampNanoseconds:   body.TimestampNSec,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.writeJSON(parsedRequest, response.Output)
+}
+
+func (s *Server) getRecords(parsedRequest *request) error {
+	body := struct {
+		Location        string
+		Limit           int
+		MaxWaitTimeMSec int
+		MaxBytes        int
+	}{}
+
+	if err := json.Unmarshal(parsedRequest.body, &body); err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	getRecordsInput := v3io.GetRecordsInput{
+		DataPlaneInput: parsedRequest.dataPlaneInput,
+		Path:           parsedRequest.path,
+		Location:       body.Location,
+		Limit:          body.Limit,
+		MaxWaitTime:    time.Duration(body.MaxWaitTimeMSec) * time.Millisecond,
+		MaxBytes:       body.MaxBytes,
+	}
+
+	response, err := s.context.GetRecordsSync(&getRecordsInput)
+	if err != nil {
+		return err
+	}
+
+	defer response.Release()
+
+	return s.writeJSON(parsedRequest, response.Output)
+}
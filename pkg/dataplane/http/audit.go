@@ -0,0 +1,106 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// the operations of the functions which modify data, by function name
+var auditedFunctionOperations = map[string]string{
+	putItemFunctionName:      "PutItem",
+	updateItemFunctionName:   "UpdateItem",
+	createStreamFunctionName: "CreateStream",
+	updateStreamFunctionName: "UpdateStream",
+	putRecordsFunctionName:   "PutRecords",
+	PutChunkFunctionName:     "PutChunk",
+	putOOSObjectFunctionName: "PutOOSObject",
+	"DirSetAttr":             "UpdateObject",
+}
+
+func (c *context) audit(dataPlaneInput *v3io.DataPlaneInput,
+	method string,
+	path string,
+	functionName string,
+	startTime time.Time,
+	statusCode int,
+	err error) {
+
+	operation, audited := getAuditedOperation(method, functionName)
+	if !audited {
+		return
+	}
+
+	c.auditHandler(&AuditEntry{
+		Time:       startTime,
+		Principal:  getPrincipal(dataPlaneInput),
+		Container:  dataPlaneInput.ContainerName,
+		Path:       path,
+		Operation:  operation,
+		RequestID:  dataPlaneInput.RequestID,
+		StatusCode: statusCode,
+		Err:        err,
+	})
+}
+
+// returns the operation of a request if it modifies data. requests without a function are object operations
+func getAuditedOperation(method string, functionName string) (string, bool) {
+	if method == http.MethodGet || method == http.MethodHead {
+		return "", false
+	}
+
+	if functionName == "" {
+		if method == http.MethodDelete {
+			return "DeleteObject", true
+		}
+
+		return "PutObject", true
+	}
+
+	operation, audited := auditedFunctionOperations[functionName]
+	return operation, audited
+}
+
+// returns the username of basic authentication, or a fingerprint of the access key, which identifies the
+// principal without exposing the key
+func getPrincipal(dataPlaneInput *v3io.DataPlaneInput) string {
+	if dataPlaneInput.AccessKey != "" {
+		accessKeyHash := sha256.Sum256([]byte(dataPlaneInput.AccessKey))
+		return "access-key:" + hex.EncodeToString(accessKeyHash[:8])
+	}
+
+	if strings.HasPrefix(dataPlaneInput.AuthenticationToken, "Basic ") {
+		usernameAndPassword, err := base64.StdEncoding.DecodeString(
+			strings.TrimPrefix(dataPlaneInput.AuthenticationToken, "Basic "))
+		if err == nil {
+			return strings.SplitN(string(usernameAndPassword), ":", 2)[0]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/base64"
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type auditSuite struct {
+	suite.Suite
+	context      v3io.Context
+	auditEntries []*AuditEntry
+}
+
+func (suite *auditSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.auditEntries = nil
+	suite.context, err = NewContext(logger, &NewContextInput{
+		Transport: &requestIDTransport{},
+		AuditHandler: func(auditEntry *AuditEntry) {
+			suite.auditEntries = append(suite.auditEntries, auditEntry)
+		},
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *auditSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *auditSuite) TestMutatingRequests() {
+	dataPlaneInput := v3io.DataPlaneInput{
+		URL:                 "http://webapi:8081",
+		ContainerName:       "bigdata",
+		AuthenticationToken: "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret")),
+	}
+
+	// reads aren't audited
+	response, err := suite.context.GetObjectSync(&v3io.GetObjectInput{DataPlaneInput: dataPlaneInput, Path: "a"})
+	suite.Require().NoError(err)
+	response.Release()
+	suite.Require().Empty(suite.auditEntries)
+
+	err = suite.context.PutObjectSync(&v3io.PutObjectInput{DataPlaneInput: dataPlaneInput, Path: "a", Body: []byte("a")})
+	suite.Require().NoError(err)
+
+	err = suite.context.DeleteObjectSync(&v3io.DeleteObjectInput{DataPlaneInput: dataPlaneInput, Path: "missing"})
+	suite.Require().Error(err)
+
+	suite.Require().Len(suite.auditEntries, 2)
+	suite.Require().Equal("PutObject", suite.auditEntries[0].Operation)
+	suite.Require().Equal("admin", suite.auditEntries[0].Principal)
+	suite.Require().Equal("bigdata", suite.auditEntries[0].Container)
+	suite.Require().Equal("a", suite.auditEntries[0].Path)
+	suite.Require().NotEmpty(suite.auditEntries[0].RequestID)
+	suite.Require().Equal(200, suite.auditEntries[0].StatusCode)
+	suite.Require().NoError(suite.auditEntries[0].Err)
+	suite.Require().Equal("DeleteObject", suite.auditEntries[1].Operation)
+	suite.Require().Equal(404, suite.auditEntries[1].StatusCode)
+	suite.Require().Error(suite.auditEntries[1].Err)
+}
+
+func (suite *auditSuite) TestAccessKeyPrincipal() {
+	principal := getPrincipal(&v3io.DataPlaneInput{AccessKey: "my-access-key"})
+	suite.Require().Contains(principal, "access-key:")
+	suite.Require().NotContains(principal, "my-access-key")
+}
+
+func TestAuditSuite(t *testing.T) {
+	suite.Run(t, new(auditSuite))
+}
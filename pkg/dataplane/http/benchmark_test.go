@@ -0,0 +1,188 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iofake "github.com/v3io/v3io-go/pkg/dataplane/fake"
+
+	"github.com/nuclio/zap"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// creates a context whose requests are served in memory by handler
+func newBenchmarkContext(b *testing.B, handler fasthttp.RequestHandler) *context {
+	listener := fasthttputil.NewInmemoryListener()
+	go fasthttp.Serve(listener, handler) // nolint: errcheck
+
+	b.Cleanup(func() {
+		listener.Close() // nolint: errcheck
+	})
+
+	return newBenchmarkContextWithClient(b, NewClient(&NewClientInput{Dial: func(string) (net.Conn, error) {
+		return listener.Dial()
+	}}))
+}
+
+func newBenchmarkContextWithClient(b *testing.B, client *fasthttp.Client) *context {
+	logger, err := nucliozap.NewNuclioZapCmd("benchmark", nucliozap.WarnLevel)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	newContext, err := NewContext(logger, &NewContextInput{HTTPClient: client})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() {
+		v3io.CloseContext(newContext) // nolint: errcheck
+	})
+
+	return newContext.(*context)
+}
+
+func BenchmarkBuildRequestURI(b *testing.B) {
+	c := &context{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.buildRequestURI("http://webapi:8081", "bigdata", "marker=a b", "some/table/item"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeTypedAttributes(b *testing.B) {
+	c := &context{}
+
+	attributes := map[string]interface{}{
+		"int":    1,
+		"float":  2.5,
+		"string": "some string",
+		"bytes":  []byte("some bytes"),
+		"bool":   true,
+		"time":   time.Unix(1600000000, 1),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.encodeTypedAttributes(attributes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetItemsParseCAPNPResponse(b *testing.B) {
+	server := v3iofake.NewServer(&v3iofake.NewServerInput{})
+	defer server.Close()
+
+	dataPlaneInput := v3io.DataPlaneInput{URL: server.URL, ContainerName: "bigdata"}
+
+	for itemIdx := 0; itemIdx < 256; itemIdx++ {
+		_, err := server.Context().PutItemSync(&v3io.PutItemInput{
+			DataPlaneInput: dataPlaneInput,
+			Path:           fmt.Sprintf("/table/item-%d", itemIdx),
+			Attributes: map[string]interface{}{
+				"int":    itemIdx,
+				"float":  float64(itemIdx) / 2,
+				"string": "some string",
+			},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// get a real capnp response to parse
+	c := newBenchmarkContextWithClient(b, NewClient(&NewClientInput{}))
+	response, err := c.GetItemsSync(&v3io.GetItemsInput{
+		DataPlaneInput: dataPlaneInput,
+		Path:           "/table/",
+		AttributeNames: []string{"*"},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer response.Release()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.getItemsParseCAPNPResponse(response, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendPutRecordsBody(b *testing.B) {
+	shardID := 1
+	data := make([]byte, 1024)
+
+	var records []*v3io.StreamRecord
+	for recordIdx := 0; recordIdx < 64; recordIdx++ {
+		records = append(records, &v3io.StreamRecord{
+			Data:         data,
+			ClientInfo:   []byte("some client info"),
+			ShardID:      &shardID,
+			PartitionKey: "some-partition-key",
+		})
+	}
+
+	var body []byte
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		body = appendPutRecordsBody(body[:0], records)
+	}
+}
+
+func BenchmarkSendRequest(b *testing.B) {
+	c := newBenchmarkContext(b, func(requestCtx *fasthttp.RequestCtx) {
+		requestCtx.SetStatusCode(fasthttp.StatusOK)
+	})
+
+	dataPlaneInput := v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"}
+	body := []byte(`{"AttributesToGet": "*"}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.sendRequest(&dataPlaneInput, "PUT", "/table/item", "", getItemHeaders, body, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
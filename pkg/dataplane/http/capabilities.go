@@ -0,0 +1,82 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"sync"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// capabilityTracker learns which optional APIs the server supports from the responses to requests, so that
+// higher layers don't need to know which cluster versions support what
+type capabilityTracker struct {
+	lock                     sync.Mutex
+	unsupportedFunctionNames map[string]bool
+	capnpGetItems            bool
+}
+
+func newCapabilityTracker() *capabilityTracker {
+	return &capabilityTracker{
+		unsupportedFunctionNames: map[string]bool{},
+		capnpGetItems:            true,
+	}
+}
+
+// called when the server rejected a request of an optional function as unsupported
+func (ct *capabilityTracker) setFunctionUnsupported(functionName string) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.unsupportedFunctionNames[functionName] = true
+}
+
+// called with whether the server encoded a GetItems response with capnp, when requested to
+func (ct *capabilityTracker) setCapnpGetItems(supported bool) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.capnpGetItems = supported
+}
+
+func (ct *capabilityTracker) supportsCapnpGetItems() bool {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	return ct.capnpGetItems
+}
+
+func (ct *capabilityTracker) getCapabilities() *v3io.Capabilities {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	return &v3io.Capabilities{
+		Streams: !ct.unsupportedFunctionNames[createStreamFunctionName] &&
+			!ct.unsupportedFunctionNames[describeStreamFunctionName] &&
+			!ct.unsupportedFunctionNames[updateStreamFunctionName] &&
+			!ct.unsupportedFunctionNames[putRecordsFunctionName] &&
+			!ct.unsupportedFunctionNames[getRecordsFunctionName] &&
+			!ct.unsupportedFunctionNames[seekShardsFunctionName],
+		Chunks:        !ct.unsupportedFunctionNames[PutChunkFunctionName],
+		OOSObjects:    !ct.unsupportedFunctionNames[putOOSObjectFunctionName],
+		CapnpGetItems: ct.capnpGetItems,
+	}
+}
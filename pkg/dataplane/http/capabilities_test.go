@@ -0,0 +1,125 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// a server which responds to GetItems with json and doesn't support chunks
+type legacyServerTransport struct {
+	numCapnpRequests int
+}
+
+func (lst *legacyServerTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	if string(request.Header.Peek("X-v3io-response-content-type")) == "capnp" {
+		lst.numCapnpRequests++
+	}
+
+	switch string(request.Header.Peek("X-v3io-function")) {
+	case getItemsFunctionName:
+		response.SetStatusCode(fasthttp.StatusOK)
+		response.Header.SetContentType("application/json")
+		response.SetBodyString(`{"Items": [], "LastItemIncluded": "TRUE"}`)
+	default:
+		response.SetStatusCode(fasthttp.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+type capabilitiesSuite struct {
+	suite.Suite
+	transport *legacyServerTransport
+	context   v3io.Context
+}
+
+func (suite *capabilitiesSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.transport = &legacyServerTransport{}
+	suite.context, err = NewContext(logger, &NewContextInput{Transport: suite.transport})
+	suite.Require().NoError(err)
+}
+
+func (suite *capabilitiesSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *capabilitiesSuite) TestLearnedFromResponses() {
+	suite.Require().Equal(&v3io.Capabilities{
+		Streams:       true,
+		Chunks:        true,
+		OOSObjects:    true,
+		CapnpGetItems: true,
+	}, suite.context.(v3io.CapabilitiesContext).Capabilities())
+
+	for i := 0; i < 2; i++ {
+		response, err := suite.context.GetItemsSync(&v3io.GetItemsInput{
+			DataPlaneInput: suite.getDataPlaneInput(),
+			Path:           "table/",
+		})
+		suite.Require().NoError(err)
+		response.Release()
+
+		// the response's headers outlive it
+		suite.Require().Equal("application/json",
+			response.Output.(*v3io.GetItemsOutput).ResponseHeader("content-type"))
+	}
+
+	// capnp is requested only until the server responds with json
+	suite.Require().Equal(1, suite.transport.numCapnpRequests)
+
+	err := suite.context.PutChunkSync(&v3io.PutChunkInput{
+		DataPlaneInput: suite.getDataPlaneInput(),
+		Path:           "file",
+	})
+	suite.Require().Equal(v3ioerrors.ErrNotSupported, errors.Cause(err))
+
+	suite.Require().Equal(&v3io.Capabilities{
+		Streams:       true,
+		Chunks:        false,
+		OOSObjects:    true,
+		CapnpGetItems: false,
+	}, suite.context.(v3io.CapabilitiesContext).Capabilities())
+}
+
+func (suite *capabilitiesSuite) getDataPlaneInput() v3io.DataPlaneInput {
+	return v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"}
+}
+
+func TestCapabilitiesSuite(t *testing.T) {
+	suite.Run(t, new(capabilitiesSuite))
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"sync"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+const defaultClusterMDRefreshInterval = time.Minute
+
+// clusterMDCache caches the cluster metadata of each cluster (by URL), since it rarely changes and
+// applications partitioning work by VN may need it per request
+type clusterMDCache struct {
+	lock            sync.Mutex
+	refreshInterval time.Duration
+	entries         map[string]*clusterMDCacheEntry
+	now             func() time.Time
+}
+
+type clusterMDCacheEntry struct {
+	getClusterMDOutput v3io.GetClusterMDOutput
+	fetchedAt          time.Time
+}
+
+func newClusterMDCache(refreshInterval time.Duration) *clusterMDCache {
+	if refreshInterval == 0 {
+		refreshInterval = defaultClusterMDRefreshInterval
+	}
+
+	return &clusterMDCache{
+		refreshInterval: refreshInterval,
+		entries:         map[string]*clusterMDCacheEntry{},
+		now:             time.Now,
+	}
+}
+
+// returns the cached metadata of the cluster, fetching it if it wasn't fetched within the refresh interval.
+// if the fetch fails, the previously fetched metadata is returned
+func (cmc *clusterMDCache) get(url string,
+	fetch func() (*v3io.GetClusterMDOutput, error)) (*v3io.GetClusterMDOutput, error) {
+	cmc.lock.Lock()
+	entry, cached := cmc.entries[url]
+	cmc.lock.Unlock()
+
+	if cached && cmc.now().Sub(entry.fetchedAt) < cmc.refreshInterval {
+		getClusterMDOutput := entry.getClusterMDOutput
+		return &getClusterMDOutput, nil
+	}
+
+	getClusterMDOutput, err := fetch()
+	if err != nil {
+		if cached {
+			staleGetClusterMDOutput := entry.getClusterMDOutput
+			return &staleGetClusterMDOutput, nil
+		}
+
+		return nil, err
+	}
+
+	cmc.lock.Lock()
+	cmc.entries[url] = &clusterMDCacheEntry{
+		getClusterMDOutput: *getClusterMDOutput,
+		fetchedAt:          cmc.now(),
+	}
+	cmc.lock.Unlock()
+
+	return getClusterMDOutput, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type clusterMDCacheSuite struct {
+	suite.Suite
+	cache      *clusterMDCache
+	now        time.Time
+	numFetches int
+	fetchErr   error
+}
+
+func (suite *clusterMDCacheSuite) SetupTest() {
+	suite.now = time.Unix(1000, 0)
+	suite.numFetches = 0
+	suite.fetchErr = nil
+	suite.cache = newClusterMDCache(time.Minute)
+	suite.cache.now = func() time.Time {
+		return suite.now
+	}
+}
+
+func (suite *clusterMDCacheSuite) TestRefresh() {
+	suite.requireNumberOfVNs("http://a:8081", 1)
+	suite.requireNumberOfVNs("http://a:8081", 1)
+	suite.Require().Equal(1, suite.numFetches)
+
+	// cached per cluster
+	suite.requireNumberOfVNs("http://b:8081", 2)
+
+	suite.now = suite.now.Add(time.Minute)
+	suite.requireNumberOfVNs("http://a:8081", 3)
+	suite.Require().Equal(3, suite.numFetches)
+}
+
+func (suite *clusterMDCacheSuite) TestStaleOnError() {
+	suite.requireNumberOfVNs("http://a:8081", 1)
+
+	suite.now = suite.now.Add(time.Minute)
+	suite.fetchErr = errors.New("Unavailable")
+	suite.requireNumberOfVNs("http://a:8081", 1)
+
+	_, err := suite.cache.get("http://b:8081", suite.fetch)
+	suite.Require().Error(err)
+}
+
+func (suite *clusterMDCacheSuite) requireNumberOfVNs(url string, expectedNumberOfVNs int) {
+	getClusterMDOutput, err := suite.cache.get(url, suite.fetch)
+	suite.Require().NoError(err)
+	suite.Require().Equal(expectedNumberOfVNs, getClusterMDOutput.NumberOfVNs)
+}
+
+// each fetch reports one more VN, to tell fetches apart
+func (suite *clusterMDCacheSuite) fetch() (*v3io.GetClusterMDOutput, error) {
+	if suite.fetchErr != nil {
+		return nil, suite.fetchErr
+	}
+
+	suite.numFetches++
+
+	return &v3io.GetClusterMDOutput{NumberOfVNs: suite.numFetches}, nil
+}
+
+func TestClusterMDCacheSuite(t *testing.T) {
+	suite.Run(t, new(clusterMDCacheSuite))
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// wraps a dial function such that connections are closed once they're older than maxConnDuration, since
+// the fasthttp client doesn't support it. an expired connection is only closed before a new request is
+// written to it, by failing the write with io.EOF - on which fasthttp retries the request on a new connection
+func newMaxConnDurationDialFunc(dial fasthttp.DialFunc, maxConnDuration time.Duration) fasthttp.DialFunc {
+	if dial == nil {
+		dial = fasthttp.Dial
+	}
+
+	return func(addr string) (net.Conn, error) {
+		conn, err := dial(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &expiringConn{
+			Conn:           conn,
+			expirationTime: time.Now().Add(maxConnDuration),
+		}, nil
+	}
+}
+
+type expiringConn struct {
+	net.Conn
+	expirationTime time.Time
+
+	// set once a response is read, such that the next write starts a new request
+	readSinceWrite int32
+}
+
+func (ec *expiringConn) Read(buffer []byte) (int, error) {
+	n, err := ec.Conn.Read(buffer)
+	atomic.StoreInt32(&ec.readSinceWrite, 1)
+
+	return n, err
+}
+
+func (ec *expiringConn) Write(buffer []byte) (int, error) {
+	if atomic.SwapInt32(&ec.readSinceWrite, 0) == 1 && time.Now().After(ec.expirationTime) {
+
+		// fasthttp closes the connection on the error
+		return 0, io.EOF
+	}
+
+	return ec.Conn.Write(buffer)
+}
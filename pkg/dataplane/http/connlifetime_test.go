@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+type connLifetimeSuite struct {
+	suite.Suite
+	server *httptest.Server
+
+	// accessed atomically
+	numConns int64
+}
+
+func (suite *connLifetimeSuite) SetupTest() {
+	atomic.StoreInt64(&suite.numConns, 0)
+
+	suite.server = httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	suite.server.Config.ConnState = func(conn net.Conn, connState http.ConnState) {
+		if connState == http.StateNew {
+			atomic.AddInt64(&suite.numConns, 1)
+		}
+	}
+
+	suite.server.Start()
+}
+
+func (suite *connLifetimeSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *connLifetimeSuite) TestMaxConnDuration() {
+	client := NewClient(&NewClientInput{MaxConnDuration: 50 * time.Millisecond})
+
+	// the connection is reused while it's young
+	suite.sendRequest(client)
+	suite.sendRequest(client)
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numConns))
+
+	// and replaced by a new one once it's expired, without failing the request
+	time.Sleep(100 * time.Millisecond)
+	suite.sendRequest(client)
+	suite.sendRequest(client)
+	suite.Require().Equal(int64(2), atomic.LoadInt64(&suite.numConns))
+}
+
+func (suite *connLifetimeSuite) TestMaxIdleConnDuration() {
+	client := NewClient(&NewClientInput{MaxIdleConnDuration: 50 * time.Millisecond})
+
+	suite.sendRequest(client)
+	suite.sendRequest(client)
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numConns))
+
+	// the idle connection is closed, so the next request opens a new one
+	time.Sleep(300 * time.Millisecond)
+	suite.sendRequest(client)
+	suite.Require().Equal(int64(2), atomic.LoadInt64(&suite.numConns))
+}
+
+func (suite *connLifetimeSuite) TestUnlimited() {
+	client := NewClient(&NewClientInput{})
+
+	suite.sendRequest(client)
+	time.Sleep(100 * time.Millisecond)
+	suite.sendRequest(client)
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numConns))
+}
+
+func (suite *connLifetimeSuite) sendRequest(client *fasthttp.Client) {
+	statusCode, _, err := client.Get(nil, suite.server.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(fasthttp.StatusOK, statusCode)
+}
+
+func TestConnLifetimeSuite(t *testing.T) {
+	suite.Run(t, new(connLifetimeSuite))
+}
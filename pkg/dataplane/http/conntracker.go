@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+)
+
+// connTracker tracks the connections of an http client by wrapping its dial function, since fasthttp
+// doesn't expose the state of its connection pool
+type connTracker struct {
+	lock                sync.Mutex
+	numOpenConnsByHost  map[string]int
+	numConnsClosedOnErr uint64
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		numOpenConnsByHost: map[string]int{},
+	}
+}
+
+func (ct *connTracker) wrapDial(dial fasthttp.DialFunc) fasthttp.DialFunc {
+	if dial == nil {
+		dial = fasthttp.Dial
+	}
+
+	return func(addr string) (net.Conn, error) {
+		conn, err := dial(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ct.lock.Lock()
+		ct.numOpenConnsByHost[addr]++
+		ct.lock.Unlock()
+
+		return &trackedConn{Conn: conn, tracker: ct, addr: addr}, nil
+	}
+}
+
+// returns a copy of the number of open connections per host
+func (ct *connTracker) getNumOpenConnsByHost() map[string]int {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	numOpenConnsByHost := make(map[string]int, len(ct.numOpenConnsByHost))
+	for addr, numOpenConns := range ct.numOpenConnsByHost {
+		numOpenConnsByHost[addr] = numOpenConns
+	}
+
+	return numOpenConnsByHost
+}
+
+func (ct *connTracker) getNumConnsClosedOnErr() uint64 {
+	return atomic.LoadUint64(&ct.numConnsClosedOnErr)
+}
+
+func (ct *connTracker) connClosed(addr string, failed bool) {
+	ct.lock.Lock()
+	ct.numOpenConnsByHost[addr]--
+	if ct.numOpenConnsByHost[addr] == 0 {
+		delete(ct.numOpenConnsByHost, addr)
+	}
+	ct.lock.Unlock()
+
+	if failed {
+		atomic.AddUint64(&ct.numConnsClosedOnErr, 1)
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	tracker   *connTracker
+	addr      string
+	failed    int32
+	closeOnce sync.Once
+}
+
+func (tc *trackedConn) Read(buffer []byte) (int, error) {
+	n, err := tc.Conn.Read(buffer)
+	tc.checkErr(err)
+
+	return n, err
+}
+
+func (tc *trackedConn) Write(buffer []byte) (int, error) {
+	n, err := tc.Conn.Write(buffer)
+	tc.checkErr(err)
+
+	return n, err
+}
+
+func (tc *trackedConn) Close() error {
+	tc.closeOnce.Do(func() {
+		tc.tracker.connClosed(tc.addr, atomic.LoadInt32(&tc.failed) != 0)
+	})
+
+	return tc.Conn.Close()
+}
+
+func (tc *trackedConn) checkErr(err error) {
+
+	// the server closing an idle connection is not an error
+	if err != nil && err != io.EOF {
+		atomic.StoreInt32(&tc.failed, 1)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// a connection whose reads return a given error
+type erroringConn struct {
+	net.Conn
+	readErr error
+}
+
+func (ec *erroringConn) Read(buffer []byte) (int, error) {
+	return 0, ec.readErr
+}
+
+func (ec *erroringConn) Close() error {
+	return nil
+}
+
+type connTrackerSuite struct {
+	suite.Suite
+	connTracker *connTracker
+	readErr     error
+	dial        fasthttp.DialFunc
+}
+
+func (suite *connTrackerSuite) SetupTest() {
+	suite.connTracker = newConnTracker()
+	suite.readErr = io.EOF
+	suite.dial = suite.connTracker.wrapDial(func(addr string) (net.Conn, error) {
+		if addr == "unreachable:80" {
+			return nil, errors.New("Connection refused")
+		}
+
+		return &erroringConn{readErr: suite.readErr}, nil
+	})
+}
+
+func (suite *connTrackerSuite) TestOpenConns() {
+	conns := []net.Conn{suite.dialConn("a:80"), suite.dialConn("a:80"), suite.dialConn("b:80")}
+
+	_, err := suite.dial("unreachable:80")
+	suite.Require().Error(err)
+
+	suite.Require().Equal(map[string]int{"a:80": 2, "b:80": 1}, suite.connTracker.getNumOpenConnsByHost())
+
+	// closing twice is counted once
+	suite.Require().NoError(conns[0].Close())
+	suite.Require().NoError(conns[0].Close())
+	suite.Require().NoError(conns[2].Close())
+
+	suite.Require().Equal(map[string]int{"a:80": 1}, suite.connTracker.getNumOpenConnsByHost())
+
+	suite.Require().NoError(conns[1].Close())
+	suite.Require().Empty(suite.connTracker.getNumOpenConnsByHost())
+}
+
+func (suite *connTrackerSuite) TestConnsClosedOnError() {
+
+	// the server closing an idle connection isn't an error
+	conn := suite.dialConn("a:80")
+	_, err := conn.Read(make([]byte, 1))
+	suite.Require().Equal(io.EOF, err)
+	suite.Require().NoError(conn.Close())
+	suite.Require().Equal(uint64(0), suite.connTracker.getNumConnsClosedOnErr())
+
+	suite.readErr = errors.New("Connection reset by peer")
+	conn = suite.dialConn("a:80")
+	_, err = conn.Read(make([]byte, 1))
+	suite.Require().Error(err)
+	suite.Require().NoError(conn.Close())
+	suite.Require().Equal(uint64(1), suite.connTracker.getNumConnsClosedOnErr())
+}
+
+func (suite *connTrackerSuite) TestContextTracksOwnClient() {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	suite.Require().NoError(err)
+
+	context := suite.createContext(&NewContextInput{})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.getObject(context, server.URL)
+	suite.Require().Equal(map[string]int{serverURL.Host: 1},
+		context.(v3io.StatsContext).Stats().NumOpenConnsByHost)
+}
+
+func (suite *connTrackerSuite) TestContextDoesNotTrackUserClient() {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	httpClient := &fasthttp.Client{}
+
+	context := suite.createContext(&NewContextInput{HTTPClient: httpClient})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.getObject(context, server.URL)
+	suite.Require().Nil(httpClient.Dial)
+	suite.Require().Nil(context.(v3io.StatsContext).Stats().NumOpenConnsByHost)
+}
+
+func (suite *connTrackerSuite) dialConn(addr string) net.Conn {
+	conn, err := suite.dial(addr)
+	suite.Require().NoError(err)
+
+	return conn
+}
+
+func (suite *connTrackerSuite) getObject(context v3io.Context, serverURL string) {
+	response, err := context.GetObjectSync(&v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: serverURL, ContainerName: "bigdata"},
+		Path:           "a",
+	})
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func (suite *connTrackerSuite) createContext(newContextInput *NewContextInput) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, newContextInput)
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func TestConnTrackerSuite(t *testing.T) {
+	suite.Run(t, new(connTrackerSuite))
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ConnectionWarmupPolicy configures establishing connections before they're needed. endpoints are
+// probed with a HEAD request per connection - any response means the connection is healthy, and
+// connections which turn out dead are replaced
+type ConnectionWarmupPolicy struct {
+
+	// endpoints to connect to when the context is created. endpoints requests were sent to are probed as well
+	URLs []string
+
+	// the connections established and probed per endpoint (defaults to 1)
+	NumConns int
+
+	// if set, endpoints are probed whenever the context sent no requests for this long
+	ProbeInterval time.Duration
+
+	// of each probe (defaults to 5 seconds)
+	Timeout time.Duration
+}
+
+type connWarmer struct {
+	context  *context
+	policy   ConnectionWarmupPolicy
+	stopChan chan struct{}
+}
+
+func newConnWarmer(context *context, policy *ConnectionWarmupPolicy) *connWarmer {
+	newConnWarmer := &connWarmer{
+		context:  context,
+		policy:   *policy,
+		stopChan: make(chan struct{}),
+	}
+
+	if newConnWarmer.policy.NumConns == 0 {
+		newConnWarmer.policy.NumConns = 1
+	}
+
+	if newConnWarmer.policy.Timeout == 0 {
+		newConnWarmer.policy.Timeout = 5 * time.Second
+	}
+
+	return newConnWarmer
+}
+
+// connects to the policy's endpoints, and starts probing if configured to
+func (cw *connWarmer) start() {
+	cw.probe(cw.policy.URLs)
+
+	if cw.policy.ProbeInterval > 0 {
+		go cw.probePeriodically()
+	}
+}
+
+func (cw *connWarmer) stop() {
+	close(cw.stopChan)
+}
+
+func (cw *connWarmer) probePeriodically() {
+	ticker := time.NewTicker(cw.policy.ProbeInterval)
+	defer ticker.Stop()
+
+	lastNumRequests := atomic.LoadUint64(&cw.context.numRequests)
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-cw.stopChan:
+			return
+		}
+
+		// only idle connections need probing
+		numRequests := atomic.LoadUint64(&cw.context.numRequests)
+		if numRequests != lastNumRequests {
+			lastNumRequests = numRequests
+			continue
+		}
+
+		cw.probe(cw.getURLs())
+	}
+}
+
+// returns the policy's endpoints and those requests were sent to
+func (cw *connWarmer) getURLs() []string {
+	urls := append([]string{}, cw.policy.URLs...)
+
+	cw.context.endpoints.Range(func(url interface{}, endpoint interface{}) bool {
+		urls = append(urls, url.(string))
+		return true
+	})
+
+	return urls
+}
+
+// probes the connections of all endpoints concurrently, returning once all probes are done
+func (cw *connWarmer) probe(urls []string) {
+	probedEndpoints := map[string]bool{}
+	probesWaitGroup := sync.WaitGroup{}
+
+	for _, url := range urls {
+		endpoint, err := cw.context.getEndpoint(url)
+		if err != nil {
+			cw.context.logger.WarnWith("Failed to parse endpoint URL", "url", url, "err", err.Error())
+			continue
+		}
+
+		// different URLs may share an endpoint
+		if probedEndpoints[endpoint] {
+			continue
+		}
+		probedEndpoints[endpoint] = true
+
+		for connIdx := 0; connIdx < cw.policy.NumConns; connIdx++ {
+			probesWaitGroup.Add(1)
+
+			go func(endpoint string) {
+				defer probesWaitGroup.Done()
+				cw.probeConn(endpoint)
+			}(endpoint)
+		}
+	}
+
+	probesWaitGroup.Wait()
+}
+
+func (cw *connWarmer) probeConn(endpoint string) {
+	request := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(request)
+
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI(endpoint + "/")
+	request.Header.SetMethod(http.MethodHead)
+	request.Header.SetUserAgent(cw.context.userAgent)
+	cw.context.headers.set(&request.Header)
+
+	// dead connections fail probes and are closed, so a probe which failed on one is retried
+	err := cw.context.doWithRetries(&v3io.DataPlaneInput{Timeout: cw.policy.Timeout}, request, response)
+	if err != nil {
+		atomic.AddUint64(&cw.context.numFailedProbes, 1)
+		cw.context.logger.DebugWith("Failed to probe connection", "endpoint", endpoint, "err", err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// counts the probes (HEAD requests) per URI
+type probeCountingTransport struct {
+	lock        sync.Mutex
+	probesByURI map[string]int
+}
+
+func (pct *probeCountingTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	if string(request.Header.Method()) == http.MethodHead {
+		pct.lock.Lock()
+		pct.probesByURI[request.URI().String()]++
+		pct.lock.Unlock()
+	}
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	return nil
+}
+
+func (pct *probeCountingTransport) getProbes(uri string) int {
+	pct.lock.Lock()
+	defer pct.lock.Unlock()
+
+	return pct.probesByURI[uri]
+}
+
+type connWarmerSuite struct {
+	suite.Suite
+	transport *probeCountingTransport
+}
+
+func (suite *connWarmerSuite) SetupTest() {
+	suite.transport = &probeCountingTransport{probesByURI: map[string]int{}}
+}
+
+func (suite *connWarmerSuite) TestWarmup() {
+	context := suite.createContext(&ConnectionWarmupPolicy{
+		URLs:     []string{"http://webapi:8081", "http://webapi:8081/ignored"},
+		NumConns: 3,
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	// probed once per connection, before the context is returned
+	suite.Require().Equal(3, suite.transport.getProbes("http://webapi:8081/"))
+}
+
+func (suite *connWarmerSuite) TestProbeIdle() {
+	context := suite.createContext(&ConnectionWarmupPolicy{ProbeInterval: 10 * time.Millisecond})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	err := context.PutObjectSync(&v3io.PutObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://other:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	})
+	suite.Require().NoError(err)
+
+	// the endpoint requests were sent to is probed once the context is idle
+	deadline := time.Now().Add(5 * time.Second)
+	for suite.transport.getProbes("http://other:8081/") == 0 {
+		suite.Require().True(time.Now().Before(deadline), "Endpoint wasn't probed")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (suite *connWarmerSuite) createContext(connectionWarmupPolicy *ConnectionWarmupPolicy) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, &NewContextInput{
+		Transport:              suite.transport,
+		ConnectionWarmupPolicy: connectionWarmupPolicy,
+	})
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func TestConnWarmerSuite(t *testing.T) {
+	suite.Run(t, new(connWarmerSuite))
+}
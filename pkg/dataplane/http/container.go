@@ -45,8 +45,8 @@ func newContainer(parentLogger logger.Logger,
 func (c *container) populateInputFields(input *v3io.DataPlaneInput) {
 	input.ContainerName = c.containerName
 	input.URL = c.session.url
-	input.AuthenticationToken = c.session.authenticationToken
-	input.AccessKey = c.session.accessKey
+	c.session.populateCredentials(input)
+	c.session.populateHeaders(input)
 }
 
 // GetItem
@@ -167,6 +167,12 @@ func (c *container) DeleteObjectSync(deleteObjectInput *v3io.DeleteObjectInput)
 	return c.session.context.DeleteObjectSync(deleteObjectInput)
 }
 
+// PresignObject
+func (c *container) PresignObject(presignObjectInput *v3io.PresignObjectInput) (*v3io.PresignObjectOutput, error) {
+	c.populateInputFields(&presignObjectInput.DataPlaneInput)
+	return c.session.context.PresignObject(presignObjectInput)
+}
+
 // GetContainers
 func (c *container) GetContainers(getContainersInput *v3io.GetContainersInput, context interface{}, responseChan chan *v3io.Response) (*v3io.Request, error) {
 	c.populateInputFields(&getContainersInput.DataPlaneInput)
@@ -229,6 +235,20 @@ func (c *container) DescribeStreamSync(describeStreamInput *v3io.DescribeStreamI
 	return c.session.context.DescribeStreamSync(describeStreamInput)
 }
 
+// UpdateStream
+func (c *container) UpdateStream(updateStreamInput *v3io.UpdateStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&updateStreamInput.DataPlaneInput)
+	return c.session.context.UpdateStream(updateStreamInput, context, responseChan)
+}
+
+// UpdateStreamSync
+func (c *container) UpdateStreamSync(updateStreamInput *v3io.UpdateStreamInput) error {
+	c.populateInputFields(&updateStreamInput.DataPlaneInput)
+	return c.session.context.UpdateStreamSync(updateStreamInput)
+}
+
 // CheckPathExists
 func (c *container) CheckPathExists(checkPathExistsInput *v3io.CheckPathExistsInput, context interface{}, responseChan chan *v3io.Response) (*v3io.Request, error) {
 	c.populateInputFields(&checkPathExistsInput.DataPlaneInput)
@@ -314,3 +334,17 @@ func (c *container) PutOOSObjectSync(putOOSObjectInput *v3io.PutOOSObjectInput)
 	c.populateInputFields(&putOOSObjectInput.DataPlaneInput)
 	return c.session.context.PutOOSObjectSync(putOOSObjectInput)
 }
+
+// GetOOSObject
+func (c *container) GetOOSObject(getOOSObjectInput *v3io.GetOOSObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getOOSObjectInput.DataPlaneInput)
+	return c.session.context.GetOOSObject(getOOSObjectInput, context, responseChan)
+}
+
+// GetOOSObjectSync
+func (c *container) GetOOSObjectSync(getOOSObjectInput *v3io.GetOOSObjectInput) (*v3io.Response, error) {
+	c.populateInputFields(&getOOSObjectInput.DataPlaneInput)
+	return c.session.context.GetOOSObjectSync(getOOSObjectInput)
+}
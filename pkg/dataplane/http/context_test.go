@@ -0,0 +1,392 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+	capnp "zombiezen.com/go/capnproto2"
+)
+
+type buildRequestURITestSuite struct {
+	suite.Suite
+}
+
+func (suite *buildRequestURITestSuite) TestPaths() {
+	c := &context{}
+
+	for _, testCase := range []struct {
+		containerName string
+		path          string
+		query         string
+		expected      string
+	}{
+		{containerName: "bigdata", path: "", expected: "/bigdata"},
+		{containerName: "bigdata", path: "/", expected: "/bigdata/"},
+		{containerName: "bigdata", path: "a/b", expected: "/bigdata/a/b"},
+		{containerName: "bigdata", path: "a/b/", expected: "/bigdata/a/b/"},
+		{containerName: "bigdata", path: "a//b//", expected: "/bigdata/a/b/"},
+		{containerName: "", path: "/", expected: "/"},
+		{containerName: "", path: "a/", expected: "/a/"},
+		{containerName: "bigdata", path: "a b/c?d%", expected: "/bigdata/a%20b/c%3Fd%25"},
+		{containerName: "bigdata", path: "a", query: "x=1&y=a b", expected: "/bigdata/a?x=1&y=a%20b"},
+	} {
+		uri, err := c.buildRequestURI("http://localhost:8081/ignored?q=1", testCase.containerName, testCase.query, testCase.path)
+		suite.Require().NoError(err)
+		suite.Require().Equal("http://localhost:8081"+testCase.expected, string(uri), testCase.path)
+	}
+
+	_, err := c.buildRequestURI("http://local host", "bigdata", "", "a")
+	suite.Require().Error(err)
+}
+
+func (suite *buildRequestURITestSuite) TestTypedPath() {
+	suite.Require().Equal("a/", getTypedPath("a", true))
+	suite.Require().Equal("a/", getTypedPath("a//", true))
+	suite.Require().Equal("a", getTypedPath("a", false))
+}
+
+type handleRequestTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *handleRequestTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *handleRequestTestSuite) TestPanicIsRecovered() {
+
+	// a context without a client panics once it tries to send the request
+	c := &context{logger: suite.logger}
+
+	responseChan := make(chan *v3io.Response, 1)
+	request := &v3io.Request{
+		ID: 1,
+		Input: &v3io.PutObjectInput{
+			DataPlaneInput: v3io.DataPlaneInput{URL: "http://localhost:8081", ContainerName: "bigdata"},
+			Path:           "a",
+		},
+		ResponseChan: responseChan,
+	}
+	request.RequestResponse = &v3io.RequestResponse{Request: *request}
+
+	suite.Require().NotPanics(func() { c.handleRequest(request) })
+
+	response := <-responseChan
+	suite.Require().Error(response.Error)
+	suite.Require().Equal(v3ioerrors.ErrPanic, errors.Cause(response.Error))
+	suite.Require().Equal(uint64(1), c.Stats().NumWorkerPanics)
+}
+
+func (suite *handleRequestTestSuite) TestExpiredRequestIsFailed() {
+	c := &context{logger: suite.logger}
+
+	responseChan := make(chan *v3io.Response, 1)
+	request := &v3io.Request{
+		ID: 1,
+		Input: &v3io.PutObjectInput{
+			DataPlaneInput: v3io.DataPlaneInput{Timeout: time.Second},
+		},
+		ResponseChan:        responseChan,
+		SendTimeNanoseconds: time.Now().Add(-2 * time.Second).UnixNano(),
+	}
+	request.RequestResponse = &v3io.RequestResponse{Request: *request}
+
+	c.handleRequest(request)
+
+	response := <-responseChan
+	suite.Require().Equal(v3ioerrors.ErrTimeout, errors.Cause(response.Error))
+	suite.Require().Equal(uint64(1), c.Stats().NumExpiredRequests)
+}
+
+type unixSocketTestSuite struct {
+	suite.Suite
+	logger         logger.Logger
+	tempDir        string
+	unixSocketPath string
+	listener       net.Listener
+	requestPaths   chan string
+}
+
+func (suite *unixSocketTestSuite) SetupTest() {
+	var err error
+
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+
+	suite.tempDir, err = ioutil.TempDir("", "v3io-uds")
+	suite.Require().NoError(err)
+
+	suite.unixSocketPath = filepath.Join(suite.tempDir, "webapi.sock")
+	suite.requestPaths = make(chan string, 1)
+
+	suite.listener, err = net.Listen("unix", suite.unixSocketPath)
+	suite.Require().NoError(err)
+
+	go fasthttp.Serve(suite.listener, func(requestCtx *fasthttp.RequestCtx) { // nolint: errcheck
+		suite.requestPaths <- string(requestCtx.Path())
+	})
+}
+
+func (suite *unixSocketTestSuite) TearDownTest() {
+	suite.listener.Close()      // nolint: errcheck
+	os.RemoveAll(suite.tempDir) // nolint: errcheck
+}
+
+func (suite *unixSocketTestSuite) TestContextOverUnixSocket() {
+	context, err := NewContext(suite.logger, &NewContextInput{
+		HTTPClient: NewClient(&NewClientInput{UnixSocketPath: suite.unixSocketPath}),
+	})
+	suite.Require().NoError(err)
+
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	// the host is ignored - the request goes through the socket
+	err = context.CheckPathExistsSync(&v3io.CheckPathExistsInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("/bigdata/a", <-suite.requestPaths)
+}
+
+func (suite *unixSocketTestSuite) TestCustomDial() {
+	dialed := false
+
+	client := NewClient(&NewClientInput{
+		Dial: func(addr string) (net.Conn, error) {
+			dialed = true
+			return net.Dial("unix", suite.unixSocketPath)
+		},
+	})
+
+	statusCode, _, err := client.Get(nil, "http://webapi:8081/bigdata/b")
+	suite.Require().NoError(err)
+	suite.Require().Equal(fasthttp.StatusOK, statusCode)
+	suite.Require().True(dialed)
+	suite.Require().Equal("/bigdata/b", <-suite.requestPaths)
+}
+
+type putRecordsBodyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *putRecordsBodyTestSuite) TestAppend() {
+	shardID := 3
+	records := []*v3io.StreamRecord{
+		{Data: []byte("first"), ClientInfo: []byte("info"), ShardID: &shardID},
+		{Data: make([]byte, 1000), PartitionKey: "key"},
+	}
+
+	// start from a buffer which is too small, to exercise growing it
+	body := appendPutRecordsBody(make([]byte, 0, 8), records)
+
+	decodedBody := struct {
+		Records []struct {
+			Data         []byte
+			ClientInfo   []byte
+			ShardID      *int `json:"ShardId"`
+			PartitionKey string
+		}
+	}{}
+
+	suite.Require().NoError(json.Unmarshal(body, &decodedBody))
+	suite.Require().Len(decodedBody.Records, 2)
+	suite.Require().Equal("first", string(decodedBody.Records[0].Data))
+	suite.Require().Equal("info", string(decodedBody.Records[0].ClientInfo))
+	suite.Require().Equal(3, *decodedBody.Records[0].ShardID)
+	suite.Require().Equal(make([]byte, 1000), decodedBody.Records[1].Data)
+	suite.Require().Nil(decodedBody.Records[1].ShardID)
+	suite.Require().Equal("key", decodedBody.Records[1].PartitionKey)
+
+	// the estimate must hold the body without growing it
+	suite.Require().True(len(body) <= estimatePutRecordsBodySize(records))
+}
+
+type unmarshalCapnpMessagesTestSuite struct {
+	suite.Suite
+}
+
+func (suite *unmarshalCapnpMessagesTestSuite) TestMessages() {
+	var body []byte
+	for _, text := range []string{"first", "second message, spanning a few words"} {
+		msg, segment, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		suite.Require().NoError(err)
+
+		textPtr, err := capnp.NewText(segment, text)
+		suite.Require().NoError(err)
+		suite.Require().NoError(msg.SetRootPtr(textPtr.List.ToPtr()))
+
+		encodedMessage, err := msg.Marshal()
+		suite.Require().NoError(err)
+		body = append(body, encodedMessage...)
+	}
+
+	// messages are read until the truncated one
+	body = append(body, body[:12]...)
+
+	capnpMessages := unmarshalCapnpMessages(body)
+	suite.Require().Len(capnpMessages, 2)
+
+	rootPtr, err := capnpMessages[1].RootPtr()
+	suite.Require().NoError(err)
+	suite.Require().Equal("second message, spanning a few words", rootPtr.Text())
+}
+
+type splitIOVecsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *splitIOVecsTestSuite) TestSplit() {
+	header, data, err := splitIOVecs([]byte("hdrfirstsecond"), "3", "3,5, 6")
+	suite.Require().NoError(err)
+	suite.Require().Equal("hdr", string(header))
+	suite.Require().Len(data, 2)
+	suite.Require().Equal("first", string(data[0]))
+	suite.Require().Equal("second", string(data[1]))
+
+	// appending to an io-vec must not overwrite the next one
+	_ = append(data[0], 'x')
+	suite.Require().Equal("second", string(data[1]))
+
+	header, data, err = splitIOVecs([]byte("hdr"), "1", "3")
+	suite.Require().NoError(err)
+	suite.Require().Equal("hdr", string(header))
+	suite.Require().Empty(data)
+}
+
+func (suite *splitIOVecsTestSuite) TestInvalidHeaders() {
+	for _, headers := range [][]string{
+		{"", "3"},
+		{"2", "3"},
+		{"2", "3,a"},
+		{"2", "3,-1"},
+		{"2", "3,20"},
+		{"2", "1,1"},
+	} {
+		_, _, err := splitIOVecs([]byte("hdrfirst"), headers[0], headers[1])
+		suite.Require().Error(err, "headers: %v", headers)
+	}
+}
+
+type conditionalMtimeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *conditionalMtimeTestSuite) TestHeaders() {
+	dataPlaneInput := v3io.DataPlaneInput{}
+	suite.Require().Equal(getItemHeaders, withConditionalMtimeHeaders(getItemHeaders, &dataPlaneInput))
+
+	dataPlaneInput.SetConditionalMtime(1581605100, 498349956)
+	headers := withConditionalMtimeHeaders(getItemHeaders, &dataPlaneInput)
+	suite.Require().Equal("1581605100", headers["conditional-mtime-sec"])
+	suite.Require().Equal("498349956", headers["conditional-mtime-nsec"])
+	suite.Require().Equal(getItemFunctionName, headers["X-v3io-function"])
+
+	// the shared headers aren't modified
+	suite.Require().NotContains(getItemHeaders, "conditional-mtime-sec")
+}
+
+type encodeTypedAttributesTestSuite struct {
+	suite.Suite
+}
+
+func (suite *encodeTypedAttributesTestSuite) TestMarshalledItem() {
+	type item struct {
+		Count   int32
+		Size    uint
+		Ratio   float32
+		Name    string
+		Enabled bool
+		Data    []byte
+		Time    time.Time
+	}
+
+	// every field type MarshalItem accepts can be encoded
+	attributes, err := v3io.MarshalItem(&item{
+		Count:   -3,
+		Size:    4,
+		Ratio:   0.5,
+		Name:    "a",
+		Enabled: true,
+		Data:    []byte("b"),
+		Time:    time.Unix(1581605100, 498349956),
+	})
+	suite.Require().NoError(err)
+
+	typedAttributes, err := (&context{}).encodeTypedAttributes(attributes)
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]map[string]interface{}{
+		"Count":   {"N": "-3"},
+		"Size":    {"N": "4"},
+		"Ratio":   {"N": "5E-01"},
+		"Name":    {"S": "a"},
+		"Enabled": {"BOOL": true},
+		"Data":    {"B": "Yg=="},
+		"Time":    {"TS": "1581605100:498349956"},
+	}, typedAttributes)
+}
+
+func TestBuildRequestURITestSuite(t *testing.T) {
+	suite.Run(t, new(buildRequestURITestSuite))
+}
+
+func TestConditionalMtimeTestSuite(t *testing.T) {
+	suite.Run(t, new(conditionalMtimeTestSuite))
+}
+
+func TestEncodeTypedAttributesTestSuite(t *testing.T) {
+	suite.Run(t, new(encodeTypedAttributesTestSuite))
+}
+
+func TestHandleRequestTestSuite(t *testing.T) {
+	suite.Run(t, new(handleRequestTestSuite))
+}
+
+func TestPutRecordsBodyTestSuite(t *testing.T) {
+	suite.Run(t, new(putRecordsBodyTestSuite))
+}
+
+func TestUnmarshalCapnpMessagesTestSuite(t *testing.T) {
+	suite.Run(t, new(unmarshalCapnpMessagesTestSuite))
+}
+
+func TestSplitIOVecsTestSuite(t *testing.T) {
+	suite.Run(t, new(splitIOVecsTestSuite))
+}
+
+func TestUnixSocketTestSuite(t *testing.T) {
+	suite.Run(t, new(unixSocketTestSuite))
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// serves a stream with a few shards, failing the deletion of some
+type streamDeletionTransport struct {
+	lock              sync.Mutex
+	numShards         int
+	failedDeletePaths map[string]bool
+	deletedPaths      []string
+}
+
+func (sdt *streamDeletionTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	path := string(request.URI().Path())
+
+	switch string(request.Header.Method()) {
+	case http.MethodGet:
+		listing := "<ListBucketResult><Name>bigdata</Name>"
+		for shardID := 0; shardID < sdt.numShards; shardID++ {
+			listing += fmt.Sprintf("<Contents><Key>stream/%d</Key></Contents>", shardID)
+		}
+		listing += "</ListBucketResult>"
+
+		response.SetStatusCode(fasthttp.StatusOK)
+		response.Header.SetContentType("application/xml")
+		response.SetBodyString(listing)
+
+	case http.MethodDelete:
+		sdt.lock.Lock()
+		defer sdt.lock.Unlock()
+
+		if sdt.failedDeletePaths[path] {
+			response.SetStatusCode(fasthttp.StatusInternalServerError)
+			return nil
+		}
+
+		sdt.deletedPaths = append(sdt.deletedPaths, path)
+		response.SetStatusCode(fasthttp.StatusNoContent)
+	}
+
+	return nil
+}
+
+func (sdt *streamDeletionTransport) getDeletedPaths() []string {
+	sdt.lock.Lock()
+	defer sdt.lock.Unlock()
+
+	deletedPaths := append([]string{}, sdt.deletedPaths...)
+	sort.Strings(deletedPaths)
+
+	return deletedPaths
+}
+
+type deleteStreamSuite struct {
+	suite.Suite
+	transport *streamDeletionTransport
+	context   v3io.Context
+}
+
+func (suite *deleteStreamSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.transport = &streamDeletionTransport{numShards: 4}
+	suite.context, err = NewContext(logger, &NewContextInput{Transport: suite.transport})
+	suite.Require().NoError(err)
+}
+
+func (suite *deleteStreamSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *deleteStreamSuite) TestDelete() {
+	err := suite.context.DeleteStreamSync(suite.getDeleteStreamInput())
+	suite.Require().NoError(err)
+
+	suite.Require().Equal([]string{
+		"/bigdata/stream/",
+		"/bigdata/stream/0",
+		"/bigdata/stream/1",
+		"/bigdata/stream/2",
+		"/bigdata/stream/3",
+	}, suite.transport.getDeletedPaths())
+}
+
+func (suite *deleteStreamSuite) TestPartialShardFailure() {
+	suite.transport.failedDeletePaths = map[string]bool{
+		"/bigdata/stream/1": true,
+		"/bigdata/stream/3": true,
+	}
+
+	err := suite.context.DeleteStreamSync(suite.getDeleteStreamInput())
+	suite.Require().Error(err)
+
+	// the error holds the error of each shard which failed, by shard path
+	multiError, ok := v3ioerrors.GetMultiError(err)
+	suite.Require().True(ok)
+	suite.Require().Equal([]string{"/stream/1", "/stream/3"}, multiError.FailedKeys())
+	suite.Require().Len(multiError.Errors(), 2)
+
+	for _, failedKey := range multiError.FailedKeys() {
+		statusCode, ok := v3ioerrors.GetStatusCode(multiError.ErrorOf(failedKey))
+		suite.Require().True(ok)
+		suite.Require().Equal(http.StatusInternalServerError, statusCode)
+	}
+
+	suite.Require().Nil(multiError.ErrorOf("/stream/0"))
+
+	// the other shards are deleted, but the stream directory is kept so that the deletion can be retried
+	suite.Require().Equal([]string{
+		"/bigdata/stream/0",
+		"/bigdata/stream/2",
+	}, suite.transport.getDeletedPaths())
+}
+
+func (suite *deleteStreamSuite) getDeleteStreamInput() *v3io.DeleteStreamInput {
+	return &v3io.DeleteStreamInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "/stream/",
+		Concurrency:    2,
+	}
+}
+
+func TestDeleteStreamSuite(t *testing.T) {
+	suite.Run(t, new(deleteStreamSuite))
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+// returned by the fast decoders for input they don't handle, which is then decoded by encoding/json
+var errFastJSONUnsupported = errors.New("Unsupported by the fast JSON decoder")
+
+// appends the same body as marshalGetItemsBody (though keys aren't sorted)
+func appendGetItemsBody(buffer []byte, getItemsInput *v3io.GetItemsInput) []byte {
+	buffer = append(buffer, '{')
+	fieldsStart := len(buffer)
+
+	appendStringField := func(name string, value string) {
+		if value != "" {
+			buffer = appendFieldName(buffer, fieldsStart, name)
+			buffer = appendJSONString(buffer, value)
+		}
+	}
+
+	appendIntField := func(name string, value int) {
+		buffer = appendFieldName(buffer, fieldsStart, name)
+		buffer = strconv.AppendInt(buffer, int64(value), 10)
+	}
+
+	appendStringField("AttributesToGet", strings.Join(getItemsAttributeNames(getItemsInput), ","))
+	appendStringField("TableName", getItemsInput.TableName)
+	appendStringField("FilterExpression", getItemsInput.Filter)
+	appendStringField("Marker", getItemsInput.Marker)
+	appendStringField("ShardingKey", getItemsInput.ShardingKey)
+
+	if getItemsInput.Limit != 0 {
+		appendIntField("Limit", getItemsInput.Limit)
+	}
+
+	if getItemsInput.TotalSegments != 0 {
+		appendIntField("TotalSegment", getItemsInput.TotalSegments)
+		appendIntField("Segment", getItemsInput.Segment)
+	}
+
+	appendStringField("SortKeyRangeStart", getItemsInput.SortKeyRangeStart)
+	appendStringField("SortKeyRangeEnd", getItemsInput.SortKeyRangeEnd)
+	appendStringField("AllowObjectScatter", string(getItemsInput.AllowObjectScatter))
+	appendStringField("ReturnData", string(getItemsInput.ReturnData))
+
+	if getItemsInput.DataMaxSize != 0 {
+		appendIntField("DataMaxSize", getItemsInput.DataMaxSize)
+	}
+
+	return append(buffer, '}')
+}
+
+// appends a field's name, preceded by a comma unless it's the first field
+func appendFieldName(buffer []byte, fieldsStart int, name string) []byte {
+	if len(buffer) > fieldsStart {
+		buffer = append(buffer, ',')
+	}
+
+	buffer = append(buffer, '"')
+	buffer = append(buffer, name...)
+	return append(buffer, '"', ':')
+}
+
+// strings of printable ASCII are appended as is, others are escaped by encoding/json
+func appendJSONString(buffer []byte, value string) []byte {
+	for valueIdx := 0; valueIdx < len(value); valueIdx++ {
+		char := value[valueIdx]
+		if char < 0x20 || char > 0x7e || char == '"' || char == '\\' || char == '<' || char == '>' || char == '&' {
+			encodedValue, _ := json.Marshal(value)
+			return append(buffer, encodedValue...)
+		}
+	}
+
+	buffer = append(buffer, '"')
+	buffer = append(buffer, value...)
+	return append(buffer, '"')
+}
+
+// decodes {"Items": [{"name": {"S": "value"}}], "NextMarker": "...", "LastItemIncluded": "...", "Scattered": "..."}
+func fastDecodeGetItemsResponse(body []byte) (*getItemsJSONResponse, error) {
+	decoder := fastJSONDecoder{data: body}
+	getItemsResponse := getItemsJSONResponse{}
+
+	err := decoder.readObject(func(key string) error {
+		var err error
+
+		switch key {
+		case "Items":
+			getItemsResponse.Items = []map[string]map[string]interface{}{}
+			err = decoder.readArray(func() error {
+				typedItem, err := decoder.readTypedAttributes()
+				if err != nil {
+					return err
+				}
+
+				getItemsResponse.Items = append(getItemsResponse.Items, typedItem)
+				return nil
+			})
+		case "NextMarker":
+			getItemsResponse.NextMarker, err = decoder.readString()
+		case "LastItemIncluded":
+			getItemsResponse.LastItemIncluded, err = decoder.readString()
+		case "Scattered":
+			getItemsResponse.Scattered, err = decoder.readString()
+		default:
+			err = errFastJSONUnsupported
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &getItemsResponse, decoder.readEnd()
+}
+
+// decodes {"Item": {"name": {"S": "value"}}}
+func fastDecodeGetItemResponse(body []byte) (map[string]map[string]interface{}, error) {
+	decoder := fastJSONDecoder{data: body}
+
+	var typedItem map[string]map[string]interface{}
+	err := decoder.readObject(func(key string) error {
+		if key != "Item" {
+			return errFastJSONUnsupported
+		}
+
+		var err error
+		typedItem, err = decoder.readTypedAttributes()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return typedItem, decoder.readEnd()
+}
+
+// a decoder of the subset of JSON used by item responses. values other than objects, arrays, strings
+// and booleans aren't supported
+type fastJSONDecoder struct {
+	data []byte
+	pos  int
+}
+
+// reads {"name": {"type": value}, ...}
+func (d *fastJSONDecoder) readTypedAttributes() (map[string]map[string]interface{}, error) {
+	typedAttributes := map[string]map[string]interface{}{}
+
+	err := d.readObject(func(attributeName string) error {
+		typedAttributeValue := map[string]interface{}{}
+
+		err := d.readObject(func(attributeType string) error {
+			value, err := d.readScalar()
+			if err != nil {
+				return err
+			}
+
+			typedAttributeValue[attributeType] = value
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		typedAttributes[attributeName] = typedAttributeValue
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return typedAttributes, nil
+}
+
+// reads an object, calling readValue with the decoder positioned at the value of each key
+func (d *fastJSONDecoder) readObject(readValue func(string) error) error {
+	if err := d.readByte('{'); err != nil {
+		return err
+	}
+
+	if d.peekByte() == '}' {
+		d.pos++
+		return nil
+	}
+
+	for {
+		key, err := d.readString()
+		if err != nil {
+			return err
+		}
+
+		if err := d.readByte(':'); err != nil {
+			return err
+		}
+
+		if err := readValue(key); err != nil {
+			return err
+		}
+
+		switch d.peekByte() {
+		case ',':
+			d.pos++
+		case '}':
+			d.pos++
+			return nil
+		default:
+			return errFastJSONUnsupported
+		}
+	}
+}
+
+// reads an array, calling readElement with the decoder positioned at each element
+func (d *fastJSONDecoder) readArray(readElement func() error) error {
+	if err := d.readByte('['); err != nil {
+		return err
+	}
+
+	if d.peekByte() == ']' {
+		d.pos++
+		return nil
+	}
+
+	for {
+		if err := readElement(); err != nil {
+			return err
+		}
+
+		switch d.peekByte() {
+		case ',':
+			d.pos++
+		case ']':
+			d.pos++
+			return nil
+		default:
+			return errFastJSONUnsupported
+		}
+	}
+}
+
+func (d *fastJSONDecoder) readScalar() (interface{}, error) {
+	switch d.peekByte() {
+	case '"':
+		return d.readString()
+	case 't':
+		return true, d.readLiteral("true")
+	case 'f':
+		return false, d.readLiteral("false")
+	default:
+		return nil, errFastJSONUnsupported
+	}
+}
+
+// strings with escape sequences are unquoted by encoding/json
+func (d *fastJSONDecoder) readString() (string, error) {
+	if err := d.readByte('"'); err != nil {
+		return "", err
+	}
+
+	start := d.pos
+	escaped := false
+
+	for ; d.pos < len(d.data); d.pos++ {
+		switch d.data[d.pos] {
+		case '\\':
+			escaped = true
+			d.pos++
+		case '"':
+			d.pos++
+
+			if !escaped {
+				return string(d.data[start : d.pos-1]), nil
+			}
+
+			var value string
+			if err := json.Unmarshal(d.data[start-1:d.pos], &value); err != nil {
+				return "", err
+			}
+
+			return value, nil
+		}
+	}
+
+	return "", errFastJSONUnsupported
+}
+
+func (d *fastJSONDecoder) readLiteral(literal string) error {
+	if len(d.data)-d.pos < len(literal) || string(d.data[d.pos:d.pos+len(literal)]) != literal {
+		return errFastJSONUnsupported
+	}
+
+	d.pos += len(literal)
+	return nil
+}
+
+func (d *fastJSONDecoder) readByte(expected byte) error {
+	if d.peekByte() != expected {
+		return errFastJSONUnsupported
+	}
+
+	d.pos++
+	return nil
+}
+
+func (d *fastJSONDecoder) readEnd() error {
+	if d.peekByte() != 0 {
+		return errFastJSONUnsupported
+	}
+
+	return nil
+}
+
+// skips whitespace and returns the next byte, or zero at the end of the data
+func (d *fastJSONDecoder) peekByte() byte {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return d.data[d.pos]
+		}
+	}
+
+	return 0
+}
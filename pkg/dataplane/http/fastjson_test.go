@@ -0,0 +1,103 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fastJSONSuite struct {
+	suite.Suite
+}
+
+func (suite *fastJSONSuite) TestAppendGetItemsBody() {
+	for _, getItemsInput := range []*v3io.GetItemsInput{
+		{},
+		{
+			AttributeNames:     []string{"a", "b"},
+			Filter:             `name == "<a>\n"`,
+			Marker:             "marker",
+			Limit:              100,
+			TotalSegments:      4,
+			AllowObjectScatter: "true",
+			DataMaxSize:        1024,
+		},
+	} {
+		expectedBody, err := marshalGetItemsBody(getItemsInput)
+		suite.Require().NoError(err)
+
+		suite.Require().JSONEq(string(expectedBody), string(appendGetItemsBody(nil, getItemsInput)))
+	}
+}
+
+func (suite *fastJSONSuite) TestDecodeGetItemsResponse() {
+	for _, body := range []string{
+		`{"Items": [], "LastItemIncluded": "TRUE"}`,
+		`{
+			"Items": [
+				{"a": {"N": "1"}, "b": {"S": "with \"escapes\" é"}},
+				{"c": {"BOOL": true}, "d": {"BOOL": false}}
+			],
+			"NextMarker": "marker",
+			"LastItemIncluded": "FALSE",
+			"Scattered": "TRUE"
+		}`,
+	} {
+		expectedResponse := getItemsJSONResponse{}
+		suite.Require().NoError(json.Unmarshal([]byte(body), &expectedResponse))
+
+		getItemsResponse, err := fastDecodeGetItemsResponse([]byte(body))
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedResponse, *getItemsResponse)
+	}
+}
+
+func (suite *fastJSONSuite) TestDecodeUnsupported() {
+	for _, body := range []string{
+		`{"Items": [{"a": {"N": 1}}]}`,
+		`{"Unknown": "a"}`,
+		`{"Items": []} trailing`,
+		`{"Items": [`,
+	} {
+		_, err := fastDecodeGetItemsResponse([]byte(body))
+		suite.Require().Error(err, body)
+	}
+
+	// falls back to encoding/json
+	getItemsResponse, err := decodeGetItemsJSONResponse([]byte(`{"Unknown": "a", "NextMarker": "marker"}`))
+	suite.Require().NoError(err)
+	suite.Require().Equal("marker", getItemsResponse.NextMarker)
+}
+
+func (suite *fastJSONSuite) TestDecodeGetItemResponse() {
+	typedItem, err := fastDecodeGetItemResponse([]byte(`{"Item": {"a": {"S": "b"}}}`))
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]map[string]interface{}{"a": {"S": "b"}}, typedItem)
+}
+
+func TestFastJSONSuite(t *testing.T) {
+	suite.Run(t, new(fastJSONSuite))
+}
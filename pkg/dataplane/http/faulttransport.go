@@ -0,0 +1,153 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// FaultInjectionPolicy configures the faults a fault injection transport injects. probabilities are
+// between 0 and 1, and each fault is drawn independently for each request
+type FaultInjectionPolicy struct {
+
+	// requests are delayed by Latency (counting towards their timeout)
+	Latency            time.Duration
+	LatencyProbability float64
+
+	// requests fail as if the server reset the connection, without being sent
+	ConnectionResetProbability float64
+
+	// requests are answered with ServerErrorStatusCode (defaults to 503), without being sent
+	ServerErrorProbability float64
+	ServerErrorStatusCode  int
+
+	// the response body is cut in half
+	TruncatedBodyProbability float64
+
+	// the seed of the random source drawing the faults (defaults to the current time)
+	Seed int64
+}
+
+type faultInjectionTransport struct {
+	transport  Transport
+	policy     FaultInjectionPolicy
+	randLock   sync.Mutex
+	randSource *rand.Rand
+}
+
+// NewFaultInjectionTransport creates a transport which sends requests through transport, injecting faults
+// according to policy. it allows testing an application's resilience to the platform's hiccups
+func NewFaultInjectionTransport(transport Transport, policy *FaultInjectionPolicy) Transport {
+	seed := policy.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	faultTransport := &faultInjectionTransport{
+		transport:  transport,
+		policy:     *policy,
+		randSource: rand.New(rand.NewSource(seed)),
+	}
+
+	if faultTransport.policy.ServerErrorStatusCode == 0 {
+		faultTransport.policy.ServerErrorStatusCode = http.StatusServiceUnavailable
+	}
+
+	return faultTransport
+}
+
+func (t *faultInjectionTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+
+	if t.draw(t.policy.LatencyProbability) {
+		if ctx == nil {
+			ctx = goctx.Background()
+		}
+
+		latency := t.policy.Latency
+		if timeout > 0 && latency >= timeout {
+			latency = timeout
+		}
+
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if timeout > 0 {
+			timeout -= latency
+			if timeout <= 0 {
+				return fasthttp.ErrTimeout
+			}
+		}
+	}
+
+	if t.draw(t.policy.ConnectionResetProbability) {
+		return &net.OpError{
+			Op:  "read",
+			Net: "tcp",
+			Err: os.NewSyscallError("read", syscall.ECONNRESET),
+		}
+	}
+
+	if t.draw(t.policy.ServerErrorProbability) {
+		response.Reset()
+		response.SetStatusCode(t.policy.ServerErrorStatusCode)
+		response.Header.SetContentType("application/json")
+		response.SetBodyString(`{"ErrorMessage": "Injected fault"}`)
+
+		return nil
+	}
+
+	if err := t.transport.Do(ctx, request, response, timeout); err != nil {
+		return err
+	}
+
+	if t.draw(t.policy.TruncatedBodyProbability) {
+		body := response.Body()
+		response.SetBody(append([]byte(nil), body[:len(body)/2]...))
+	}
+
+	return nil
+}
+
+func (t *faultInjectionTransport) draw(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+
+	t.randLock.Lock()
+	defer t.randLock.Unlock()
+
+	return t.randSource.Float64() < probability
+}
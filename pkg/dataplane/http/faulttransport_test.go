@@ -0,0 +1,121 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// responds to all requests with a fixed body
+type fakeTransport struct {
+	numRequests int
+}
+
+func (ft *fakeTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	ft.numRequests++
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	response.SetBodyString("0123456789")
+
+	return nil
+}
+
+type faultInjectionTransportSuite struct {
+	suite.Suite
+	innerTransport *fakeTransport
+	request        *fasthttp.Request
+	response       *fasthttp.Response
+}
+
+func (suite *faultInjectionTransportSuite) SetupTest() {
+	suite.innerTransport = &fakeTransport{}
+	suite.request = fasthttp.AcquireRequest()
+	suite.response = fasthttp.AcquireResponse()
+}
+
+func (suite *faultInjectionTransportSuite) TearDownTest() {
+	fasthttp.ReleaseRequest(suite.request)
+	fasthttp.ReleaseResponse(suite.response)
+}
+
+func (suite *faultInjectionTransportSuite) TestNoFaults() {
+	transport := NewFaultInjectionTransport(suite.innerTransport, &FaultInjectionPolicy{})
+
+	err := transport.Do(nil, suite.request, suite.response, 0)
+	suite.Require().NoError(err)
+	suite.Require().Equal("0123456789", string(suite.response.Body()))
+}
+
+func (suite *faultInjectionTransportSuite) TestConnectionReset() {
+	transport := NewFaultInjectionTransport(suite.innerTransport, &FaultInjectionPolicy{
+		ConnectionResetProbability: 1,
+	})
+
+	err := transport.Do(nil, suite.request, suite.response, 0)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), syscall.ECONNRESET.Error())
+	suite.Require().Equal(0, suite.innerTransport.numRequests)
+}
+
+func (suite *faultInjectionTransportSuite) TestServerError() {
+	transport := NewFaultInjectionTransport(suite.innerTransport, &FaultInjectionPolicy{
+		ServerErrorProbability: 1,
+	})
+
+	err := transport.Do(nil, suite.request, suite.response, 0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(fasthttp.StatusServiceUnavailable, suite.response.StatusCode())
+	suite.Require().Equal(0, suite.innerTransport.numRequests)
+}
+
+func (suite *faultInjectionTransportSuite) TestTruncatedBody() {
+	transport := NewFaultInjectionTransport(suite.innerTransport, &FaultInjectionPolicy{
+		TruncatedBodyProbability: 1,
+	})
+
+	err := transport.Do(nil, suite.request, suite.response, 0)
+	suite.Require().NoError(err)
+	suite.Require().Equal("01234", string(suite.response.Body()))
+}
+
+func (suite *faultInjectionTransportSuite) TestLatencyExceedingTimeout() {
+	transport := NewFaultInjectionTransport(suite.innerTransport, &FaultInjectionPolicy{
+		Latency:            time.Hour,
+		LatencyProbability: 1,
+	})
+
+	err := transport.Do(nil, suite.request, suite.response, 10*time.Millisecond)
+	suite.Require().Equal(fasthttp.ErrTimeout, err)
+	suite.Require().Equal(0, suite.innerTransport.numRequests)
+}
+
+func TestFaultInjectionTransportSuite(t *testing.T) {
+	suite.Run(t, new(faultInjectionTransportSuite))
+}
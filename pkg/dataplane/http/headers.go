@@ -27,6 +27,7 @@ const (
 	getItemsFunctionName       = "GetItems"
 	createStreamFunctionName   = "CreateStream"
 	describeStreamFunctionName = "DescribeStream"
+	updateStreamFunctionName   = "UpdateStream"
 	putRecordsFunctionName     = "PutRecords"
 	getRecordsFunctionName     = "GetRecords"
 	seekShardsFunctionName     = "SeekShard"
@@ -35,6 +36,26 @@ const (
 	PutChunkFunctionName       = "PutChunk"
 )
 
+// functions which backends other than v3io (e.g. object only gateways) may not support
+var optionalFunctionNames = map[string]bool{
+	createStreamFunctionName:   true,
+	describeStreamFunctionName: true,
+	updateStreamFunctionName:   true,
+	putRecordsFunctionName:     true,
+	getRecordsFunctionName:     true,
+	seekShardsFunctionName:     true,
+	putOOSObjectFunctionName:   true,
+	PutChunkFunctionName:       true,
+}
+
+// the header maps below are encoded once (see staticHeaderSets) and must not be modified
+
+// headers for update stream
+var updateStreamHeaders = map[string]string{
+	"Content-Type":    "application/json",
+	"X-v3io-function": updateStreamFunctionName,
+}
+
 // headers for put item
 var putItemHeaders = map[string]string{
 	"Content-Type":    "application/json",
@@ -114,6 +135,11 @@ var putOOSObjectHeaders = map[string]string{
 	"X-v3io-function": putOOSObjectFunctionName,
 }
 
+// headers for OOS get object
+var getOOSObjectHeaders = map[string]string{
+	"X-v3io-function": putOOSObjectFunctionName,
+}
+
 // map between SeekShardInputType and its encoded counterpart
 var seekShardsInputTypeToString = [...]string{
 	"TIME",
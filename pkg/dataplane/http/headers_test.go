@@ -0,0 +1,173 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// records the headers of the last request
+type headerRecordingTransport struct {
+	lock    sync.Mutex
+	headers map[string]string
+}
+
+func (hrt *headerRecordingTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	headers := map[string]string{}
+	request.Header.VisitAll(func(name []byte, value []byte) {
+		headers[string(name)] = string(value)
+	})
+
+	hrt.lock.Lock()
+	hrt.headers = headers
+	hrt.lock.Unlock()
+
+	response.SetStatusCode(fasthttp.StatusOK)
+
+	return nil
+}
+
+func (hrt *headerRecordingTransport) getHeaders() map[string]string {
+	hrt.lock.Lock()
+	defer hrt.lock.Unlock()
+
+	return hrt.headers
+}
+
+type headersSuite struct {
+	suite.Suite
+	transport *headerRecordingTransport
+	context   v3io.Context
+}
+
+func (suite *headersSuite) SetupTest() {
+	suite.transport = &headerRecordingTransport{}
+}
+
+func (suite *headersSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *headersSuite) TestDefaultUserAgent() {
+	container := suite.createContainer(&NewContextInput{}, nil)
+
+	suite.getObject(container, nil)
+	suite.Require().Equal(DefaultUserAgent(), suite.transport.getHeaders()["User-Agent"])
+	suite.Require().True(strings.HasPrefix(DefaultUserAgent(), "v3io-go/"))
+}
+
+func (suite *headersSuite) TestHeaders() {
+	container := suite.createContainer(&NewContextInput{
+		UserAgent: "app/1.0",
+		Headers: map[string]string{
+			"x-context": "context",
+			"X-Session": "context",
+			"X-Input":   "context",
+		},
+	}, map[string]string{
+		"X-Session": "session",
+		"X-Input":   "session",
+	})
+
+	// the input's headers take precedence over the session's, which take precedence over the context's
+	suite.getObject(container, map[string]string{"X-Input": "input"})
+
+	headers := suite.transport.getHeaders()
+	suite.Require().Equal("context", headers["X-Context"])
+	suite.Require().Equal("session", headers["X-Session"])
+	suite.Require().Equal("input", headers["X-Input"])
+	suite.Require().Equal("app/1.0", headers["User-Agent"])
+
+	// the session's headers aren't affected by the input's
+	suite.getObject(container, nil)
+	suite.Require().Equal("session", suite.transport.getHeaders()["X-Input"])
+}
+
+func (suite *headersSuite) TestSessionHeadersAreCopied() {
+	sessionHeaders := map[string]string{"X-Session": "session"}
+	container := suite.createContainer(&NewContextInput{}, sessionHeaders)
+
+	// changing the headers given to the session doesn't affect it
+	sessionHeaders["X-Session"] = "changed"
+	sessionHeaders["X-Added"] = "added"
+
+	// nor does changing the headers of an input after they were populated with the session's
+	getObjectInput := v3io.GetObjectInput{Path: "a"}
+	response, err := container.GetObjectSync(&getObjectInput)
+	suite.Require().NoError(err)
+	response.Release()
+
+	getObjectInput.Headers["X-Session"] = "input"
+
+	suite.getObject(container, nil)
+
+	headers := suite.transport.getHeaders()
+	suite.Require().Equal("session", headers["X-Session"])
+	suite.Require().NotContains(headers, "X-Added")
+}
+
+func (suite *headersSuite) getObject(container v3io.Container, headers map[string]string) {
+	response, err := container.GetObjectSync(&v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{Headers: headers},
+		Path:           "a",
+	})
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func (suite *headersSuite) createContainer(newContextInput *NewContextInput,
+	sessionHeaders map[string]string) v3io.Container {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	newContextInput.Transport = suite.transport
+
+	suite.context, err = NewContext(logger, newContextInput)
+	suite.Require().NoError(err)
+
+	session, err := suite.context.NewSession(&v3io.NewSessionInput{
+		URL:     "http://webapi:8081",
+		Headers: sessionHeaders,
+	})
+	suite.Require().NoError(err)
+
+	container, err := session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+
+	return container
+}
+
+func TestHeadersSuite(t *testing.T) {
+	suite.Run(t, new(headersSuite))
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"net/textproto"
+	"reflect"
+
+	"github.com/valyala/fasthttp"
+)
+
+type headerField struct {
+	name  []byte
+	value []byte
+}
+
+// headers encoded once, with canonical names, so that they're not converted per request
+type headerSet []headerField
+
+func newHeaderSet(headers map[string]string) headerSet {
+	encodedHeaders := make(headerSet, 0, len(headers))
+	for headerName, headerValue := range headers {
+		encodedHeaders = append(encodedHeaders, headerField{
+			name:  []byte(textproto.CanonicalMIMEHeaderKey(headerName)),
+			value: []byte(headerValue),
+		})
+	}
+
+	return encodedHeaders
+}
+
+func (hs headerSet) set(requestHeader *fasthttp.RequestHeader) {
+	for _, field := range hs {
+		requestHeader.SetCanonical(field.name, field.value)
+	}
+}
+
+func (hs headerSet) add(requestHeader *fasthttp.RequestHeader) {
+	for _, field := range hs {
+		requestHeader.AddBytesKV(field.name, field.value)
+	}
+}
+
+// the header sets of the static header maps (see headers.go), by the address of the map
+var staticHeaderSets = map[uintptr]headerSet{}
+
+func init() {
+	for _, headers := range []map[string]string{
+		updateStreamHeaders,
+		putItemHeaders,
+		getClusterMDHeaders,
+		updateItemHeaders,
+		getItemHeaders,
+		getItemsHeaders,
+		getItemsHeadersCapnp,
+		createStreamHeaders,
+		describeStreamHeaders,
+		putRecordsHeaders,
+		putChunkHeaders,
+		getRecordsHeaders,
+		seekShardsHeaders,
+		putOOSObjectHeaders,
+		getOOSObjectHeaders,
+	} {
+		staticHeaderSets[reflect.ValueOf(headers).Pointer()] = newHeaderSet(headers)
+	}
+}
+
+// returns the encoded header set of a static header map. headers built per request aren't found
+func getStaticHeaderSet(headers map[string]string) (headerSet, bool) {
+	if headers == nil {
+		return nil, false
+	}
+
+	encodedHeaders, found := staticHeaderSets[reflect.ValueOf(headers).Pointer()]
+	return encodedHeaders, found
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iohttp
+
+import (
+	goctx "context"
+	"sort"
+	"sync"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+const (
+	latencyTrackerNumSamples       = 1024
+	latencyTrackerMinSamples       = 64
+	latencyTrackerRecalculateEvery = 64
+)
+
+// tracks the latency of recent requests and derives the hedging delay from it
+type latencyTracker struct {
+	lock              sync.Mutex
+	samples           []time.Duration
+	nextSampleIndex   int
+	numRecorded       int
+	percentile        float64
+	initialDelay      time.Duration
+	currentPercentile time.Duration
+}
+
+func newLatencyTracker(percentile float64, initialDelay time.Duration) *latencyTracker {
+	if percentile <= 0 || percentile >= 1 {
+		percentile = 0.95
+	}
+
+	if initialDelay == 0 {
+		initialDelay = 100 * time.Millisecond
+	}
+
+	return &latencyTracker{
+		samples:      make([]time.Duration, 0, latencyTrackerNumSamples),
+		percentile:   percentile,
+		initialDelay: initialDelay,
+	}
+}
+
+func (lt *latencyTracker) record(latency time.Duration) {
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	if len(lt.samples) < cap(lt.samples) {
+		lt.samples = append(lt.samples, latency)
+	} else {
+		lt.samples[lt.nextSampleIndex] = latency
+	}
+
+	lt.nextSampleIndex = (lt.nextSampleIndex + 1) % cap(lt.samples)
+	lt.numRecorded++
+
+	// sorting the samples on every request is wasteful - do it periodically
+	if len(lt.samples) >= latencyTrackerMinSamples && lt.numRecorded%latencyTrackerRecalculateEvery == 0 {
+		sortedSamples := make([]time.Duration, len(lt.samples))
+		copy(sortedSamples, lt.samples)
+		sort.Slice(sortedSamples, func(i, j int) bool { return sortedSamples[i] < sortedSamples[j] })
+
+		lt.currentPercentile = sortedSamples[int(float64(len(sortedSamples)-1)*lt.percentile)]
+	}
+}
+
+func (lt *latencyTracker) delay() time.Duration {
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	if lt.currentPercentile == 0 {
+		return lt.initialDelay
+	}
+
+	return lt.currentPercentile
+}
+
+type hedgedResult struct {
+	dataPlaneInput *v3io.DataPlaneInput // the request's copy, which outlives the caller's input
+	response       *v3io.Response
+	err            error
+}
+
+// sendHedgedRequest sends a request and, if no response arrived within the hedging delay, sends an identical
+// one. the first successful response is returned and the other request is cancelled through its context.
+// transports which can't abort a request in flight (like the default fasthttp one) complete it regardless,
+// in which case its response is released once it arrives - so hedging may add up to one request per slow
+// read. hedges are only sent while a connection slot is free (see NewContextInput.MaxConns), so that they
+// don't delay other requests
+func (c *context) sendHedgedRequest(dataPlaneInput *v3io.DataPlaneInput,
+	method string,
+	path string,
+	query string,
+	headers map[string]string,
+	body []byte) (*v3io.Response, error) {
+
+	if c.hedgingPolicy == nil {
+		return c.sendRequest(dataPlaneInput, method, path, query, headers, body, false)
+	}
+
+	resultChan := make(chan hedgedResult, 2)
+
+	// each request gets its own context so that the loser can be cancelled
+	send := func() goctx.CancelFunc {
+		parentCtx := dataPlaneInput.Ctx
+		if parentCtx == nil {
+			parentCtx = goctx.Background()
+		}
+
+		requestDataPlaneInput := *dataPlaneInput
+		ctx, cancel := goctx.WithCancel(parentCtx)
+		requestDataPlaneInput.Ctx = ctx
+
+		go func() {
+			startTime := time.Now()
+			response, err := c.sendRequest(&requestDataPlaneInput, method, path, query, headers, body, false)
+			if err == nil {
+				c.readLatencyTracker.record(time.Since(startTime))
+			}
+
+			resultChan <- hedgedResult{dataPlaneInput: &requestDataPlaneInput, response: response, err: err}
+		}()
+
+		return cancel
+	}
+
+	hedgingDelay := c.hedgingPolicy.Delay
+	if hedgingDelay == 0 {
+		hedgingDelay = c.readLatencyTracker.delay()
+	}
+
+	cancelOriginal := send()
+	defer cancelOriginal()
+
+	select {
+	case result := <-resultChan:
+		return result.response, result.err
+	case <-time.After(hedgingDelay):
+	}
+
+	if !c.hasFreeConnSlot() {
+		result := <-resultChan
+		return result.response, result.err
+	}
+
+	// the original request is taking too long - hedge it. whichever request loses is cancelled on return
+	cancelHedge := send()
+	defer cancelHedge()
+
+	result := <-resultChan
+	if result.err == nil {
+		go c.releaseHedgedResult(resultChan)
+		return result.response, nil
+	}
+
+	// the first response was an error, the other one may still succeed
+	c.releaseHedgedResultResponse(&result)
+
+	result = <-resultChan
+	return result.response, result.err
+}
+
+// returns whether a request can be sent without waiting for a connection slot
+func (c *context) hasFreeConnSlot() bool {
+	if c.connSemaphore == nil {
+		return true
+	}
+
+	if !c.connSemaphore.TryAcquire(1) {
+		return false
+	}
+
+	c.connSemaphore.Release(1)
+
+	return true
+}
+
+func (c *context) releaseHedgedResult(resultChan chan hedgedResult) {
+	result := <-resultChan
+	c.releaseHedgedResultResponse(&result)
+}
+
+func (c *context) releaseHedgedResultResponse(result *hedgedResult) {
+	if result.err != nil {
+		result.response = c.extractResponseFromError(result.dataPlaneInput, result.err)
+	}
+
+	if result.response != nil {
+		result.response.Release()
+	}
+}
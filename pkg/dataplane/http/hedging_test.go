@@ -0,0 +1,196 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// delays the response to the first request and responds with the index of the request. if cancellable,
+// requests are aborted when their context is done
+type slowFirstTransport struct {
+	lock         sync.Mutex
+	firstDelay   time.Duration
+	cancellable  bool
+	numRequests  int
+	numCompleted int
+	numCancelled int
+}
+
+func (sft *slowFirstTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	sft.lock.Lock()
+	sft.numRequests++
+	requestIndex := sft.numRequests
+	sft.lock.Unlock()
+
+	delay := time.Duration(0)
+	if requestIndex == 1 {
+		delay = sft.firstDelay
+	}
+
+	if sft.cancellable {
+		select {
+		case <-ctx.Done():
+			sft.lock.Lock()
+			sft.numCancelled++
+			sft.lock.Unlock()
+
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	} else {
+		time.Sleep(delay)
+	}
+
+	sft.lock.Lock()
+	sft.numCompleted++
+	sft.lock.Unlock()
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	response.SetBodyString(strconv.Itoa(requestIndex))
+
+	return nil
+}
+
+func (sft *slowFirstTransport) getCounts() (int, int, int) {
+	sft.lock.Lock()
+	defer sft.lock.Unlock()
+
+	return sft.numRequests, sft.numCompleted, sft.numCancelled
+}
+
+type hedgingSuite struct {
+	suite.Suite
+	getObjectInput v3io.GetObjectInput
+}
+
+func (suite *hedgingSuite) SetupTest() {
+	suite.getObjectInput = v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	}
+}
+
+func (suite *hedgingSuite) TestFastOriginalIsNotHedged() {
+	transport := &slowFirstTransport{}
+	context := suite.createContext(transport, nil)
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	response, err := context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal("1", string(response.Body()))
+	response.Release()
+
+	numRequests, _, _ := transport.getCounts()
+	suite.Require().Equal(1, numRequests)
+}
+
+func (suite *hedgingSuite) TestHedgeWinsAndLoserIsCancelled() {
+	transport := &slowFirstTransport{firstDelay: 5 * time.Second, cancellable: true}
+	context := suite.createContext(transport, nil)
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	startTime := time.Now()
+	response, err := context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal("2", string(response.Body()))
+	suite.Require().True(time.Since(startTime) < time.Second)
+	response.Release()
+
+	suite.waitFor(func() bool {
+		_, _, numCancelled := transport.getCounts()
+		return numCancelled == 1
+	})
+}
+
+func (suite *hedgingSuite) TestLoserIsReleased() {
+
+	// the original can't be cancelled, so it completes after the hedge won
+	transport := &slowFirstTransport{firstDelay: 200 * time.Millisecond}
+	context := suite.createContext(transport, &ResponseMemoryPolicy{MaxBytes: 1024})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	response, err := context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal("2", string(response.Body()))
+	response.Release()
+
+	suite.waitFor(func() bool {
+		_, numCompleted, _ := transport.getCounts()
+		return numCompleted == 2
+	})
+
+	suite.waitFor(func() bool {
+		return context.(v3io.StatsContext).Stats().NumResponseBytes == 0
+	})
+}
+
+func (suite *hedgingSuite) TestLatencyTracker() {
+	tracker := newLatencyTracker(0.5, 100*time.Millisecond)
+
+	// the initial delay is used until enough samples were recorded
+	for sampleIdx := 1; sampleIdx < latencyTrackerMinSamples; sampleIdx++ {
+		tracker.record(time.Duration(sampleIdx) * time.Millisecond)
+	}
+
+	suite.Require().Equal(100*time.Millisecond, tracker.delay())
+
+	tracker.record(latencyTrackerMinSamples * time.Millisecond)
+	suite.Require().Equal(32*time.Millisecond, tracker.delay())
+}
+
+func (suite *hedgingSuite) createContext(transport Transport, responseMemoryPolicy *ResponseMemoryPolicy) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, &NewContextInput{
+		Transport:            transport,
+		HedgingPolicy:        &HedgingPolicy{Delay: 20 * time.Millisecond},
+		ResponseMemoryPolicy: responseMemoryPolicy,
+	})
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func (suite *hedgingSuite) waitFor(condition func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !condition(); {
+		suite.Require().True(time.Now().Before(deadline), "Timed out waiting for condition")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHedgingSuite(t *testing.T) {
+	suite.Run(t, new(hedgingSuite))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/json"
+	"strings"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// the JSON encoding and decoding of the hottest request and response bodies. when built with
+// -tags v3iofastjson, they're encoded and decoded by the hand written codecs in fastjson.go rather
+// than by encoding/json, which reflects
+
+type getItemsJSONResponse struct {
+	Items            []map[string]map[string]interface{}
+	NextMarker       string
+	LastItemIncluded string
+	Scattered        string
+}
+
+func encodeGetItemsBody(getItemsInput *v3io.GetItemsInput) ([]byte, error) {
+	if useFastJSON {
+		return appendGetItemsBody(nil, getItemsInput), nil
+	}
+
+	return marshalGetItemsBody(getItemsInput)
+}
+
+// the fast decoders only handle the common shape of responses, anything else is decoded by encoding/json
+func decodeGetItemsJSONResponse(body []byte) (*getItemsJSONResponse, error) {
+	if useFastJSON {
+		if getItemsResponse, err := fastDecodeGetItemsResponse(body); err == nil {
+			return getItemsResponse, nil
+		}
+	}
+
+	getItemsResponse := getItemsJSONResponse{}
+	if err := json.Unmarshal(body, &getItemsResponse); err != nil {
+		return nil, err
+	}
+
+	return &getItemsResponse, nil
+}
+
+func decodeGetItemJSONResponse(body []byte) (map[string]map[string]interface{}, error) {
+	if useFastJSON {
+		if typedItem, err := fastDecodeGetItemResponse(body); err == nil {
+			return typedItem, nil
+		}
+	}
+
+	getItemResponse := struct {
+		Item map[string]map[string]interface{}
+	}{}
+
+	if err := json.Unmarshal(body, &getItemResponse); err != nil {
+		return nil, err
+	}
+
+	return getItemResponse.Item, nil
+}
+
+// returns the attributes to request. the item's name is requested explicitly if it should be returned,
+// along with all attributes if none were specified
+func getItemsAttributeNames(getItemsInput *v3io.GetItemsInput) []string {
+	if !getItemsInput.ReturnItemName {
+		return getItemsInput.AttributeNames
+	}
+
+	if len(getItemsInput.AttributeNames) == 0 {
+		return []string{v3io.ItemNameAttribute, "*"}
+	}
+
+	for _, attributeName := range getItemsInput.AttributeNames {
+		if attributeName == v3io.ItemNameAttribute {
+			return getItemsInput.AttributeNames
+		}
+	}
+
+	return append([]string{v3io.ItemNameAttribute}, getItemsInput.AttributeNames...)
+}
+
+func marshalGetItemsBody(getItemsInput *v3io.GetItemsInput) ([]byte, error) {
+	body := map[string]interface{}{}
+
+	if attributeNames := getItemsAttributeNames(getItemsInput); len(attributeNames) > 0 {
+		body["AttributesToGet"] = strings.Join(attributeNames, ",")
+	}
+
+	if getItemsInput.TableName != "" {
+		body["TableName"] = getItemsInput.TableName
+	}
+
+	if getItemsInput.Filter != "" {
+		body["FilterExpression"] = getItemsInput.Filter
+	}
+
+	if getItemsInput.Marker != "" {
+		body["Marker"] = getItemsInput.Marker
+	}
+
+	if getItemsInput.ShardingKey != "" {
+		body["ShardingKey"] = getItemsInput.ShardingKey
+	}
+
+	if getItemsInput.Limit != 0 {
+		body["Limit"] = getItemsInput.Limit
+	}
+
+	if getItemsInput.TotalSegments != 0 {
+		body["TotalSegment"] = getItemsInput.TotalSegments
+		body["Segment"] = getItemsInput.Segment
+	}
+
+	if getItemsInput.SortKeyRangeStart != "" {
+		body["SortKeyRangeStart"] = getItemsInput.SortKeyRangeStart
+	}
+
+	if getItemsInput.SortKeyRangeEnd != "" {
+		body["SortKeyRangeEnd"] = getItemsInput.SortKeyRangeEnd
+	}
+
+	if getItemsInput.AllowObjectScatter != "" {
+		body["AllowObjectScatter"] = getItemsInput.AllowObjectScatter
+	}
+	if getItemsInput.ReturnData != "" {
+		body["ReturnData"] = getItemsInput.ReturnData
+	}
+	if getItemsInput.DataMaxSize != 0 {
+		body["DataMaxSize"] = getItemsInput.DataMaxSize
+	}
+
+	return json.Marshal(body)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/logger"
+)
+
+type trackedResponse struct {
+	leakedResponse LeakedResponse
+	reported       bool
+}
+
+// tracks responses handed to the caller until they're released (see LeakDetectionPolicy)
+type leakDetector struct {
+	logger    logger.Logger
+	policy    LeakDetectionPolicy
+	lock      sync.Mutex
+	responses map[*v3io.Response]*trackedResponse
+	stopChan  chan struct{}
+}
+
+func newLeakDetector(parentLogger logger.Logger, policy *LeakDetectionPolicy) *leakDetector {
+	newLeakDetector := &leakDetector{
+		logger:    parentLogger.GetChild("leakDetector"),
+		policy:    *policy,
+		responses: map[*v3io.Response]*trackedResponse{},
+		stopChan:  make(chan struct{}),
+	}
+
+	if newLeakDetector.policy.MaxAge == 0 {
+		newLeakDetector.policy.MaxAge = time.Minute
+	}
+
+	if newLeakDetector.policy.CheckInterval == 0 {
+		newLeakDetector.policy.CheckInterval = newLeakDetector.policy.MaxAge
+	}
+
+	if newLeakDetector.policy.OnLeak == nil {
+		newLeakDetector.policy.OnLeak = newLeakDetector.logLeak
+	}
+
+	return newLeakDetector
+}
+
+func (ld *leakDetector) start() {
+	go func() {
+		ticker := time.NewTicker(ld.policy.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ld.check()
+			case <-ld.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (ld *leakDetector) stop() {
+	close(ld.stopChan)
+}
+
+func (ld *leakDetector) track(response *v3io.Response) {
+	ld.lock.Lock()
+	ld.responses[response] = &trackedResponse{
+		leakedResponse: LeakedResponse{
+			RequestID:   response.RequestID,
+			AllocatedAt: time.Now(),
+			Stack:       string(debug.Stack()),
+		},
+	}
+	ld.lock.Unlock()
+
+	addReleaseHook(response, func() {
+		ld.lock.Lock()
+		delete(ld.responses, response)
+		ld.lock.Unlock()
+	})
+}
+
+// reports responses older than the max age which weren't reported yet
+func (ld *leakDetector) check() {
+	var leakedResponses []*LeakedResponse
+
+	ld.lock.Lock()
+	for _, trackedResponse := range ld.responses {
+		if !trackedResponse.reported && time.Since(trackedResponse.leakedResponse.AllocatedAt) >= ld.policy.MaxAge {
+			trackedResponse.reported = true
+			leakedResponses = append(leakedResponses, &trackedResponse.leakedResponse)
+		}
+	}
+	ld.lock.Unlock()
+
+	// called without the lock, so that handlers may release responses
+	for _, leakedResponse := range leakedResponses {
+		ld.policy.OnLeak(leakedResponse)
+	}
+}
+
+func (ld *leakDetector) getNumResponses() int {
+	ld.lock.Lock()
+	defer ld.lock.Unlock()
+
+	return len(ld.responses)
+}
+
+func (ld *leakDetector) logLeak(leakedResponse *LeakedResponse) {
+	ld.logger.WarnWith("Response wasn't released",
+		"requestID", leakedResponse.RequestID,
+		"age", time.Since(leakedResponse.AllocatedAt).String(),
+		"stack", leakedResponse.Stack)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type leakDetectorSuite struct {
+	suite.Suite
+	context             v3io.Context
+	leakedResponsesChan chan *LeakedResponse
+}
+
+func (suite *leakDetectorSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.leakedResponsesChan = make(chan *LeakedResponse, 10)
+	suite.context, err = NewContext(logger, &NewContextInput{
+		Transport: &fixedBodyTransport{},
+		LeakDetectionPolicy: &LeakDetectionPolicy{
+			MaxAge:        50 * time.Millisecond,
+			CheckInterval: 10 * time.Millisecond,
+			OnLeak: func(leakedResponse *LeakedResponse) {
+				suite.leakedResponsesChan <- leakedResponse
+			},
+		},
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *leakDetectorSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *leakDetectorSuite) TestReportsLeakedResponse() {
+	getObjectInput := v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	}
+
+	releasedResponse, err := suite.context.GetObjectSync(&getObjectInput)
+	suite.Require().NoError(err)
+	releasedResponse.Release()
+
+	leakedResponse, err := suite.context.GetObjectSync(&getObjectInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, suite.context.(v3io.StatsContext).Stats().NumUnreleasedResponses)
+
+	select {
+	case reportedResponse := <-suite.leakedResponsesChan:
+		suite.Require().Equal(leakedResponse.RequestID, reportedResponse.RequestID)
+		suite.Require().Contains(reportedResponse.Stack, "TestReportsLeakedResponse")
+	case <-time.After(5 * time.Second):
+		suite.Fail("Leaked response wasn't reported")
+	}
+
+	// reported once
+	time.Sleep(100 * time.Millisecond)
+	suite.Require().Empty(suite.leakedResponsesChan)
+
+	leakedResponse.Release()
+	suite.Require().Equal(0, suite.context.(v3io.StatsContext).Stats().NumUnreleasedResponses)
+}
+
+func TestLeakDetectorSuite(t *testing.T) {
+	suite.Run(t, new(leakDetectorSuite))
+}
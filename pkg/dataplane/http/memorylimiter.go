@@ -0,0 +1,119 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"sync"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// tracks the bytes held by unreleased responses (see ResponseMemoryPolicy)
+type memoryLimiter struct {
+	policy   *ResponseMemoryPolicy
+	lock     sync.Mutex
+	numBytes int64
+
+	// closed (and replaced) whenever bytes are released, to wake up waiting requests
+	releasedChan chan struct{}
+}
+
+func newMemoryLimiter(policy *ResponseMemoryPolicy) *memoryLimiter {
+	return &memoryLimiter{
+		policy:       policy,
+		releasedChan: make(chan struct{}),
+	}
+}
+
+// returns once the budget isn't exhausted, or fails if it is and either the policy fails fast or the
+// context is done / timeout expires first
+func (ml *memoryLimiter) wait(ctx goctx.Context, timeout time.Duration) error {
+	if ctx == nil {
+		ctx = goctx.Background()
+	}
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutChan = timer.C
+	}
+
+	for {
+		ml.lock.Lock()
+		numBytes := ml.numBytes
+		releasedChan := ml.releasedChan
+		ml.lock.Unlock()
+
+		if numBytes < ml.policy.MaxBytes {
+			return nil
+		}
+
+		if ml.policy.FailFast {
+			return errors.Wrapf(v3ioerrors.ErrLimitExceeded,
+				"Responses hold %d bytes, exceeding the budget of %d bytes", numBytes, ml.policy.MaxBytes)
+		}
+
+		select {
+		case <-releasedChan:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "Context done while waiting for responses to be released")
+		case <-timeoutChan:
+			return errors.Wrap(v3ioerrors.ErrTimeout, "Timed out waiting for responses to be released")
+		}
+	}
+}
+
+// accounts for the response's body until it's released
+func (ml *memoryLimiter) hold(response *v3io.Response) {
+	numBytes := int64(len(response.HTTPResponse.Body()))
+
+	ml.lock.Lock()
+	ml.numBytes += numBytes
+	ml.lock.Unlock()
+
+	addReleaseHook(response, func() {
+		ml.release(numBytes)
+	})
+}
+
+func (ml *memoryLimiter) release(numBytes int64) {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	ml.numBytes -= numBytes
+
+	close(ml.releasedChan)
+	ml.releasedChan = make(chan struct{})
+}
+
+func (ml *memoryLimiter) getNumBytes() int64 {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	return ml.numBytes
+}
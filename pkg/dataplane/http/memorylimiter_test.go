@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// responds with a body of 10 bytes
+type fixedBodyTransport struct{}
+
+func (fbt *fixedBodyTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	response.SetStatusCode(fasthttp.StatusOK)
+	response.SetBodyString("0123456789")
+
+	return nil
+}
+
+type memoryLimiterSuite struct {
+	suite.Suite
+	getObjectInput v3io.GetObjectInput
+}
+
+func (suite *memoryLimiterSuite) SetupTest() {
+	suite.getObjectInput = v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	}
+}
+
+func (suite *memoryLimiterSuite) TestFailFast() {
+	context := suite.createContext(&ResponseMemoryPolicy{MaxBytes: 10, FailFast: true})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	response, err := context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(10), context.(v3io.StatsContext).Stats().NumResponseBytes)
+
+	_, err = context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().Equal(v3ioerrors.ErrLimitExceeded, errors.Cause(err))
+
+	response.Release()
+	suite.Require().Equal(int64(0), context.(v3io.StatsContext).Stats().NumResponseBytes)
+
+	response, err = context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func (suite *memoryLimiterSuite) TestWait() {
+	context := suite.createContext(&ResponseMemoryPolicy{MaxBytes: 10})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	response, err := context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+
+	// times out while the budget is exhausted
+	suite.getObjectInput.Timeout = 50 * time.Millisecond
+	_, err = context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().Equal(v3ioerrors.ErrTimeout, errors.Cause(err))
+
+	// proceeds once the response is released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		response.Release()
+	}()
+
+	suite.getObjectInput.Timeout = 0
+	response, err = context.GetObjectSync(&suite.getObjectInput)
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func (suite *memoryLimiterSuite) createContext(responseMemoryPolicy *ResponseMemoryPolicy) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, &NewContextInput{
+		Transport:            &fixedBodyTransport{},
+		ResponseMemoryPolicy: responseMemoryPolicy,
+	})
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func TestMemoryLimiterSuite(t *testing.T) {
+	suite.Run(t, new(memoryLimiterSuite))
+}
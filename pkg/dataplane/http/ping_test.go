@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// responds to requests with a given status code after a delay, recording the last request
+type pingTransport struct {
+	delay      time.Duration
+	statusCode int
+	err        error
+	method     string
+	path       string
+	accessKey  string
+}
+
+func (pt *pingTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	pt.method = string(request.Header.Method())
+	pt.path = string(request.URI().Path())
+	pt.accessKey = string(request.Header.Peek("X-v3io-session-key"))
+
+	time.Sleep(pt.delay)
+
+	if pt.err != nil {
+		return pt.err
+	}
+
+	response.SetStatusCode(pt.statusCode)
+
+	return nil
+}
+
+type pingSuite struct {
+	suite.Suite
+	transport *pingTransport
+	context   v3io.Context
+}
+
+func (suite *pingSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.transport = &pingTransport{delay: 10 * time.Millisecond}
+	suite.context, err = NewContext(logger, &NewContextInput{Transport: suite.transport})
+	suite.Require().NoError(err)
+}
+
+func (suite *pingSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *pingSuite) TestSuccess() {
+	suite.transport.statusCode = http.StatusOK
+
+	pingOutput, err := suite.ping()
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, pingOutput.StatusCode)
+	suite.Require().True(pingOutput.Latency >= suite.transport.delay)
+
+	// a HEAD of the container's root
+	suite.Require().Equal(http.MethodHead, suite.transport.method)
+	suite.Require().Equal("/bigdata/", suite.transport.path)
+}
+
+func (suite *pingSuite) TestErrorResponse() {
+	suite.transport.statusCode = http.StatusForbidden
+
+	// the output is returned along with the error, to tell rejected requests from unreachable servers
+	pingOutput, err := suite.ping()
+	suite.Require().Error(err)
+	suite.Require().NotNil(pingOutput)
+	suite.Require().Equal(http.StatusForbidden, pingOutput.StatusCode)
+	suite.Require().True(pingOutput.Latency >= suite.transport.delay)
+}
+
+func (suite *pingSuite) TestUnreachable() {
+	suite.transport.err = syscall.ECONNREFUSED
+
+	pingOutput, err := suite.ping()
+	suite.Require().Error(err)
+	suite.Require().NotNil(pingOutput)
+	suite.Require().Equal(0, pingOutput.StatusCode)
+}
+
+func (suite *pingSuite) TestSession() {
+	suite.transport.statusCode = http.StatusOK
+
+	session, err := suite.context.NewSession(&v3io.NewSessionInput{
+		URL:       "http://webapi:8081",
+		AccessKey: "key",
+	})
+	suite.Require().NoError(err)
+
+	// the session's URL and credentials are used
+	pingOutput, err := session.(v3io.Pinger).Ping(&v3io.PingInput{
+		DataPlaneInput: v3io.DataPlaneInput{ContainerName: "bigdata"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, pingOutput.StatusCode)
+	suite.Require().Equal("/bigdata/", suite.transport.path)
+	suite.Require().Equal("key", suite.transport.accessKey)
+}
+
+func (suite *pingSuite) ping() (*v3io.PingOutput, error) {
+	return suite.context.(v3io.PingContext).Ping(&v3io.PingInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+	})
+}
+
+func TestPingSuite(t *testing.T) {
+	suite.Run(t, new(pingSuite))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+)
+
+// the body of error responses of KV and stream operations
+type jsonErrorBody struct {
+	ErrorCode    interface{} // a number, or a string in some versions
+	ErrorMessage string
+	Resource     string
+	RequestID    string `json:"RequestId"`
+}
+
+// the body of error responses of object operations (S3 style)
+type xmlErrorBody struct {
+	Code      string
+	Message   string
+	Resource  string
+	RequestID string `xml:"RequestId"`
+}
+
+// creates a platform error from err and whatever details can be parsed from the body of the error response
+func newPlatformError(err error, body []byte) v3ioerrors.PlatformError {
+	body = bytes.TrimSpace(body)
+
+	switch {
+	case bytes.HasPrefix(body, []byte("{")):
+		var errorBody jsonErrorBody
+
+		// decode numbers as is, since error codes may not fit a float
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+
+		if decoder.Decode(&errorBody) == nil {
+			code := ""
+			if errorBody.ErrorCode != nil {
+				code = fmt.Sprint(errorBody.ErrorCode)
+			}
+
+			return v3ioerrors.NewPlatformError(err,
+				code,
+				errorBody.ErrorMessage,
+				errorBody.Resource,
+				errorBody.RequestID)
+		}
+	case bytes.HasPrefix(body, []byte("<")):
+		var errorBody xmlErrorBody
+		if xml.Unmarshal(body, &errorBody) == nil {
+			return v3ioerrors.NewPlatformError(err,
+				errorBody.Code,
+				errorBody.Message,
+				errorBody.Resource,
+				errorBody.RequestID)
+		}
+	}
+
+	return v3ioerrors.NewPlatformError(err, "", "", "", "")
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type platformErrorTestSuite struct {
+	suite.Suite
+}
+
+func (suite *platformErrorTestSuite) TestJSONBody() {
+	platformError := newPlatformError(errors.New("failed"),
+		[]byte(`{"ErrorCode": -201326594, "ErrorMessage": "Item not found", "RequestId": "abc"}`))
+
+	suite.Require().Equal("-201326594", platformError.Code())
+	suite.Require().Equal("Item not found", platformError.Message())
+	suite.Require().Equal("abc", platformError.RequestID())
+	suite.Require().Equal("failed", platformError.Error())
+}
+
+func (suite *platformErrorTestSuite) TestXMLBody() {
+	platformError := newPlatformError(errors.New("failed"), []byte(`
+<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>NoSuchKey</Code>
+  <Message>The specified key does not exist.</Message>
+  <Resource>/bigdata/a</Resource>
+  <RequestId>abc</RequestId>
+</Error>`))
+
+	suite.Require().Equal("NoSuchKey", platformError.Code())
+	suite.Require().Equal("The specified key does not exist.", platformError.Message())
+	suite.Require().Equal("/bigdata/a", platformError.Resource())
+	suite.Require().Equal("abc", platformError.RequestID())
+}
+
+func (suite *platformErrorTestSuite) TestUnparsableBody() {
+	platformError := newPlatformError(errors.New("failed"), []byte("Internal error"))
+	suite.Require().Empty(platformError.Code())
+	suite.Require().Equal("failed", platformError.Error())
+}
+
+func (suite *platformErrorTestSuite) TestGetPlatformError() {
+	err := errors.Wrap(v3ioerrors.NewErrorWithStatusCodeAndResponse(
+		newPlatformError(errors.New("failed"), []byte(`{"ErrorCode": 1}`)), 404, nil), "Failed to get item")
+
+	platformError, found := v3ioerrors.GetPlatformError(err)
+	suite.Require().True(found)
+	suite.Require().Equal("1", platformError.Code())
+
+	_, found = v3ioerrors.GetPlatformError(errors.New("failed"))
+	suite.Require().False(found)
+}
+
+func TestPlatformErrorTestSuite(t *testing.T) {
+	suite.Run(t, new(platformErrorTestSuite))
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// returns the proxy through which to connect to addr, or nil to connect directly
+type proxyFunc func(addr string) (*url.URL, error)
+
+// returns the proxy for addr according to HTTP_PROXY, HTTPS_PROXY and NO_PROXY. since the dial function
+// only gets the address, port 443 is assumed to be https
+func proxyFromEnvironment(addr string) (*url.URL, error) {
+	scheme := "http"
+	if _, port, err := net.SplitHostPort(addr); err == nil && port == "443" {
+		scheme = "https"
+	}
+
+	return http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+}
+
+// returns a dial function which tunnels connections through the proxy returned by getProxy with CONNECT
+func newProxyDialFunc(dial fasthttp.DialFunc, getProxy proxyFunc, dialTimeout time.Duration) fasthttp.DialFunc {
+	return func(addr string) (net.Conn, error) {
+		proxyURL, err := getProxy(addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get proxy")
+		}
+
+		if proxyURL == nil {
+			return dial(addr)
+		}
+
+		return dialThroughProxy(dial, proxyURL, addr, dialTimeout)
+	}
+}
+
+func dialThroughProxy(dial fasthttp.DialFunc, proxyURL *url.URL, addr string, timeout time.Duration) (net.Conn, error) {
+	if proxyURL.Scheme != "http" {
+		return nil, errors.Errorf("Unsupported proxy scheme: %s", proxyURL.Scheme)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	conn, err := dial(proxyAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to connect to proxy %s", proxyAddr)
+	}
+
+	// bound the handshake with the proxy by the dial timeout
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Wrap(err, "Failed to set proxy handshake deadline")
+	}
+
+	connectRequest := http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connectRequest.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := connectRequest.Write(conn); err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Wrapf(err, "Failed to send CONNECT to proxy %s", proxyAddr)
+	}
+
+	connectResponse, err := http.ReadResponse(bufio.NewReader(conn), &connectRequest)
+	if err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Wrapf(err, "Failed to read CONNECT response from proxy %s", proxyAddr)
+	}
+
+	connectResponse.Body.Close() // nolint: errcheck
+
+	if connectResponse.StatusCode != http.StatusOK {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Errorf("Proxy %s refused to connect to %s: %s", proxyAddr, addr, connectResponse.Status)
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Wrap(err, "Failed to reset proxy handshake deadline")
+	}
+
+	return conn, nil
+}
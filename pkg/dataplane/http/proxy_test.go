@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type proxyTestSuite struct {
+	suite.Suite
+	server          *httptest.Server
+	proxyListener   net.Listener
+	connectRequests chan *http.Request
+}
+
+func (suite *proxyTestSuite) SetupTest() {
+	var err error
+
+	suite.server = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	suite.connectRequests = make(chan *http.Request, 1)
+
+	suite.proxyListener, err = net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+
+	go suite.serveProxy()
+}
+
+func (suite *proxyTestSuite) TearDownTest() {
+	suite.proxyListener.Close() // nolint: errcheck
+	suite.server.Close()
+}
+
+func (suite *proxyTestSuite) TestConnectThroughProxy() {
+	client := NewClient(&NewClientInput{
+		ProxyURL: &url.URL{
+			Scheme: "http",
+			User:   url.UserPassword("user", "password"),
+			Host:   suite.proxyListener.Addr().String(),
+		},
+	})
+
+	statusCode, _, err := client.Get(nil, suite.server.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, statusCode)
+
+	connectRequest := <-suite.connectRequests
+	suite.Require().Equal(http.MethodConnect, connectRequest.Method)
+	suite.Require().Equal(suite.server.Listener.Addr().String(), connectRequest.Host)
+	suite.Require().Equal("Basic dXNlcjpwYXNzd29yZA==", connectRequest.Header.Get("Proxy-Authorization"))
+}
+
+func (suite *proxyTestSuite) TestProxyRefuses() {
+	client := NewClient(&NewClientInput{
+		ProxyURL: &url.URL{Scheme: "http", Host: suite.proxyListener.Addr().String()},
+	})
+
+	// the proxy only lets authenticated requests through
+	_, _, err := client.Get(nil, suite.server.URL)
+	suite.Require().Error(err)
+}
+
+// a minimal proxy which tunnels authenticated CONNECT requests
+func (suite *proxyTestSuite) serveProxy() {
+	for {
+		conn, err := suite.proxyListener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close() // nolint: errcheck
+
+			request, err := http.ReadRequest(bufio.NewReader(conn))
+			if err != nil {
+				return
+			}
+
+			suite.connectRequests <- request
+
+			if request.Header.Get("Proxy-Authorization") == "" {
+				conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")) // nolint: errcheck
+				return
+			}
+
+			targetConn, err := net.Dial("tcp", request.Host)
+			if err != nil {
+				return
+			}
+
+			defer targetConn.Close() // nolint: errcheck
+
+			conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")) // nolint: errcheck
+
+			go io.Copy(targetConn, conn) // nolint: errcheck
+			io.Copy(conn, targetConn)    // nolint: errcheck
+		}()
+	}
+}
+
+func TestProxyTestSuite(t *testing.T) {
+	suite.Run(t, new(proxyTestSuite))
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+type RecordReplayMode string
+
+const (
+	// requests are sent through the transport, and the interactions are recorded
+	RecordReplayModeRecord RecordReplayMode = "record"
+
+	// responses are replayed from the recorded interactions, without sending requests
+	RecordReplayModeReplay RecordReplayMode = "replay"
+)
+
+type NewRecordReplayTransportInput struct {
+	Mode RecordReplayMode
+
+	// the file the interactions are saved to (see RecordReplayTransport.Save) or replayed from
+	Path string
+
+	// the transport requests are sent through when recording
+	Transport Transport
+
+	// header values which aren't recorded, in addition to credentials
+	ScrubbedHeaderNames []string
+}
+
+type recordedRequest struct {
+	Method  string            `json:"method"`
+	URI     string            `json:"uri"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	Body       []byte            `json:"body,omitempty"`
+}
+
+type recordedInteraction struct {
+	Request  recordedRequest   `json:"request"`
+	Response *recordedResponse `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type recording struct {
+	Interactions []*recordedInteraction `json:"interactions"`
+}
+
+// RecordReplayTransport records requests and their responses to a file, and replays them later without
+// a server, for hermetic tests of code using the SDK. when replaying, a request is answered with the
+// response of the next unreplayed recorded request with the same method, URI (without the host) and body.
+// credentials are never recorded
+type RecordReplayTransport struct {
+	mode                RecordReplayMode
+	path                string
+	transport           Transport
+	scrubbedHeaderNames map[string]bool
+	lock                sync.Mutex
+	recording           recording
+	unreplayed          map[string][]*recordedInteraction
+}
+
+func NewRecordReplayTransport(newRecordReplayTransportInput *NewRecordReplayTransportInput) (*RecordReplayTransport, error) {
+	recordReplayTransport := RecordReplayTransport{
+		mode:                newRecordReplayTransportInput.Mode,
+		path:                newRecordReplayTransportInput.Path,
+		transport:           newRecordReplayTransportInput.Transport,
+		scrubbedHeaderNames: map[string]bool{},
+		unreplayed:          map[string][]*recordedInteraction{},
+	}
+
+	for headerName := range sanitizedHeaderNames {
+		recordReplayTransport.scrubbedHeaderNames[headerName] = true
+	}
+
+	for _, headerName := range newRecordReplayTransportInput.ScrubbedHeaderNames {
+		recordReplayTransport.scrubbedHeaderNames[strings.ToLower(headerName)] = true
+	}
+
+	switch recordReplayTransport.mode {
+	case RecordReplayModeRecord:
+		if recordReplayTransport.transport == nil {
+			return nil, errors.New("Transport must be set when recording")
+		}
+	case RecordReplayModeReplay:
+		contents, err := ioutil.ReadFile(recordReplayTransport.path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read recording %s", recordReplayTransport.path)
+		}
+
+		if err := json.Unmarshal(contents, &recordReplayTransport.recording); err != nil {
+			return nil, errors.Wrapf(err, "Failed to decode recording %s", recordReplayTransport.path)
+		}
+
+		for _, interaction := range recordReplayTransport.recording.Interactions {
+			key := getInteractionKey(interaction.Request.Method, interaction.Request.URI, interaction.Request.Body)
+			recordReplayTransport.unreplayed[key] = append(recordReplayTransport.unreplayed[key], interaction)
+		}
+	default:
+		return nil, errors.Errorf("Invalid record/replay mode: %s", recordReplayTransport.mode)
+	}
+
+	return &recordReplayTransport, nil
+}
+
+func (t *RecordReplayTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	if t.mode == RecordReplayModeReplay {
+		return t.replay(request, response)
+	}
+
+	err := t.transport.Do(ctx, request, response, timeout)
+
+	interaction := recordedInteraction{
+		Request: recordedRequest{
+			Method:  string(request.Header.Method()),
+			URI:     string(request.RequestURI()),
+			Headers: map[string]string{},
+			Body:    append([]byte(nil), request.Body()...),
+		},
+	}
+
+	request.Header.VisitAll(func(key []byte, value []byte) {
+		interaction.Request.Headers[string(key)] = t.scrub(string(key), string(value))
+	})
+
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Response = &recordedResponse{
+			StatusCode: response.StatusCode(),
+			Headers:    map[string]string{},
+			Body:       append([]byte(nil), response.Body()...),
+		}
+
+		response.Header.VisitAll(func(key []byte, value []byte) {
+			interaction.Response.Headers[string(key)] = t.scrub(string(key), string(value))
+		})
+	}
+
+	t.lock.Lock()
+	t.recording.Interactions = append(t.recording.Interactions, &interaction)
+	t.lock.Unlock()
+
+	return err
+}
+
+// Save writes the interactions recorded so far to the file
+func (t *RecordReplayTransport) Save() error {
+	if t.mode != RecordReplayModeRecord {
+		return errors.New("Only recordings can be saved")
+	}
+
+	t.lock.Lock()
+	contents, err := json.MarshalIndent(&t.recording, "", "  ")
+	t.lock.Unlock()
+
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode recording")
+	}
+
+	return ioutil.WriteFile(t.path, contents, 0644)
+}
+
+func (t *RecordReplayTransport) replay(request *fasthttp.Request, response *fasthttp.Response) error {
+	key := getInteractionKey(string(request.Header.Method()), string(request.RequestURI()), request.Body())
+
+	t.lock.Lock()
+	interactions := t.unreplayed[key]
+	if len(interactions) == 0 {
+		t.lock.Unlock()
+		return errors.Errorf("No recorded response for %s %s", request.Header.Method(), request.RequestURI())
+	}
+
+	interaction := interactions[0]
+	t.unreplayed[key] = interactions[1:]
+	t.lock.Unlock()
+
+	if interaction.Response == nil {
+		return errors.New(interaction.Error)
+	}
+
+	response.Reset()
+	response.SetStatusCode(interaction.Response.StatusCode)
+
+	for headerName, headerValue := range interaction.Response.Headers {
+		switch headerName {
+
+		// set along with the body
+		case "Content-Length":
+		default:
+			response.Header.Set(headerName, headerValue)
+		}
+	}
+
+	response.SetBody(interaction.Response.Body)
+
+	return nil
+}
+
+func (t *RecordReplayTransport) scrub(headerName string, headerValue string) string {
+	if t.scrubbedHeaderNames[strings.ToLower(headerName)] {
+		return "SANITIZED"
+	}
+
+	return headerValue
+}
+
+func getInteractionKey(method string, uri string, body []byte) string {
+	return method + " " + uri + "\n" + string(body)
+}
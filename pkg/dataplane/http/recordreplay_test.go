@@ -0,0 +1,98 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+type recordReplayTransportSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+func (suite *recordReplayTransportSuite) SetupTest() {
+	var err error
+
+	suite.tempDir, err = ioutil.TempDir("", "recordreplay-test")
+	suite.Require().NoError(err)
+}
+
+func (suite *recordReplayTransportSuite) TearDownTest() {
+	os.RemoveAll(suite.tempDir) // nolint: errcheck
+}
+
+func (suite *recordReplayTransportSuite) TestRecordAndReplay() {
+	recordingPath := filepath.Join(suite.tempDir, "recording.json")
+	innerTransport := &fakeTransport{}
+
+	recordingTransport, err := NewRecordReplayTransport(&NewRecordReplayTransportInput{
+		Mode:      RecordReplayModeRecord,
+		Path:      recordingPath,
+		Transport: innerTransport,
+	})
+	suite.Require().NoError(err)
+
+	suite.doRequest(recordingTransport, "/bigdata/a")
+	suite.Require().NoError(recordingTransport.Save())
+
+	// credentials aren't recorded
+	contents, err := ioutil.ReadFile(recordingPath)
+	suite.Require().NoError(err)
+	suite.Require().NotContains(string(contents), "secret")
+
+	replayingTransport, err := NewRecordReplayTransport(&NewRecordReplayTransportInput{
+		Mode: RecordReplayModeReplay,
+		Path: recordingPath,
+	})
+	suite.Require().NoError(err)
+
+	response := suite.doRequest(replayingTransport, "/bigdata/a")
+	suite.Require().Equal(fasthttp.StatusOK, response.StatusCode())
+	suite.Require().Equal("0123456789", string(response.Body()))
+	suite.Require().Equal(1, innerTransport.numRequests)
+
+	// each interaction is replayed once
+	request := fasthttp.AcquireRequest()
+	request.SetRequestURI("http://localhost:8081/bigdata/a")
+	suite.Require().Error(replayingTransport.Do(nil, request, response, 0))
+}
+
+func (suite *recordReplayTransportSuite) doRequest(transport Transport, path string) *fasthttp.Response {
+	request := fasthttp.AcquireRequest()
+	request.SetRequestURI("http://localhost:8081" + path)
+	request.Header.Set("X-v3io-session-key", "secret")
+
+	response := fasthttp.AcquireResponse()
+	suite.Require().NoError(transport.Do(nil, request, response, 0))
+
+	return response
+}
+
+func TestRecordReplayTransportSuite(t *testing.T) {
+	suite.Run(t, new(recordReplayTransportSuite))
+}
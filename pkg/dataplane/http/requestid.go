@@ -0,0 +1,48 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+)
+
+const requestIDHeaderName = "X-v3io-request-id"
+
+// request IDs are a random per-process prefix followed by a counter, which is unique without the cost of
+// generating random IDs per request
+var requestIDPrefix = newRequestIDPrefix()
+var lastRequestIDSuffix uint64
+
+func newRequestID() string {
+	return requestIDPrefix + "-" + strconv.FormatUint(atomic.AddUint64(&lastRequestIDSuffix, 1), 16)
+}
+
+func newRequestIDPrefix() string {
+	randomBytes := make([]byte, 8)
+
+	// fall back to an all zeros prefix, which is still unique within the process
+	rand.Read(randomBytes) // nolint: errcheck
+
+	return hex.EncodeToString(randomBytes)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// fails objects named "missing", recording the request IDs it received
+type requestIDTransport struct {
+	requestIDs []string
+}
+
+func (rit *requestIDTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	rit.requestIDs = append(rit.requestIDs, string(request.Header.Peek(requestIDHeaderName)))
+
+	if string(request.URI().Path()) == "/bigdata/missing" {
+		response.SetStatusCode(fasthttp.StatusNotFound)
+	} else {
+		response.SetStatusCode(fasthttp.StatusOK)
+	}
+
+	return nil
+}
+
+type requestIDSuite struct {
+	suite.Suite
+	transport *requestIDTransport
+	context   v3io.Context
+}
+
+func (suite *requestIDSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.transport = &requestIDTransport{}
+	suite.context, err = NewContext(logger, &NewContextInput{Transport: suite.transport})
+	suite.Require().NoError(err)
+}
+
+func (suite *requestIDSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *requestIDSuite) TestGenerated() {
+	getObjectInput := v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err := suite.context.GetObjectSync(&getObjectInput)
+		suite.Require().NoError(err)
+		suite.Require().Equal(suite.transport.requestIDs[i], response.RequestID)
+		response.Release()
+	}
+
+	// unique per request, and the input isn't modified
+	suite.Require().NotEmpty(suite.transport.requestIDs[0])
+	suite.Require().NotEqual(suite.transport.requestIDs[0], suite.transport.requestIDs[1])
+	suite.Require().Empty(getObjectInput.RequestID)
+
+	getObjectInput.Path = "missing"
+	_, err := suite.context.GetObjectSync(&getObjectInput)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), suite.transport.requestIDs[2])
+}
+
+func (suite *requestIDSuite) TestGiven() {
+	response, err := suite.context.GetObjectSync(&v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{
+			URL:           "http://webapi:8081",
+			ContainerName: "bigdata",
+			RequestID:     "my-request",
+		},
+		Path: "a",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("my-request", response.RequestID)
+	suite.Require().Equal([]string{"my-request"}, suite.transport.requestIDs)
+	response.Release()
+}
+
+func TestRequestIDSuite(t *testing.T) {
+	suite.Run(t, new(requestIDSuite))
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// headers whose values are replaced when logged
+var sanitizedHeaderNames = map[string]bool{
+	"authorization":      true,
+	"x-v3io-session-key": true,
+}
+
+func (c *context) logRequest(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	statusCode int,
+	latency time.Duration,
+	err error) {
+
+	success := err == nil
+	if success && c.requestLogPolicy.SampleRate > 0 && rand.Float64() >= c.requestLogPolicy.SampleRate {
+		return
+	}
+
+	requestLogEntry := RequestLogEntry{
+		Method:      string(request.Header.Method()),
+		Path:        string(request.URI().Path()),
+		Function:    string(request.Header.Peek("X-v3io-function")),
+		RequestID:   string(request.Header.Peek(requestIDHeaderName)),
+		Headers:     getSanitizedHeaders(request),
+		StatusCode:  statusCode,
+		Latency:     latency,
+		RequestSize: len(request.Body()),
+		Err:         err,
+	}
+
+	if statusCode != 0 {
+		requestLogEntry.ResponseSize = len(response.Body())
+	}
+
+	if c.requestLogPolicy.Handler != nil {
+		c.requestLogPolicy.Handler(&requestLogEntry)
+		return
+	}
+
+	c.logger.DebugWithCtx(ctx,
+		"Request",
+		"method", requestLogEntry.Method,
+		"path", requestLogEntry.Path,
+		"function", requestLogEntry.Function,
+		"requestID", requestLogEntry.RequestID,
+		"headers", requestLogEntry.Headers,
+		"statusCode", requestLogEntry.StatusCode,
+		"latency", requestLogEntry.Latency.String(),
+		"requestSize", requestLogEntry.RequestSize,
+		"responseSize", requestLogEntry.ResponseSize,
+		"err", requestLogEntry.Err)
+}
+
+func getSanitizedHeaders(request *fasthttp.Request) map[string]string {
+	headers := map[string]string{}
+
+	request.Header.VisitAll(func(key []byte, value []byte) {
+		if sanitizedHeaderNames[strings.ToLower(string(key))] {
+			headers[string(key)] = "SANITIZED"
+		} else {
+			headers[string(key)] = string(value)
+		}
+	})
+
+	return headers
+}
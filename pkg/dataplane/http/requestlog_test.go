@@ -0,0 +1,167 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// fails requests for paths containing "fail"
+type failingPathTransport struct{}
+
+func (fpt *failingPathTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	if strings.Contains(string(request.URI().Path()), "fail") {
+		response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return nil
+	}
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	response.SetBodyString("0123456789")
+
+	return nil
+}
+
+type requestLogSuite struct {
+	suite.Suite
+	context v3io.Context
+	lock    sync.Mutex
+	entries []*RequestLogEntry
+}
+
+func (suite *requestLogSuite) SetupTest() {
+	suite.entries = nil
+}
+
+func (suite *requestLogSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *requestLogSuite) TestEntry() {
+	suite.createContext(0)
+
+	response, err := suite.context.GetObjectSync(&v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{
+			URL:                 "http://webapi:8081",
+			ContainerName:       "bigdata",
+			AccessKey:           "secret-key",
+			AuthenticationToken: "Basic secret-token",
+			RequestID:           "request-id",
+		},
+		Path: "a",
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	entries := suite.getEntries()
+	suite.Require().Len(entries, 1)
+	suite.Require().Equal("GET", entries[0].Method)
+	suite.Require().Equal("/bigdata/a", entries[0].Path)
+	suite.Require().Equal("request-id", entries[0].RequestID)
+	suite.Require().Equal(fasthttp.StatusOK, entries[0].StatusCode)
+	suite.Require().Equal(10, entries[0].ResponseSize)
+	suite.Require().NoError(entries[0].Err)
+
+	// credentials are never logged
+	suite.Require().Equal("SANITIZED", entries[0].Headers["X-V3io-Session-Key"])
+	suite.Require().Equal("SANITIZED", entries[0].Headers["Authorization"])
+
+	for _, headerValue := range entries[0].Headers {
+		suite.Require().NotContains(headerValue, "secret")
+	}
+}
+
+func (suite *requestLogSuite) TestSampling() {
+	suite.createContext(0.2)
+
+	numRequests := 1000
+	for requestIdx := 0; requestIdx < numRequests; requestIdx++ {
+		suite.getObject("a")
+		suite.getObject("fail")
+	}
+
+	numSuccessful := 0
+	numFailed := 0
+
+	for _, entry := range suite.getEntries() {
+		if entry.Err != nil {
+			numFailed++
+		} else {
+			numSuccessful++
+		}
+	}
+
+	// failed requests are always logged, successful ones are sampled
+	suite.Require().Equal(numRequests, numFailed)
+	suite.Require().InDelta(200, numSuccessful, 100)
+}
+
+func (suite *requestLogSuite) getObject(path string) {
+	response, err := suite.context.GetObjectSync(&v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           path,
+	})
+
+	if err == nil {
+		response.Release()
+	}
+}
+
+func (suite *requestLogSuite) getEntries() []*RequestLogEntry {
+	suite.lock.Lock()
+	defer suite.lock.Unlock()
+
+	return suite.entries
+}
+
+func (suite *requestLogSuite) createContext(sampleRate float64) {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.context, err = NewContext(logger, &NewContextInput{
+		Transport: &failingPathTransport{},
+		RequestLogPolicy: &RequestLogPolicy{
+			SampleRate: sampleRate,
+			Handler: func(requestLogEntry *RequestLogEntry) {
+				suite.lock.Lock()
+				suite.entries = append(suite.entries, requestLogEntry)
+				suite.lock.Unlock()
+			},
+		},
+	})
+	suite.Require().NoError(err)
+}
+
+func TestRequestLogSuite(t *testing.T) {
+	suite.Run(t, new(requestLogSuite))
+}
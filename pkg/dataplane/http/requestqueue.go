@@ -0,0 +1,113 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iohttp
+
+import (
+	"container/heap"
+	"sync"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// a bounded queue of requests, ordered by priority and then by submission order. workers wait on
+// the items channel, which holds a token per queued request, so that they can select on it
+type requestQueue struct {
+	lock     sync.Mutex
+	heap     requestHeap
+	sequence uint64
+	slots    chan struct{}
+	items    chan struct{}
+}
+
+func newRequestQueue(capacity int) *requestQueue {
+	return &requestQueue{
+		slots: make(chan struct{}, capacity),
+		items: make(chan struct{}, capacity),
+	}
+}
+
+// push adds a request to the queue, blocking while the queue is full
+func (rq *requestQueue) push(request *v3io.Request) {
+	rq.slots <- struct{}{}
+
+	rq.lock.Lock()
+	heap.Push(&rq.heap, &queuedRequest{request: request, sequence: rq.sequence})
+	rq.sequence++
+	rq.lock.Unlock()
+
+	rq.items <- struct{}{}
+}
+
+// pop removes the request with the highest priority. must only be called after receiving from items
+func (rq *requestQueue) pop() *v3io.Request {
+	rq.lock.Lock()
+	queuedRequest := heap.Pop(&rq.heap).(*queuedRequest)
+	rq.lock.Unlock()
+
+	<-rq.slots
+
+	return queuedRequest.request
+}
+
+func (rq *requestQueue) len() int {
+	return len(rq.items)
+}
+
+func (rq *requestQueue) cap() int {
+	return cap(rq.items)
+}
+
+type queuedRequest struct {
+	request  *v3io.Request
+	sequence uint64
+}
+
+// implements heap.Interface
+type requestHeap []*queuedRequest
+
+func (rh requestHeap) Len() int {
+	return len(rh)
+}
+
+func (rh requestHeap) Less(i, j int) bool {
+	if rh[i].request.Priority != rh[j].request.Priority {
+		return rh[i].request.Priority > rh[j].request.Priority
+	}
+
+	return rh[i].sequence < rh[j].sequence
+}
+
+func (rh requestHeap) Swap(i, j int) {
+	rh[i], rh[j] = rh[j], rh[i]
+}
+
+func (rh *requestHeap) Push(x interface{}) {
+	*rh = append(*rh, x.(*queuedRequest))
+}
+
+func (rh *requestHeap) Pop() interface{} {
+	old := *rh
+	lastIndex := len(old) - 1
+	item := old[lastIndex]
+	old[lastIndex] = nil
+	*rh = old[:lastIndex]
+
+	return item
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iohttp
+
+import (
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type requestQueueSuite struct {
+	suite.Suite
+}
+
+func (suite *requestQueueSuite) TestPriorityOrder() {
+	requestQueue := newRequestQueue(8)
+
+	for requestIndex, priority := range []v3io.RequestPriority{
+		v3io.RequestPriorityLow,
+		v3io.RequestPriorityNormal,
+		v3io.RequestPriorityHigh,
+		v3io.RequestPriorityNormal,
+		v3io.RequestPriorityHigh,
+	} {
+		requestQueue.push(&v3io.Request{ID: uint64(requestIndex), Priority: priority})
+	}
+
+	suite.Require().Equal(5, requestQueue.len())
+	suite.Require().Equal(8, requestQueue.cap())
+
+	var poppedIDs []uint64
+	for requestQueue.len() > 0 {
+		<-requestQueue.items
+		poppedIDs = append(poppedIDs, requestQueue.pop().ID)
+	}
+
+	// highest priority first, in submission order within the same priority
+	suite.Require().Equal([]uint64{2, 4, 1, 3, 0}, poppedIDs)
+}
+
+func TestRequestQueueSuite(t *testing.T) {
+	suite.Run(t, new(requestQueueSuite))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"sync/atomic"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// RetryPolicy configures retrying requests which failed before a response was received, e.g. since
+// the server closed an idle connection (see https://github.com/valyala/fasthttp/issues/189)
+type RetryPolicy struct {
+	MaxAttempts int             // per request, including the first (defaults to 8)
+	Budget      time.Duration   // the time a request may spend retrying, including backoff (defaults to 5 seconds)
+	Backoff     *common.Backoff // between attempts (defaults to 10ms, doubling up to 500ms, with jitter)
+
+	// returns whether a failed attempt should be retried (defaults to fasthttp.ErrConnectionClosed only, as
+	// other errors may follow a request the server already applied). v3ioerrors.IsRetryable retries more broadly
+	IsRetryable func(error) bool
+}
+
+func newRetryPolicy(retryPolicy *RetryPolicy) *RetryPolicy {
+	newRetryPolicy := RetryPolicy{}
+	if retryPolicy != nil {
+		newRetryPolicy = *retryPolicy
+	}
+
+	if newRetryPolicy.MaxAttempts == 0 {
+		newRetryPolicy.MaxAttempts = 8
+	}
+
+	if newRetryPolicy.Budget == 0 {
+		newRetryPolicy.Budget = 5 * time.Second
+	}
+
+	if newRetryPolicy.Backoff == nil {
+		newRetryPolicy.Backoff = &common.Backoff{
+			Min:    10 * time.Millisecond,
+			Max:    500 * time.Millisecond,
+			Factor: 2,
+			Jitter: true,
+		}
+	}
+
+	if newRetryPolicy.IsRetryable == nil {
+		newRetryPolicy.IsRetryable = isConnectionClosed
+	}
+
+	return &newRetryPolicy
+}
+
+func isConnectionClosed(err error) bool {
+	return err == fasthttp.ErrConnectionClosed
+}
+
+// sends the request through the transport, retrying per the retry policy. a connection slot (see
+// NewContextInput.MaxConns) is only held while an attempt is in flight
+func (c *context) doWithRetries(dataPlaneInput *v3io.DataPlaneInput,
+	request *fasthttp.Request,
+	response *fasthttp.Response) error {
+	var retryDeadline time.Time
+
+	for attempt := 0; ; attempt++ {
+		err := c.doOnce(dataPlaneInput, request, response)
+		if err == nil || !c.retryPolicy.IsRetryable(err) {
+			return err
+		}
+
+		if attempt+1 >= c.retryPolicy.MaxAttempts {
+			atomic.AddUint64(&c.numRetriesExhausted, 1)
+			return errors.Wrapf(err, "Failed after %d attempts", attempt+1)
+		}
+
+		// ForAttempt is safe for concurrent use, unlike Duration
+		backoffDuration := c.retryPolicy.Backoff.ForAttempt(float64(attempt))
+
+		if retryDeadline.IsZero() {
+			retryDeadline = time.Now().Add(c.retryPolicy.Budget)
+		}
+
+		if time.Now().Add(backoffDuration).After(retryDeadline) {
+			atomic.AddUint64(&c.numRetriesExhausted, 1)
+			return errors.Wrapf(err, "Retry budget of %s exhausted after %d attempts", c.retryPolicy.Budget, attempt+1)
+		}
+
+		if err := sleepWithContext(dataPlaneInput.Ctx, backoffDuration); err != nil {
+			return errors.Wrap(err, "Context done while waiting to retry")
+		}
+
+		atomic.AddUint64(&c.numRetries, 1)
+	}
+}
+
+func (c *context) doOnce(dataPlaneInput *v3io.DataPlaneInput,
+	request *fasthttp.Request,
+	response *fasthttp.Response) error {
+	if c.connSemaphore != nil {
+		atomic.AddInt64(&c.numPendingConnAcquisitions, 1)
+		err := c.connSemaphore.Acquire(goctx.TODO(), 1)
+		atomic.AddInt64(&c.numPendingConnAcquisitions, -1)
+		if err != nil {
+			return err
+		}
+
+		defer c.connSemaphore.Release(1)
+	}
+
+	timeouts := c.getTimeouts(dataPlaneInput)
+
+	if timeoutsTransport, ok := c.transport.(TimeoutsTransport); ok {
+		return timeoutsTransport.DoWithTimeouts(dataPlaneInput.Ctx, request, response, &timeouts)
+	}
+
+	return c.transport.Do(dataPlaneInput.Ctx, request, response, timeouts.Total)
+}
+
+// returns the timeouts of the input, defaulting to the context's
+// returns an error if the timeouts bound phases of a request which the transport can't time (only
+// transports implementing TimeoutsTransport can). the field names are those reported in the error
+func (c *context) validateTimeouts(timeouts *Timeouts, connectFieldName string, responseHeaderFieldName string) error {
+	if _, supportsTimeouts := c.transport.(TimeoutsTransport); supportsTimeouts {
+		return nil
+	}
+
+	if timeouts.Connect != 0 {
+		return v3ioerrors.NewErrorWithField(connectFieldName,
+			"is not supported by the transport (see NewClientInput.DialTimeout)")
+	}
+
+	if timeouts.ResponseHeader != 0 {
+		return v3ioerrors.NewErrorWithField(responseHeaderFieldName, "is not supported by the transport")
+	}
+
+	return nil
+}
+
+func (c *context) getTimeouts(dataPlaneInput *v3io.DataPlaneInput) Timeouts {
+	timeouts := c.defaultTimeouts
+
+	if dataPlaneInput.ConnectTimeout != 0 {
+		timeouts.Connect = dataPlaneInput.ConnectTimeout
+	}
+
+	if dataPlaneInput.ResponseHeaderTimeout != 0 {
+		timeouts.ResponseHeader = dataPlaneInput.ResponseHeaderTimeout
+	}
+
+	if dataPlaneInput.Timeout != 0 {
+		timeouts.Total = dataPlaneInput.Timeout
+	}
+
+	return timeouts
+}
+
+func sleepWithContext(ctx goctx.Context, duration time.Duration) error {
+	if ctx == nil {
+		time.Sleep(duration)
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
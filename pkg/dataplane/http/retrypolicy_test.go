@@ -0,0 +1,121 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// fails the first numFailures requests as if the server closed the connection
+type connectionClosedTransport struct {
+	numFailures int
+	numRequests int
+}
+
+func (cct *connectionClosedTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	cct.numRequests++
+	if cct.numRequests <= cct.numFailures {
+		return fasthttp.ErrConnectionClosed
+	}
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	return nil
+}
+
+type retryPolicySuite struct {
+	suite.Suite
+	transport *connectionClosedTransport
+}
+
+func (suite *retryPolicySuite) SetupTest() {
+	suite.transport = &connectionClosedTransport{}
+}
+
+func (suite *retryPolicySuite) TestRetriesConnectionClosed() {
+	context := suite.createContext(&RetryPolicy{Backoff: &common.Backoff{Min: time.Millisecond, Max: time.Millisecond}})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.transport.numFailures = 3
+	suite.Require().NoError(suite.putObject(context))
+	suite.Require().Equal(4, suite.transport.numRequests)
+	suite.Require().Equal(uint64(3), context.(v3io.StatsContext).Stats().NumRetries)
+	suite.Require().Equal(uint64(0), context.(v3io.StatsContext).Stats().NumRetriesExhausted)
+}
+
+func (suite *retryPolicySuite) TestMaxAttempts() {
+	context := suite.createContext(&RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     &common.Backoff{Min: time.Millisecond, Max: time.Millisecond},
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.transport.numFailures = 3
+	err := suite.putObject(context)
+	suite.Require().Equal(fasthttp.ErrConnectionClosed, errors.RootCause(err))
+	suite.Require().Equal(2, suite.transport.numRequests)
+	suite.Require().Equal(uint64(1), context.(v3io.StatsContext).Stats().NumRetriesExhausted)
+}
+
+func (suite *retryPolicySuite) TestBudget() {
+	context := suite.createContext(&RetryPolicy{
+		Budget:  150 * time.Millisecond,
+		Backoff: &common.Backoff{Min: 100 * time.Millisecond, Max: 100 * time.Millisecond},
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.transport.numFailures = 3
+	suite.Require().Error(suite.putObject(context))
+	suite.Require().Equal(2, suite.transport.numRequests)
+}
+
+func (suite *retryPolicySuite) createContext(retryPolicy *RetryPolicy) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, &NewContextInput{Transport: suite.transport, RetryPolicy: retryPolicy})
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func (suite *retryPolicySuite) putObject(context v3io.Context) error {
+	return context.PutObjectSync(&v3io.PutObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	})
+}
+
+func TestRetryPolicySuite(t *testing.T) {
+	suite.Run(t, new(retryPolicySuite))
+}
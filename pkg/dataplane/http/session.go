@@ -22,16 +22,23 @@ package v3iohttp
 import (
 	"encoding/base64"
 	"fmt"
+	"sync"
 
 	"github.com/v3io/v3io-go/pkg/dataplane"
 
+	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 )
 
 type session struct {
-	logger              logger.Logger
-	context             *context
-	url                 string
+	logger             logger.Logger
+	context            *context
+	url                string
+	refreshCredentials v3io.RefreshCredentialsFunc
+	headers            map[string]string
+
+	// credentials may be replaced by refreshCredentials
+	credentialsLock     sync.RWMutex
 	authenticationToken string
 	accessKey           string
 }
@@ -41,20 +48,28 @@ func newSession(parentLogger logger.Logger,
 	url string,
 	username string,
 	password string,
-	accessKey string) (v3io.Session, error) {
+	accessKey string,
+	refreshCredentials v3io.RefreshCredentialsFunc,
+	headers map[string]string) (v3io.Session, error) {
 
-	authenticationToken := ""
-	if username != "" && password != "" && accessKey == "" {
-		authenticationToken = GenerateAuthenticationToken(username, password)
+	newSession := &session{
+		logger:             parentLogger.GetChild("session"),
+		context:            context,
+		url:                url,
+		refreshCredentials: refreshCredentials,
 	}
 
-	return &session{
-		logger:              parentLogger.GetChild("session"),
-		context:             context,
-		url:                 url,
-		authenticationToken: authenticationToken,
-		accessKey:           accessKey,
-	}, nil
+	// copied, so that the caller can't change the headers of the session's requests
+	if len(headers) > 0 {
+		newSession.headers = make(map[string]string, len(headers))
+		for headerName, headerValue := range headers {
+			newSession.headers[headerName] = headerValue
+		}
+	}
+
+	newSession.setCredentials(username, password, accessKey)
+
+	return newSession, nil
 }
 
 // NewContainer creates a container
@@ -62,6 +77,84 @@ func (s *session) NewContainer(newContainerInput *v3io.NewContainerInput) (v3io.
 	return newContainer(s.logger, s, newContainerInput.ContainerName)
 }
 
+// Ping pings the container of the input with the session's URL and credentials (see v3io.PingContext)
+func (s *session) Ping(pingInput *v3io.PingInput) (*v3io.PingOutput, error) {
+	pingInput.URL = s.url
+	s.populateCredentials(&pingInput.DataPlaneInput)
+	s.populateHeaders(&pingInput.DataPlaneInput)
+
+	return s.context.Ping(pingInput)
+}
+
+// RefreshCredentials replaces the session's credentials with fresh ones and populates the input with them.
+// if the session's credentials already changed since the input was populated (e.g. by a concurrent refresh),
+// the input is populated with them without refreshing again
+func (s *session) RefreshCredentials(dataPlaneInput *v3io.DataPlaneInput) error {
+	s.credentialsLock.Lock()
+	defer s.credentialsLock.Unlock()
+
+	if s.authenticationToken == dataPlaneInput.AuthenticationToken && s.accessKey == dataPlaneInput.AccessKey {
+		credentials, err := s.refreshCredentials()
+		if err != nil {
+			return errors.Wrap(err, "Failed to refresh credentials")
+		}
+
+		if credentials == nil {
+			return errors.New("Credentials refresh returned no credentials")
+		}
+
+		s.setCredentials(credentials.Username, credentials.Password, credentials.AccessKey)
+
+		s.logger.DebugWithCtx(dataPlaneInput.Ctx, "Refreshed credentials")
+	}
+
+	dataPlaneInput.AuthenticationToken = s.authenticationToken
+	dataPlaneInput.AccessKey = s.accessKey
+
+	return nil
+}
+
+func (s *session) populateCredentials(dataPlaneInput *v3io.DataPlaneInput) {
+	s.credentialsLock.RLock()
+	defer s.credentialsLock.RUnlock()
+
+	dataPlaneInput.AuthenticationToken = s.authenticationToken
+	dataPlaneInput.AccessKey = s.accessKey
+
+	if s.refreshCredentials != nil {
+		dataPlaneInput.CredentialsRefresher = s
+	}
+}
+
+// adds the session's headers to those of the input, which take precedence. the input gets a new map,
+// so that neither the session's headers nor the caller's are modified by later changes to the other
+func (s *session) populateHeaders(dataPlaneInput *v3io.DataPlaneInput) {
+	if len(s.headers) == 0 {
+		return
+	}
+
+	headers := make(map[string]string, len(s.headers)+len(dataPlaneInput.Headers))
+	for headerName, headerValue := range s.headers {
+		headers[headerName] = headerValue
+	}
+
+	for headerName, headerValue := range dataPlaneInput.Headers {
+		headers[headerName] = headerValue
+	}
+
+	dataPlaneInput.Headers = headers
+}
+
+// must be called with the credentials lock held (or before the session is shared)
+func (s *session) setCredentials(username string, password string, accessKey string) {
+	s.authenticationToken = ""
+	if username != "" && password != "" && accessKey == "" {
+		s.authenticationToken = GenerateAuthenticationToken(username, password)
+	}
+
+	s.accessKey = accessKey
+}
+
 func GenerateAuthenticationToken(username string, password string) string {
 
 	// generate token for basic authentication
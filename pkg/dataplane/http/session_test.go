@@ -0,0 +1,184 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// rejects requests whose access key isn't the current one. rejections may be held until a number of
+// requests were rejected, so that they're all rejected before any refreshes
+type accessKeyTransport struct {
+	lock                  sync.Mutex
+	accessKey             string
+	numUnauthorizedToHold int
+
+	// accessed atomically
+	numUnauthorized int64
+}
+
+func (akt *accessKeyTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	akt.lock.Lock()
+	accessKey := akt.accessKey
+	akt.lock.Unlock()
+
+	if string(request.Header.Peek("X-v3io-session-key")) != accessKey {
+		atomic.AddInt64(&akt.numUnauthorized, 1)
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			if akt.getNumUnauthorized() >= akt.numUnauthorizedToHold {
+				break
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		response.SetStatusCode(fasthttp.StatusUnauthorized)
+		return nil
+	}
+
+	response.SetStatusCode(fasthttp.StatusOK)
+	response.SetBodyString("0123456789")
+
+	return nil
+}
+
+func (akt *accessKeyTransport) rotate(accessKey string) {
+	akt.lock.Lock()
+	defer akt.lock.Unlock()
+
+	akt.accessKey = accessKey
+}
+
+func (akt *accessKeyTransport) getNumUnauthorized() int {
+	return int(atomic.LoadInt64(&akt.numUnauthorized))
+}
+
+type sessionSuite struct {
+	suite.Suite
+	transport    *accessKeyTransport
+	context      v3io.Context
+	numRefreshes int64
+}
+
+func (suite *sessionSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.transport = &accessKeyTransport{accessKey: "old"}
+	suite.numRefreshes = 0
+
+	suite.context, err = NewContext(logger, &NewContextInput{
+		Transport:  suite.transport,
+		NumWorkers: 8,
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *sessionSuite) TearDownTest() {
+	v3io.CloseContext(suite.context) // nolint: errcheck
+}
+
+func (suite *sessionSuite) TestRefreshOnUnauthorized() {
+	container := suite.createContainer()
+
+	// the key is rotated on the server - the first request is rejected, refreshes and is retried
+	suite.transport.rotate("new")
+
+	response, err := container.GetObjectSync(&v3io.GetObjectInput{Path: "a"})
+	suite.Require().NoError(err)
+	response.Release()
+
+	suite.Require().Equal(1, suite.transport.getNumUnauthorized())
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numRefreshes))
+
+	// later requests use the refreshed key
+	response, err = container.GetObjectSync(&v3io.GetObjectInput{Path: "a"})
+	suite.Require().NoError(err)
+	response.Release()
+
+	suite.Require().Equal(1, suite.transport.getNumUnauthorized())
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numRefreshes))
+}
+
+func (suite *sessionSuite) TestConcurrentRequestsShareRefresh() {
+	numRequests := 8
+
+	container := suite.createContainer()
+
+	// all requests are rejected before any refreshes, so they must all wait for the same refresh
+	suite.transport.numUnauthorizedToHold = numRequests
+	suite.transport.rotate("new")
+
+	var waitGroup sync.WaitGroup
+	for requestIdx := 0; requestIdx < numRequests; requestIdx++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			response, err := container.GetObjectSync(&v3io.GetObjectInput{Path: "a"})
+			if suite.NoError(err) {
+				response.Release()
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	suite.Require().Equal(numRequests, suite.transport.getNumUnauthorized())
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.numRefreshes))
+}
+
+func (suite *sessionSuite) createContainer() v3io.Container {
+	session, err := suite.context.NewSession(&v3io.NewSessionInput{
+		URL:       "http://webapi:8081",
+		AccessKey: "old",
+		RefreshCredentials: func() (*v3io.Credentials, error) {
+			atomic.AddInt64(&suite.numRefreshes, 1)
+
+			return &v3io.Credentials{AccessKey: "new"}, nil
+		},
+	})
+	suite.Require().NoError(err)
+
+	container, err := session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+
+	return container
+}
+
+func TestSessionSuite(t *testing.T) {
+	suite.Run(t, new(sessionSuite))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/nuclio/errors"
+)
+
+type NewTLSConfigInput struct {
+
+	// a PEM bundle of the CAs to trust instead of the system's
+	CACertPath string
+
+	// a PEM certificate and key to authenticate to the server with
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// defaults to TLS 1.2
+	MinVersion uint16
+
+	// skip verifying the server's certificate. insecure - for testing only
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig creates a TLS configuration for NewClientInput or NewNetHTTPTransportInput
+func NewTLSConfig(newTLSConfigInput *NewTLSConfigInput) (*tls.Config, error) {
+	tlsConfig := tls.Config{
+		MinVersion:         newTLSConfigInput.MinVersion,
+		InsecureSkipVerify: newTLSConfigInput.InsecureSkipVerify, // nolint: gosec
+	}
+
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	if newTLSConfigInput.CACertPath != "" {
+		caCerts, err := ioutil.ReadFile(newTLSConfigInput.CACertPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read CA certificates from %s", newTLSConfigInput.CACertPath)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCerts) {
+			return nil, errors.Errorf("No CA certificates found in %s", newTLSConfigInput.CACertPath)
+		}
+	}
+
+	if newTLSConfigInput.ClientCertPath != "" || newTLSConfigInput.ClientKeyPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(newTLSConfigInput.ClientCertPath, newTLSConfigInput.ClientKeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load client certificate")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return &tlsConfig, nil
+}
+
+// returns the given TLS configuration, or a default one which verifies the server unless insecureSkipVerify is set
+func getTLSConfig(tlsConfig *tls.Config, insecureSkipVerify bool) *tls.Config {
+	if tlsConfig != nil {
+		return tlsConfig
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify, // nolint: gosec
+	}
+}
+
+// returns a copy of the TLS configuration which verifies the server and requires at least TLS 1.2
+func getStrictTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	strictTLSConfig := getTLSConfig(tlsConfig, false).Clone()
+	strictTLSConfig.InsecureSkipVerify = false
+
+	if strictTLSConfig.MinVersion < tls.VersionTLS12 {
+		strictTLSConfig.MinVersion = tls.VersionTLS12
+	}
+
+	return strictTLSConfig
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type tlsTestSuite struct {
+	suite.Suite
+	server  *httptest.Server
+	tempDir string
+}
+
+func (suite *tlsTestSuite) SetupTest() {
+	var err error
+
+	suite.server = httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	suite.tempDir, err = ioutil.TempDir("", "v3io-tls")
+	suite.Require().NoError(err)
+}
+
+func (suite *tlsTestSuite) TearDownTest() {
+	suite.server.Close()
+	os.RemoveAll(suite.tempDir) // nolint: errcheck
+}
+
+func (suite *tlsTestSuite) TestVerifiedByDefault() {
+	_, _, err := NewClient(&NewClientInput{}).Get(nil, suite.server.URL)
+	suite.Require().Error(err)
+
+	// strict TLS overrides an insecure configuration
+	_, _, err = NewClient((&NewClientInput{InsecureSkipVerify: true}).WithStrictTLS()).Get(nil, suite.server.URL)
+	suite.Require().Error(err)
+}
+
+func (suite *tlsTestSuite) TestInsecureSkipVerify() {
+	statusCode, _, err := NewClient(&NewClientInput{InsecureSkipVerify: true}).Get(nil, suite.server.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, statusCode)
+}
+
+func (suite *tlsTestSuite) TestCACert() {
+	caCertPath := filepath.Join(suite.tempDir, "ca.pem")
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: suite.server.Certificate().Raw})
+	suite.Require().NoError(ioutil.WriteFile(caCertPath, caCert, 0600))
+
+	tlsConfig, err := NewTLSConfig(&NewTLSConfigInput{CACertPath: caCertPath})
+	suite.Require().NoError(err)
+
+	statusCode, _, err := NewClient(&NewClientInput{TLSConfig: tlsConfig}).Get(nil, suite.server.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, statusCode)
+
+	_, err = NewTLSConfig(&NewTLSConfigInput{CACertPath: filepath.Join(suite.tempDir, "missing.pem")})
+	suite.Require().Error(err)
+}
+
+func TestTLSTestSuite(t *testing.T) {
+	suite.Run(t, new(tlsTestSuite))
+}
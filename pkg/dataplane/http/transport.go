@@ -0,0 +1,286 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"bytes"
+	goctx "context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// Transport sends requests to the server and reads their responses
+type Transport interface {
+
+	// Do sends the request and reads the response into response. ctx may be nil, and a non
+	// positive timeout means no timeout
+	Do(ctx goctx.Context, request *fasthttp.Request, response *fasthttp.Response, timeout time.Duration) error
+}
+
+// Timeouts bound the phases of a request. a non positive timeout means no timeout
+type Timeouts struct {
+	Connect        time.Duration // for establishing a connection, if one is made
+	ResponseHeader time.Duration // from writing the request until the response starts arriving
+	Total          time.Duration // for the whole request, including reading the body
+}
+
+// TimeoutsTransport is implemented by transports which can bound each phase of a request. other
+// transports are only given the total timeout
+type TimeoutsTransport interface {
+	DoWithTimeouts(ctx goctx.Context, request *fasthttp.Request, response *fasthttp.Response, timeouts *Timeouts) error
+}
+
+type fastHTTPTransport struct {
+	client *fasthttp.Client
+}
+
+// NewFastHTTPTransport creates a transport sending requests with a fasthttp client. this is the default
+func NewFastHTTPTransport(client *fasthttp.Client) Transport {
+	return &fastHTTPTransport{
+		client: client,
+	}
+}
+
+func (t *fastHTTPTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	// failures are retried by the context (see RetryPolicy)
+	if timeout <= 0 {
+		return t.client.Do(request, response)
+	}
+
+	return t.client.DoTimeout(request, response, timeout)
+}
+
+type NewNetHTTPTransportInput struct {
+	TLSConfig       *tls.Config // see NewTLSConfig. by default, the server's certificate is verified
+	DialTimeout     time.Duration
+	MaxConnsPerHost int
+
+	// skip verifying the server's certificate if TLSConfig isn't set. insecure - for testing only
+	InsecureSkipVerify bool
+
+	// connect through this proxy. if not set, the proxy is taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	ProxyURL *url.URL
+
+	// if set, used as is (the other fields are ignored)
+	Client *http.Client
+}
+
+type netHTTPTransport struct {
+	client *http.Client
+}
+
+// NewNetHTTPTransport creates a transport sending requests with a net/http client, which supports
+// HTTP/2 (over TLS) and cancellation through the request's context
+func NewNetHTTPTransport(newNetHTTPTransportInput *NewNetHTTPTransportInput) Transport {
+	client := newNetHTTPTransportInput.Client
+	if client == nil {
+		tlsConfig := getTLSConfig(newNetHTTPTransportInput.TLSConfig, newNetHTTPTransportInput.InsecureSkipVerify)
+
+		dialTimeout := newNetHTTPTransportInput.DialTimeout
+		if dialTimeout == 0 {
+			dialTimeout = fasthttp.DefaultDialTimeout
+		}
+
+		proxy := http.ProxyFromEnvironment
+		if newNetHTTPTransportInput.ProxyURL != nil {
+			proxy = http.ProxyURL(newNetHTTPTransportInput.ProxyURL)
+		}
+
+		client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:             proxy,
+				DialContext:       (&net.Dialer{Timeout: dialTimeout}).DialContext,
+				TLSClientConfig:   tlsConfig,
+				MaxConnsPerHost:   newNetHTTPTransportInput.MaxConnsPerHost,
+				ForceAttemptHTTP2: true,
+			},
+		}
+	}
+
+	return &netHTTPTransport{
+		client: client,
+	}
+}
+
+func (t *netHTTPTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	return t.DoWithTimeouts(ctx, request, response, &Timeouts{Total: timeout})
+}
+
+func (t *netHTTPTransport) DoWithTimeouts(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeouts *Timeouts) error {
+	if ctx == nil {
+		ctx = goctx.Background()
+	}
+
+	if timeouts.Total > 0 {
+		var cancel goctx.CancelFunc
+		ctx, cancel = goctx.WithTimeout(ctx, timeouts.Total)
+		defer cancel()
+	}
+
+	// the connect and response header phases are bounded by cancelling the request if they take too long
+	ctx, cancel := goctx.WithCancel(ctx)
+	defer cancel()
+
+	connectTimer := newPhaseTimer("connect", timeouts.Connect, cancel)
+	responseHeaderTimer := newPhaseTimer("response header", timeouts.ResponseHeader, cancel)
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart:         func(string, string) { connectTimer.start() },
+		ConnectDone:          func(string, string, error) { connectTimer.stop() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { responseHeaderTimer.start() },
+		GotFirstResponseByte: responseHeaderTimer.stop,
+	})
+
+	httpRequest, err := http.NewRequest(string(request.Header.Method()),
+		string(request.URI().FullURI()),
+		bytes.NewReader(request.Body()))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	httpRequest = httpRequest.WithContext(ctx)
+
+	request.Header.VisitAll(func(key []byte, value []byte) {
+		switch string(key) {
+
+		// set by net/http
+		case "Host", "Content-Length", "Connection":
+		default:
+			httpRequest.Header.Add(string(key), string(value))
+		}
+	})
+
+	httpResponse, err := t.client.Do(httpRequest)
+	if err != nil {
+		for _, timer := range []*phaseTimer{connectTimer, responseHeaderTimer} {
+			if timer.expired() {
+				return errors.Wrapf(v3ioerrors.ErrTimeout, "Timed out after %s waiting for %s", timer.timeout, timer.phase)
+			}
+		}
+
+		return err
+	}
+
+	connectTimer.stop()
+	responseHeaderTimer.stop()
+
+	defer httpResponse.Body.Close() // nolint: errcheck
+
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read response body")
+	}
+
+	response.Reset()
+	response.SetStatusCode(httpResponse.StatusCode)
+
+	for key, values := range httpResponse.Header {
+		switch key {
+		case "Content-Type":
+			response.Header.SetContentType(httpResponse.Header.Get(key))
+
+		// set along with the body
+		case "Content-Length":
+		default:
+			for _, value := range values {
+				response.Header.Add(key, value)
+			}
+		}
+	}
+
+	response.SetBody(body)
+
+	return nil
+}
+
+// cancels a request if a phase of it takes longer than the timeout. the phase is only timed the first
+// time it starts
+type phaseTimer struct {
+	phase     string
+	timeout   time.Duration
+	cancel    func()
+	lock      sync.Mutex
+	timer     *time.Timer
+	started   bool
+	isExpired bool
+}
+
+func newPhaseTimer(phase string, timeout time.Duration, cancel func()) *phaseTimer {
+	return &phaseTimer{
+		phase:   phase,
+		timeout: timeout,
+		cancel:  cancel,
+	}
+}
+
+func (pt *phaseTimer) start() {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	if pt.timeout <= 0 || pt.started {
+		return
+	}
+
+	pt.started = true
+	pt.timer = time.AfterFunc(pt.timeout, func() {
+		pt.lock.Lock()
+		pt.isExpired = true
+		pt.lock.Unlock()
+
+		pt.cancel()
+	})
+}
+
+func (pt *phaseTimer) stop() {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	if pt.timer != nil {
+		pt.timer.Stop()
+	}
+}
+
+func (pt *phaseTimer) expired() bool {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	return pt.isExpired
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+type netHTTPTransportSuite struct {
+	suite.Suite
+	server    *httptest.Server
+	transport Transport
+}
+
+func (suite *netHTTPTransportSuite) SetupTest() {
+	suite.server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		// the headers arrive immediately, the body doesn't
+		if request.URL.Path == "/slowbody" {
+			writer.WriteHeader(http.StatusOK)
+			writer.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+			writer.Write([]byte("body")) // nolint: errcheck
+			return
+		}
+
+		body, _ := ioutil.ReadAll(request.Body)
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("X-Function", request.Header.Get("X-v3io-function"))
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write(append([]byte(request.Method+":"), body...)) // nolint: errcheck
+	}))
+
+	suite.transport = NewNetHTTPTransport(&NewNetHTTPTransportInput{})
+}
+
+func (suite *netHTTPTransportSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *netHTTPTransportSuite) TestDo() {
+	request := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(request)
+
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI(suite.server.URL + "/bigdata/a")
+	request.Header.SetMethod(http.MethodPut)
+	request.Header.Set("X-v3io-function", "PutItem")
+	request.SetBody([]byte("body"))
+
+	err := suite.transport.Do(nil, request, response, 0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusCreated, response.StatusCode())
+	suite.Require().Equal("application/json", string(response.Header.ContentType()))
+	suite.Require().Equal("PutItem", string(response.Header.Peek("X-Function")))
+	suite.Require().Equal("PUT:body", string(response.Body()))
+}
+
+func (suite *netHTTPTransportSuite) TestTimeout() {
+	request := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(request)
+
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI(suite.server.URL + "/slow")
+
+	err := suite.transport.Do(goctx.Background(), request, response, 10*time.Millisecond)
+	suite.Require().Error(err)
+}
+
+func (suite *netHTTPTransportSuite) TestResponseHeaderTimeout() {
+	request := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(request)
+
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(response)
+
+	timeoutsTransport := suite.transport.(TimeoutsTransport)
+
+	request.SetRequestURI(suite.server.URL + "/slow")
+	err := timeoutsTransport.DoWithTimeouts(nil, request, response, &Timeouts{ResponseHeader: 50 * time.Millisecond})
+	suite.Require().Equal(v3ioerrors.ErrTimeout, errors.Cause(err))
+
+	// a slow body isn't bound by the response header timeout
+	request.SetRequestURI(suite.server.URL + "/slowbody")
+	err = timeoutsTransport.DoWithTimeouts(nil, request, response, &Timeouts{ResponseHeader: 50 * time.Millisecond})
+	suite.Require().NoError(err)
+	suite.Require().Equal("body", string(response.Body()))
+}
+
+type unsupportedTimeoutsSuite struct {
+	suite.Suite
+}
+
+func (suite *unsupportedTimeoutsSuite) TestRequestTimeouts() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	// fixedBodyTransport can't time the phases of a request
+	context, err := NewContext(logger, &NewContextInput{Transport: &fixedBodyTransport{}})
+	suite.Require().NoError(err)
+
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	getObjectInput := v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{
+			URL:                   "http://webapi:8081",
+			ContainerName:         "bigdata",
+			ResponseHeaderTimeout: time.Second,
+		},
+		Path: "a",
+	}
+
+	_, err = context.GetObjectSync(&getObjectInput)
+	suite.Require().Equal("ResponseHeaderTimeout", err.(v3ioerrors.ErrorWithField).Field())
+
+	getObjectInput.ResponseHeaderTimeout = 0
+	getObjectInput.Timeout = time.Second
+
+	response, err := context.GetObjectSync(&getObjectInput)
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func (suite *unsupportedTimeoutsSuite) TestDefaultTimeouts() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	_, err = NewContext(logger, &NewContextInput{
+		Transport:       &fixedBodyTransport{},
+		DefaultTimeouts: Timeouts{Connect: time.Second},
+	})
+	suite.Require().Equal("DefaultTimeouts.Connect", err.(v3ioerrors.ErrorWithField).Field())
+
+	// the net/http transport supports them
+	context, err := NewContext(logger, &NewContextInput{
+		Transport:       NewNetHTTPTransport(&NewNetHTTPTransportInput{}),
+		DefaultTimeouts: Timeouts{Connect: time.Second, ResponseHeader: time.Second},
+	})
+	suite.Require().NoError(err)
+	v3io.CloseContext(context) // nolint: errcheck
+}
+
+func TestNetHTTPTransportSuite(t *testing.T) {
+	suite.Run(t, new(netHTTPTransportSuite))
+}
+
+func TestUnsupportedTimeoutsSuite(t *testing.T) {
+	suite.Run(t, new(unsupportedTimeoutsSuite))
+}
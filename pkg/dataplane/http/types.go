@@ -19,11 +19,151 @@ such restriction.
 */
 package v3iohttp
 
-import "github.com/valyala/fasthttp"
+import (
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
 
 type NewContextInput struct {
+	// the client to send requests with, ignored if Transport is set. the client isn't modified, so its
+	// connections aren't tracked - ContextStats.NumOpenConnsByHost and NumConnsClosedOnError are only
+	// reported for the client the context creates when this isn't set
 	HTTPClient     *fasthttp.Client
+	Transport      Transport // defaults to a fasthttp transport (see NewNetHTTPTransport for an alternative)
 	NumWorkers     int
 	RequestChanLen int
 	MaxConns       int
+
+	// skip verifying the server's certificate when creating the default client. insecure - for testing only
+	InsecureSkipVerify bool
+
+	// if larger than NumWorkers, workers are added while requests are queued, up to MaxWorkers. workers
+	// above NumWorkers exit after being idle for WorkerIdleTimeout (defaults to 30 seconds)
+	MaxWorkers        int
+	WorkerIdleTimeout time.Duration
+
+	// if set, GetItems requests are served by a dedicated pool of workers so that long scans
+	// don't delay cheap point operations. MaxScanWorkers behaves like MaxWorkers
+	NumScanWorkers int
+	MaxScanWorkers int
+
+	// if set, idempotent reads (GetItem, GetObject) are hedged
+	HedgingPolicy *HedgingPolicy
+
+	// the User-Agent of all requests (defaults to v3io-go/<version>, see DefaultUserAgent)
+	UserAgent string
+
+	// headers added to every request
+	Headers map[string]string
+
+	// if set, requests are logged
+	RequestLogPolicy *RequestLogPolicy
+
+	// cluster metadata (GetClusterMD) is cached per cluster and fetched again once older than this
+	// (defaults to a minute). if negative, it isn't cached
+	ClusterMDRefreshInterval time.Duration
+
+	// if set, called synchronously after every request which modifies data, whether it succeeded or not
+	AuditHandler func(*AuditEntry)
+
+	// the timeouts of requests which don't set their own (see DataPlaneInput.Timeout, ConnectTimeout
+	// and ResponseHeaderTimeout). the default transport only supports the total timeout per request -
+	// its connect timeout is NewClientInput.DialTimeout, and NewContext fails if Connect or ResponseHeader
+	// are set along with a transport which doesn't implement TimeoutsTransport
+	DefaultTimeouts Timeouts
+
+	// requests which failed before a response was received are retried per this policy (see RetryPolicy
+	// for the defaults)
+	RetryPolicy *RetryPolicy
+
+	// if set, connections are established when the context is created (blocking until they are), and
+	// optionally probed while idle
+	ConnectionWarmupPolicy *ConnectionWarmupPolicy
+
+	// if set, bounds the memory held by responses which weren't released yet
+	ResponseMemoryPolicy *ResponseMemoryPolicy
+
+	// if set, responses which aren't released in time are reported. enabled with the defaults in debug
+	// builds (see v3io.DebugBuild)
+	LeakDetectionPolicy *LeakDetectionPolicy
+}
+
+// LeakDetectionPolicy configures reporting responses which weren't released. the stack of the goroutine
+// which sent the request is captured per response, so this is meant for debugging
+type LeakDetectionPolicy struct {
+	MaxAge        time.Duration // responses not released after this long are reported once (defaults to a minute)
+	CheckInterval time.Duration // defaults to MaxAge
+
+	// called per leaked response. defaults to logging a warning
+	OnLeak func(*LeakedResponse)
+}
+
+// LeakedResponse describes a response which wasn't released in time
+type LeakedResponse struct {
+	RequestID   string
+	AllocatedAt time.Time
+	Stack       string // of the goroutine which sent the request
+}
+
+// ResponseMemoryPolicy configures a budget for the bodies of unreleased responses. since the size of a
+// response is only known once it's read, requests are admitted while the budget isn't exhausted, and
+// the budget may be exceeded by the responses in flight
+type ResponseMemoryPolicy struct {
+	MaxBytes int64
+
+	// fail requests with ErrLimitExceeded while the budget is exhausted rather than waiting for
+	// responses to be released (bounded by the request's context and timeout)
+	FailFast bool
+}
+
+// AuditEntry describes a request which modifies data (e.g. PutItem, PutObject, DeleteObject, PutRecords)
+type AuditEntry struct {
+	Time       time.Time // when the request was sent
+	Principal  string    // see getPrincipal
+	Container  string
+	Path       string
+	Operation  string // e.g. PutItem, or PutObject for writes of objects
+	RequestID  string // see DataPlaneInput.RequestID
+	StatusCode int    // zero if no response was received
+	Err        error
+}
+
+// RequestLogPolicy configures logging a structured entry per request. credentials are never logged
+type RequestLogPolicy struct {
+
+	// the fraction of successful requests which are logged, between 0 and 1 (defaults to 1). failed
+	// requests are always logged
+	SampleRate float64
+
+	// if set, called with the entries instead of logging them at debug level with the context's logger
+	Handler func(*RequestLogEntry)
+}
+
+type RequestLogEntry struct {
+	Method       string
+	Path         string
+	Function     string            // the v3io function (X-v3io-function), if any
+	RequestID    string            // see DataPlaneInput.RequestID
+	Headers      map[string]string // the request's headers, with credentials sanitized
+	StatusCode   int               // zero if no response was received
+	Latency      time.Duration
+	RequestSize  int
+	ResponseSize int
+	Err          error
+}
+
+// HedgingPolicy configures hedged reads - if a read takes longer than the hedging delay, a second
+// identical request is sent and the first response to arrive is used. the other request is cancelled
+// if the transport supports it (the net/http transport does), and otherwise completes and is released
+type HedgingPolicy struct {
+
+	// hedge after a fixed delay. if zero, the delay is derived from the latency of recent reads
+	Delay time.Duration
+
+	// the latency percentile of recent reads after which a request is hedged (defaults to 0.95)
+	Percentile float64
+
+	// the delay used until enough latency samples have been collected (defaults to 100ms)
+	InitialDelay time.Duration
 }
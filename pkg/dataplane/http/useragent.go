@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const modulePath = "github.com/v3io/v3io-go"
+
+var (
+	defaultUserAgent     string
+	defaultUserAgentOnce sync.Once
+)
+
+// DefaultUserAgent returns the User-Agent sent by contexts by default - v3io-go/<version>, where the version
+// is that of the v3io-go module the binary was built with ("unknown" if it isn't available)
+func DefaultUserAgent() string {
+	defaultUserAgentOnce.Do(func() {
+		defaultUserAgent = "v3io-go/" + getModuleVersion()
+	})
+
+	return defaultUserAgent
+}
+
+func getModuleVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	modules := append([]*debug.Module{&buildInfo.Main}, buildInfo.Deps...)
+	for _, module := range modules {
+		if module.Path != modulePath {
+			continue
+		}
+
+		// replaced modules report the version of the replacement
+		if module.Replace != nil {
+			module = module.Replace
+		}
+
+		if module.Version != "" && module.Version != "(devel)" {
+			return module.Version
+		}
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iohttp
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/logger"
+)
+
+// a pool of workers reading requests from a priority queue. the pool starts with minWorkers workers and,
+// if maxWorkers is larger, adds workers while requests are queued. workers above the minimum
+// exit after being idle for idleTimeout
+type workerPool struct {
+	logger       logger.Logger
+	context      *context
+	requestQueue *requestQueue
+	stopChan     chan struct{}
+	minWorkers   int
+	maxWorkers   int
+	idleTimeout  time.Duration
+
+	// held for reading while submitting, so that stop can't drain the queue before a submitted
+	// request is pushed
+	stopLock sync.RWMutex
+	stopped  bool
+
+	// accessed atomically
+	numWorkers      int64
+	nextWorkerIndex int64
+}
+
+func newWorkerPool(parentLogger logger.Logger,
+	context *context,
+	name string,
+	requestChanLen int,
+	minWorkers int,
+	maxWorkers int,
+	idleTimeout time.Duration) *workerPool {
+
+	if maxWorkers < minWorkers {
+		maxWorkers = minWorkers
+	}
+
+	if idleTimeout == 0 {
+		idleTimeout = 30 * time.Second
+	}
+
+	return &workerPool{
+		logger:       parentLogger.GetChild(name),
+		context:      context,
+		requestQueue: newRequestQueue(requestChanLen),
+		stopChan:     make(chan struct{}),
+		minWorkers:   minWorkers,
+		maxWorkers:   maxWorkers,
+		idleTimeout:  idleTimeout,
+	}
+}
+
+func (wp *workerPool) start() {
+	for workerIndex := 0; workerIndex < wp.minWorkers; workerIndex++ {
+		wp.startWorker(false)
+	}
+}
+
+// stop signals the workers to exit and fails the requests still waiting for a worker. requests submitted
+// afterwards are rejected with ErrStopped
+func (wp *workerPool) stop() {
+	wp.stopLock.Lock()
+	wp.stopped = true
+	wp.stopLock.Unlock()
+
+	close(wp.stopChan)
+
+	for {
+		select {
+		case <-wp.requestQueue.items:
+			wp.context.respond(wp.requestQueue.pop(), nil, v3ioerrors.ErrStopped)
+		default:
+			return
+		}
+	}
+}
+
+func (wp *workerPool) submit(request *v3io.Request) error {
+
+	// workers keep draining the queue until stop takes the lock, so a push blocked on a full queue
+	// can't hold stop off indefinitely
+	wp.stopLock.RLock()
+	defer wp.stopLock.RUnlock()
+
+	if wp.stopped {
+		return v3ioerrors.ErrStopped
+	}
+
+	wp.requestQueue.push(request)
+
+	// requests are queued, meaning all workers are busy - add a worker if allowed
+	if wp.maxWorkers > wp.minWorkers && wp.requestQueue.len() > 0 {
+		wp.scaleUp()
+	}
+
+	return nil
+}
+
+func (wp *workerPool) scaleUp() {
+	for {
+		numWorkers := atomic.LoadInt64(&wp.numWorkers)
+		if numWorkers >= int64(wp.maxWorkers) {
+			return
+		}
+
+		// reserve the slot before starting the worker so concurrent submits don't exceed the maximum
+		if atomic.CompareAndSwapInt64(&wp.numWorkers, numWorkers, numWorkers+1) {
+			go wp.workerEntry(int(atomic.AddInt64(&wp.nextWorkerIndex, 1)-1), true)
+			return
+		}
+	}
+}
+
+func (wp *workerPool) startWorker(elastic bool) {
+	atomic.AddInt64(&wp.numWorkers, 1)
+	go wp.workerEntry(int(atomic.AddInt64(&wp.nextWorkerIndex, 1)-1), elastic)
+}
+
+func (wp *workerPool) getNumWorkers() int {
+	return int(atomic.LoadInt64(&wp.numWorkers))
+}
+
+func (wp *workerPool) workerEntry(workerIndex int, elastic bool) {
+
+	// workers of the minimal set only exit when the pool is stopped
+	if !elastic {
+		for {
+			select {
+			case <-wp.requestQueue.items:
+				wp.context.handleRequest(wp.requestQueue.pop())
+			case <-wp.stopChan:
+				atomic.AddInt64(&wp.numWorkers, -1)
+				return
+			}
+		}
+	}
+
+	idleTimer := time.NewTimer(wp.idleTimeout)
+	defer idleTimer.Stop()
+
+	for {
+		select {
+		case <-wp.requestQueue.items:
+			wp.context.handleRequest(wp.requestQueue.pop())
+
+			// restart the idle period
+			if !idleTimer.Stop() {
+				<-idleTimer.C
+			}
+			idleTimer.Reset(wp.idleTimeout)
+
+		case <-idleTimer.C:
+			atomic.AddInt64(&wp.numWorkers, -1)
+			wp.logger.DebugWith("Idle worker exiting", "workerIndex", workerIndex)
+			return
+
+		case <-wp.stopChan:
+			atomic.AddInt64(&wp.numWorkers, -1)
+			return
+		}
+	}
+}
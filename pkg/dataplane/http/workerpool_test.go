@@ -0,0 +1,293 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iohttp
+
+import (
+	goctx "context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// blocks the requests of the given function until unblocked, to keep workers busy
+type blockingTransport struct {
+	functionName string
+	unblockChan  chan struct{}
+
+	// accessed atomically
+	numBlocked int64
+}
+
+func newBlockingTransport(functionName string) *blockingTransport {
+	return &blockingTransport{
+		functionName: functionName,
+		unblockChan:  make(chan struct{}),
+	}
+}
+
+func (bt *blockingTransport) Do(ctx goctx.Context,
+	request *fasthttp.Request,
+	response *fasthttp.Response,
+	timeout time.Duration) error {
+	functionName := string(request.Header.Peek("X-v3io-function"))
+
+	if functionName == bt.functionName {
+		atomic.AddInt64(&bt.numBlocked, 1)
+		<-bt.unblockChan
+		atomic.AddInt64(&bt.numBlocked, -1)
+	}
+
+	response.SetStatusCode(fasthttp.StatusOK)
+
+	if functionName == getItemsFunctionName {
+		response.Header.SetContentType("application/json")
+		response.SetBodyString(`{"Items": [], "LastItemIncluded": "TRUE"}`)
+	}
+
+	return nil
+}
+
+func (bt *blockingTransport) getNumBlocked() int {
+	return int(atomic.LoadInt64(&bt.numBlocked))
+}
+
+func (bt *blockingTransport) unblock() {
+	close(bt.unblockChan)
+}
+
+type workerPoolSuite struct {
+	suite.Suite
+	getObjectInput v3io.GetObjectInput
+}
+
+func (suite *workerPoolSuite) SetupTest() {
+	suite.getObjectInput = v3io.GetObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{URL: "http://webapi:8081", ContainerName: "bigdata"},
+		Path:           "a",
+	}
+}
+
+func (suite *workerPoolSuite) TestCloseWhileSubmitting() {
+	context := suite.createContext(&NewContextInput{
+		Transport:      &fixedBodyTransport{},
+		NumWorkers:     2,
+		RequestChanLen: 4,
+	})
+
+	numSubmitters := 8
+	numRequestsPerSubmitter := 100
+	responseChan := make(chan *v3io.Response, numSubmitters*numRequestsPerSubmitter)
+
+	var numSubmitted int64
+	var waitGroup sync.WaitGroup
+
+	for submitterIdx := 0; submitterIdx < numSubmitters; submitterIdx++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for requestIdx := 0; requestIdx < numRequestsPerSubmitter; requestIdx++ {
+				if _, err := context.GetObject(&suite.getObjectInput, nil, responseChan); err != nil {
+					suite.Equal(v3ioerrors.ErrStopped, err)
+					return
+				}
+
+				atomic.AddInt64(&numSubmitted, 1)
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	suite.Require().NoError(v3io.CloseContext(context))
+	waitGroup.Wait()
+
+	// every request which was accepted must be responded to, either by a worker or by the close
+	for responseIdx := int64(0); responseIdx < atomic.LoadInt64(&numSubmitted); responseIdx++ {
+		select {
+		case response := <-responseChan:
+			response.Release()
+		case <-time.After(5 * time.Second):
+			suite.FailNow("Accepted request wasn't responded to", "%d/%d responses", responseIdx, numSubmitted)
+		}
+	}
+
+	// requests submitted after the close are rejected rather than blocking
+	_, err := context.GetObject(&suite.getObjectInput, nil, responseChan)
+	suite.Require().Equal(v3ioerrors.ErrStopped, err)
+}
+
+func (suite *workerPoolSuite) TestScaleUpUnderLoad() {
+	transport := newBlockingTransport(getItemsFunctionName)
+	context := suite.createContext(&NewContextInput{
+		Transport:  transport,
+		NumWorkers: 1,
+		MaxWorkers: 4,
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	suite.Require().Equal(1, suite.getNumWorkers(context))
+
+	numRequests := 8
+	responseChan := make(chan *v3io.Response, numRequests)
+
+	for requestIdx := 0; requestIdx < numRequests; requestIdx++ {
+		_, err := context.GetItems(suite.getGetItemsInput(), nil, responseChan)
+		suite.Require().NoError(err)
+	}
+
+	// workers are added while requests are queued, up to the maximum
+	suite.waitFor(func() bool {
+		return transport.getNumBlocked() == 4
+	})
+
+	suite.Require().Equal(4, suite.getNumWorkers(context))
+
+	transport.unblock()
+
+	for requestIdx := 0; requestIdx < numRequests; requestIdx++ {
+		response := <-responseChan
+		suite.Require().NoError(response.Error)
+		response.Release()
+	}
+}
+
+func (suite *workerPoolSuite) TestScaleDownOnIdle() {
+	transport := newBlockingTransport(getItemsFunctionName)
+	context := suite.createContext(&NewContextInput{
+		Transport:         transport,
+		NumWorkers:        1,
+		MaxWorkers:        4,
+		WorkerIdleTimeout: 20 * time.Millisecond,
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	responseChan := make(chan *v3io.Response, 8)
+
+	for requestIdx := 0; requestIdx < 8; requestIdx++ {
+		_, err := context.GetItems(suite.getGetItemsInput(), nil, responseChan)
+		suite.Require().NoError(err)
+	}
+
+	suite.waitFor(func() bool {
+		return suite.getNumWorkers(context) == 4
+	})
+
+	transport.unblock()
+
+	for requestIdx := 0; requestIdx < 8; requestIdx++ {
+		(<-responseChan).Release()
+	}
+
+	// the added workers exit once idle, leaving the minimum
+	suite.waitFor(func() bool {
+		return suite.getNumWorkers(context) == 1
+	})
+
+	// and the pool still serves requests
+	_, err := context.GetObject(&suite.getObjectInput, nil, responseChan)
+	suite.Require().NoError(err)
+	(<-responseChan).Release()
+}
+
+func (suite *workerPoolSuite) TestScanPoolSplit() {
+	transport := newBlockingTransport(getItemsFunctionName)
+	context := suite.createContext(&NewContextInput{
+		Transport:      transport,
+		NumWorkers:     1,
+		NumScanWorkers: 1,
+	})
+	defer v3io.CloseContext(context) // nolint: errcheck
+
+	scanResponseChan := make(chan *v3io.Response, 2)
+
+	// occupy the scan worker and queue another scan behind it
+	for requestIdx := 0; requestIdx < 2; requestIdx++ {
+		_, err := context.GetItems(suite.getGetItemsInput(), nil, scanResponseChan)
+		suite.Require().NoError(err)
+	}
+
+	suite.waitFor(func() bool {
+		return transport.getNumBlocked() == 1
+	})
+
+	// point operations are served by their own workers, so they don't wait for the scans
+	responseChan := make(chan *v3io.Response, 1)
+	_, err := context.GetObject(&suite.getObjectInput, nil, responseChan)
+	suite.Require().NoError(err)
+
+	select {
+	case response := <-responseChan:
+		suite.Require().NoError(response.Error)
+		response.Release()
+	case <-time.After(5 * time.Second):
+		suite.FailNow("Point operation waited for scans")
+	}
+
+	suite.Require().Equal(1, transport.getNumBlocked())
+	suite.Require().Equal(1, context.(v3io.StatsContext).Stats().NumPendingRequests)
+
+	transport.unblock()
+
+	for requestIdx := 0; requestIdx < 2; requestIdx++ {
+		(<-scanResponseChan).Release()
+	}
+}
+
+func (suite *workerPoolSuite) getGetItemsInput() *v3io.GetItemsInput {
+	return &v3io.GetItemsInput{
+		DataPlaneInput: suite.getObjectInput.DataPlaneInput,
+		Path:           "table/",
+	}
+}
+
+func (suite *workerPoolSuite) getNumWorkers(context v3io.Context) int {
+	return context.(v3io.StatsContext).Stats().NumWorkers
+}
+
+func (suite *workerPoolSuite) waitFor(condition func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !condition(); {
+		suite.Require().True(time.Now().Before(deadline), "Timed out waiting for condition")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (suite *workerPoolSuite) createContext(newContextInput *NewContextInput) v3io.Context {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	context, err := NewContext(logger, newContextInput)
+	suite.Require().NoError(err)
+
+	return context
+}
+
+func TestWorkerPoolSuite(t *testing.T) {
+	suite.Run(t, new(workerPoolSuite))
+}
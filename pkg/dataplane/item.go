@@ -25,8 +25,16 @@ import (
 	"github.com/v3io/v3io-go/pkg/errors"
 )
 
+// the attribute holding the name of an item
+const ItemNameAttribute = "__name"
+
 type Item map[string]interface{}
 
+// GetName returns the name of the item, if it was returned (see GetItemsInput.ReturnItemName)
+func (i Item) GetName() (string, error) {
+	return i.GetFieldString(ItemNameAttribute)
+}
+
 func (i Item) GetField(name string) interface{} {
 	return i[name]
 }
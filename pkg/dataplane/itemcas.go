@@ -0,0 +1,173 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type UpdateItemCASInput struct {
+	DataPlaneInput
+	Path string
+
+	// the attributes read and passed to Mutate (defaults to all user attributes)
+	AttributeNames []string
+
+	// returns the attributes to update given the current item, which is nil if the item doesn't exist.
+	// may be called several times, once per attempt. returning no attributes ends without updating
+	Mutate func(item Item) (map[string]interface{}, error)
+
+	// the number of times the update is retried if the item was modified since it was read (defaults to 10)
+	MaxRetries int
+
+	// the wait between retries (defaults to an exponential backoff from 10ms to 1s)
+	Backoff *common.Backoff
+}
+
+type UpdateItemCASOutput struct {
+	Updated      bool
+	MtimeSecs    int
+	MtimeNSecs   int
+	NumConflicts int // the number of attempts which failed since the item was modified concurrently
+}
+
+// UpdateItemCAS updates an item with optimistic concurrency - the item is read, the update is derived from
+// it by Mutate, and written on the condition that the item's mtime didn't change since it was read. if it
+// did, the cycle is retried with backoff. returns v3ioerrors.ErrConflict if all attempts conflicted
+func UpdateItemCAS(container Container, updateItemCASInput *UpdateItemCASInput) (*UpdateItemCASOutput, error) {
+	if updateItemCASInput.Mutate == nil {
+		return nil, errors.New("Mutate must be set")
+	}
+
+	maxRetries := updateItemCASInput.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 10
+	}
+
+	backoff := &common.Backoff{
+		Min:    10 * time.Millisecond,
+		Max:    time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	if updateItemCASInput.Backoff != nil {
+		backoff = updateItemCASInput.Backoff.Copy()
+	}
+
+	updateItemCASOutput := UpdateItemCASOutput{}
+
+	for attemptIdx := 0; ; attemptIdx++ {
+		item, condition, err := getItemForCAS(container, updateItemCASInput)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get item %s", updateItemCASInput.Path)
+		}
+
+		attributes, err := updateItemCASInput.Mutate(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to mutate item")
+		}
+
+		if len(attributes) == 0 {
+			return &updateItemCASOutput, nil
+		}
+
+		response, err := container.UpdateItemSync(&UpdateItemInput{
+			DataPlaneInput: updateItemCASInput.DataPlaneInput,
+			Path:           updateItemCASInput.Path,
+			Attributes:     attributes,
+			Condition:      condition,
+		})
+		if err == nil {
+			updateItemOutput := response.Output.(*UpdateItemOutput)
+			updateItemCASOutput.Updated = true
+			updateItemCASOutput.MtimeSecs = updateItemOutput.MtimeSecs
+			updateItemCASOutput.MtimeNSecs = updateItemOutput.MtimeNSecs
+			response.Release()
+
+			return &updateItemCASOutput, nil
+		}
+
+		if statusCode, _ := v3ioerrors.GetStatusCode(err); statusCode != http.StatusPreconditionFailed {
+			return nil, errors.Wrapf(err, "Failed to update item %s", updateItemCASInput.Path)
+		}
+
+		updateItemCASOutput.NumConflicts++
+
+		if attemptIdx == maxRetries {
+			return nil, errors.Wrapf(v3ioerrors.ErrConflict,
+				"Item %s was modified concurrently on all %d attempts",
+				updateItemCASInput.Path,
+				attemptIdx+1)
+		}
+
+		if err := sleepWithContext(updateItemCASInput.Ctx, backoff.Duration()); err != nil {
+			return nil, errors.Wrap(err, "Context done while waiting to retry update")
+		}
+	}
+}
+
+// returns the item (nil if it doesn't exist) and the condition under which it wasn't modified since
+func getItemForCAS(container Container, updateItemCASInput *UpdateItemCASInput) (Item, string, error) {
+	attributeNames := updateItemCASInput.AttributeNames
+	if len(attributeNames) == 0 {
+		attributeNames = []string{"*"}
+	}
+
+	response, err := container.GetItemSync(&GetItemInput{
+		DataPlaneInput: updateItemCASInput.DataPlaneInput,
+		Path:           updateItemCASInput.Path,
+		AttributeNames: append([]string{"__mtime_secs", "__mtime_nsecs"}, attributeNames...),
+	})
+	if err != nil {
+		if statusCode, _ := v3ioerrors.GetStatusCode(err); statusCode == http.StatusNotFound {
+			return nil, "not(exists(__name))", nil
+		}
+
+		return nil, "", err
+	}
+
+	defer response.Release()
+
+	item := response.Output.(*GetItemOutput).Item
+
+	mtimeSecs, err := item.GetFieldInt("__mtime_secs")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to get mtime")
+	}
+
+	mtimeNSecs, err := item.GetFieldInt("__mtime_nsecs")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to get mtime")
+	}
+
+	delete(item, "__mtime_secs")
+	delete(item, "__mtime_nsecs")
+
+	return item, fmt.Sprintf("(__mtime_secs == %d) AND (__mtime_nsecs == %d)", mtimeSecs, mtimeNSecs), nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// a single item, whose conditional updates succeed if the condition names its current mtime
+type fakeCASContainer struct {
+	Container
+	attributes map[string]interface{}
+	mtimeSecs  int
+
+	// called before each update, to simulate concurrent writers
+	beforeUpdate func()
+}
+
+func (fcc *fakeCASContainer) GetItemSync(getItemInput *GetItemInput) (*Response, error) {
+	if fcc.attributes == nil {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.New("Not found"), http.StatusNotFound)
+	}
+
+	item := Item{"__mtime_secs": fcc.mtimeSecs, "__mtime_nsecs": 0}
+	for attributeName, attributeValue := range fcc.attributes {
+		item[attributeName] = attributeValue
+	}
+
+	return &Response{Output: &GetItemOutput{Item: item}}, nil
+}
+
+func (fcc *fakeCASContainer) UpdateItemSync(updateItemInput *UpdateItemInput) (*Response, error) {
+	if fcc.beforeUpdate != nil {
+		fcc.beforeUpdate()
+	}
+
+	expectedCondition := "not(exists(__name))"
+	if fcc.attributes != nil {
+		expectedCondition = fmt.Sprintf("(__mtime_secs == %d) AND (__mtime_nsecs == 0)", fcc.mtimeSecs)
+	}
+
+	if updateItemInput.Condition != expectedCondition {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.New("Precondition failed"), http.StatusPreconditionFailed)
+	}
+
+	fcc.write(updateItemInput.Attributes)
+
+	return &Response{Output: &UpdateItemOutput{MtimeSecs: fcc.mtimeSecs}}, nil
+}
+
+func (fcc *fakeCASContainer) write(attributes map[string]interface{}) {
+	if fcc.attributes == nil {
+		fcc.attributes = map[string]interface{}{}
+	}
+
+	for attributeName, attributeValue := range attributes {
+		fcc.attributes[attributeName] = attributeValue
+	}
+
+	fcc.mtimeSecs++
+}
+
+type itemCASSuite struct {
+	suite.Suite
+	container *fakeCASContainer
+}
+
+func (suite *itemCASSuite) SetupTest() {
+	suite.container = &fakeCASContainer{}
+}
+
+func (suite *itemCASSuite) TestRetryOnConflict() {
+	suite.container.write(map[string]interface{}{"counter": 1})
+
+	// another writer increments the counter before the first update
+	numConcurrentWrites := 0
+	suite.container.beforeUpdate = func() {
+		if numConcurrentWrites == 0 {
+			numConcurrentWrites++
+			suite.container.write(map[string]interface{}{"counter": suite.container.attributes["counter"].(int) + 1})
+		}
+	}
+
+	updateItemCASOutput, err := UpdateItemCAS(suite.container, suite.getIncrementInput(3))
+	suite.Require().NoError(err)
+	suite.Require().True(updateItemCASOutput.Updated)
+	suite.Require().Equal(1, updateItemCASOutput.NumConflicts)
+	suite.Require().Equal(3, updateItemCASOutput.MtimeSecs)
+	suite.Require().Equal(3, suite.container.attributes["counter"])
+}
+
+func (suite *itemCASSuite) TestCreate() {
+	updateItemCASOutput, err := UpdateItemCAS(suite.container, suite.getIncrementInput(3))
+	suite.Require().NoError(err)
+	suite.Require().True(updateItemCASOutput.Updated)
+	suite.Require().Equal(1, suite.container.attributes["counter"])
+}
+
+func (suite *itemCASSuite) TestConflictOnAllAttempts() {
+	suite.container.write(map[string]interface{}{"counter": 1})
+	suite.container.beforeUpdate = func() {
+		suite.container.write(nil)
+	}
+
+	_, err := UpdateItemCAS(suite.container, suite.getIncrementInput(2))
+	suite.Require().Equal(v3ioerrors.ErrConflict, errors.Cause(err))
+}
+
+func (suite *itemCASSuite) getIncrementInput(maxRetries int) *UpdateItemCASInput {
+	return &UpdateItemCASInput{
+		Path: "counters/a",
+		Mutate: func(item Item) (map[string]interface{}, error) {
+			if item == nil {
+				return map[string]interface{}{"counter": 1}, nil
+			}
+
+			counter, err := item.GetFieldInt("counter")
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]interface{}{"counter": counter + 1}, nil
+		},
+		MaxRetries: maxRetries,
+		Backoff:    &common.Backoff{Min: time.Millisecond, Max: time.Millisecond},
+	}
+}
+
+func TestItemCASSuite(t *testing.T) {
+	suite.Run(t, new(itemCASSuite))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"strings"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// the names of items in tables with a sort key are <sharding key>.<sort key>. the sharding key is the
+// part left of the first period, so only the sort key may contain periods
+const itemKeySeparator = "."
+
+// the longest item name the platform accepts, in bytes
+const maxItemNameLength = 255
+
+// ItemKey is the key of an item - its sharding key and, for tables with a sort key, its sort key
+type ItemKey struct {
+	ShardingKey string
+	SortKey     string
+}
+
+// NewItemKey returns the key of the given sharding key and sort key (which may be empty), failing with
+// ErrInvalidInput if they can't make up an item name
+func NewItemKey(shardingKey string, sortKey string) (ItemKey, error) {
+	if strings.Contains(shardingKey, itemKeySeparator) {
+		return ItemKey{}, v3ioerrors.NewErrorWithField("ShardingKey", "must not contain periods")
+	}
+
+	itemKey := ItemKey{ShardingKey: shardingKey, SortKey: sortKey}
+	if err := ValidateItemName(itemKey.String()); err != nil {
+		return ItemKey{}, err
+	}
+
+	return itemKey, nil
+}
+
+// ParseItemKey splits an item name into its sharding key and sort key
+func ParseItemKey(itemName string) (ItemKey, error) {
+	if err := ValidateItemName(itemName); err != nil {
+		return ItemKey{}, err
+	}
+
+	separatorIndex := strings.Index(itemName, itemKeySeparator)
+	if separatorIndex == -1 {
+		return ItemKey{ShardingKey: itemName}, nil
+	}
+
+	return ItemKey{
+		ShardingKey: itemName[:separatorIndex],
+		SortKey:     itemName[separatorIndex+len(itemKeySeparator):],
+	}, nil
+}
+
+// KeyOf returns the key of an item, which must have been read along with its name (see
+// GetItemsInput.ReturnItemName)
+func KeyOf(item Item) (ItemKey, error) {
+	itemName, err := item.GetName()
+	if err != nil {
+		return ItemKey{}, errors.Wrap(err, "Failed to get item name")
+	}
+
+	return ParseItemKey(itemName)
+}
+
+// String returns the item name of the key
+func (ik ItemKey) String() string {
+	if ik.SortKey == "" {
+		return ik.ShardingKey
+	}
+
+	return ik.ShardingKey + itemKeySeparator + ik.SortKey
+}
+
+// ValidateItemName fails with ErrInvalidInput if the platform wouldn't accept the name for an item
+func ValidateItemName(itemName string) error {
+	switch {
+	case itemName == "":
+		return v3ioerrors.NewErrorWithField("ItemName", "must not be empty")
+	case itemName == "." || itemName == "..":
+		return v3ioerrors.NewErrorWithField("ItemName", "must not be . or ..")
+	case len(itemName) > maxItemNameLength:
+		return v3ioerrors.NewErrorWithField("ItemName", "must not be longer than 255 bytes")
+	case strings.ContainsAny(itemName, "/\x00"):
+		return v3ioerrors.NewErrorWithField("ItemName", "must not contain slashes or null characters")
+	case strings.HasPrefix(itemName, itemKeySeparator):
+		return v3ioerrors.NewErrorWithField("ItemName", "must not start with a period (an empty sharding key)")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"errors"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type itemKeySuite struct {
+	suite.Suite
+}
+
+func (suite *itemKeySuite) TestBuildAndParse() {
+	for _, testCase := range []struct {
+		itemName string
+		itemKey  ItemKey
+	}{
+		{itemName: "a", itemKey: ItemKey{ShardingKey: "a"}},
+		{itemName: "a.b", itemKey: ItemKey{ShardingKey: "a", SortKey: "b"}},
+		{itemName: "a.b.c", itemKey: ItemKey{ShardingKey: "a", SortKey: "b.c"}},
+	} {
+		itemKey, err := NewItemKey(testCase.itemKey.ShardingKey, testCase.itemKey.SortKey)
+		suite.Require().NoError(err)
+		suite.Require().Equal(testCase.itemName, itemKey.String())
+
+		itemKey, err = ParseItemKey(testCase.itemName)
+		suite.Require().NoError(err)
+		suite.Require().Equal(testCase.itemKey, itemKey)
+	}
+}
+
+func (suite *itemKeySuite) TestInvalid() {
+	for _, itemName := range []string{"", ".", "..", ".a", "a/b", "a\x00"} {
+		_, err := ParseItemKey(itemName)
+		suite.Require().True(errors.Is(err, v3ioerrors.ErrInvalidInput), itemName)
+	}
+
+	_, err := NewItemKey("a.b", "c")
+	suite.Require().Error(err)
+}
+
+func (suite *itemKeySuite) TestKeyOf() {
+	itemKey, err := KeyOf(Item{ItemNameAttribute: "a.b", "value": 1})
+	suite.Require().NoError(err)
+	suite.Require().Equal(ItemKey{ShardingKey: "a", SortKey: "b"}, itemKey)
+
+	_, err = KeyOf(Item{"value": 1})
+	suite.Require().Error(err)
+}
+
+func TestItemKeySuite(t *testing.T) {
+	suite.Run(t, new(itemKeySuite))
+}
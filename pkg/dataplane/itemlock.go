@@ -0,0 +1,187 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+const (
+	lockOwnerAttributeKey      = "lock_owner"
+	lockExpirationAttributeKey = "lock_expiration"
+)
+
+// MinItemLockTTL is the shortest lease LockItem accepts. shorter leases would have to be extended faster
+// than a round trip to the server and the owners' clock skew allow
+const MinItemLockTTL = time.Second
+
+// ItemLock is a lease on a KV item, acquired with LockItem. while held, the lease is extended in the
+// background. expiration is stamped using the local clock, so owners' clocks must be roughly in sync
+type ItemLock struct {
+	container   Container
+	path        string
+	owner       string
+	quotedOwner string // for use in conditions
+	ttl         time.Duration
+	stopChan    chan struct{}
+	lostChan    chan struct{}
+	waitGroup   sync.WaitGroup
+	stopOnce    sync.Once
+}
+
+// LockItem acquires a lease on the item at path for owner, using conditional updates of lock attributes
+// on the item (which is created if it doesn't exist). the lease is held until Unlock is called, or until
+// it could not be extended for ttl, which must be at least MinItemLockTTL. returns v3ioerrors.ErrLocked
+// if another owner holds the lease
+func LockItem(container Container, path string, owner string, ttl time.Duration) (*ItemLock, error) {
+	if owner == "" {
+		return nil, errors.New("Lock owner must be set")
+	}
+
+	quotedOwner, err := QuoteString(owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid lock owner: %s", owner)
+	}
+
+	if ttl < MinItemLockTTL {
+		return nil, errors.Errorf("Lock TTL must be at least %s, got %s", MinItemLockTTL, ttl)
+	}
+
+	itemLock := &ItemLock{
+		container:   container,
+		path:        path,
+		owner:       owner,
+		quotedOwner: quotedOwner,
+		ttl:         ttl,
+		stopChan:    make(chan struct{}),
+		lostChan:    make(chan struct{}),
+	}
+
+	// the lock can be taken if it's free, expired or already ours
+	condition := fmt.Sprintf("not(exists(%s)) OR (%s < %d) OR (%s)",
+		lockOwnerAttributeKey,
+		lockExpirationAttributeKey,
+		time.Now().UnixNano(),
+		itemLock.ownedCondition())
+
+	if err := itemLock.update(condition, time.Now().Add(ttl).UnixNano()); err != nil {
+		return nil, errors.Wrapf(err, "Failed to lock item %s", path)
+	}
+
+	itemLock.waitGroup.Add(1)
+	go itemLock.keepAlive()
+
+	return itemLock, nil
+}
+
+// Lost returns a channel which is closed if the lease was lost (i.e. it could not be extended in time)
+func (il *ItemLock) Lost() <-chan struct{} {
+	return il.lostChan
+}
+
+// Unlock stops extending the lease and releases it, unless it was already lost
+func (il *ItemLock) Unlock() error {
+	il.stop()
+	il.waitGroup.Wait()
+
+	select {
+	case <-il.lostChan:
+		return nil
+	default:
+	}
+
+	if err := il.update(il.ownedCondition(), 0); err != nil {
+		return errors.Wrapf(err, "Failed to unlock item %s", il.path)
+	}
+
+	return nil
+}
+
+func (il *ItemLock) keepAlive() {
+	defer il.waitGroup.Done()
+
+	ticker := time.NewTicker(il.ttl / 3)
+	defer ticker.Stop()
+
+	expiration := time.Now().Add(il.ttl)
+
+	for {
+		select {
+		case <-ticker.C:
+			nextExpiration := time.Now().Add(il.ttl)
+
+			err := il.update(il.ownedCondition(), nextExpiration.UnixNano())
+			if err == nil {
+				expiration = nextExpiration
+				continue
+			}
+
+			// lost if someone else took the lock, or if we failed to extend it in time
+			if errors.Cause(err) == v3ioerrors.ErrLocked || time.Now().After(expiration) {
+				close(il.lostChan)
+				return
+			}
+
+		case <-il.stopChan:
+			return
+		}
+	}
+}
+
+func (il *ItemLock) stop() {
+	il.stopOnce.Do(func() {
+		close(il.stopChan)
+	})
+}
+
+func (il *ItemLock) ownedCondition() string {
+	return fmt.Sprintf("%s == %s", lockOwnerAttributeKey, il.quotedOwner)
+}
+
+func (il *ItemLock) update(condition string, expiration int64) error {
+	response, err := il.container.UpdateItemSync(&UpdateItemInput{
+		Path:      il.path,
+		Condition: condition,
+		Attributes: map[string]interface{}{
+			lockOwnerAttributeKey:      il.owner,
+			lockExpirationAttributeKey: expiration,
+		},
+	})
+
+	if err != nil {
+		if statusCode, _ := v3ioerrors.GetStatusCode(err); statusCode == http.StatusPreconditionFailed {
+			return errors.Wrap(v3ioerrors.ErrLocked, "Item is locked by another owner")
+		}
+
+		return err
+	}
+
+	response.Release()
+
+	return nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	lockFreeConditionRegexp    = regexp.MustCompile(`not\(exists\(lock_owner\)\)`)
+	lockExpiredConditionRegexp = regexp.MustCompile(`lock_expiration < (\d+)`)
+	lockOwnedConditionRegexp   = regexp.MustCompile(`lock_owner == '([^']*)'`)
+)
+
+// a single item, whose updates evaluate the lock conditions LockItem generates. failed conditions
+// are returned wrapped, as they would be by a layer above the transport
+type fakeLockContainer struct {
+	Container
+	lock               sync.Mutex
+	owner              string
+	expiration         int64
+	numUpdates         int
+	numReleasedUpdates int
+	failUpdatesOfOwner string
+}
+
+func (flc *fakeLockContainer) UpdateItemSync(updateItemInput *UpdateItemInput) (*Response, error) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	owner := updateItemInput.Attributes[lockOwnerAttributeKey].(string)
+	if owner == flc.failUpdatesOfOwner {
+		return nil, errors.New("Update failed")
+	}
+
+	if !flc.evaluate(updateItemInput.Condition) {
+		return nil, errors.Wrap(v3ioerrors.NewErrorWithStatusCode(errors.New("Precondition failed"),
+			http.StatusPreconditionFailed), "Failed to update item")
+	}
+
+	flc.owner = owner
+	flc.expiration = updateItemInput.Attributes[lockExpirationAttributeKey].(int64)
+	flc.numUpdates++
+
+	return &Response{
+		Output: &UpdateItemOutput{},
+		OnRelease: func() {
+			flc.lock.Lock()
+			flc.numReleasedUpdates++
+			flc.lock.Unlock()
+		},
+	}, nil
+}
+
+func (flc *fakeLockContainer) evaluate(condition string) bool {
+	if lockFreeConditionRegexp.MatchString(condition) && flc.owner == "" {
+		return true
+	}
+
+	if match := lockExpiredConditionRegexp.FindStringSubmatch(condition); match != nil {
+		now, _ := strconv.ParseInt(match[1], 10, 64)
+		if flc.expiration < now {
+			return true
+		}
+	}
+
+	if match := lockOwnedConditionRegexp.FindStringSubmatch(condition); match != nil {
+		return flc.owner == match[1]
+	}
+
+	return false
+}
+
+func (flc *fakeLockContainer) getState() (string, int64, int, int) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	return flc.owner, flc.expiration, flc.numUpdates, flc.numReleasedUpdates
+}
+
+func (flc *fakeLockContainer) setFailUpdatesOfOwner(owner string) {
+	flc.lock.Lock()
+	defer flc.lock.Unlock()
+
+	flc.failUpdatesOfOwner = owner
+}
+
+type itemLockSuite struct {
+	suite.Suite
+	container *fakeLockContainer
+}
+
+func (suite *itemLockSuite) SetupTest() {
+	suite.container = &fakeLockContainer{}
+}
+
+func (suite *itemLockSuite) TestAcquireAndRelease() {
+	itemLock, err := LockItem(suite.container, "locks/a", "owner-1", time.Minute)
+	suite.Require().NoError(err)
+
+	owner, expiration, _, _ := suite.container.getState()
+	suite.Require().Equal("owner-1", owner)
+	suite.Require().True(expiration > time.Now().UnixNano())
+
+	suite.Require().NoError(itemLock.Unlock())
+
+	// the lease is released, so another owner can take it right away
+	_, expiration, _, _ = suite.container.getState()
+	suite.Require().Equal(int64(0), expiration)
+
+	itemLock, err = LockItem(suite.container, "locks/a", "owner-2", time.Minute)
+	suite.Require().NoError(err)
+	suite.Require().NoError(itemLock.Unlock())
+
+	suite.requireAllResponsesReleased()
+}
+
+func (suite *itemLockSuite) TestContention() {
+	itemLock, err := LockItem(suite.container, "locks/a", "owner-1", time.Minute)
+	suite.Require().NoError(err)
+
+	_, err = LockItem(suite.container, "locks/a", "owner-2", time.Minute)
+	suite.Require().Equal(v3ioerrors.ErrLocked, errors.RootCause(err))
+
+	// the same owner may re-acquire its own lease
+	reacquiredItemLock, err := LockItem(suite.container, "locks/a", "owner-1", time.Minute)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(reacquiredItemLock.Unlock())
+	suite.Require().NoError(itemLock.Unlock())
+
+	suite.requireAllResponsesReleased()
+}
+
+func (suite *itemLockSuite) TestInvalidTTL() {
+	for _, ttl := range []time.Duration{0, time.Nanosecond, MinItemLockTTL - 1} {
+		_, err := LockItem(suite.container, "locks/a", "owner-1", ttl)
+		suite.Require().Error(err, ttl)
+	}
+
+	_, _, numUpdates, _ := suite.container.getState()
+	suite.Require().Zero(numUpdates)
+}
+
+func (suite *itemLockSuite) TestExpiry() {
+	ttl := MinItemLockTTL
+
+	itemLock, err := LockItem(suite.container, "locks/a", "owner-1", ttl)
+	suite.Require().NoError(err)
+
+	// owner-1 can no longer extend its lease, so it expires and is lost
+	suite.container.setFailUpdatesOfOwner("owner-1")
+
+	select {
+	case <-itemLock.Lost():
+	case <-time.After(10 * ttl):
+		suite.Fail("Lease was not lost")
+	}
+
+	otherItemLock, err := LockItem(suite.container, "locks/a", "owner-2", ttl)
+	suite.Require().NoError(err)
+
+	owner, _, _, _ := suite.container.getState()
+	suite.Require().Equal("owner-2", owner)
+
+	// unlocking a lost lease is a no-op
+	suite.Require().NoError(itemLock.Unlock())
+	suite.Require().NoError(otherItemLock.Unlock())
+
+	suite.requireAllResponsesReleased()
+}
+
+func (suite *itemLockSuite) TestLostToAnotherOwner() {
+	ttl := MinItemLockTTL
+
+	itemLock, err := LockItem(suite.container, "locks/a", "owner-1", ttl)
+	suite.Require().NoError(err)
+
+	// owner-2 takes the lease once owner-1's keep-alive fails and the lease expires
+	suite.container.setFailUpdatesOfOwner("owner-1")
+	time.Sleep(2 * ttl)
+	suite.container.setFailUpdatesOfOwner("")
+
+	otherItemLock, err := LockItem(suite.container, "locks/a", "owner-2", time.Minute)
+	suite.Require().NoError(err)
+
+	select {
+	case <-itemLock.Lost():
+	case <-time.After(10 * ttl):
+		suite.Fail("Lease was not lost")
+	}
+
+	suite.Require().NoError(itemLock.Unlock())
+	suite.Require().NoError(otherItemLock.Unlock())
+
+	owner, _, _, _ := suite.container.getState()
+	suite.Require().Equal("owner-2", owner)
+
+	suite.requireAllResponsesReleased()
+}
+
+func (suite *itemLockSuite) requireAllResponsesReleased() {
+	_, _, numUpdates, numReleasedUpdates := suite.container.getState()
+	suite.Require().Equal(numUpdates, numReleasedUpdates)
+}
+
+func TestItemLockSuite(t *testing.T) {
+	suite.Run(t, new(itemLockSuite))
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// MarshalItem converts a struct (or a pointer to one) to item attributes. the attribute name of a field is
+// taken from its "v3io" tag, or is the field name if there's no tag. fields tagged "-" and unexported
+// fields are skipped. integers are converted to int64 (or uint64 if unsigned) and floats to float64, so
+// that fields may be of any numeric type. strings, bools, []byte and time.Time are passed as is, and any
+// other field type is an error
+func MarshalItem(value interface{}) (map[string]interface{}, error) {
+	structValue := reflect.Indirect(reflect.ValueOf(value))
+	if structValue.Kind() != reflect.Struct {
+		return nil, errors.Errorf("Expected a struct, got %T", value)
+	}
+
+	attributes := map[string]interface{}{}
+
+	for _, field := range getItemFields(structValue.Type()) {
+		attributeValue, err := getItemAttribute(structValue.Field(field.index))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get attribute %s from field", field.attributeName)
+		}
+
+		attributes[field.attributeName] = attributeValue
+	}
+
+	return attributes, nil
+}
+
+// UnmarshalItem populates the struct pointed to by value from an item, by the same attribute names as
+// MarshalItem. numeric attributes are converted to the field's numeric type. attributes without a
+// matching field are ignored
+func UnmarshalItem(item Item, value interface{}) error {
+	pointerValue := reflect.ValueOf(value)
+	if pointerValue.Kind() != reflect.Ptr || pointerValue.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("Expected a pointer to a struct, got %T", value)
+	}
+
+	structValue := pointerValue.Elem()
+
+	for _, field := range getItemFields(structValue.Type()) {
+		attributeValue, found := item[field.attributeName]
+		if !found || attributeValue == nil {
+			continue
+		}
+
+		if err := setItemField(structValue.Field(field.index), attributeValue); err != nil {
+			return errors.Wrapf(err, "Failed to set field from attribute %s", field.attributeName)
+		}
+	}
+
+	return nil
+}
+
+// ItemAttributeNames returns the attribute names MarshalItem produces for a struct type, for use as
+// the attributes to get
+func ItemAttributeNames(value interface{}) []string {
+	structType := reflect.TypeOf(value)
+	if structType == nil {
+		return nil
+	}
+
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var attributeNames []string
+	for _, field := range getItemFields(structType) {
+		attributeNames = append(attributeNames, field.attributeName)
+	}
+
+	return attributeNames
+}
+
+type itemField struct {
+	index         int
+	attributeName string
+}
+
+func getItemFields(structType reflect.Type) []itemField {
+	var itemFields []itemField
+
+	for fieldIndex := 0; fieldIndex < structType.NumField(); fieldIndex++ {
+		field := structType.Field(fieldIndex)
+
+		// skip unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		attributeName := field.Name
+		if tag, tagFound := field.Tag.Lookup("v3io"); tagFound {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				attributeName = tagName
+			}
+		}
+
+		itemFields = append(itemFields, itemField{index: fieldIndex, attributeName: attributeName})
+	}
+
+	return itemFields
+}
+
+// returns the value of a field as one of the attribute types the data plane can encode
+func getItemAttribute(fieldValue reflect.Value) (interface{}, error) {
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fieldValue.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fieldValue.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return fieldValue.Float(), nil
+	case reflect.String:
+		return fieldValue.String(), nil
+	case reflect.Bool:
+		return fieldValue.Bool(), nil
+	case reflect.Slice:
+		if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			return fieldValue.Bytes(), nil
+		}
+	case reflect.Struct:
+		if fieldValue.Type() == timeType {
+			return fieldValue.Interface(), nil
+		}
+	}
+
+	return nil, errors.Wrapf(v3ioerrors.ErrInvalidTypeConversion,
+		"Unsupported field type %s (expected a number, string, bool, []byte or time.Time)",
+		fieldValue.Type())
+}
+
+func setItemField(fieldValue reflect.Value, attributeValue interface{}) error {
+	attributeReflectValue := reflect.ValueOf(attributeValue)
+
+	if attributeReflectValue.Type().AssignableTo(fieldValue.Type()) {
+		fieldValue.Set(attributeReflectValue)
+		return nil
+	}
+
+	// numbers are returned as int or float64 - convert them to the field's numeric type
+	if isNumericKind(attributeReflectValue.Kind()) && isNumericKind(fieldValue.Kind()) {
+		fieldValue.Set(attributeReflectValue.Convert(fieldValue.Type()))
+		return nil
+	}
+
+	return errors.Wrapf(v3ioerrors.ErrInvalidTypeConversion,
+		"Can't convert %s to %s",
+		attributeReflectValue.Type(),
+		fieldValue.Type())
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type testItem struct {
+	Name     string `v3io:"name"`
+	Age      int    `v3io:"age"`
+	Score    float32
+	Ignored  string `v3io:"-"`
+	internal string
+}
+
+type itemMarshallerSuite struct {
+	suite.Suite
+}
+
+func (suite *itemMarshallerSuite) TestMarshal() {
+	attributes, err := MarshalItem(&testItem{Name: "a", Age: 3, Score: 1.5, Ignored: "x", internal: "y"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]interface{}{
+		"name":  "a",
+		"age":   int64(3),
+		"Score": 1.5,
+	}, attributes)
+
+	_, err = MarshalItem("not a struct")
+	suite.Require().Error(err)
+}
+
+func (suite *itemMarshallerSuite) TestMarshalTypes() {
+	type kind string
+
+	now := time.Now()
+
+	// numbers are widened to int64, uint64 and float64
+	attributes, err := MarshalItem(struct {
+		Int8    int8
+		Int32   int32
+		Uint    uint
+		Uint16  uint16
+		Float32 float32
+		Kind    kind
+		Bool    bool
+		Bytes   []byte
+		Time    time.Time
+	}{-1, 2, 3, 4, 0.5, "k", true, []byte("b"), now})
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]interface{}{
+		"Int8":    int64(-1),
+		"Int32":   int64(2),
+		"Uint":    uint64(3),
+		"Uint16":  uint64(4),
+		"Float32": 0.5,
+		"Kind":    "k",
+		"Bool":    true,
+		"Bytes":   []byte("b"),
+		"Time":    now,
+	}, attributes)
+
+	for _, value := range []interface{}{
+		struct{ Map map[string]int }{},
+		struct{ Slice []string }{},
+		struct{ Nested testItem }{},
+		struct{ Pointer *int }{},
+	} {
+		_, err = MarshalItem(value)
+		suite.Require().Error(err, "%T", value)
+	}
+}
+
+func (suite *itemMarshallerSuite) TestUnmarshal() {
+	var value testItem
+
+	// numbers are converted to the fields' types
+	err := UnmarshalItem(Item{"name": "a", "age": 3.0, "Score": 2, "other": true}, &value)
+	suite.Require().NoError(err)
+	suite.Require().Equal(testItem{Name: "a", Age: 3, Score: 2}, value)
+
+	err = UnmarshalItem(Item{"name": 5}, &value)
+	suite.Require().Error(err)
+
+	err = UnmarshalItem(Item{}, value)
+	suite.Require().Error(err)
+}
+
+func (suite *itemMarshallerSuite) TestAttributeNames() {
+	suite.Require().Equal([]string{"name", "age", "Score"}, ItemAttributeNames(testItem{}))
+	suite.Require().Equal([]string{"name", "age", "Score"}, ItemAttributeNames(&testItem{}))
+}
+
+func TestItemMarshallerSuite(t *testing.T) {
+	suite.Run(t, new(itemMarshallerSuite))
+}
@@ -0,0 +1,342 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/nuclio/errors"
+)
+
+// ItemsFormat is the file format of exported and imported items
+type ItemsFormat string
+
+const (
+	// a header row naming the attributes, followed by a row per item
+	ItemsFormatCSV ItemsFormat = "csv"
+
+	// a JSON object per line, per item
+	ItemsFormatJSONLines ItemsFormat = "jsonl"
+)
+
+func (f ItemsFormat) Validate() error {
+	switch f {
+	case ItemsFormatCSV, ItemsFormatJSONLines:
+		return nil
+	default:
+		return errors.Errorf("Invalid items format: %s", f)
+	}
+}
+
+type ExportItemsInput struct {
+	DataPlaneInput
+	Path   string
+	Filter string
+	Format ItemsFormat
+
+	// the attributes to export (defaults to the name and all user attributes). for CSV, these are the
+	// columns, and by default the columns are the attributes of the first item
+	AttributeNames []string
+}
+
+type ExportItemsOutput struct {
+	NumItems int
+}
+
+type ImportItemsInput struct {
+	DataPlaneInput
+	Path   string
+	Format ItemsFormat
+
+	// the column holding the name of each item (defaults to __name)
+	KeyColumn string
+
+	// the maximum number of items written concurrently (defaults to 16)
+	Parallelism int
+}
+
+type ImportItemsOutput struct {
+	NumItems int
+}
+
+// ExportItems writes the items of a table to writer, a page at a time. blob attributes are written base64
+// encoded, so they're imported as strings
+func ExportItems(container Container, exportItemsInput *ExportItemsInput, writer io.Writer) (*ExportItemsOutput, error) {
+	if err := exportItemsInput.Format.Validate(); err != nil {
+		return nil, err
+	}
+
+	attributeNames := exportItemsInput.AttributeNames
+	if len(attributeNames) == 0 {
+		attributeNames = []string{"__name", "*"}
+	}
+
+	var itemWriter func(Item) error
+	var flush func() error
+
+	switch exportItemsInput.Format {
+	case ItemsFormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		itemWriter = newCSVItemWriter(csvWriter, exportItemsInput.AttributeNames)
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	case ItemsFormatJSONLines:
+		bufferedWriter := bufio.NewWriter(writer)
+		encoder := json.NewEncoder(bufferedWriter)
+		itemWriter = func(item Item) error {
+			return encoder.Encode(item)
+		}
+		flush = bufferedWriter.Flush
+	}
+
+	exportItemsOutput := ExportItemsOutput{}
+
+	err := scanTable(container, &GetItemsInput{
+		DataPlaneInput: exportItemsInput.DataPlaneInput,
+		Path:           exportItemsInput.Path,
+		AttributeNames: attributeNames,
+		Filter:         exportItemsInput.Filter,
+	}, func(item Item) error {
+		exportItemsOutput.NumItems++
+		return itemWriter(item)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to export items of %s", exportItemsInput.Path)
+	}
+
+	if err := flush(); err != nil {
+		return nil, errors.Wrap(err, "Failed to write items")
+	}
+
+	return &exportItemsOutput, nil
+}
+
+// ImportItems writes the items read from reader to a table, replacing existing items. CSV values are imported
+// as integers or floats if they parse as such, and as strings otherwise
+func ImportItems(container Container, importItemsInput *ImportItemsInput, reader io.Reader) (*ImportItemsOutput, error) {
+	if err := importItemsInput.Format.Validate(); err != nil {
+		return nil, err
+	}
+
+	keyColumn := importItemsInput.KeyColumn
+	if keyColumn == "" {
+		keyColumn = "__name"
+	}
+
+	var readItem func() (Item, error)
+
+	switch importItemsInput.Format {
+	case ItemsFormatCSV:
+		readItem = newCSVItemReader(csv.NewReader(reader))
+	case ItemsFormatJSONLines:
+		decoder := json.NewDecoder(reader)
+		decoder.UseNumber()
+		readItem = func() (Item, error) {
+			return decodeJSONItem(decoder)
+		}
+	}
+
+	numItemsRead := 0
+
+	// name the items after their key column, as RestoreTable expects
+	nextItem := func() (Item, error) {
+		item, err := readItem()
+		if err != nil || item == nil {
+			return nil, err
+		}
+
+		numItemsRead++
+
+		key, found := item[keyColumn]
+		if !found || key == nil {
+			return nil, errors.Errorf("Item %d has no %s", numItemsRead, keyColumn)
+		}
+
+		item["__name"] = fmt.Sprint(key)
+
+		return item, nil
+	}
+
+	restoreTableOutput, err := RestoreTable(container, &RestoreTableInput{
+		DataPlaneInput: importItemsInput.DataPlaneInput,
+		Path:           importItemsInput.Path,
+		ConflictPolicy: ConflictPolicyOverwrite,
+		Parallelism:    importItemsInput.Parallelism,
+	}, nextItem)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to import items to %s", importItemsInput.Path)
+	}
+
+	return &ImportItemsOutput{NumItems: restoreTableOutput.NumRestoredItems}, nil
+}
+
+// returns a function writing items as CSV rows, after a header row of the columns. if no columns are
+// given, they're taken from the first item
+func newCSVItemWriter(csvWriter *csv.Writer, columns []string) func(Item) error {
+	return func(item Item) error {
+		if columns == nil {
+			columns = getItemColumns(item)
+			if err := csvWriter.Write(columns); err != nil {
+				return err
+			}
+		}
+
+		row := make([]string, len(columns))
+		numAttributesWritten := 0
+
+		for columnIdx, column := range columns {
+			if value, found := item[column]; found {
+				row[columnIdx] = formatCSVValue(value)
+				numAttributesWritten++
+			}
+		}
+
+		// rather than drop attributes silently
+		if numAttributesWritten != len(item) {
+			return errors.Errorf("Item %v has attributes other than the columns %v, which should be given as "+
+				"the attribute names", item["__name"], columns)
+		}
+
+		return csvWriter.Write(row)
+	}
+}
+
+// the name column first, followed by the other attributes in order
+func getItemColumns(item Item) []string {
+	var columns []string
+	for attributeName := range item {
+		if attributeName != "__name" {
+			columns = append(columns, attributeName)
+		}
+	}
+
+	sort.Strings(columns)
+
+	if _, found := item["__name"]; found {
+		columns = append([]string{"__name"}, columns...)
+	}
+
+	return columns
+}
+
+func formatCSVValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return base64.StdEncoding.EncodeToString(typedValue)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'g', -1, 64)
+	default:
+		return fmt.Sprint(typedValue)
+	}
+}
+
+// returns a function reading items from CSV rows, following a header row of the columns. empty values are
+// left unset
+func newCSVItemReader(csvReader *csv.Reader) func() (Item, error) {
+	var columns []string
+
+	return func() (Item, error) {
+		if columns == nil {
+			header, err := csvReader.Read()
+			if err == io.EOF {
+				return nil, nil
+			}
+
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to read CSV header")
+			}
+
+			columns = header
+		}
+
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read CSV row")
+		}
+
+		item := Item{}
+		for columnIdx, value := range row {
+			if value != "" {
+				item[columns[columnIdx]] = parseCSVValue(value)
+			}
+		}
+
+		return item, nil
+	}
+}
+
+func parseCSVValue(value string) interface{} {
+	if intValue, err := strconv.Atoi(value); err == nil {
+		return intValue
+	}
+
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatValue
+	}
+
+	return value
+}
+
+// decodes the next JSON object, converting its numbers to integers or floats
+func decodeJSONItem(decoder *json.Decoder) (Item, error) {
+	var attributes map[string]interface{}
+	if err := decoder.Decode(&attributes); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "Failed to decode item")
+	}
+
+	item := Item{}
+	for attributeName, attributeValue := range attributes {
+		number, isNumber := attributeValue.(json.Number)
+		if !isNumber {
+			item[attributeName] = attributeValue
+			continue
+		}
+
+		if intValue, err := strconv.Atoi(number.String()); err == nil {
+			item[attributeName] = intValue
+		} else if floatValue, err := number.Float64(); err == nil {
+			item[attributeName] = floatValue
+		} else {
+			item[attributeName] = number.String()
+		}
+	}
+
+	return item, nil
+}
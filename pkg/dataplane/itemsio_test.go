@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// returns the items of the table in order of their names, in a single page
+func (ftc *fakeTableContainer) GetItemsSync(getItemsInput *GetItemsInput) (*Response, error) {
+	var itemPaths []string
+	for itemPath := range ftc.items {
+		if path.Dir(itemPath) == path.Clean(getItemsInput.Path) {
+			itemPaths = append(itemPaths, itemPath)
+		}
+	}
+
+	sort.Strings(itemPaths)
+
+	getItemsOutput := GetItemsOutput{Last: true}
+	for _, itemPath := range itemPaths {
+		item := Item{"__name": path.Base(itemPath)}
+		for attributeName, attributeValue := range ftc.items[itemPath] {
+			item[attributeName] = attributeValue
+		}
+
+		getItemsOutput.Items = append(getItemsOutput.Items, item)
+	}
+
+	return &Response{Output: &getItemsOutput}, nil
+}
+
+type itemsIOSuite struct {
+	suite.Suite
+	container *fakeTableContainer
+}
+
+func (suite *itemsIOSuite) SetupTest() {
+	suite.container = &fakeTableContainer{
+		items: map[string]map[string]interface{}{},
+	}
+}
+
+func (suite *itemsIOSuite) TestImportCSVExportJSONLines() {
+	importItemsOutput, err := ImportItems(suite.container, &ImportItemsInput{
+		Path:        "table/",
+		Format:      ItemsFormatCSV,
+		KeyColumn:   "id",
+		Parallelism: 2,
+	}, strings.NewReader("id,name,score\n1,a,1.5\n2,b,\n3,\"c, d\",7\n"))
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, importItemsOutput.NumItems)
+
+	// the key column is kept, and empty values are left unset
+	suite.Require().Equal(map[string]interface{}{"id": 1, "name": "a", "score": 1.5}, suite.container.items["table/1"])
+	suite.Require().Equal(map[string]interface{}{"id": 2, "name": "b"}, suite.container.items["table/2"])
+	suite.Require().Equal(map[string]interface{}{"id": 3, "name": "c, d", "score": 7}, suite.container.items["table/3"])
+
+	var buffer bytes.Buffer
+	exportItemsOutput, err := ExportItems(suite.container, &ExportItemsInput{
+		Path:   "table/",
+		Format: ItemsFormatJSONLines,
+	}, &buffer)
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, exportItemsOutput.NumItems)
+	suite.Require().Equal(`{"__name":"1","id":1,"name":"a","score":1.5}
+{"__name":"2","id":2,"name":"b"}
+{"__name":"3","id":3,"name":"c, d","score":7}
+`, buffer.String())
+
+	// and back, by name
+	suite.SetupTest()
+	importItemsOutput, err = ImportItems(suite.container, &ImportItemsInput{
+		Path:   "table/",
+		Format: ItemsFormatJSONLines,
+	}, &buffer)
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, importItemsOutput.NumItems)
+	suite.Require().Equal(map[string]interface{}{"id": 1, "name": "a", "score": 1.5}, suite.container.items["table/1"])
+}
+
+func (suite *itemsIOSuite) TestExportCSV() {
+	suite.container.items["table/a"] = map[string]interface{}{"count": 1, "blob": []byte("x")}
+	suite.container.items["table/b"] = map[string]interface{}{"count": 2}
+
+	var buffer bytes.Buffer
+	_, err := ExportItems(suite.container, &ExportItemsInput{
+		Path:   "table/",
+		Format: ItemsFormatCSV,
+	}, &buffer)
+	suite.Require().NoError(err)
+	suite.Require().Equal("__name,blob,count\na,eA==,1\nb,,2\n", buffer.String())
+
+	// an item with attributes other than the columns fails the export
+	suite.container.items["table/c"] = map[string]interface{}{"other": 3}
+	_, err = ExportItems(suite.container, &ExportItemsInput{
+		Path:   "table/",
+		Format: ItemsFormatCSV,
+	}, &buffer)
+	suite.Require().Error(err)
+}
+
+func (suite *itemsIOSuite) TestMissingKeyColumn() {
+	_, err := ImportItems(suite.container, &ImportItemsInput{
+		Path:      "table/",
+		Format:    ItemsFormatCSV,
+		KeyColumn: "id",
+	}, strings.NewReader("name\na\n"))
+	suite.Require().Error(err)
+}
+
+func TestItemsIOSuite(t *testing.T) {
+	suite.Run(t, new(itemsIOSuite))
+}
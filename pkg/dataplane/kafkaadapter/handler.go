@@ -0,0 +1,165 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package kafkaadapter
+
+import (
+	"sort"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	"github.com/v3io/v3io-go/pkg/dataplane/streamconsumergroup"
+
+	"github.com/nuclio/errors"
+)
+
+type handler struct {
+	consumerGroupHandler ConsumerGroupHandler
+}
+
+// NewHandler adapts a consumer group handler to a stream consumer group handler, to be passed to
+// Member.Consume
+func NewHandler(consumerGroupHandler ConsumerGroupHandler) streamconsumergroup.Handler {
+	return &handler{
+		consumerGroupHandler: consumerGroupHandler,
+	}
+}
+
+func (h *handler) Setup(session streamconsumergroup.Session) error {
+	return h.consumerGroupHandler.Setup(&consumerGroupSession{session: session})
+}
+
+func (h *handler) Cleanup(session streamconsumergroup.Session) error {
+	return h.consumerGroupHandler.Cleanup(&consumerGroupSession{session: session})
+}
+
+func (h *handler) ConsumeClaim(session streamconsumergroup.Session, claim streamconsumergroup.Claim) error {
+	consumerGroupClaim := consumerGroupClaim{
+		claim:       claim,
+		messageChan: make(chan *ConsumerMessage),
+	}
+
+	// stops converting if the handler returns before consuming all of the messages
+	doneChan := make(chan struct{})
+	defer close(doneChan)
+
+	go consumerGroupClaim.convertRecordBatches(doneChan)
+
+	return h.consumerGroupHandler.ConsumeClaim(&consumerGroupSession{session: session}, &consumerGroupClaim)
+}
+
+func (h *handler) Abort(session streamconsumergroup.Session) error {
+	return nil
+}
+
+type consumerGroupSession struct {
+	session streamconsumergroup.Session
+}
+
+func (cgs *consumerGroupSession) Claims() map[string][]int32 {
+	claims := map[string][]int32{}
+
+	for _, claim := range cgs.session.GetClaims() {
+		claims[claim.GetStreamPath()] = append(claims[claim.GetStreamPath()], int32(claim.GetShardID()))
+	}
+
+	for _, shardIDs := range claims {
+		sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+	}
+
+	return claims
+}
+
+func (cgs *consumerGroupSession) MemberID() string {
+	return cgs.session.GetMemberID()
+}
+
+// MarkMessage can't fail, as in sarama. marking only fails for shards the session doesn't claim
+func (cgs *consumerGroupSession) MarkMessage(message *ConsumerMessage, metadata string) {
+	cgs.session.MarkRecord(message.record) // nolint: errcheck
+}
+
+// Commit can't fail, as in sarama. failed commits are retried by the member's periodic commits
+func (cgs *consumerGroupSession) Commit() {
+	cgs.session.Commit() // nolint: errcheck
+}
+
+func (cgs *consumerGroupSession) Session() streamconsumergroup.Session {
+	return cgs.session
+}
+
+type consumerGroupClaim struct {
+	claim       streamconsumergroup.Claim
+	messageChan chan *ConsumerMessage
+}
+
+func (cgc *consumerGroupClaim) Topic() string {
+	return cgc.claim.GetStreamPath()
+}
+
+func (cgc *consumerGroupClaim) Partition() int32 {
+	return int32(cgc.claim.GetShardID())
+}
+
+func (cgc *consumerGroupClaim) Messages() <-chan *ConsumerMessage {
+	return cgc.messageChan
+}
+
+// converts the claim's record batches to messages until the batches run out or done is closed
+func (cgc *consumerGroupClaim) convertRecordBatches(doneChan <-chan struct{}) {
+	defer close(cgc.messageChan)
+
+	for recordBatch := range cgc.claim.GetRecordBatchChan() {
+		for recordIdx := range recordBatch.Records {
+			// a message whose headers can't be decoded is delivered without them
+			message, _ := NewConsumerMessage(cgc.claim.GetStreamPath(), &recordBatch.Records[recordIdx])
+
+			select {
+			case cgc.messageChan <- message:
+			case <-doneChan:
+				return
+			}
+		}
+	}
+}
+
+// NewConsumerMessage converts a consumed record to a message. the message is returned along with an error if
+// the record's headers can't be decoded
+func NewConsumerMessage(streamPath string, record *v3io.StreamRecord) (*ConsumerMessage, error) {
+	message := ConsumerMessage{
+		Topic:  streamPath,
+		Offset: int64(record.SequenceNumber),
+		Key:    record.PartitionKey,
+		Value:  record.Data,
+		record: record,
+	}
+
+	if record.ShardID != nil {
+		message.Partition = int32(*record.ShardID)
+	}
+
+	headers, err := record.GetHeaders()
+	if err != nil {
+		return &message, errors.Wrap(err, "Failed to decode message headers")
+	}
+
+	message.Headers = headers
+
+	return &message, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package kafkaadapter
+
+import (
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type NewSyncProducerInput struct {
+	Container v3io.Container
+
+	// put messages in the shard set in their Partition, rather than have the stream pick it by key
+	ManualPartitioning bool
+
+	// the number of times messages which failed to be put are retried (defaults to 3)
+	MaxRetries int
+}
+
+type syncProducer struct {
+	container          v3io.Container
+	manualPartitioning bool
+	maxRetries         int
+}
+
+// NewSyncProducer creates a producer which puts messages with put records, returning once they're put
+func NewSyncProducer(newSyncProducerInput *NewSyncProducerInput) (SyncProducer, error) {
+	if newSyncProducerInput.Container == nil {
+		return nil, errors.New("Container must be set")
+	}
+
+	return &syncProducer{
+		container:          newSyncProducerInput.Container,
+		manualPartitioning: newSyncProducerInput.ManualPartitioning,
+		maxRetries:         newSyncProducerInput.MaxRetries,
+	}, nil
+}
+
+func (sp *syncProducer) SendMessage(message *ProducerMessage) (int32, int64, error) {
+	if err := sp.SendMessages([]*ProducerMessage{message}); err != nil {
+		if multiError, ok := err.(v3ioerrors.MultiError); ok {
+			err = multiError.Errors()[0]
+		}
+
+		return -1, -1, err
+	}
+
+	return message.Partition, message.Offset, nil
+}
+
+func (sp *syncProducer) SendMessages(messages []*ProducerMessage) error {
+	var failures []error
+
+	// put records takes the records of a single stream
+	for _, topicMessages := range groupMessagesByTopic(messages) {
+		records := make([]*v3io.StreamRecord, len(topicMessages))
+		for messageIdx, message := range topicMessages {
+			record, err := sp.messageToRecord(message)
+			if err != nil {
+				return err
+			}
+
+			records[messageIdx] = record
+		}
+
+		putRecordsOutput, err := v3io.PutRecordsWithRetry(sp.container, &v3io.PutRecordsWithRetryInput{
+			PutRecordsInput: v3io.PutRecordsInput{
+				Path:    topicMessages[0].Topic,
+				Records: records,
+			},
+			MaxRetries: sp.maxRetries,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to put messages in topic %s", topicMessages[0].Topic)
+		}
+
+		for messageIdx, putRecordResult := range putRecordsOutput.Records {
+			if putRecordResult.ErrorCode != 0 {
+				failures = append(failures, errors.Errorf("Failed to put message in topic %s: %s (%d)",
+					topicMessages[messageIdx].Topic,
+					putRecordResult.ErrorMessage,
+					putRecordResult.ErrorCode))
+				continue
+			}
+
+			topicMessages[messageIdx].Partition = int32(putRecordResult.ShardID)
+			topicMessages[messageIdx].Offset = int64(putRecordResult.SequenceNumber)
+		}
+	}
+
+	if len(failures) > 0 {
+		return v3ioerrors.NewMultiError(failures)
+	}
+
+	return nil
+}
+
+func (sp *syncProducer) Close() error {
+	return nil
+}
+
+func (sp *syncProducer) messageToRecord(message *ProducerMessage) (*v3io.StreamRecord, error) {
+	record := v3io.StreamRecord{
+		Data:         message.Value,
+		PartitionKey: message.Key,
+	}
+
+	if sp.manualPartitioning {
+		shardID := int(message.Partition)
+		record.ShardID = &shardID
+	}
+
+	if len(message.Headers) > 0 {
+		if err := record.SetHeaders(message.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// groups the messages by topic, keeping their order within each topic
+func groupMessagesByTopic(messages []*ProducerMessage) [][]*ProducerMessage {
+	var groups [][]*ProducerMessage
+	groupIndexes := map[string]int{}
+
+	for _, message := range messages {
+		groupIdx, found := groupIndexes[message.Topic]
+		if !found {
+			groupIdx = len(groups)
+			groupIndexes[message.Topic] = groupIdx
+			groups = append(groups, nil)
+		}
+
+		groups[groupIdx] = append(groups[groupIdx], message)
+	}
+
+	return groups
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package kafkaadapter
+
+import (
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iomock "github.com/v3io/v3io-go/pkg/dataplane/mock"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type syncProducerSuite struct {
+	suite.Suite
+	container v3io.Container
+}
+
+func (suite *syncProducerSuite) SetupTest() {
+	session, err := v3iomock.NewContext().NewSession(&v3io.NewSessionInput{URL: "http://localhost:8081"})
+	suite.Require().NoError(err)
+
+	suite.container, err = session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+
+	err = suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 2})
+	suite.Require().NoError(err)
+}
+
+func (suite *syncProducerSuite) TestSendMessages() {
+	syncProducer, err := NewSyncProducer(&NewSyncProducerInput{
+		Container:          suite.container,
+		ManualPartitioning: true,
+	})
+	suite.Require().NoError(err)
+
+	partition, offset, err := syncProducer.SendMessage(&ProducerMessage{
+		Topic:     "/stream/",
+		Key:       "key",
+		Value:     []byte("first"),
+		Headers:   map[string]string{"header": "value"},
+		Partition: 1,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(int32(1), partition)
+	suite.Require().Equal(int64(1), offset)
+
+	messages := []*ProducerMessage{
+		{Topic: "/stream/", Value: []byte("second"), Partition: 1},
+		{Topic: "/stream/", Value: []byte("third"), Partition: 1},
+	}
+
+	err = syncProducer.SendMessages(messages)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(2), messages[0].Offset)
+	suite.Require().Equal(int64(3), messages[1].Offset)
+
+	// the consumed messages hold what was sent
+	response, err := suite.container.SeekShardSync(&v3io.SeekShardInput{
+		Path: "/stream/1",
+		Type: v3io.SeekShardInputTypeEarliest,
+	})
+	suite.Require().NoError(err)
+
+	location := response.Output.(*v3io.SeekShardOutput).Location
+	response.Release()
+
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{
+		Path:     "/stream/1",
+		Location: location,
+		Limit:    10,
+	})
+	suite.Require().NoError(err)
+	defer response.Release()
+
+	getRecordsOutput := response.Output.(*v3io.GetRecordsOutput)
+	suite.Require().Len(getRecordsOutput.Records, 3)
+
+	shardID := 1
+	getRecordsResult := getRecordsOutput.Records[0]
+	consumerMessage, err := NewConsumerMessage("/stream/", &v3io.StreamRecord{
+		ShardID:        &shardID,
+		Data:           getRecordsResult.Data,
+		ClientInfo:     getRecordsResult.ClientInfo,
+		PartitionKey:   getRecordsResult.PartitionKey,
+		SequenceNumber: getRecordsResult.SequenceNumber,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(int32(1), consumerMessage.Partition)
+	suite.Require().Equal(int64(1), consumerMessage.Offset)
+	suite.Require().Equal("key", consumerMessage.Key)
+	suite.Require().Equal("first", string(consumerMessage.Value))
+	suite.Require().Equal(map[string]string{"header": "value"}, consumerMessage.Headers)
+}
+
+func (suite *syncProducerSuite) TestGroupMessagesByTopic() {
+	messages := []*ProducerMessage{
+		{Topic: "a", Key: "1"},
+		{Topic: "b", Key: "2"},
+		{Topic: "a", Key: "3"},
+	}
+
+	groups := groupMessagesByTopic(messages)
+	suite.Require().Equal([][]*ProducerMessage{
+		{messages[0], messages[2]},
+		{messages[1]},
+	}, groups)
+}
+
+func TestSyncProducerSuite(t *testing.T) {
+	suite.Run(t, new(syncProducerSuite))
+}
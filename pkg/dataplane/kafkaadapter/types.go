@@ -0,0 +1,102 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+// Package kafkaadapter exposes sarama shaped producer and consumer group interfaces over v3io streams, to ease
+// porting code written against Kafka. topics are stream paths, partitions are shard IDs and offsets are
+// sequence numbers
+package kafkaadapter
+
+import (
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	"github.com/v3io/v3io-go/pkg/dataplane/streamconsumergroup"
+)
+
+// ProducerMessage is a record to put in a stream
+type ProducerMessage struct {
+	Topic   string // the stream path
+	Key     string // the partition key, by which the stream picks the shard
+	Value   []byte
+	Headers map[string]string
+
+	// the shard to put the message in, honored only by producers with manual partitioning. set to the shard
+	// the message was put in once it's sent
+	Partition int32
+
+	// set to the sequence number of the message once it's sent
+	Offset int64
+}
+
+// ConsumerMessage is a record consumed from a stream
+type ConsumerMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       string
+	Value     []byte
+	Headers   map[string]string
+
+	record *v3io.StreamRecord
+}
+
+type SyncProducer interface {
+
+	// SendMessage puts a message, returning the shard and sequence number it was put with
+	SendMessage(*ProducerMessage) (int32, int64, error)
+
+	// SendMessages puts messages, setting the shard and sequence number of those which were put. messages
+	// which failed are reported in a v3ioerrors.MultiError
+	SendMessages([]*ProducerMessage) error
+
+	Close() error
+}
+
+// ConsumerGroupSession is the session of a stream consumer group member
+type ConsumerGroupSession interface {
+
+	// Claims returns the shards claimed by the session, by stream path
+	Claims() map[string][]int32
+
+	MemberID() string
+
+	// MarkMessage marks the message as consumed. the metadata is ignored, as v3io streams don't keep it
+	MarkMessage(*ConsumerMessage, string)
+
+	// Commit commits the messages marked so far
+	Commit()
+
+	// Session returns the underlying stream consumer group session
+	Session() streamconsumergroup.Session
+}
+
+// ConsumerGroupClaim is a shard claimed by a session
+type ConsumerGroupClaim interface {
+	Topic() string
+	Partition() int32
+	Messages() <-chan *ConsumerMessage
+}
+
+// ConsumerGroupHandler handles the claims of a session, as a stream consumer group handler would
+type ConsumerGroupHandler interface {
+	Setup(ConsumerGroupSession) error
+	Cleanup(ConsumerGroupSession) error
+
+	// ConsumeClaim must consume the claim's messages until the channel is closed
+	ConsumeClaim(ConsumerGroupSession, ConsumerGroupClaim) error
+}
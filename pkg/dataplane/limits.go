@@ -0,0 +1,159 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"fmt"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+const secondsInHour = 60 * 60
+
+// StreamLimits holds the limits a cluster imposes on streams. The cluster doesn't report them, so
+// they're not enforced by the client - callers which know their cluster's limits can check inputs
+// against them before creating or updating streams. A zero limit means the limit is unknown and is
+// not enforced
+type StreamLimits struct {
+	MaxShardCount           int
+	MinRetentionPeriodHours int
+	MaxRetentionPeriodHours int
+}
+
+// ValidateCreateStreamInput verifies that the shard count and retention of the given input are sane,
+// regardless of the cluster's limits, returning an ErrorWithLimit describing the first violation found
+func ValidateCreateStreamInput(createStreamInput *CreateStreamInput) error {
+	if createStreamInput.ShardCount < 1 {
+		return newLimitError("ShardCount", createStreamInput.ShardCount, 1, "is below the minimum of")
+	}
+
+	if createStreamInput.RetentionPeriodHours < 0 {
+		return newLimitError("RetentionPeriodHours", createStreamInput.RetentionPeriodHours, 0, "is below the minimum of")
+	}
+
+	if createStreamInput.RetentionPeriodSeconds < 0 {
+		return newLimitError("RetentionPeriodSeconds", createStreamInput.RetentionPeriodSeconds, 0, "is below the minimum of")
+	}
+
+	if createStreamInput.RetentionPeriodHours != 0 && createStreamInput.RetentionPeriodSeconds != 0 {
+		return errors.New("RetentionPeriodHours and RetentionPeriodSeconds are mutually exclusive")
+	}
+
+	return nil
+}
+
+// ValidateUpdateStreamInput verifies that the fields the given input changes are sane, regardless of
+// the cluster's limits, returning an ErrorWithLimit describing the first violation found
+func ValidateUpdateStreamInput(updateStreamInput *UpdateStreamInput) error {
+	if updateStreamInput.ShardCount < 0 {
+		return newLimitError("ShardCount", updateStreamInput.ShardCount, 0, "is below the minimum of")
+	}
+
+	if updateStreamInput.RetentionPeriodHours < 0 {
+		return newLimitError("RetentionPeriodHours", updateStreamInput.RetentionPeriodHours, 0, "is below the minimum of")
+	}
+
+	if updateStreamInput.ShardCount == 0 && updateStreamInput.RetentionPeriodHours == 0 {
+		return errors.New("Either ShardCount or RetentionPeriodHours must be set")
+	}
+
+	return nil
+}
+
+// ValidateCreateStreamInput verifies that the given input is sane and within the limits, returning
+// an ErrorWithLimit describing the first violation found
+func (sl *StreamLimits) ValidateCreateStreamInput(createStreamInput *CreateStreamInput) error {
+	if err := ValidateCreateStreamInput(createStreamInput); err != nil {
+		return err
+	}
+
+	if sl.MaxShardCount > 0 && createStreamInput.ShardCount > sl.MaxShardCount {
+		return newLimitError("ShardCount", createStreamInput.ShardCount, sl.MaxShardCount, "exceeds the cluster maximum of")
+	}
+
+	// a zero retention period lets the server pick its default, don't check it against the minimum
+	if sl.MinRetentionPeriodHours > 0 &&
+		createStreamInput.RetentionPeriodHours != 0 &&
+		createStreamInput.RetentionPeriodHours < sl.MinRetentionPeriodHours {
+		return newLimitError("RetentionPeriodHours",
+			createStreamInput.RetentionPeriodHours,
+			sl.MinRetentionPeriodHours,
+			"is below the cluster minimum of")
+	}
+
+	if sl.MaxRetentionPeriodHours > 0 && createStreamInput.RetentionPeriodHours > sl.MaxRetentionPeriodHours {
+		return newLimitError("RetentionPeriodHours",
+			createStreamInput.RetentionPeriodHours,
+			sl.MaxRetentionPeriodHours,
+			"exceeds the cluster maximum of")
+	}
+
+	// the cluster's limits are in hours
+	if sl.MinRetentionPeriodHours > 0 &&
+		createStreamInput.RetentionPeriodSeconds != 0 &&
+		createStreamInput.RetentionPeriodSeconds < sl.MinRetentionPeriodHours*secondsInHour {
+		return newLimitError("RetentionPeriodSeconds",
+			createStreamInput.RetentionPeriodSeconds,
+			sl.MinRetentionPeriodHours*secondsInHour,
+			"is below the cluster minimum of")
+	}
+
+	if sl.MaxRetentionPeriodHours > 0 &&
+		createStreamInput.RetentionPeriodSeconds > sl.MaxRetentionPeriodHours*secondsInHour {
+		return newLimitError("RetentionPeriodSeconds",
+			createStreamInput.RetentionPeriodSeconds,
+			sl.MaxRetentionPeriodHours*secondsInHour,
+			"exceeds the cluster maximum of")
+	}
+
+	return nil
+}
+
+// ValidateUpdateStreamInput verifies that the fields the given input changes are sane and within the
+// limits, returning an ErrorWithLimit describing the first violation found
+func (sl *StreamLimits) ValidateUpdateStreamInput(updateStreamInput *UpdateStreamInput) error {
+	if err := ValidateUpdateStreamInput(updateStreamInput); err != nil {
+		return err
+	}
+
+	// the stream is validated as if it were created with the updated fields, skipping those left unchanged
+	createStreamInput := CreateStreamInput{
+		ShardCount:           updateStreamInput.ShardCount,
+		RetentionPeriodHours: updateStreamInput.RetentionPeriodHours,
+	}
+
+	if createStreamInput.ShardCount == 0 {
+		createStreamInput.ShardCount = 1
+	}
+
+	return sl.ValidateCreateStreamInput(&createStreamInput)
+}
+
+func newLimitError(field string, value int, limit int, violation string) error {
+	return v3ioerrors.NewErrorWithLimit(fmt.Errorf("%s: %s (%d) %s %d",
+		v3ioerrors.ErrLimitExceeded.Error(),
+		field,
+		value,
+		violation,
+		limit), field, value, limit)
+}
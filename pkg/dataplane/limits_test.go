@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3io
+
+import (
+	"testing"
+
+	"github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type streamLimitsSuite struct {
+	suite.Suite
+}
+
+func (suite *streamLimitsSuite) TestValidateCreateStreamInput() {
+	streamLimits := &StreamLimits{
+		MaxShardCount:           16,
+		MinRetentionPeriodHours: 1,
+		MaxRetentionPeriodHours: 168,
+	}
+
+	for _, testCase := range []struct {
+		name                   string
+		streamLimits           *StreamLimits
+		shardCount             int
+		retentionPeriodHours   int
+		retentionPeriodSeconds int
+		expectedField          string
+		expectedLimit          int
+	}{
+		{
+			name:                 "withinLimits",
+			streamLimits:         streamLimits,
+			shardCount:           16,
+			retentionPeriodHours: 168,
+		},
+		{
+			name:         "defaultRetention",
+			streamLimits: streamLimits,
+			shardCount:   1,
+		},
+		{
+			name:                 "unknownLimits",
+			streamLimits:         &StreamLimits{},
+			shardCount:           1024,
+			retentionPeriodHours: 10000,
+		},
+		{
+			name:                 "noLimits",
+			shardCount:           1024,
+			retentionPeriodHours: 10000,
+		},
+		{
+			name:          "noShards",
+			shardCount:    0,
+			expectedField: "ShardCount",
+			expectedLimit: 1,
+		},
+		{
+			name:                 "tooManyShards",
+			streamLimits:         streamLimits,
+			shardCount:           17,
+			retentionPeriodHours: 1,
+			expectedField:        "ShardCount",
+			expectedLimit:        16,
+		},
+		{
+			name:                 "retentionTooLong",
+			streamLimits:         streamLimits,
+			shardCount:           1,
+			retentionPeriodHours: 169,
+			expectedField:        "RetentionPeriodHours",
+			expectedLimit:        168,
+		},
+		{
+			name:                 "negativeRetention",
+			streamLimits:         streamLimits,
+			shardCount:           1,
+			retentionPeriodHours: -1,
+			expectedField:        "RetentionPeriodHours",
+			expectedLimit:        0,
+		},
+		{
+			name:                   "retentionSecondsWithinLimits",
+			streamLimits:           streamLimits,
+			shardCount:             1,
+			retentionPeriodSeconds: 5400,
+		},
+		{
+			name:                   "retentionSecondsTooShort",
+			streamLimits:           streamLimits,
+			shardCount:             1,
+			retentionPeriodSeconds: 60,
+			expectedField:          "RetentionPeriodSeconds",
+			expectedLimit:          3600,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			createStreamInput := CreateStreamInput{
+				ShardCount:             testCase.shardCount,
+				RetentionPeriodHours:   testCase.retentionPeriodHours,
+				RetentionPeriodSeconds: testCase.retentionPeriodSeconds,
+			}
+
+			// without limits, only the sanity checks apply
+			var err error
+			if testCase.streamLimits == nil {
+				err = ValidateCreateStreamInput(&createStreamInput)
+			} else {
+				err = testCase.streamLimits.ValidateCreateStreamInput(&createStreamInput)
+			}
+
+			if testCase.expectedField == "" {
+				suite.Require().NoError(err)
+				return
+			}
+
+			errWithLimit, ok := err.(v3ioerrors.ErrorWithLimit)
+			suite.Require().True(ok)
+			suite.Require().Equal(testCase.expectedField, errWithLimit.Field())
+			suite.Require().Equal(testCase.expectedLimit, errWithLimit.Limit())
+		})
+	}
+
+	err := streamLimits.ValidateCreateStreamInput(&CreateStreamInput{
+		ShardCount:             1,
+		RetentionPeriodHours:   1,
+		RetentionPeriodSeconds: 3600,
+	})
+	suite.Require().Error(err)
+}
+
+func TestStreamLimitsSuite(t *testing.T) {
+	suite.Run(t, new(streamLimitsSuite))
+}
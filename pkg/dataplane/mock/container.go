@@ -0,0 +1,356 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iomock
+
+import (
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// container populates the inputs with the container name and URL and passes them to the context
+type container struct {
+	session       *session
+	containerName string
+}
+
+func (c *container) populateInputFields(input *v3io.DataPlaneInput) {
+	input.ContainerName = c.containerName
+	input.URL = c.session.url
+}
+
+// GetClusterMD
+func (c *container) GetClusterMD(getClusterMDInput *v3io.GetClusterMDInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getClusterMDInput.DataPlaneInput)
+	return c.session.context.GetClusterMD(getClusterMDInput, context, responseChan)
+}
+
+// GetClusterMDSync
+func (c *container) GetClusterMDSync(getClusterMDInput *v3io.GetClusterMDInput) (*v3io.Response, error) {
+	c.populateInputFields(&getClusterMDInput.DataPlaneInput)
+	return c.session.context.GetClusterMDSync(getClusterMDInput)
+}
+
+// GetContainers
+func (c *container) GetContainers(getContainersInput *v3io.GetContainersInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getContainersInput.DataPlaneInput)
+	return c.session.context.GetContainers(getContainersInput, context, responseChan)
+}
+
+// GetContainersSync
+func (c *container) GetContainersSync(getContainersInput *v3io.GetContainersInput) (*v3io.Response, error) {
+	c.populateInputFields(&getContainersInput.DataPlaneInput)
+	return c.session.context.GetContainersSync(getContainersInput)
+}
+
+// GetContainerContents
+func (c *container) GetContainerContents(getContainerContentsInput *v3io.GetContainerContentsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getContainerContentsInput.DataPlaneInput)
+	return c.session.context.GetContainerContents(getContainerContentsInput, context, responseChan)
+}
+
+// GetContainerContentsSync
+func (c *container) GetContainerContentsSync(getContainerContentsInput *v3io.GetContainerContentsInput) (*v3io.Response, error) {
+	c.populateInputFields(&getContainerContentsInput.DataPlaneInput)
+	return c.session.context.GetContainerContentsSync(getContainerContentsInput)
+}
+
+// CheckPathExists
+func (c *container) CheckPathExists(checkPathExistsInput *v3io.CheckPathExistsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&checkPathExistsInput.DataPlaneInput)
+	return c.session.context.CheckPathExists(checkPathExistsInput, context, responseChan)
+}
+
+// CheckPathExistsSync
+func (c *container) CheckPathExistsSync(checkPathExistsInput *v3io.CheckPathExistsInput) error {
+	c.populateInputFields(&checkPathExistsInput.DataPlaneInput)
+	return c.session.context.CheckPathExistsSync(checkPathExistsInput)
+}
+
+// GetObject
+func (c *container) GetObject(getObjectInput *v3io.GetObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getObjectInput.DataPlaneInput)
+	return c.session.context.GetObject(getObjectInput, context, responseChan)
+}
+
+// GetObjectSync
+func (c *container) GetObjectSync(getObjectInput *v3io.GetObjectInput) (*v3io.Response, error) {
+	c.populateInputFields(&getObjectInput.DataPlaneInput)
+	return c.session.context.GetObjectSync(getObjectInput)
+}
+
+// PutObject
+func (c *container) PutObject(putObjectInput *v3io.PutObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putObjectInput.DataPlaneInput)
+	return c.session.context.PutObject(putObjectInput, context, responseChan)
+}
+
+// PutObjectSync
+func (c *container) PutObjectSync(putObjectInput *v3io.PutObjectInput) error {
+	c.populateInputFields(&putObjectInput.DataPlaneInput)
+	return c.session.context.PutObjectSync(putObjectInput)
+}
+
+// UpdateObjectSync
+func (c *container) UpdateObjectSync(updateObjectInput *v3io.UpdateObjectInput) error {
+	c.populateInputFields(&updateObjectInput.DataPlaneInput)
+	return c.session.context.UpdateObjectSync(updateObjectInput)
+}
+
+// DeleteObject
+func (c *container) DeleteObject(deleteObjectInput *v3io.DeleteObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&deleteObjectInput.DataPlaneInput)
+	return c.session.context.DeleteObject(deleteObjectInput, context, responseChan)
+}
+
+// DeleteObjectSync
+func (c *container) DeleteObjectSync(deleteObjectInput *v3io.DeleteObjectInput) error {
+	c.populateInputFields(&deleteObjectInput.DataPlaneInput)
+	return c.session.context.DeleteObjectSync(deleteObjectInput)
+}
+
+// PresignObject
+func (c *container) PresignObject(presignObjectInput *v3io.PresignObjectInput) (*v3io.PresignObjectOutput, error) {
+	c.populateInputFields(&presignObjectInput.DataPlaneInput)
+	return c.session.context.PresignObject(presignObjectInput)
+}
+
+// GetItem
+func (c *container) GetItem(getItemInput *v3io.GetItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getItemInput.DataPlaneInput)
+	return c.session.context.GetItem(getItemInput, context, responseChan)
+}
+
+// GetItemSync
+func (c *container) GetItemSync(getItemInput *v3io.GetItemInput) (*v3io.Response, error) {
+	c.populateInputFields(&getItemInput.DataPlaneInput)
+	return c.session.context.GetItemSync(getItemInput)
+}
+
+// GetItems
+func (c *container) GetItems(getItemsInput *v3io.GetItemsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getItemsInput.DataPlaneInput)
+	return c.session.context.GetItems(getItemsInput, context, responseChan)
+}
+
+// GetItemsSync
+func (c *container) GetItemsSync(getItemsInput *v3io.GetItemsInput) (*v3io.Response, error) {
+	c.populateInputFields(&getItemsInput.DataPlaneInput)
+	return c.session.context.GetItemsSync(getItemsInput)
+}
+
+// PutItem
+func (c *container) PutItem(putItemInput *v3io.PutItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putItemInput.DataPlaneInput)
+	return c.session.context.PutItem(putItemInput, context, responseChan)
+}
+
+// PutItemSync
+func (c *container) PutItemSync(putItemInput *v3io.PutItemInput) (*v3io.Response, error) {
+	c.populateInputFields(&putItemInput.DataPlaneInput)
+	return c.session.context.PutItemSync(putItemInput)
+}
+
+// PutItems
+func (c *container) PutItems(putItemsInput *v3io.PutItemsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putItemsInput.DataPlaneInput)
+	return c.session.context.PutItems(putItemsInput, context, responseChan)
+}
+
+// PutItemsSync
+func (c *container) PutItemsSync(putItemsInput *v3io.PutItemsInput) (*v3io.Response, error) {
+	c.populateInputFields(&putItemsInput.DataPlaneInput)
+	return c.session.context.PutItemsSync(putItemsInput)
+}
+
+// UpdateItem
+func (c *container) UpdateItem(updateItemInput *v3io.UpdateItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&updateItemInput.DataPlaneInput)
+	return c.session.context.UpdateItem(updateItemInput, context, responseChan)
+}
+
+// UpdateItemSync
+func (c *container) UpdateItemSync(updateItemInput *v3io.UpdateItemInput) (*v3io.Response, error) {
+	c.populateInputFields(&updateItemInput.DataPlaneInput)
+	return c.session.context.UpdateItemSync(updateItemInput)
+}
+
+// CreateStream
+func (c *container) CreateStream(createStreamInput *v3io.CreateStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&createStreamInput.DataPlaneInput)
+	return c.session.context.CreateStream(createStreamInput, context, responseChan)
+}
+
+// CreateStreamSync
+func (c *container) CreateStreamSync(createStreamInput *v3io.CreateStreamInput) error {
+	c.populateInputFields(&createStreamInput.DataPlaneInput)
+	return c.session.context.CreateStreamSync(createStreamInput)
+}
+
+// DescribeStream
+func (c *container) DescribeStream(describeStreamInput *v3io.DescribeStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&describeStreamInput.DataPlaneInput)
+	return c.session.context.DescribeStream(describeStreamInput, context, responseChan)
+}
+
+// DescribeStreamSync
+func (c *container) DescribeStreamSync(describeStreamInput *v3io.DescribeStreamInput) (*v3io.Response, error) {
+	c.populateInputFields(&describeStreamInput.DataPlaneInput)
+	return c.session.context.DescribeStreamSync(describeStreamInput)
+}
+
+// UpdateStream
+func (c *container) UpdateStream(updateStreamInput *v3io.UpdateStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&updateStreamInput.DataPlaneInput)
+	return c.session.context.UpdateStream(updateStreamInput, context, responseChan)
+}
+
+// UpdateStreamSync
+func (c *container) UpdateStreamSync(updateStreamInput *v3io.UpdateStreamInput) error {
+	c.populateInputFields(&updateStreamInput.DataPlaneInput)
+	return c.session.context.UpdateStreamSync(updateStreamInput)
+}
+
+// DeleteStream
+func (c *container) DeleteStream(deleteStreamInput *v3io.DeleteStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&deleteStreamInput.DataPlaneInput)
+	return c.session.context.DeleteStream(deleteStreamInput, context, responseChan)
+}
+
+// DeleteStreamSync
+func (c *container) DeleteStreamSync(deleteStreamInput *v3io.DeleteStreamInput) error {
+	c.populateInputFields(&deleteStreamInput.DataPlaneInput)
+	return c.session.context.DeleteStreamSync(deleteStreamInput)
+}
+
+// SeekShard
+func (c *container) SeekShard(seekShardInput *v3io.SeekShardInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&seekShardInput.DataPlaneInput)
+	return c.session.context.SeekShard(seekShardInput, context, responseChan)
+}
+
+// SeekShardSync
+func (c *container) SeekShardSync(seekShardInput *v3io.SeekShardInput) (*v3io.Response, error) {
+	c.populateInputFields(&seekShardInput.DataPlaneInput)
+	return c.session.context.SeekShardSync(seekShardInput)
+}
+
+// PutRecords
+func (c *container) PutRecords(putRecordsInput *v3io.PutRecordsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putRecordsInput.DataPlaneInput)
+	return c.session.context.PutRecords(putRecordsInput, context, responseChan)
+}
+
+// PutRecordsSync
+func (c *container) PutRecordsSync(putRecordsInput *v3io.PutRecordsInput) (*v3io.Response, error) {
+	c.populateInputFields(&putRecordsInput.DataPlaneInput)
+	return c.session.context.PutRecordsSync(putRecordsInput)
+}
+
+// PutChunk
+func (c *container) PutChunk(putChunkInput *v3io.PutChunkInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putChunkInput.DataPlaneInput)
+	return c.session.context.PutChunk(putChunkInput, context, responseChan)
+}
+
+// PutChunkSync
+func (c *container) PutChunkSync(putChunkInput *v3io.PutChunkInput) error {
+	c.populateInputFields(&putChunkInput.DataPlaneInput)
+	return c.session.context.PutChunkSync(putChunkInput)
+}
+
+// GetRecords
+func (c *container) GetRecords(getRecordsInput *v3io.GetRecordsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getRecordsInput.DataPlaneInput)
+	return c.session.context.GetRecords(getRecordsInput, context, responseChan)
+}
+
+// GetRecordsSync
+func (c *container) GetRecordsSync(getRecordsInput *v3io.GetRecordsInput) (*v3io.Response, error) {
+	c.populateInputFields(&getRecordsInput.DataPlaneInput)
+	return c.session.context.GetRecordsSync(getRecordsInput)
+}
+
+// PutOOSObject
+func (c *container) PutOOSObject(putOOSObjectInput *v3io.PutOOSObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&putOOSObjectInput.DataPlaneInput)
+	return c.session.context.PutOOSObject(putOOSObjectInput, context, responseChan)
+}
+
+// PutOOSObjectSync
+func (c *container) PutOOSObjectSync(putOOSObjectInput *v3io.PutOOSObjectInput) error {
+	c.populateInputFields(&putOOSObjectInput.DataPlaneInput)
+	return c.session.context.PutOOSObjectSync(putOOSObjectInput)
+}
+
+// GetOOSObject
+func (c *container) GetOOSObject(getOOSObjectInput *v3io.GetOOSObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	c.populateInputFields(&getOOSObjectInput.DataPlaneInput)
+	return c.session.context.GetOOSObject(getOOSObjectInput, context, responseChan)
+}
+
+// GetOOSObjectSync
+func (c *container) GetOOSObjectSync(getOOSObjectInput *v3io.GetOOSObjectInput) (*v3io.Response, error) {
+	c.populateInputFields(&getOOSObjectInput.DataPlaneInput)
+	return c.session.context.GetOOSObjectSync(getOOSObjectInput)
+}
@@ -0,0 +1,660 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+// Package v3iomock provides an in-memory implementation of the data plane, for testing code which uses
+// it without a live cluster. objects, items and streams are held in maps per container. filters, conditions
+// and update expressions aren't evaluated - requests which use them fail with ErrNotSupported
+package v3iomock
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+type file struct {
+	data       []byte
+	attributes map[string]interface{}
+	ctime      time.Time
+	mtime      time.Time
+}
+
+type containerState struct {
+	files       map[string]*file
+	directories map[string]bool // directories created explicitly
+	streams     map[string]*stream
+}
+
+// Context is an in-memory v3io.Context. any container name is valid, and containers are created on first use
+type Context struct {
+	lock          sync.Mutex
+	containers    map[string]*containerState
+	lastRequestID uint64
+	numRequests   uint64
+}
+
+// NewContext creates an empty in-memory context
+func NewContext() *Context {
+	return &Context{
+		containers: map[string]*containerState{},
+	}
+}
+
+// NewSession creates a session. credentials are ignored
+func (c *Context) NewSession(newSessionInput *v3io.NewSessionInput) (v3io.Session, error) {
+	return &session{
+		context: c,
+		url:     newSessionInput.URL,
+	}, nil
+}
+
+// Stats returns the number of requests made - the other statistics don't apply
+func (c *Context) Stats() *v3io.ContextStats {
+	return &v3io.ContextStats{
+		NumRequests: atomic.LoadUint64(&c.numRequests),
+	}
+}
+
+// Ping always succeeds
+func (c *Context) Ping(pingInput *v3io.PingInput) (*v3io.PingOutput, error) {
+	atomic.AddUint64(&c.numRequests, 1)
+
+	return &v3io.PingOutput{StatusCode: http.StatusOK}, nil
+}
+
+// Capabilities returns the APIs the mock supports
+func (c *Context) Capabilities() *v3io.Capabilities {
+	return &v3io.Capabilities{
+		Streams:       true,
+		CapnpGetItems: true,
+	}
+}
+
+func (c *Context) Close() error {
+	return nil
+}
+
+// GetClusterMD
+func (c *Context) GetClusterMD(getClusterMDInput *v3io.GetClusterMDInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getClusterMDInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetClusterMDSync(getClusterMDInput)
+	})
+}
+
+// GetClusterMDSync
+func (c *Context) GetClusterMDSync(getClusterMDInput *v3io.GetClusterMDInput) (*v3io.Response, error) {
+	atomic.AddUint64(&c.numRequests, 1)
+
+	return newResponse(&v3io.GetClusterMDOutput{NumberOfVNs: 1}), nil
+}
+
+// GetContainers
+func (c *Context) GetContainers(getContainersInput *v3io.GetContainersInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getContainersInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetContainersSync(getContainersInput)
+	})
+}
+
+// GetContainersSync returns the containers used so far
+func (c *Context) GetContainersSync(getContainersInput *v3io.GetContainersInput) (*v3io.Response, error) {
+	atomic.AddUint64(&c.numRequests, 1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var containerNames []string
+	for containerName := range c.containers {
+		containerNames = append(containerNames, containerName)
+	}
+
+	sort.Strings(containerNames)
+
+	getContainersOutput := v3io.GetContainersOutput{}
+	for containerIdx, containerName := range containerNames {
+		getContainersOutput.Results.Containers = append(getContainersOutput.Results.Containers, v3io.ContainerInfo{
+			Name: containerName,
+			ID:   containerIdx + 1,
+		})
+	}
+
+	return newResponse(&getContainersOutput), nil
+}
+
+// GetContainerContents
+func (c *Context) GetContainerContents(getContainerContentsInput *v3io.GetContainerContentsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getContainerContentsInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetContainerContentsSync(getContainerContentsInput)
+	})
+}
+
+// GetContainerContentsSync lists the files and directories directly under the path
+func (c *Context) GetContainerContentsSync(getContainerContentsInput *v3io.GetContainerContentsInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&getContainerContentsInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	dirPath := cleanPath(getContainerContentsInput.Path)
+	getContainerContentsOutput := v3io.GetContainerContentsOutput{
+		Name: getContainerContentsInput.ContainerName,
+	}
+
+	contents, commonPrefixes := container.list(dirPath)
+
+	// entries are returned in order of their keys, starting after the marker
+	var keys []string
+	for key := range contents {
+		if !getContainerContentsInput.DirectoriesOnly {
+			keys = append(keys, key)
+		}
+	}
+
+	for key := range commonPrefixes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if getContainerContentsInput.Marker != "" && key <= getContainerContentsInput.Marker {
+			continue
+		}
+
+		if getContainerContentsInput.Limit > 0 &&
+			len(getContainerContentsOutput.Contents)+len(getContainerContentsOutput.CommonPrefixes) ==
+				getContainerContentsInput.Limit {
+			getContainerContentsOutput.IsTruncated = true
+			break
+		}
+
+		if content, found := contents[key]; found {
+			getContainerContentsOutput.Contents = append(getContainerContentsOutput.Contents, content)
+		} else {
+			getContainerContentsOutput.CommonPrefixes = append(getContainerContentsOutput.CommonPrefixes,
+				commonPrefixes[key])
+		}
+
+		getContainerContentsOutput.NextMarker = key
+	}
+
+	if !getContainerContentsOutput.IsTruncated {
+		getContainerContentsOutput.NextMarker = ""
+	}
+
+	return newResponse(&getContainerContentsOutput), nil
+}
+
+// CheckPathExists
+func (c *Context) CheckPathExists(checkPathExistsInput *v3io.CheckPathExistsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(checkPathExistsInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.CheckPathExistsSync(checkPathExistsInput)
+	})
+}
+
+// CheckPathExistsSync
+func (c *Context) CheckPathExistsSync(checkPathExistsInput *v3io.CheckPathExistsInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&checkPathExistsInput.DataPlaneInput)
+	if err != nil {
+		return err
+	}
+
+	filePath := cleanPath(checkPathExistsInput.Path)
+	if _, found := container.files[filePath]; found && !checkPathExistsInput.IsDirectory {
+		return nil
+	}
+
+	if container.isDirectory(filePath) {
+		return nil
+	}
+
+	return newNotFoundError(checkPathExistsInput.Path)
+}
+
+// GetObject
+func (c *Context) GetObject(getObjectInput *v3io.GetObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getObjectInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetObjectSync(getObjectInput)
+	})
+}
+
+// GetObjectSync returns the object's data in the response body
+func (c *Context) GetObjectSync(getObjectInput *v3io.GetObjectInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&getObjectInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	object, found := container.files[cleanPath(getObjectInput.Path)]
+	if !found {
+		return nil, newNotFoundError(getObjectInput.Path)
+	}
+
+	data := object.data
+	if getObjectInput.Offset > len(data) {
+		data = nil
+	} else {
+		data = data[getObjectInput.Offset:]
+	}
+
+	if getObjectInput.NumBytes > 0 && getObjectInput.NumBytes < len(data) {
+		data = data[:getObjectInput.NumBytes]
+	}
+
+	response := newResponse(nil)
+	response.HTTPResponse.SetBody(data)
+
+	return response, nil
+}
+
+// PutObject
+func (c *Context) PutObject(putObjectInput *v3io.PutObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putObjectInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.PutObjectSync(putObjectInput)
+	})
+}
+
+// PutObjectSync writes the object's data, or creates a directory if IsDirectory is set
+func (c *Context) PutObjectSync(putObjectInput *v3io.PutObjectInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&putObjectInput.DataPlaneInput)
+	if err != nil {
+		return err
+	}
+
+	filePath := cleanPath(putObjectInput.Path)
+	if putObjectInput.IsDirectory {
+		container.directories[filePath] = true
+		return nil
+	}
+
+	object := container.getOrCreateFile(filePath)
+
+	switch {
+	case putObjectInput.Append:
+		object.data = append(object.data, putObjectInput.Body...)
+	case putObjectInput.Offset > 0:
+		if end := putObjectInput.Offset + len(putObjectInput.Body); end > len(object.data) {
+			object.data = append(object.data, make([]byte, end-len(object.data))...)
+		}
+
+		copy(object.data[putObjectInput.Offset:], putObjectInput.Body)
+	default:
+		object.data = append([]byte(nil), putObjectInput.Body...)
+	}
+
+	object.mtime = time.Now()
+
+	return nil
+}
+
+// UpdateObjectSync updates the object's mtime. directory attributes are ignored
+func (c *Context) UpdateObjectSync(updateObjectInput *v3io.UpdateObjectInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&updateObjectInput.DataPlaneInput)
+	if err != nil {
+		return err
+	}
+
+	filePath := cleanPath(updateObjectInput.Path)
+	if object, found := container.files[filePath]; found && !updateObjectInput.IsDirectory {
+		object.mtime = time.Now()
+		return nil
+	}
+
+	if container.isDirectory(filePath) {
+		return nil
+	}
+
+	return newNotFoundError(updateObjectInput.Path)
+}
+
+// DeleteObject
+func (c *Context) DeleteObject(deleteObjectInput *v3io.DeleteObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(deleteObjectInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.DeleteObjectSync(deleteObjectInput)
+	})
+}
+
+// DeleteObjectSync deletes a file, or an empty directory if IsDirectory is set
+func (c *Context) DeleteObjectSync(deleteObjectInput *v3io.DeleteObjectInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&deleteObjectInput.DataPlaneInput)
+	if err != nil {
+		return err
+	}
+
+	filePath := cleanPath(deleteObjectInput.Path)
+
+	if !deleteObjectInput.IsDirectory {
+
+		// deleting a shard drops its records and attributes. the shard itself goes once its stream is deleted
+		if existingShard, err := c.getShard(&deleteObjectInput.DataPlaneInput, filePath); err == nil {
+			existingShard.records = nil
+			delete(container.files, filePath)
+			return nil
+		}
+
+		if _, found := container.files[filePath]; !found {
+			return newNotFoundError(deleteObjectInput.Path)
+		}
+
+		delete(container.files, filePath)
+		return nil
+	}
+
+	// a stream's shards are deleted along with it
+	if _, found := container.streams[filePath]; found {
+		delete(container.streams, filePath)
+		return nil
+	}
+
+	if contents, commonPrefixes := container.list(filePath); len(contents) > 0 || len(commonPrefixes) > 0 {
+		return v3ioerrors.NewErrorWithStatusCode(errors.Errorf("Directory %s is not empty", deleteObjectInput.Path),
+			http.StatusConflict)
+	}
+
+	delete(container.directories, filePath)
+
+	return nil
+}
+
+// PresignObject signs with the access key of the input, as the mock's sessions have none
+func (c *Context) PresignObject(presignObjectInput *v3io.PresignObjectInput) (*v3io.PresignObjectOutput, error) {
+	return v3io.SignObjectRequest(presignObjectInput, time.Now())
+}
+
+// PutOOSObject is not supported
+func (c *Context) PutOOSObject(putOOSObjectInput *v3io.PutOOSObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putOOSObjectInput, context, responseChan, func() (*v3io.Response, error) {
+		return nil, c.PutOOSObjectSync(putOOSObjectInput)
+	})
+}
+
+// PutOOSObjectSync is not supported
+func (c *Context) PutOOSObjectSync(putOOSObjectInput *v3io.PutOOSObjectInput) error {
+	return newNotSupportedError("PutOOSObject")
+}
+
+// GetOOSObject is not supported
+func (c *Context) GetOOSObject(getOOSObjectInput *v3io.GetOOSObjectInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getOOSObjectInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetOOSObjectSync(getOOSObjectInput)
+	})
+}
+
+// GetOOSObjectSync is not supported
+func (c *Context) GetOOSObjectSync(getOOSObjectInput *v3io.GetOOSObjectInput) (*v3io.Response, error) {
+	return nil, newNotSupportedError("GetOOSObject")
+}
+
+// calls sendSync and posts its result to the response channel, as an asynchronous request would
+func (c *Context) sendAsync(input interface{},
+	context interface{},
+	responseChan chan *v3io.Response,
+	sendSync func() (*v3io.Response, error)) (*v3io.Request, error) {
+
+	request := &v3io.Request{
+		ID:                  atomic.AddUint64(&c.lastRequestID, 1),
+		Input:               input,
+		Context:             context,
+		ResponseChan:        responseChan,
+		SendTimeNanoseconds: time.Now().UnixNano(),
+	}
+
+	if dataPlaneInputGetter, ok := input.(v3io.DataPlaneInputGetter); ok {
+		request.Priority = dataPlaneInputGetter.GetDataPlaneInput().Priority
+	}
+
+	response, err := sendSync()
+	if response == nil {
+		response = &v3io.Response{}
+	}
+
+	response.ID = request.ID
+	response.Error = err
+	response.Context = context
+	response.RequestResponse = &v3io.RequestResponse{Request: *request}
+
+	// as with the http context, the response is posted from another goroutine than the caller's
+	go func() {
+		responseChan <- response
+	}()
+
+	return request, nil
+}
+
+// must be called with the lock held
+func (c *Context) getContainer(dataPlaneInput *v3io.DataPlaneInput) (*containerState, error) {
+	atomic.AddUint64(&c.numRequests, 1)
+
+	if dataPlaneInput.ContainerName == "" {
+		return nil, errors.New("ContainerName must not be empty")
+	}
+
+	container, found := c.containers[dataPlaneInput.ContainerName]
+	if !found {
+		container = &containerState{
+			files:       map[string]*file{},
+			directories: map[string]bool{},
+			streams:     map[string]*stream{},
+		}
+
+		c.containers[dataPlaneInput.ContainerName] = container
+	}
+
+	return container, nil
+}
+
+func (cs *containerState) getOrCreateFile(filePath string) *file {
+	object, found := cs.files[filePath]
+	if !found {
+		object = &file{
+			attributes: map[string]interface{}{},
+			ctime:      time.Now(),
+		}
+
+		cs.files[filePath] = object
+	}
+
+	return object
+}
+
+// returns whether the path is a directory - created explicitly, a stream, or the parent of another path
+func (cs *containerState) isDirectory(dirPath string) bool {
+	if dirPath == "" || cs.directories[dirPath] || cs.streams[dirPath] != nil {
+		return true
+	}
+
+	prefix := dirPath + "/"
+	for filePath := range cs.files {
+		if strings.HasPrefix(filePath, prefix) {
+			return true
+		}
+	}
+
+	for otherDirPath := range cs.directories {
+		if strings.HasPrefix(otherDirPath, prefix) {
+			return true
+		}
+	}
+
+	for streamPath := range cs.streams {
+		if strings.HasPrefix(streamPath, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns the files and directories directly under the directory, by key
+func (cs *containerState) list(dirPath string) (map[string]v3io.Content, map[string]v3io.CommonPrefix) {
+	contents := map[string]v3io.Content{}
+	commonPrefixes := map[string]v3io.CommonPrefix{}
+
+	addDirectory := func(childPath string) {
+		key := childPath + "/"
+		if _, found := commonPrefixes[key]; found {
+			return
+		}
+
+		commonPrefix := v3io.CommonPrefix{
+			Prefix: key,
+			Mode:   "040755",
+		}
+
+		if stream, found := cs.streams[childPath]; found {
+			commonPrefix.ShardCount = len(stream.shards)
+			commonPrefix.RetentionPeriodHours = stream.getRetentionPeriodHours()
+			commonPrefix.RetentionPeriodSeconds = stream.retentionPeriodSeconds
+		}
+
+		commonPrefixes[key] = commonPrefix
+	}
+
+	for filePath, object := range cs.files {
+		if childPath, isDirectChild := getChildPath(dirPath, filePath); isDirectChild {
+			size := len(object.data)
+			contents[childPath] = v3io.Content{
+				Key:          childPath,
+				Size:         &size,
+				LastModified: object.mtime.UTC().Format(time.RFC3339Nano),
+				Mode:         "0100644",
+			}
+		} else if childPath != "" {
+			addDirectory(childPath)
+		}
+	}
+
+	for otherDirPath := range cs.directories {
+		if childPath, _ := getChildPath(dirPath, otherDirPath); childPath != "" {
+			addDirectory(childPath)
+		}
+	}
+
+	for streamPath, stream := range cs.streams {
+		if childPath, _ := getChildPath(dirPath, streamPath); childPath != "" {
+			addDirectory(childPath)
+		}
+
+		// shards are the files of the stream's directory
+		if streamPath == dirPath {
+			for shardID, shard := range stream.shards {
+				shardPath := path.Join(streamPath, fmt.Sprint(shardID))
+				size := 0
+				lastSequenceID := len(shard.records)
+
+				contents[shardPath] = v3io.Content{
+					Key:            shardPath,
+					Size:           &size,
+					LastSequenceID: &lastSequenceID,
+					Mode:           "0100644",
+				}
+			}
+		}
+	}
+
+	return contents, commonPrefixes
+}
+
+// returns the path of the child of dirPath which filePath is under (empty if it isn't), and whether the
+// child is filePath itself
+func getChildPath(dirPath string, filePath string) (string, bool) {
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + "/"
+	}
+
+	if filePath == dirPath || !strings.HasPrefix(filePath, prefix) {
+		return "", false
+	}
+
+	childName := strings.SplitN(strings.TrimPrefix(filePath, prefix), "/", 2)[0]
+	childPath := prefix + childName
+
+	return childPath, childPath == filePath
+}
+
+// paths are held relative to the container, without leading or trailing slashes
+func cleanPath(filePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+filePath), "/")
+}
+
+func newResponse(output interface{}) *v3io.Response {
+	httpResponse := fasthttp.AcquireResponse()
+	httpResponse.SetStatusCode(http.StatusOK)
+
+	return &v3io.Response{
+		Output:       output,
+		HTTPResponse: httpResponse,
+	}
+}
+
+func newNotFoundError(filePath string) error {
+	return v3ioerrors.NewErrorWithStatusCode(errors.Errorf("%s not found", filePath), http.StatusNotFound)
+}
+
+func newNotSupportedError(feature string) error {
+	return errors.Wrapf(v3ioerrors.ErrNotSupported, "%s is not supported by the mock", feature)
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iomock
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type contextTestSuite struct {
+	suite.Suite
+	context   v3io.Context
+	container v3io.Container
+}
+
+func (suite *contextTestSuite) SetupTest() {
+	suite.context = NewContext()
+
+	session, err := suite.context.NewSession(&v3io.NewSessionInput{URL: "http://localhost:8081"})
+	suite.Require().NoError(err)
+
+	suite.container, err = session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+}
+
+func (suite *contextTestSuite) TestObjects() {
+	err := suite.container.PutObjectSync(&v3io.PutObjectInput{Path: "/dir/a", Body: []byte("hello")})
+	suite.Require().NoError(err)
+
+	err = suite.container.PutObjectSync(&v3io.PutObjectInput{Path: "/dir/a", Body: []byte(" world"), Append: true})
+	suite.Require().NoError(err)
+
+	response, err := suite.container.GetObjectSync(&v3io.GetObjectInput{Path: "/dir/a", Offset: 1, NumBytes: 4})
+	suite.Require().NoError(err)
+	suite.Require().Equal("ello", string(response.Body()))
+	response.Release()
+
+	suite.Require().NoError(suite.container.CheckPathExistsSync(&v3io.CheckPathExistsInput{Path: "/dir/"}))
+
+	// a non empty directory can't be deleted
+	err = suite.container.DeleteObjectSync(&v3io.DeleteObjectInput{Path: "/dir/", IsDirectory: true})
+	suite.Require().Error(err)
+
+	err = suite.container.DeleteObjectSync(&v3io.DeleteObjectInput{Path: "/dir/a"})
+	suite.Require().NoError(err)
+
+	_, err = suite.container.GetObjectSync(&v3io.GetObjectInput{Path: "/dir/a"})
+	suite.Require().Error(err)
+}
+
+func (suite *contextTestSuite) TestItems() {
+	for _, itemName := range []string{"c", "a", "b"} {
+		_, err := suite.container.PutItemSync(&v3io.PutItemInput{
+			Path:       "/table/" + itemName,
+			Attributes: map[string]interface{}{"name": itemName, "value": int64(1)},
+		})
+		suite.Require().NoError(err)
+	}
+
+	_, err := suite.container.UpdateItemSync(&v3io.UpdateItemInput{
+		Path:       "/table/a",
+		Attributes: map[string]interface{}{"value": 2},
+	})
+	suite.Require().NoError(err)
+
+	response, err := suite.container.GetItemSync(&v3io.GetItemInput{
+		Path:           "/table/a",
+		AttributeNames: []string{"__name", "*"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(v3io.Item{"__name": "a", "name": "a", "value": 2}, response.Output.(*v3io.GetItemOutput).Item)
+	response.Release()
+
+	// scan the table a page at a time
+	itemsCursor, err := v3io.NewItemsCursor(suite.container, &v3io.GetItemsInput{
+		Path:           "/table/",
+		AttributeNames: []string{"name"},
+		Limit:          2,
+	})
+	suite.Require().NoError(err)
+
+	items, err := itemsCursor.AllSync()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]v3io.Item{{"name": "a"}, {"name": "b"}, {"name": "c"}}, items)
+
+	_, err = suite.container.GetItemsSync(&v3io.GetItemsInput{Path: "/table/", Filter: "value > 1"})
+	suite.Require().Error(err)
+}
+
+func (suite *contextTestSuite) TestStreams() {
+	err := suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 2})
+	suite.Require().NoError(err)
+
+	shardID := 1
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{ShardID: &shardID, Data: []byte("a")},
+			{ShardID: &shardID, Data: []byte("b")},
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, response.Output.(*v3io.PutRecordsOutput).FailedRecordCount)
+	response.Release()
+
+	response, err = suite.container.SeekShardSync(&v3io.SeekShardInput{
+		Path:                   "/stream/1",
+		Type:                   v3io.SeekShardInputTypeSequence,
+		StartingSequenceNumber: 2,
+	})
+	suite.Require().NoError(err)
+	location := response.Output.(*v3io.SeekShardOutput).Location
+	response.Release()
+
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/1", Location: location})
+	suite.Require().NoError(err)
+
+	getRecordsOutput := response.Output.(*v3io.GetRecordsOutput)
+	suite.Require().Len(getRecordsOutput.Records, 1)
+	suite.Require().Equal("b", string(getRecordsOutput.Records[0].Data))
+	suite.Require().Equal(0, getRecordsOutput.RecordsBehindLatest)
+	response.Release()
+
+	response, err = suite.container.GetContainerContentsSync(&v3io.GetContainerContentsInput{Path: "/"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(2, response.Output.(*v3io.GetContainerContentsOutput).CommonPrefixes[0].ShardCount)
+	response.Release()
+
+	suite.Require().NoError(suite.container.DeleteStreamSync(&v3io.DeleteStreamInput{Path: "/stream/"}))
+	_, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/"})
+	suite.Require().Error(err)
+}
+
+func (suite *contextTestSuite) TestDeleteShard() {
+	err := suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 2})
+	suite.Require().NoError(err)
+
+	shardID := 1
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path:    "/stream/",
+		Records: []*v3io.StreamRecord{{ShardID: &shardID, Data: []byte("a")}},
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	// deleting a shard drops its records, but the stream remains until it's deleted
+	suite.Require().NoError(suite.container.DeleteObjectSync(&v3io.DeleteObjectInput{Path: "/stream/1"}))
+
+	response, err = suite.container.SeekShardSync(&v3io.SeekShardInput{
+		Path: "/stream/1",
+		Type: v3io.SeekShardInputTypeEarliest,
+	})
+	suite.Require().NoError(err)
+	location := response.Output.(*v3io.SeekShardOutput).Location
+	response.Release()
+
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/1", Location: location})
+	suite.Require().NoError(err)
+	suite.Require().Empty(response.Output.(*v3io.GetRecordsOutput).Records)
+	response.Release()
+
+	response, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/"})
+	suite.Require().NoError(err)
+	response.Release()
+
+	suite.Require().NoError(suite.container.DeleteStreamSync(&v3io.DeleteStreamInput{Path: "/stream/"}))
+	_, err = suite.container.DescribeStreamSync(&v3io.DescribeStreamInput{Path: "/stream/"})
+	suite.Require().Error(err)
+}
+
+func (suite *contextTestSuite) TestSeekShardByTime() {
+	err := suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 1})
+	suite.Require().NoError(err)
+
+	shardID := 0
+	for _, data := range []string{"a", "b"} {
+		response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+			Path:    "/stream/",
+			Records: []*v3io.StreamRecord{{ShardID: &shardID, Data: []byte(data)}},
+		})
+		suite.Require().NoError(err)
+		response.Release()
+
+		time.Sleep(time.Millisecond)
+	}
+
+	response, err := suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/0", Location: "0", Limit: 2})
+	suite.Require().NoError(err)
+	secondRecord := response.Output.(*v3io.GetRecordsOutput).Records[1]
+	response.Release()
+
+	// seeking to the second record's arrival time skips the first, even within the same second
+	seekShardInput := v3io.SeekShardInput{Path: "/stream/0", Type: v3io.SeekShardInputTypeTime}
+	seekShardInput.SetTime(time.Unix(int64(secondRecord.ArrivalTimeSec), int64(secondRecord.ArrivalTimeNSec)))
+
+	response, err = suite.container.SeekShardSync(&seekShardInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal("1", response.Output.(*v3io.SeekShardOutput).Location)
+	response.Release()
+}
+
+func (suite *contextTestSuite) TestGetRecordsLongPoll() {
+	err := suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 1})
+	suite.Require().NoError(err)
+
+	shardID := 0
+	putRecordsInput := &v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{ShardID: &shardID, Data: []byte("aaa")},
+			{ShardID: &shardID, Data: []byte("bbb")},
+		},
+	}
+
+	// a record put while the request waits is returned by it
+	time.AfterFunc(20*time.Millisecond, func() {
+		response, err := suite.container.PutRecordsSync(putRecordsInput)
+		if err == nil {
+			response.Release()
+		}
+	})
+
+	response, err := suite.container.GetRecordsSync(&v3io.GetRecordsInput{
+		Path:        "/stream/0",
+		Location:    "0",
+		MaxWaitTime: 5 * time.Second,
+		MaxBytes:    4,
+	})
+	suite.Require().NoError(err)
+
+	// the records are bounded by size
+	getRecordsOutput := response.Output.(*v3io.GetRecordsOutput)
+	suite.Require().Len(getRecordsOutput.Records, 1)
+	suite.Require().Equal("1", getRecordsOutput.NextLocation)
+	response.Release()
+
+	// the first record is returned regardless of its size
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/0", Location: "1", MaxBytes: 1})
+	suite.Require().NoError(err)
+	suite.Require().Len(response.Output.(*v3io.GetRecordsOutput).Records, 1)
+	response.Release()
+
+	// with no records put, the request returns an empty batch once the wait time passes
+	response, err = suite.container.GetRecordsSync(&v3io.GetRecordsInput{
+		Path:        "/stream/0",
+		Location:    "2",
+		MaxWaitTime: 10 * time.Millisecond,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Empty(response.Output.(*v3io.GetRecordsOutput).Records)
+	response.Release()
+}
+
+func (suite *contextTestSuite) TestPresignObject() {
+	objectPresigner, ok := suite.container.(v3io.ObjectPresigner)
+	suite.Require().True(ok)
+
+	presignObjectOutput, err := objectPresigner.PresignObject(&v3io.PresignObjectInput{
+		DataPlaneInput: v3io.DataPlaneInput{AccessKey: "key"},
+		Method:         http.MethodGet,
+		Path:           "/dir/a",
+	})
+	suite.Require().NoError(err)
+
+	// the URL is of the container's object, and is signed with the access key
+	presignedURL, err := url.Parse(presignObjectOutput.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal("/bigdata/dir/a", presignedURL.Path)
+	suite.Require().NoError(v3io.VerifyPresignedURL(http.MethodGet, presignedURL, "key", time.Now()))
+}
+
+func (suite *contextTestSuite) TestAsync() {
+	responseChan := make(chan *v3io.Response)
+
+	request, err := suite.container.GetItem(&v3io.GetItemInput{Path: "/missing"}, "ctx", responseChan)
+	suite.Require().NoError(err)
+
+	select {
+	case response := <-responseChan:
+		suite.Require().Equal(request.ID, response.ID)
+		suite.Require().Equal("ctx", response.Context)
+		suite.Require().Error(response.Error)
+	case <-time.After(time.Second):
+		suite.Fail("Timed out waiting for response")
+	}
+}
+
+func TestContextTestSuite(t *testing.T) {
+	suite.Run(t, new(contextTestSuite))
+}
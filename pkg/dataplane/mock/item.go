@@ -0,0 +1,367 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iomock
+
+import (
+	"hash/fnv"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+)
+
+// GetItem
+func (c *Context) GetItem(getItemInput *v3io.GetItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getItemInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetItemSync(getItemInput)
+	})
+}
+
+// GetItemSync
+func (c *Context) GetItemSync(getItemInput *v3io.GetItemInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&getItemInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	itemPath := cleanPath(getItemInput.Path)
+
+	// shards are items whose system attributes describe their records
+	if existingShard, err := c.getShard(&getItemInput.DataPlaneInput, itemPath); err == nil {
+		return newResponse(&v3io.GetItemOutput{
+			Item: existingShard.getItem(container.files[itemPath], path.Base(itemPath), getItemInput.AttributeNames),
+		}), nil
+	}
+
+	item, found := container.files[itemPath]
+	if !found {
+		return nil, newNotFoundError(getItemInput.Path)
+	}
+
+	return newResponse(&v3io.GetItemOutput{
+		Item: item.getItem(path.Base(itemPath), getItemInput.AttributeNames),
+	}), nil
+}
+
+// GetItems
+func (c *Context) GetItems(getItemsInput *v3io.GetItemsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getItemsInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetItemsSync(getItemsInput)
+	})
+}
+
+// GetItemsSync returns the items of the table in order of their names. the sharding key and sort key range
+// are supported, filters aren't
+func (c *Context) GetItemsSync(getItemsInput *v3io.GetItemsInput) (*v3io.Response, error) {
+	if getItemsInput.Filter != "" {
+		return nil, newNotSupportedError("Filter")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&getItemsInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	tablePath := cleanPath(getItemsInput.Path)
+
+	var itemNames []string
+	for itemPath := range container.files {
+		if path.Dir("/"+itemPath) != path.Clean("/"+tablePath) {
+			continue
+		}
+
+		itemName := path.Base(itemPath)
+		if getItemsInput.Marker != "" && itemName <= getItemsInput.Marker {
+			continue
+		}
+
+		if !inSegment(itemName, getItemsInput.Segment, getItemsInput.TotalSegments) ||
+			!inShardingKeyRange(itemName, getItemsInput) {
+			continue
+		}
+
+		itemNames = append(itemNames, itemName)
+	}
+
+	sort.Strings(itemNames)
+
+	getItemsOutput := v3io.GetItemsOutput{Last: true}
+
+	if getItemsInput.Limit > 0 && len(itemNames) > getItemsInput.Limit {
+		itemNames = itemNames[:getItemsInput.Limit]
+		getItemsOutput.Last = false
+		getItemsOutput.NextMarker = itemNames[len(itemNames)-1]
+	}
+
+	for _, itemName := range itemNames {
+		item := container.files[path.Join(tablePath, itemName)]
+		getItemsOutput.Items = append(getItemsOutput.Items, item.getItem(itemName, getItemsInput.AttributeNames))
+	}
+
+	return newResponse(&getItemsOutput), nil
+}
+
+// PutItem
+func (c *Context) PutItem(putItemInput *v3io.PutItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putItemInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.PutItemSync(putItemInput)
+	})
+}
+
+// PutItemSync
+func (c *Context) PutItemSync(putItemInput *v3io.PutItemInput) (*v3io.Response, error) {
+	if putItemInput.Condition != "" {
+		return nil, newNotSupportedError("Condition")
+	}
+
+	if err := putItemInput.UpdateMode.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&putItemInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// puts replace the item's attributes unless told otherwise
+	item := container.setAttributes(cleanPath(putItemInput.Path),
+		putItemInput.Attributes,
+		putItemInput.UpdateMode != v3io.UpdateModeCreateOrReplace)
+
+	return newResponse(&v3io.PutItemOutput{
+		MtimeSecs:  int(item.mtime.Unix()),
+		MtimeNSecs: item.mtime.Nanosecond(),
+	}), nil
+}
+
+// PutItems
+func (c *Context) PutItems(putItemsInput *v3io.PutItemsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putItemsInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.PutItemsSync(putItemsInput)
+	})
+}
+
+// PutItemsSync
+func (c *Context) PutItemsSync(putItemsInput *v3io.PutItemsInput) (*v3io.Response, error) {
+	if putItemsInput.Condition != "" {
+		return nil, newNotSupportedError("Condition")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&putItemsInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	for itemName, attributes := range putItemsInput.Items {
+		container.setAttributes(path.Join(cleanPath(putItemsInput.Path), itemName), attributes, true)
+	}
+
+	return newResponse(&v3io.PutItemsOutput{
+		Success: true,
+		Errors:  map[string]error{},
+	}), nil
+}
+
+// UpdateItem
+func (c *Context) UpdateItem(updateItemInput *v3io.UpdateItemInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(updateItemInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.UpdateItemSync(updateItemInput)
+	})
+}
+
+// UpdateItemSync sets the given attributes, creating the item if it doesn't exist
+func (c *Context) UpdateItemSync(updateItemInput *v3io.UpdateItemInput) (*v3io.Response, error) {
+	if updateItemInput.Expression != nil {
+		return nil, newNotSupportedError("Expression")
+	}
+
+	if updateItemInput.Condition != "" {
+		return nil, newNotSupportedError("Condition")
+	}
+
+	if err := updateItemInput.UpdateMode.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&updateItemInput.DataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	item := container.setAttributes(cleanPath(updateItemInput.Path),
+		updateItemInput.Attributes,
+		updateItemInput.UpdateMode == v3io.UpdateModeOverwrite)
+
+	return newResponse(&v3io.UpdateItemOutput{
+		MtimeSecs:  int(item.mtime.Unix()),
+		MtimeNSecs: item.mtime.Nanosecond(),
+	}), nil
+}
+
+func (cs *containerState) setAttributes(itemPath string, attributes map[string]interface{}, replace bool) *file {
+	item := cs.getOrCreateFile(itemPath)
+
+	if replace {
+		item.attributes = map[string]interface{}{}
+	}
+
+	for attributeName, attributeValue := range attributes {
+		item.attributes[attributeName] = normalizeValue(attributeValue)
+	}
+
+	item.mtime = time.Now()
+
+	return item
+}
+
+// returns the requested attributes of the item. "*" stands for all the user attributes, and "**" for all
+// the attributes including the system ones
+func (f *file) getItem(itemName string, attributeNames []string) v3io.Item {
+	if len(attributeNames) == 0 {
+		attributeNames = []string{"*"}
+	}
+
+	systemAttributes := map[string]interface{}{
+		"__name":        itemName,
+		"__size":        len(f.data),
+		"__mtime_secs":  int(f.mtime.Unix()),
+		"__mtime_nsecs": f.mtime.Nanosecond(),
+		"__ctime_secs":  int(f.ctime.Unix()),
+		"__ctime_nsecs": f.ctime.Nanosecond(),
+	}
+
+	item := v3io.Item{}
+
+	for _, attributeName := range attributeNames {
+		switch attributeName {
+		case "*", "**":
+			for userAttributeName, attributeValue := range f.attributes {
+				item[userAttributeName] = copyValue(attributeValue)
+			}
+
+			if attributeName == "**" {
+				for systemAttributeName, attributeValue := range systemAttributes {
+					item[systemAttributeName] = attributeValue
+				}
+			}
+		default:
+			if attributeValue, found := f.attributes[attributeName]; found {
+				item[attributeName] = copyValue(attributeValue)
+			} else if attributeValue, found := systemAttributes[attributeName]; found {
+				item[attributeName] = attributeValue
+			}
+		}
+	}
+
+	return item
+}
+
+// converts values to the types the http context decodes them to
+func normalizeValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case int8:
+		return int(typedValue)
+	case int16:
+		return int(typedValue)
+	case int32:
+		return int(typedValue)
+	case int64:
+		return int(typedValue)
+	case uint8:
+		return int(typedValue)
+	case uint16:
+		return int(typedValue)
+	case uint32:
+		return int(typedValue)
+	case uint64:
+		return int(typedValue)
+	case uint:
+		return int(typedValue)
+	case float32:
+		return float64(typedValue)
+	default:
+		return copyValue(value)
+	}
+}
+
+// copies byte slices, so that the mock's state can't be changed through values passed to or from it
+func copyValue(value interface{}) interface{} {
+	if bytesValue, ok := value.([]byte); ok {
+		return append([]byte(nil), bytesValue...)
+	}
+
+	return value
+}
+
+func inSegment(itemName string, segment int, totalSegments int) bool {
+	if totalSegments <= 1 {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(itemName)) // nolint: errcheck
+
+	return int(hash.Sum32()%uint32(totalSegments)) == segment
+}
+
+// items of sharded tables are named <sharding key>.<sort key>
+func inShardingKeyRange(itemName string, getItemsInput *v3io.GetItemsInput) bool {
+	if getItemsInput.ShardingKey == "" {
+		return true
+	}
+
+	prefix := getItemsInput.ShardingKey + "."
+	if !strings.HasPrefix(itemName, prefix) {
+		return false
+	}
+
+	sortKey := strings.TrimPrefix(itemName, prefix)
+
+	return (getItemsInput.SortKeyRangeStart == "" || sortKey >= getItemsInput.SortKeyRangeStart) &&
+		(getItemsInput.SortKeyRangeEnd == "" || sortKey < getItemsInput.SortKeyRangeEnd)
+}
@@ -0,0 +1,511 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3iomock
+
+import (
+	"hash/fnv"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// how often a get records request waiting for records checks the shard
+const getRecordsPollInterval = 5 * time.Millisecond
+
+const secondsInHour = 60 * 60
+
+type stream struct {
+	retentionPeriodSeconds int
+	shards                 []*shard
+	nextShardID            int // for records without a shard ID or partition key
+}
+
+// the sequence number of a record is its index in the shard plus one. locations are record indexes
+type shard struct {
+	records []v3io.GetRecordsResult
+}
+
+// returns the requested attributes of the shard, along with those set on it as an item (e.g. committed
+// sequence numbers of consumer groups). the mtime of a shard is the arrival time of its last record
+func (s *shard) getItem(shardFile *file, itemName string, attributeNames []string) v3io.Item {
+	if shardFile == nil {
+		shardFile = &file{attributes: map[string]interface{}{}}
+	}
+
+	shardFile = &file{
+		attributes: shardFile.attributes,
+		mtime:      shardFile.mtime,
+		ctime:      shardFile.ctime,
+	}
+
+	if len(s.records) > 0 {
+		lastRecord := s.records[len(s.records)-1]
+		shardFile.mtime = time.Unix(int64(lastRecord.ArrivalTimeSec), int64(lastRecord.ArrivalTimeNSec))
+	}
+
+	item := shardFile.getItem(itemName, attributeNames)
+
+	for _, attributeName := range attributeNames {
+		if attributeName == "**" || attributeName == "__last_sequence_num" {
+			item["__last_sequence_num"] = len(s.records)
+		}
+	}
+
+	return item
+}
+
+// CreateStream
+func (c *Context) CreateStream(createStreamInput *v3io.CreateStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(createStreamInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.CreateStreamSync(createStreamInput)
+	})
+}
+
+// CreateStreamSync
+func (c *Context) CreateStreamSync(createStreamInput *v3io.CreateStreamInput) error {
+	if createStreamInput.ShardCount < 1 {
+		return v3ioerrors.NewErrorWithStatusCode(errors.New("Shard count must be positive"), http.StatusBadRequest)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	container, err := c.getContainer(&createStreamInput.DataPlaneInput)
+	if err != nil {
+		return err
+	}
+
+	streamPath := cleanPath(createStreamInput.Path)
+	if _, found := container.files[streamPath]; found || container.isDirectory(streamPath) {
+		return v3ioerrors.NewErrorWithStatusCode(errors.Errorf("%s already exists", createStreamInput.Path),
+			http.StatusConflict)
+	}
+
+	newStream := &stream{
+		retentionPeriodSeconds: createStreamInput.RetentionPeriodSeconds,
+	}
+
+	if createStreamInput.RetentionPeriodHours != 0 {
+		newStream.retentionPeriodSeconds = createStreamInput.RetentionPeriodHours * secondsInHour
+	}
+
+	for shardIdx := 0; shardIdx < createStreamInput.ShardCount; shardIdx++ {
+		newStream.shards = append(newStream.shards, &shard{})
+	}
+
+	container.streams[streamPath] = newStream
+
+	return nil
+}
+
+// DescribeStream
+func (c *Context) DescribeStream(describeStreamInput *v3io.DescribeStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(describeStreamInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.DescribeStreamSync(describeStreamInput)
+	})
+}
+
+// DescribeStreamSync
+func (c *Context) DescribeStreamSync(describeStreamInput *v3io.DescribeStreamInput) (*v3io.Response, error) {
+	describeStreamOutput, err := c.describeStream(describeStreamInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// shards are described through the context's own requests, which take the lock
+	if describeStreamInput.IncludeShards {
+		describeStreamOutput.Shards, err = v3io.DescribeShards(c,
+			&describeStreamInput.DataPlaneInput,
+			describeStreamInput.Path,
+			describeStreamOutput.ShardCount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newResponse(describeStreamOutput), nil
+}
+
+func (c *Context) describeStream(describeStreamInput *v3io.DescribeStreamInput) (*v3io.DescribeStreamOutput, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	existingStream, err := c.getStream(&describeStreamInput.DataPlaneInput, describeStreamInput.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v3io.DescribeStreamOutput{
+		ShardCount:           len(existingStream.shards),
+		RetentionPeriodHours: existingStream.getRetentionPeriodHours(),
+	}, nil
+}
+
+// UpdateStream
+func (c *Context) UpdateStream(updateStreamInput *v3io.UpdateStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(updateStreamInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.UpdateStreamSync(updateStreamInput)
+	})
+}
+
+// UpdateStreamSync
+func (c *Context) UpdateStreamSync(updateStreamInput *v3io.UpdateStreamInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	existingStream, err := c.getStream(&updateStreamInput.DataPlaneInput, updateStreamInput.Path)
+	if err != nil {
+		return err
+	}
+
+	if updateStreamInput.ShardCount != 0 && updateStreamInput.ShardCount < len(existingStream.shards) {
+		return v3ioerrors.NewErrorWithStatusCode(errors.Errorf("Shard count can't be decreased from %d to %d",
+			len(existingStream.shards),
+			updateStreamInput.ShardCount), http.StatusBadRequest)
+	}
+
+	for len(existingStream.shards) < updateStreamInput.ShardCount {
+		existingStream.shards = append(existingStream.shards, &shard{})
+	}
+
+	if updateStreamInput.RetentionPeriodHours != 0 {
+		existingStream.retentionPeriodSeconds = updateStreamInput.RetentionPeriodHours * secondsInHour
+	}
+
+	return nil
+}
+
+// DeleteStream
+func (c *Context) DeleteStream(deleteStreamInput *v3io.DeleteStreamInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(deleteStreamInput, context, responseChan, func() (*v3io.Response, error) {
+		return newResponse(nil), c.DeleteStreamSync(deleteStreamInput)
+	})
+}
+
+// DeleteStreamSync
+func (c *Context) DeleteStreamSync(deleteStreamInput *v3io.DeleteStreamInput) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, err := c.getStream(&deleteStreamInput.DataPlaneInput, deleteStreamInput.Path); err != nil {
+		return err
+	}
+
+	delete(c.containers[deleteStreamInput.ContainerName].streams, cleanPath(deleteStreamInput.Path))
+
+	return nil
+}
+
+// PutRecords
+func (c *Context) PutRecords(putRecordsInput *v3io.PutRecordsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putRecordsInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.PutRecordsSync(putRecordsInput)
+	})
+}
+
+// PutRecordsSync adds the records to the shard given by their shard ID, or else by a hash of their partition
+// key, or else round robin
+func (c *Context) PutRecordsSync(putRecordsInput *v3io.PutRecordsInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	existingStream, err := c.getStream(&putRecordsInput.DataPlaneInput, putRecordsInput.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	putRecordsOutput := v3io.PutRecordsOutput{}
+	arrivalTime := time.Now()
+
+	for _, record := range putRecordsInput.Records {
+		shardID := existingStream.getShardID(record)
+		if shardID < 0 || shardID >= len(existingStream.shards) {
+			putRecordsOutput.FailedRecordCount++
+			putRecordsOutput.Records = append(putRecordsOutput.Records, v3io.PutRecordResult{
+				ShardID:      shardID,
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Invalid shard ID",
+			})
+
+			continue
+		}
+
+		recordShard := existingStream.shards[shardID]
+		sequenceNumber := uint64(len(recordShard.records) + 1)
+
+		recordShard.records = append(recordShard.records, v3io.GetRecordsResult{
+			ArrivalTimeSec:  int(arrivalTime.Unix()),
+			ArrivalTimeNSec: arrivalTime.Nanosecond(),
+			SequenceNumber:  sequenceNumber,
+			ClientInfo:      append([]byte(nil), record.ClientInfo...),
+			PartitionKey:    record.PartitionKey,
+			Data:            append([]byte(nil), record.Data...),
+		})
+
+		putRecordsOutput.Records = append(putRecordsOutput.Records, v3io.PutRecordResult{
+			SequenceNumber: sequenceNumber,
+			ShardID:        shardID,
+		})
+	}
+
+	return newResponse(&putRecordsOutput), nil
+}
+
+// SeekShard
+func (c *Context) SeekShard(seekShardInput *v3io.SeekShardInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(seekShardInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.SeekShardSync(seekShardInput)
+	})
+}
+
+// SeekShardSync
+func (c *Context) SeekShardSync(seekShardInput *v3io.SeekShardInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	existingShard, err := c.getShard(&seekShardInput.DataPlaneInput, seekShardInput.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIdx := 0
+
+	switch seekShardInput.Type {
+	case v3io.SeekShardInputTypeEarliest:
+	case v3io.SeekShardInputTypeLatest:
+		recordIdx = len(existingShard.records)
+	case v3io.SeekShardInputTypeSequence:
+		for recordIdx < len(existingShard.records) &&
+			existingShard.records[recordIdx].SequenceNumber < seekShardInput.StartingSequenceNumber {
+			recordIdx++
+		}
+	case v3io.SeekShardInputTypeTime:
+		for recordIdx < len(existingShard.records) && arrivedBefore(&existingShard.records[recordIdx],
+			seekShardInput.Timestamp,
+			seekShardInput.TimestampNanoseconds) {
+			recordIdx++
+		}
+	default:
+		return nil, errors.Errorf("Invalid seek type: %d", seekShardInput.Type)
+	}
+
+	return newResponse(&v3io.SeekShardOutput{
+		Location: strconv.Itoa(recordIdx),
+	}), nil
+}
+
+// GetRecords
+func (c *Context) GetRecords(getRecordsInput *v3io.GetRecordsInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(getRecordsInput, context, responseChan, func() (*v3io.Response, error) {
+		return c.GetRecordsSync(getRecordsInput)
+	})
+}
+
+// GetRecordsSync
+func (c *Context) GetRecordsSync(getRecordsInput *v3io.GetRecordsInput) (*v3io.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	existingShard, err := c.getShard(&getRecordsInput.DataPlaneInput, getRecordsInput.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIdx, err := strconv.Atoi(getRecordsInput.Location)
+	if err != nil || recordIdx < 0 || recordIdx > len(existingShard.records) {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.Errorf("Invalid location: %s", getRecordsInput.Location),
+			http.StatusBadRequest)
+	}
+
+	// at the end of the shard, wait for records to be put
+	if recordIdx == len(existingShard.records) && getRecordsInput.MaxWaitTime > 0 {
+		existingShard, err = c.waitForRecords(&getRecordsInput.DataPlaneInput,
+			getRecordsInput.Path,
+			recordIdx,
+			getRecordsInput.MaxWaitTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endRecordIdx := len(existingShard.records)
+	if getRecordsInput.Limit > 0 && recordIdx+getRecordsInput.Limit < endRecordIdx {
+		endRecordIdx = recordIdx + getRecordsInput.Limit
+	}
+
+	if getRecordsInput.MaxBytes > 0 {
+		numBytes := 0
+		for limitedRecordIdx := recordIdx; limitedRecordIdx < endRecordIdx; limitedRecordIdx++ {
+			numBytes += len(existingShard.records[limitedRecordIdx].Data)
+
+			// the first record is returned regardless of its size
+			if numBytes > getRecordsInput.MaxBytes && limitedRecordIdx > recordIdx {
+				endRecordIdx = limitedRecordIdx
+				break
+			}
+		}
+	}
+
+	getRecordsOutput := v3io.GetRecordsOutput{
+		NextLocation:        strconv.Itoa(endRecordIdx),
+		RecordsBehindLatest: len(existingShard.records) - endRecordIdx,
+	}
+
+	for _, record := range existingShard.records[recordIdx:endRecordIdx] {
+		record.ClientInfo = append([]byte(nil), record.ClientInfo...)
+		record.Data = append([]byte(nil), record.Data...)
+		getRecordsOutput.Records = append(getRecordsOutput.Records, record)
+	}
+
+	if getRecordsOutput.RecordsBehindLatest > 0 {
+		nextRecord := existingShard.records[endRecordIdx]
+		nextRecordArrivalTime := time.Unix(int64(nextRecord.ArrivalTimeSec), int64(nextRecord.ArrivalTimeNSec))
+		getRecordsOutput.MSecBehindLatest = int(time.Since(nextRecordArrivalTime) / time.Millisecond)
+	}
+
+	return newResponse(&getRecordsOutput), nil
+}
+
+// polls the shard until it has more than numRecords records, the wait time passes or the request's context
+// is done. returns the shard as it was last seen. must be called with the lock held, which is released while
+// waiting
+func (c *Context) waitForRecords(dataPlaneInput *v3io.DataPlaneInput,
+	shardPath string,
+	numRecords int,
+	maxWaitTime time.Duration) (*shard, error) {
+	deadline := time.Now().Add(maxWaitTime)
+
+	for {
+		existingShard, err := c.getShard(dataPlaneInput, shardPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(existingShard.records) > numRecords || !time.Now().Before(deadline) {
+			return existingShard, nil
+		}
+
+		if dataPlaneInput.Ctx != nil && dataPlaneInput.Ctx.Err() != nil {
+			return existingShard, nil
+		}
+
+		c.lock.Unlock()
+		time.Sleep(getRecordsPollInterval)
+		c.lock.Lock()
+	}
+}
+
+// PutChunk is not supported
+func (c *Context) PutChunk(putChunkInput *v3io.PutChunkInput,
+	context interface{},
+	responseChan chan *v3io.Response) (*v3io.Request, error) {
+	return c.sendAsync(putChunkInput, context, responseChan, func() (*v3io.Response, error) {
+		return nil, c.PutChunkSync(putChunkInput)
+	})
+}
+
+// PutChunkSync is not supported
+func (c *Context) PutChunkSync(putChunkInput *v3io.PutChunkInput) error {
+	return newNotSupportedError("PutChunk")
+}
+
+// must be called with the lock held
+func (c *Context) getStream(dataPlaneInput *v3io.DataPlaneInput, streamPath string) (*stream, error) {
+	container, err := c.getContainer(dataPlaneInput)
+	if err != nil {
+		return nil, err
+	}
+
+	existingStream, found := container.streams[cleanPath(streamPath)]
+	if !found {
+		return nil, newNotFoundError(streamPath)
+	}
+
+	return existingStream, nil
+}
+
+// shards are addressed as <stream path>/<shard ID>. must be called with the lock held
+func (c *Context) getShard(dataPlaneInput *v3io.DataPlaneInput, shardPath string) (*shard, error) {
+	shardPath = cleanPath(shardPath)
+
+	existingStream, err := c.getStream(dataPlaneInput, path.Dir(shardPath))
+	if err != nil {
+		return nil, err
+	}
+
+	shardID, err := strconv.Atoi(path.Base(shardPath))
+	if err != nil || shardID < 0 || shardID >= len(existingStream.shards) {
+		return nil, newNotFoundError(shardPath)
+	}
+
+	return existingStream.shards[shardID], nil
+}
+
+func arrivedBefore(record *v3io.GetRecordsResult, timestampSec int, timestampNSec int) bool {
+	if record.ArrivalTimeSec != timestampSec {
+		return record.ArrivalTimeSec < timestampSec
+	}
+
+	return record.ArrivalTimeNSec < timestampNSec
+}
+
+// retention periods set in seconds are rounded up to whole hours
+func (s *stream) getRetentionPeriodHours() int {
+	return (s.retentionPeriodSeconds + secondsInHour - 1) / secondsInHour
+}
+
+func (s *stream) getShardID(record *v3io.StreamRecord) int {
+	if record.ShardID != nil {
+		return *record.ShardID
+	}
+
+	if record.PartitionKey != "" {
+		hash := fnv.New32a()
+		hash.Write([]byte(record.PartitionKey)) // nolint: errcheck
+
+		return int(hash.Sum32() % uint32(len(s.shards)))
+	}
+
+	shardID := s.nextShardID
+	s.nextShardID = (s.nextShardID + 1) % len(s.shards)
+
+	return shardID
+}
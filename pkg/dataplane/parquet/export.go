@@ -0,0 +1,255 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+// Package v3ioparquet writes table scans as parquet files
+package v3ioparquet
+
+import (
+	"io"
+	"sync"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioarrow "github.com/v3io/v3io-go/pkg/dataplane/arrow"
+
+	"github.com/nuclio/errors"
+)
+
+type ExportToParquetInput struct {
+	v3io.DataPlaneInput
+	Path           string
+	Filter         string
+	AttributeNames []string // defaults to the name and all user attributes
+
+	// the number of segments the table is scanned in concurrently (defaults to 1). the items of the segments
+	// are interleaved in the file
+	TotalSegments int
+
+	// the number of items per row group (defaults to 10000)
+	RowGroupSize int
+}
+
+type ExportToParquetOutput struct {
+	NumItems     int
+	NumRowGroups int
+	Schema       *v3ioarrow.Schema
+}
+
+// ExportToParquet writes the items of a table as a parquet file, a row group at a time. the column types are
+// derived from the attributes of the first row group, so attributes first seen after it, or seen with other
+// types, fail the export. set the attribute names to export a known set of attributes
+func ExportToParquet(container v3io.Container,
+	exportToParquetInput *ExportToParquetInput,
+	writer io.Writer) (*ExportToParquetOutput, error) {
+
+	rowGroupSize := exportToParquetInput.RowGroupSize
+	if rowGroupSize <= 0 {
+		rowGroupSize = 10000
+	}
+
+	itemsChan := make(chan v3io.Item, rowGroupSize)
+	doneChan := make(chan struct{})
+	scanErrChan := make(chan error, 1)
+
+	go func() {
+		scanErrChan <- scanSegments(container, exportToParquetInput, itemsChan, doneChan)
+		close(itemsChan)
+	}()
+
+	exportToParquetOutput, err := writeItems(itemsChan, rowGroupSize, writer)
+
+	// stop the scan if the write failed, and wait for it
+	close(doneChan)
+	for range itemsChan {
+	}
+
+	if scanErr := <-scanErrChan; scanErr != nil {
+		return nil, errors.Wrapf(scanErr, "Failed to scan table %s", exportToParquetInput.Path)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to export table %s", exportToParquetInput.Path)
+	}
+
+	return exportToParquetOutput, nil
+}
+
+func writeItems(itemsChan <-chan v3io.Item, rowGroupSize int, writer io.Writer) (*ExportToParquetOutput, error) {
+	exportToParquetOutput := ExportToParquetOutput{}
+	var parquetWriter *Writer
+	var fieldNames map[string]bool
+	var items []v3io.Item
+
+	createWriter := func() error {
+		schema, err := v3ioarrow.InferSchema(items)
+		if err != nil {
+			return err
+		}
+
+		// the following row groups may not have all of the attributes
+		fieldNames = map[string]bool{}
+		for fieldIdx := range schema.Fields {
+			schema.Fields[fieldIdx].Nullable = true
+			fieldNames[schema.Fields[fieldIdx].Name] = true
+		}
+
+		parquetWriter, err = NewWriter(writer, schema)
+		if err != nil {
+			return err
+		}
+
+		exportToParquetOutput.Schema = schema
+
+		return nil
+	}
+
+	writeRowGroup := func() error {
+		if parquetWriter == nil {
+			if err := createWriter(); err != nil {
+				return err
+			}
+		}
+
+		// fail items with attributes which aren't in the schema, rather than drop them silently
+		for _, item := range items {
+			for attributeName, attributeValue := range item {
+				if attributeValue != nil && !fieldNames[attributeName] {
+					return errors.Errorf("Attribute %s isn't in the schema derived from the first row group",
+						attributeName)
+				}
+			}
+		}
+
+		recordBatch, err := v3ioarrow.NewRecordBatch(parquetWriter.schema, items)
+		if err != nil {
+			return err
+		}
+
+		if err := parquetWriter.WriteRecordBatch(recordBatch); err != nil {
+			return err
+		}
+
+		exportToParquetOutput.NumItems += len(items)
+		exportToParquetOutput.NumRowGroups++
+		items = items[:0]
+
+		return nil
+	}
+
+	for item := range itemsChan {
+		items = append(items, item)
+
+		if len(items) == rowGroupSize {
+			if err := writeRowGroup(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(items) > 0 {
+		if err := writeRowGroup(); err != nil {
+			return nil, err
+		}
+	}
+
+	// an empty table is written as a file with no columns or row groups
+	if parquetWriter == nil {
+		if err := createWriter(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parquetWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return &exportToParquetOutput, nil
+}
+
+// scans the segments of the table concurrently, passing their items to the channel until done is closed
+func scanSegments(container v3io.Container,
+	exportToParquetInput *ExportToParquetInput,
+	itemsChan chan<- v3io.Item,
+	doneChan <-chan struct{}) error {
+
+	totalSegments := exportToParquetInput.TotalSegments
+	if totalSegments <= 0 {
+		totalSegments = 1
+	}
+
+	attributeNames := exportToParquetInput.AttributeNames
+	if len(attributeNames) == 0 {
+		attributeNames = []string{"__name", "*"}
+	}
+
+	var waitGroup sync.WaitGroup
+	errChan := make(chan error, totalSegments)
+
+	for segment := 0; segment < totalSegments; segment++ {
+		getItemsInput := v3io.GetItemsInput{
+			DataPlaneInput: exportToParquetInput.DataPlaneInput,
+			Path:           exportToParquetInput.Path,
+			AttributeNames: attributeNames,
+			Filter:         exportToParquetInput.Filter,
+		}
+
+		if totalSegments > 1 {
+			getItemsInput.Segment = segment
+			getItemsInput.TotalSegments = totalSegments
+		}
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			if err := scanSegment(container, &getItemsInput, itemsChan, doneChan); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+	close(errChan)
+
+	return <-errChan
+}
+
+func scanSegment(container v3io.Container,
+	getItemsInput *v3io.GetItemsInput,
+	itemsChan chan<- v3io.Item,
+	doneChan <-chan struct{}) error {
+
+	itemsCursor, err := v3io.NewItemsCursor(container, getItemsInput)
+	if err != nil {
+		return err
+	}
+
+	defer itemsCursor.Release()
+
+	for itemsCursor.NextSync() {
+		select {
+		case itemsChan <- itemsCursor.GetItem():
+		case <-doneChan:
+			return nil
+		}
+	}
+
+	return itemsCursor.Err()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioarrow "github.com/v3io/v3io-go/pkg/dataplane/arrow"
+	v3iomock "github.com/v3io/v3io-go/pkg/dataplane/mock"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type exportSuite struct {
+	suite.Suite
+	container v3io.Container
+}
+
+func (suite *exportSuite) SetupTest() {
+	session, err := v3iomock.NewContext().NewSession(&v3io.NewSessionInput{URL: "http://localhost:8081"})
+	suite.Require().NoError(err)
+
+	suite.container, err = session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+
+	for itemName, attributes := range map[string]map[string]interface{}{
+		"a": {"count": 1, "score": 1.5, "label": "a"},
+		"b": {"count": 2, "label": "bb"},
+		"c": {"count": 3, "score": 3.25},
+	} {
+		_, err := suite.container.PutItemSync(&v3io.PutItemInput{Path: "/table/" + itemName, Attributes: attributes})
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *exportSuite) TestExport() {
+	var buffer bytes.Buffer
+
+	exportToParquetOutput, err := ExportToParquet(suite.container, &ExportToParquetInput{
+		Path:         "/table/",
+		RowGroupSize: 2,
+	}, &buffer)
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, exportToParquetOutput.NumItems)
+	suite.Require().Equal(2, exportToParquetOutput.NumRowGroups)
+	suite.Require().Equal([]v3ioarrow.Field{
+		{Name: "__name", Type: v3ioarrow.TypeUTF8, Nullable: true},
+		{Name: "count", Type: v3ioarrow.TypeInt64, Nullable: true},
+		{Name: "label", Type: v3ioarrow.TypeUTF8, Nullable: true},
+		{Name: "score", Type: v3ioarrow.TypeFloat64, Nullable: true},
+	}, exportToParquetOutput.Schema.Fields)
+
+	// the file starts and ends with the magic, preceded by the length of the metadata
+	file := buffer.Bytes()
+	suite.Require().Equal(magic, file[:4])
+	suite.Require().Equal(magic, file[len(file)-4:])
+
+	metadataLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	suite.Require().True(metadataLength < len(file)-12)
+
+	// the first column chunk follows the magic: a page header and the name column's page
+	pageHeader := encodePageHeader(4+2+(4+1)*2, 2)
+	suite.Require().Equal(pageHeader, file[4:4+len(pageHeader)])
+
+	page := file[4+len(pageHeader):]
+	suite.Require().Equal([]byte{2, 0, 0, 0, 3, 3}, page[:6])
+	suite.Require().Equal([]byte{1, 0, 0, 0, 'a', 1, 0, 0, 0, 'b'}, page[6:16])
+}
+
+func (suite *exportSuite) TestAttributeAddedAfterFirstRowGroup() {
+	_, err := suite.container.PutItemSync(&v3io.PutItemInput{
+		Path:       "/table/d",
+		Attributes: map[string]interface{}{"other": 1},
+	})
+	suite.Require().NoError(err)
+
+	_, err = ExportToParquet(suite.container, &ExportToParquetInput{
+		Path:         "/table/",
+		RowGroupSize: 1,
+	}, &bytes.Buffer{})
+	suite.Require().Error(err)
+}
+
+func (suite *exportSuite) TestThriftEncoder() {
+	encoder := thriftEncoder{}
+	encoder.beginStruct()
+	encoder.writeI32Field(1, -1)
+	encoder.writeBoolField(3, true)
+	encoder.writeStringField(20, "ab")
+	encoder.writeListField(21, thriftTypeI32, 15)
+	for i := 0; i < 15; i++ {
+		encoder.writeI32(1)
+	}
+	encoder.endStruct()
+
+	expected := []byte{0x15, 0x01, 0x21, 0x08, 40, 2, 'a', 'b', 0x19, 0xf5, 15}
+	expected = append(expected, bytes.Repeat([]byte{2}, 15)...)
+	expected = append(expected, 0)
+
+	suite.Require().Equal(expected, encoder.buffer)
+}
+
+func TestExportSuite(t *testing.T) {
+	suite.Run(t, new(exportSuite))
+}
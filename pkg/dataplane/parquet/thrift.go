@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioparquet
+
+import (
+	"encoding/binary"
+)
+
+// the types of the thrift compact protocol
+const (
+	thriftTypeBoolTrue  = 1
+	thriftTypeBoolFalse = 2
+	thriftTypeI32       = 5
+	thriftTypeI64       = 6
+	thriftTypeBinary    = 8
+	thriftTypeList      = 9
+	thriftTypeStruct    = 12
+)
+
+// encodes parquet's thrift structures with the compact protocol. fields must be written in increasing order
+// of their IDs within each struct
+type thriftEncoder struct {
+	buffer       []byte
+	lastFieldIDs []int16
+}
+
+func (te *thriftEncoder) beginStruct() {
+	te.lastFieldIDs = append(te.lastFieldIDs, 0)
+}
+
+func (te *thriftEncoder) endStruct() {
+	te.buffer = append(te.buffer, 0)
+	te.lastFieldIDs = te.lastFieldIDs[:len(te.lastFieldIDs)-1]
+}
+
+func (te *thriftEncoder) writeFieldHeader(fieldID int16, fieldType byte) {
+	lastFieldID := &te.lastFieldIDs[len(te.lastFieldIDs)-1]
+
+	if delta := fieldID - *lastFieldID; delta > 0 && delta <= 15 {
+		te.buffer = append(te.buffer, byte(delta)<<4|fieldType)
+	} else {
+		te.buffer = append(te.buffer, fieldType)
+		te.writeVarint(int64(fieldID))
+	}
+
+	*lastFieldID = fieldID
+}
+
+func (te *thriftEncoder) writeI32Field(fieldID int16, value int32) {
+	te.writeFieldHeader(fieldID, thriftTypeI32)
+	te.writeVarint(int64(value))
+}
+
+func (te *thriftEncoder) writeI64Field(fieldID int16, value int64) {
+	te.writeFieldHeader(fieldID, thriftTypeI64)
+	te.writeVarint(value)
+}
+
+func (te *thriftEncoder) writeBoolField(fieldID int16, value bool) {
+	if value {
+		te.writeFieldHeader(fieldID, thriftTypeBoolTrue)
+	} else {
+		te.writeFieldHeader(fieldID, thriftTypeBoolFalse)
+	}
+}
+
+func (te *thriftEncoder) writeStringField(fieldID int16, value string) {
+	te.writeFieldHeader(fieldID, thriftTypeBinary)
+	te.writeString(value)
+}
+
+func (te *thriftEncoder) writeStructField(fieldID int16) {
+	te.writeFieldHeader(fieldID, thriftTypeStruct)
+	te.beginStruct()
+}
+
+func (te *thriftEncoder) writeListField(fieldID int16, elementType byte, size int) {
+	te.writeFieldHeader(fieldID, thriftTypeList)
+
+	if size < 15 {
+		te.buffer = append(te.buffer, byte(size)<<4|elementType)
+	} else {
+		te.buffer = append(te.buffer, 0xf0|elementType)
+		te.writeUvarint(uint64(size))
+	}
+}
+
+func (te *thriftEncoder) writeI32(value int32) {
+	te.writeVarint(int64(value))
+}
+
+func (te *thriftEncoder) writeString(value string) {
+	te.writeUvarint(uint64(len(value)))
+	te.buffer = append(te.buffer, value...)
+}
+
+// integers are zigzag encoded varints
+func (te *thriftEncoder) writeVarint(value int64) {
+	te.writeUvarint(uint64((value << 1) ^ (value >> 63)))
+}
+
+func (te *thriftEncoder) writeUvarint(value uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	te.buffer = append(te.buffer, encoded[:binary.PutUvarint(encoded[:], value)]...)
+}
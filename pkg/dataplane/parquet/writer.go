@@ -0,0 +1,384 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioparquet
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	v3ioarrow "github.com/v3io/v3io-go/pkg/dataplane/arrow"
+
+	"github.com/nuclio/errors"
+)
+
+var magic = []byte("PAR1")
+
+// parquet's enums
+const (
+	parquetTypeBoolean   = 0
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	convertedTypeUTF8            = 0
+	convertedTypeTimestampMicros = 10
+
+	repetitionTypeOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeDataPage = 0
+
+	compressionCodecUncompressed = 0
+)
+
+// Writer writes record batches as the row groups of a parquet file. all columns are optional, and their
+// pages are plain encoded and uncompressed
+type Writer struct {
+	writer    io.Writer
+	schema    *v3ioarrow.Schema
+	offset    int64
+	numRows   int64
+	rowGroups []*rowGroup
+	closed    bool
+}
+
+type rowGroup struct {
+	numRows      int64
+	totalSize    int64
+	columnChunks []*columnChunk
+}
+
+type columnChunk struct {
+	parquetType    int32
+	numValues      int64
+	totalSize      int64
+	dataPageOffset int64
+}
+
+// NewWriter creates a writer of a parquet file with the schema, writing the file's header
+func NewWriter(writer io.Writer, schema *v3ioarrow.Schema) (*Writer, error) {
+	newWriter := Writer{
+		writer: writer,
+		schema: schema,
+	}
+
+	for _, field := range schema.Fields {
+		if _, _, err := getParquetType(field.Type); err != nil {
+			return nil, errors.Wrapf(err, "Failed to map the type of field %s", field.Name)
+		}
+	}
+
+	if err := newWriter.write(magic); err != nil {
+		return nil, err
+	}
+
+	return &newWriter, nil
+}
+
+// WriteRecordBatch writes the record batch as a row group. the batch must have the writer's schema
+func (w *Writer) WriteRecordBatch(recordBatch *v3ioarrow.RecordBatch) error {
+	if w.closed {
+		return errors.New("Writer is closed")
+	}
+
+	if len(recordBatch.Columns) != len(w.schema.Fields) {
+		return errors.Errorf("Expected %d columns, got %d", len(w.schema.Fields), len(recordBatch.Columns))
+	}
+
+	newRowGroup := rowGroup{
+		numRows: int64(recordBatch.NumRows),
+	}
+
+	for fieldIdx, field := range w.schema.Fields {
+		column := recordBatch.Columns[fieldIdx]
+		if column.Name != field.Name || column.Type != field.Type {
+			return errors.Errorf("Expected column %s of type %s, got column %s of type %s",
+				field.Name,
+				field.Type,
+				column.Name,
+				column.Type)
+		}
+
+		newColumnChunk, err := w.writeColumnChunk(column)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to write column %s", field.Name)
+		}
+
+		newRowGroup.totalSize += newColumnChunk.totalSize
+		newRowGroup.columnChunks = append(newRowGroup.columnChunks, newColumnChunk)
+	}
+
+	w.rowGroups = append(w.rowGroups, &newRowGroup)
+	w.numRows += newRowGroup.numRows
+
+	return nil
+}
+
+// Close writes the file's footer. it doesn't close the underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	fileMetadata := w.encodeFileMetadata()
+
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, uint32(len(fileMetadata)))
+
+	for _, buffer := range [][]byte{fileMetadata, footer, magic} {
+		if err := w.write(buffer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writes the column as a single data page
+func (w *Writer) writeColumnChunk(column *v3ioarrow.Column) (*columnChunk, error) {
+	parquetType, _, err := getParquetType(column.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	definitionLevels := encodeDefinitionLevels(column)
+	values := encodeValues(column)
+
+	pageBody := make([]byte, 4, 4+len(definitionLevels)+len(values))
+	binary.LittleEndian.PutUint32(pageBody, uint32(len(definitionLevels)))
+	pageBody = append(pageBody, definitionLevels...)
+	pageBody = append(pageBody, values...)
+
+	pageHeader := encodePageHeader(len(pageBody), column.Length)
+
+	newColumnChunk := columnChunk{
+		parquetType:    parquetType,
+		numValues:      int64(column.Length),
+		totalSize:      int64(len(pageHeader) + len(pageBody)),
+		dataPageOffset: w.offset,
+	}
+
+	if err := w.write(pageHeader); err != nil {
+		return nil, err
+	}
+
+	if err := w.write(pageBody); err != nil {
+		return nil, err
+	}
+
+	return &newColumnChunk, nil
+}
+
+func (w *Writer) write(buffer []byte) error {
+	numBytesWritten, err := w.writer.Write(buffer)
+	w.offset += int64(numBytesWritten)
+
+	if err != nil {
+		return errors.Wrap(err, "Failed to write parquet file")
+	}
+
+	return nil
+}
+
+func (w *Writer) encodeFileMetadata() []byte {
+	encoder := thriftEncoder{}
+	encoder.beginStruct()
+	encoder.writeI32Field(1, 1)
+
+	// the schema is flattened, a root element followed by its children
+	encoder.writeListField(2, thriftTypeStruct, len(w.schema.Fields)+1)
+	encoder.beginStruct()
+	encoder.writeStringField(4, "schema")
+	encoder.writeI32Field(5, int32(len(w.schema.Fields)))
+	encoder.endStruct()
+
+	for _, field := range w.schema.Fields {
+		parquetType, convertedType, _ := getParquetType(field.Type)
+
+		encoder.beginStruct()
+		encoder.writeI32Field(1, parquetType)
+		encoder.writeI32Field(3, repetitionTypeOptional)
+		encoder.writeStringField(4, field.Name)
+		if convertedType >= 0 {
+			encoder.writeI32Field(6, convertedType)
+		}
+		encoder.endStruct()
+	}
+
+	encoder.writeI64Field(3, w.numRows)
+
+	encoder.writeListField(4, thriftTypeStruct, len(w.rowGroups))
+	for _, rowGroup := range w.rowGroups {
+		encoder.beginStruct()
+		encoder.writeListField(1, thriftTypeStruct, len(rowGroup.columnChunks))
+
+		for columnChunkIdx, columnChunk := range rowGroup.columnChunks {
+			encoder.beginStruct()
+			encoder.writeI64Field(2, columnChunk.dataPageOffset)
+
+			// column metadata
+			encoder.writeStructField(3)
+			encoder.writeI32Field(1, columnChunk.parquetType)
+			encoder.writeListField(2, thriftTypeI32, 2)
+			encoder.writeI32(encodingPlain)
+			encoder.writeI32(encodingRLE)
+			encoder.writeListField(3, thriftTypeBinary, 1)
+			encoder.writeString(w.schema.Fields[columnChunkIdx].Name)
+			encoder.writeI32Field(4, compressionCodecUncompressed)
+			encoder.writeI64Field(5, columnChunk.numValues)
+			encoder.writeI64Field(6, columnChunk.totalSize)
+			encoder.writeI64Field(7, columnChunk.totalSize)
+			encoder.writeI64Field(9, columnChunk.dataPageOffset)
+			encoder.endStruct()
+
+			encoder.endStruct()
+		}
+
+		encoder.writeI64Field(2, rowGroup.totalSize)
+		encoder.writeI64Field(3, rowGroup.numRows)
+		encoder.endStruct()
+	}
+
+	encoder.writeStringField(6, "v3io-go")
+	encoder.endStruct()
+
+	return encoder.buffer
+}
+
+func encodePageHeader(pageSize int, numValues int) []byte {
+	encoder := thriftEncoder{}
+	encoder.beginStruct()
+	encoder.writeI32Field(1, pageTypeDataPage)
+	encoder.writeI32Field(2, int32(pageSize))
+	encoder.writeI32Field(3, int32(pageSize))
+
+	// data page header
+	encoder.writeStructField(5)
+	encoder.writeI32Field(1, int32(numValues))
+	encoder.writeI32Field(2, encodingPlain)
+	encoder.writeI32Field(3, encodingRLE)
+	encoder.writeI32Field(4, encodingRLE)
+	encoder.endStruct()
+
+	encoder.endStruct()
+
+	return encoder.buffer
+}
+
+// encodes the definition levels - 1 for values, 0 for nulls - with the RLE / bit packed hybrid encoding, as
+// a single bit packed run
+func encodeDefinitionLevels(column *v3ioarrow.Column) []byte {
+	numGroups := (column.Length + 7) / 8
+
+	encoder := thriftEncoder{}
+	encoder.writeUvarint(uint64(numGroups<<1 | 1))
+
+	// a bit per value, least significant bit first, which is how the validity bitmap is laid out
+	if column.Validity != nil {
+		return append(encoder.buffer, column.Validity[:numGroups]...)
+	}
+
+	for valueIdx := 0; valueIdx < column.Length; valueIdx += 8 {
+		numValuesInGroup := column.Length - valueIdx
+		if numValuesInGroup > 8 {
+			numValuesInGroup = 8
+		}
+
+		encoder.buffer = append(encoder.buffer, byte(1<<uint(numValuesInGroup)-1))
+	}
+
+	return encoder.buffer
+}
+
+// plain encodes the values which aren't null
+func encodeValues(column *v3ioarrow.Column) []byte {
+	var values []byte
+	numValues := 0
+
+	for valueIdx := 0; valueIdx < column.Length; valueIdx++ {
+		if column.IsNull(valueIdx) {
+			continue
+		}
+
+		switch column.Type {
+		case v3ioarrow.TypeInt64:
+			values = appendUint64(values, uint64(column.Int64(valueIdx)))
+		case v3ioarrow.TypeTimestamp:
+			values = appendUint64(values, uint64(column.Int64(valueIdx)/1000))
+		case v3ioarrow.TypeFloat64:
+			values = appendUint64(values, math.Float64bits(column.Float64(valueIdx)))
+		case v3ioarrow.TypeBoolean:
+			if numValues%8 == 0 {
+				values = append(values, 0)
+			}
+
+			if column.Bool(valueIdx) {
+				values[numValues/8] |= 1 << uint(numValues%8)
+			}
+		case v3ioarrow.TypeUTF8, v3ioarrow.TypeBinary:
+			value := column.Bytes(valueIdx)
+			values = appendUint32(values, uint32(len(value)))
+			values = append(values, value...)
+		}
+
+		numValues++
+	}
+
+	return values
+}
+
+// returns the parquet type and converted type (or -1 if none) of a column type. timestamps are written in
+// microseconds, which more readers support than nanoseconds
+func getParquetType(columnType v3ioarrow.Type) (int32, int32, error) {
+	switch columnType {
+	case v3ioarrow.TypeInt64:
+		return parquetTypeInt64, -1, nil
+	case v3ioarrow.TypeFloat64:
+		return parquetTypeDouble, -1, nil
+	case v3ioarrow.TypeBoolean:
+		return parquetTypeBoolean, -1, nil
+	case v3ioarrow.TypeUTF8:
+		return parquetTypeByteArray, convertedTypeUTF8, nil
+	case v3ioarrow.TypeBinary:
+		return parquetTypeByteArray, -1, nil
+	case v3ioarrow.TypeTimestamp:
+		return parquetTypeInt64, convertedTypeTimestampMicros, nil
+	default:
+		return 0, 0, errors.Errorf("Unsupported type %s", columnType)
+	}
+}
+
+func appendUint64(buffer []byte, value uint64) []byte {
+	var encoded [8]byte
+	binary.LittleEndian.PutUint64(encoded[:], value)
+	return append(buffer, encoded[:]...)
+}
+
+func appendUint32(buffer []byte, value uint32) []byte {
+	var encoded [4]byte
+	binary.LittleEndian.PutUint32(encoded[:], value)
+	return append(buffer, encoded[:]...)
+}
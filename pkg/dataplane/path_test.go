@@ -0,0 +1,65 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type pathTestSuite struct {
+	suite.Suite
+}
+
+func (suite *pathTestSuite) TestDirectoryPath() {
+	for _, testCase := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "/"},
+		{path: "/", expected: "/"},
+		{path: "a", expected: "a/"},
+		{path: "a/", expected: "a/"},
+		{path: "a//", expected: "a/"},
+		{path: "/a/b", expected: "/a/b/"},
+	} {
+		suite.Require().Equal(testCase.expected, DirectoryPath(testCase.path), testCase.path)
+	}
+}
+
+func (suite *pathTestSuite) TestObjectPath() {
+	for _, testCase := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: ""},
+		{path: "a", expected: "a"},
+		{path: "a/", expected: "a"},
+		{path: "/a/b//", expected: "/a/b"},
+	} {
+		suite.Require().Equal(testCase.expected, ObjectPath(testCase.path), testCase.path)
+	}
+}
+
+func TestPathTestSuite(t *testing.T) {
+	suite.Run(t, new(pathTestSuite))
+}
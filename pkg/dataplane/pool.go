@@ -0,0 +1,197 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"reflect"
+	"sync"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// NewContextFunc creates a context for a pool
+type NewContextFunc func() (Context, error)
+
+// Pool shares contexts between users in the same process. A context (and a session over it) is created
+// lazily on the first Acquire for a given URL and set of credentials, and is closed when its last user
+// releases it. all users of a context share its session, so they must pass the same session options
+type Pool struct {
+	lock       sync.Mutex
+	newContext NewContextFunc
+	entries    map[poolKey]*poolEntry
+}
+
+type poolKey struct {
+	url       string
+	username  string
+	password  string
+	accessKey string
+}
+
+type poolEntry struct {
+	context  Context
+	session  Session
+	refCount int
+
+	// the options the session was created with, which later users must match
+	refreshCredentials RefreshCredentialsFunc
+	headers            map[string]string
+}
+
+// PooledContext is a context acquired from a pool. Release must be called once the context is no
+// longer used
+type PooledContext struct {
+	Context Context
+	Session Session
+
+	pool     *Pool
+	key      poolKey
+	released bool
+}
+
+func NewPool(newContext NewContextFunc) *Pool {
+	return &Pool{
+		newContext: newContext,
+		entries:    map[poolKey]*poolEntry{},
+	}
+}
+
+// Acquire returns the context shared by all users of the URL and credentials in the given input,
+// creating it if needed. returns v3ioerrors.ErrConflict if the context exists but its session was created
+// with a different RefreshCredentials function or different Headers
+func (p *Pool) Acquire(newSessionInput *NewSessionInput) (*PooledContext, error) {
+	key := poolKey{
+		url:       newSessionInput.URL,
+		username:  newSessionInput.Username,
+		password:  newSessionInput.Password,
+		accessKey: newSessionInput.AccessKey,
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry, found := p.entries[key]
+	if !found {
+		context, err := p.newContext()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create context")
+		}
+
+		session, err := context.NewSession(newSessionInput)
+		if err != nil {
+			CloseContext(context) // nolint: errcheck
+			return nil, errors.Wrap(err, "Failed to create session")
+		}
+
+		entry = &poolEntry{
+			context:            context,
+			session:            session,
+			refreshCredentials: newSessionInput.RefreshCredentials,
+			headers:            map[string]string{},
+		}
+
+		for headerName, headerValue := range newSessionInput.Headers {
+			entry.headers[headerName] = headerValue
+		}
+
+		p.entries[key] = entry
+	} else if !entry.sessionOptionsEqual(newSessionInput) {
+		return nil, errors.Wrap(v3ioerrors.ErrConflict,
+			"Pooled context was acquired with different RefreshCredentials or Headers")
+	}
+
+	entry.refCount++
+
+	return &PooledContext{
+		Context: entry.context,
+		Session: entry.session,
+		pool:    p,
+		key:     key,
+	}, nil
+}
+
+// refresh functions are compared by their code, as functions aren't comparable
+func (pe *poolEntry) sessionOptionsEqual(newSessionInput *NewSessionInput) bool {
+	if (pe.refreshCredentials == nil) != (newSessionInput.RefreshCredentials == nil) {
+		return false
+	}
+
+	if pe.refreshCredentials != nil &&
+		reflect.ValueOf(pe.refreshCredentials).Pointer() != reflect.ValueOf(newSessionInput.RefreshCredentials).Pointer() {
+		return false
+	}
+
+	if len(pe.headers) != len(newSessionInput.Headers) {
+		return false
+	}
+
+	for headerName, headerValue := range newSessionInput.Headers {
+		if entryHeaderValue, found := pe.headers[headerName]; !found || entryHeaderValue != headerValue {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NumContexts returns the number of contexts currently held by the pool
+func (p *Pool) NumContexts() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.entries)
+}
+
+func (p *Pool) release(key poolKey) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry, found := p.entries[key]
+	if !found {
+		return errors.New("Context not found in pool")
+	}
+
+	entry.refCount--
+	if entry.refCount > 0 {
+		return nil
+	}
+
+	delete(p.entries, key)
+
+	return CloseContext(entry.context)
+}
+
+// Release releases the context, closing it if this was its last user. Releasing more than once
+// has no effect
+func (pc *PooledContext) Release() error {
+	pc.pool.lock.Lock()
+	released := pc.released
+	pc.released = true
+	pc.pool.lock.Unlock()
+
+	if released {
+		return nil
+	}
+
+	return pc.pool.release(pc.key)
+}
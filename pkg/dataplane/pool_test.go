@@ -0,0 +1,149 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3io
+
+import (
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeContext struct {
+	Context
+	closed bool
+}
+
+func (fc *fakeContext) NewSession(*NewSessionInput) (Session, error) {
+	return nil, nil
+}
+
+func (fc *fakeContext) Close() error {
+	fc.closed = true
+	return nil
+}
+
+type poolSuite struct {
+	suite.Suite
+	pool            *Pool
+	createdContexts []*fakeContext
+}
+
+func (suite *poolSuite) SetupTest() {
+	suite.createdContexts = nil
+	suite.pool = NewPool(func() (Context, error) {
+		context := &fakeContext{}
+		suite.createdContexts = append(suite.createdContexts, context)
+		return context, nil
+	})
+}
+
+func (suite *poolSuite) TestShareAndRelease() {
+	newSessionInput := NewSessionInput{URL: "http://webapi:8081", AccessKey: "key"}
+
+	pooledContext1, err := suite.pool.Acquire(&newSessionInput)
+	suite.Require().NoError(err)
+
+	pooledContext2, err := suite.pool.Acquire(&newSessionInput)
+	suite.Require().NoError(err)
+
+	// different credentials get a different context
+	pooledContext3, err := suite.pool.Acquire(&NewSessionInput{URL: "http://webapi:8081", AccessKey: "other"})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(suite.createdContexts, 2)
+	suite.Require().True(pooledContext1.Context == pooledContext2.Context)
+	suite.Require().True(pooledContext1.Context != pooledContext3.Context)
+	suite.Require().Equal(2, suite.pool.NumContexts())
+
+	// releasing twice must not release the context for the other user
+	suite.Require().NoError(pooledContext1.Release())
+	suite.Require().NoError(pooledContext1.Release())
+	suite.Require().False(suite.createdContexts[0].closed)
+
+	suite.Require().NoError(pooledContext2.Release())
+	suite.Require().True(suite.createdContexts[0].closed)
+	suite.Require().False(suite.createdContexts[1].closed)
+	suite.Require().Equal(1, suite.pool.NumContexts())
+
+	// acquiring after the last release creates a new context
+	pooledContext4, err := suite.pool.Acquire(&newSessionInput)
+	suite.Require().NoError(err)
+	suite.Require().Len(suite.createdContexts, 3)
+	suite.Require().NoError(pooledContext4.Release())
+	suite.Require().NoError(pooledContext3.Release())
+	suite.Require().Equal(0, suite.pool.NumContexts())
+}
+
+func (suite *poolSuite) TestSessionOptionsMustMatch() {
+	refreshCredentials := func() (*Credentials, error) { return &Credentials{AccessKey: "fresh"}, nil }
+	newSessionInput := NewSessionInput{
+		URL:                "http://webapi:8081",
+		AccessKey:          "key",
+		RefreshCredentials: refreshCredentials,
+		Headers:            map[string]string{"X-Header": "value"},
+	}
+
+	pooledContext1, err := suite.pool.Acquire(&newSessionInput)
+	suite.Require().NoError(err)
+
+	// the same options share the context
+	sameNewSessionInput := newSessionInput
+	sameNewSessionInput.Headers = map[string]string{"X-Header": "value"}
+	pooledContext2, err := suite.pool.Acquire(&sameNewSessionInput)
+	suite.Require().NoError(err)
+	suite.Require().True(pooledContext1.Context == pooledContext2.Context)
+
+	// different options would be silently dropped in favour of the pooled session's, so they're rejected
+	for _, differentNewSessionInput := range []NewSessionInput{
+		{URL: newSessionInput.URL, AccessKey: "key", Headers: newSessionInput.Headers},
+		{URL: newSessionInput.URL, AccessKey: "key", RefreshCredentials: refreshCredentials},
+		{
+			URL:                newSessionInput.URL,
+			AccessKey:          "key",
+			RefreshCredentials: refreshCredentials,
+			Headers:            map[string]string{"X-Header": "other"},
+		},
+		{
+			URL:       newSessionInput.URL,
+			AccessKey: "key",
+			RefreshCredentials: func() (*Credentials, error) {
+				return nil, nil
+			},
+			Headers: newSessionInput.Headers,
+		},
+	} {
+		differentNewSessionInput := differentNewSessionInput
+
+		_, err = suite.pool.Acquire(&differentNewSessionInput)
+		suite.Require().Equal(v3ioerrors.ErrConflict, errors.RootCause(err))
+	}
+
+	suite.Require().Len(suite.createdContexts, 1)
+	suite.Require().NoError(pooledContext1.Release())
+	suite.Require().NoError(pooledContext2.Release())
+	suite.Require().Equal(0, suite.pool.NumContexts())
+}
+
+func TestPoolSuite(t *testing.T) {
+	suite.Run(t, new(poolSuite))
+}
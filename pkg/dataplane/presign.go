@@ -0,0 +1,133 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// the query parameters of presigned URLs
+const (
+	PresignedURLExpiresParameter   = "X-v3io-expires"
+	PresignedURLSignatureParameter = "X-v3io-signature"
+)
+
+const defaultPresignExpiry = 15 * time.Minute
+
+type PresignObjectInput struct {
+	DataPlaneInput
+	Method  string // http.MethodGet or http.MethodPut
+	Path    string
+	Expires time.Duration // defaults to 15 minutes
+}
+
+type PresignObjectOutput struct {
+
+	// the object's URL, signed with the access key until ExpiresAt. the web API doesn't check the signature,
+	// so the URL is meant for a gateway which checks it with VerifyPresignedURL and then adds the credentials
+	URL string
+
+	// headers which authenticate requests to the web API directly. they're valid for as long as the access
+	// key is, so they should only be handed to trusted services
+	Headers map[string]string
+
+	ExpiresAt time.Time
+}
+
+// SignObjectRequest presigns a request for an object with the access key of the input
+func SignObjectRequest(presignObjectInput *PresignObjectInput, now time.Time) (*PresignObjectOutput, error) {
+	if presignObjectInput.AccessKey == "" {
+		return nil, errors.New("Access key must be set to presign requests")
+	}
+
+	if presignObjectInput.Method != http.MethodGet && presignObjectInput.Method != http.MethodPut {
+		return nil, errors.Errorf("Unsupported method: %s", presignObjectInput.Method)
+	}
+
+	objectURL, err := url.Parse(presignObjectInput.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse URL %s", presignObjectInput.URL)
+	}
+
+	expires := presignObjectInput.Expires
+	if expires <= 0 {
+		expires = defaultPresignExpiry
+	}
+
+	expiresAt := now.Add(expires)
+	objectURL.Path = path.Join("/", presignObjectInput.ContainerName, presignObjectInput.Path)
+
+	query := url.Values{}
+	query.Set(PresignedURLExpiresParameter, strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set(PresignedURLSignatureParameter, signObjectRequest(presignObjectInput.AccessKey,
+		presignObjectInput.Method,
+		objectURL.Path,
+		expiresAt.Unix()))
+	objectURL.RawQuery = query.Encode()
+
+	return &PresignObjectOutput{
+		URL:       objectURL.String(),
+		Headers:   map[string]string{"X-v3io-session-key": presignObjectInput.AccessKey},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifyPresignedURL checks that the URL was presigned for the method with the access key and hasn't expired
+func VerifyPresignedURL(method string, presignedURL *url.URL, accessKey string, now time.Time) error {
+	query := presignedURL.Query()
+
+	expiresAt, err := strconv.ParseInt(query.Get(PresignedURLExpiresParameter), 10, 64)
+	if err != nil {
+		return v3ioerrors.NewErrorWithStatusCode(errors.New("Presigned URL has no valid expiry"),
+			http.StatusForbidden)
+	}
+
+	expectedSignature := signObjectRequest(accessKey, strings.ToUpper(method), presignedURL.Path, expiresAt)
+	if !hmac.Equal([]byte(query.Get(PresignedURLSignatureParameter)), []byte(expectedSignature)) {
+		return v3ioerrors.NewErrorWithStatusCode(errors.New("Presigned URL signature mismatch"),
+			http.StatusForbidden)
+	}
+
+	if now.Unix() > expiresAt {
+		return v3ioerrors.NewErrorWithStatusCode(errors.New("Presigned URL expired"), http.StatusForbidden)
+	}
+
+	return nil
+}
+
+func signObjectRequest(accessKey string, method string, objectPath string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(accessKey))
+	mac.Write([]byte(method + "\n" + objectPath + "\n" + strconv.FormatInt(expiresAt, 10))) // nolint: errcheck
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type presignSuite struct {
+	suite.Suite
+}
+
+func (suite *presignSuite) TestSignAndVerify() {
+	now := time.Unix(1000, 0)
+
+	presignObjectOutput, err := SignObjectRequest(&PresignObjectInput{
+		DataPlaneInput: DataPlaneInput{
+			URL:           "https://webapi.example.com:8443",
+			ContainerName: "bigdata",
+			AccessKey:     "access-key",
+		},
+		Method:  http.MethodGet,
+		Path:    "/dir/object",
+		Expires: time.Minute,
+	}, now)
+	suite.Require().NoError(err)
+	suite.Require().Equal(now.Add(time.Minute), presignObjectOutput.ExpiresAt)
+	suite.Require().Equal("access-key", presignObjectOutput.Headers["X-v3io-session-key"])
+
+	presignedURL, err := url.Parse(presignObjectOutput.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal("webapi.example.com:8443", presignedURL.Host)
+	suite.Require().Equal("/bigdata/dir/object", presignedURL.Path)
+
+	suite.Require().NoError(VerifyPresignedURL(http.MethodGet, presignedURL, "access-key", now.Add(time.Minute)))
+
+	// another method, another key or a later time fail verification
+	suite.Require().Error(VerifyPresignedURL(http.MethodPut, presignedURL, "access-key", now))
+	suite.Require().Error(VerifyPresignedURL(http.MethodGet, presignedURL, "other-key", now))
+	suite.Require().Error(VerifyPresignedURL(http.MethodGet, presignedURL, "access-key", now.Add(2*time.Minute)))
+
+	// as does a tampered path or expiry
+	tamperedURL := *presignedURL
+	tamperedURL.Path = "/bigdata/dir/other"
+	suite.Require().Error(VerifyPresignedURL(http.MethodGet, &tamperedURL, "access-key", now))
+
+	query := presignedURL.Query()
+	query.Set(PresignedURLExpiresParameter, "999999999")
+	tamperedURL = *presignedURL
+	tamperedURL.RawQuery = query.Encode()
+	suite.Require().Error(VerifyPresignedURL(http.MethodGet, &tamperedURL, "access-key", now))
+}
+
+func (suite *presignSuite) TestInvalidInput() {
+	_, err := SignObjectRequest(&PresignObjectInput{Method: http.MethodGet, Path: "/object"}, time.Now())
+	suite.Require().Error(err)
+
+	_, err = SignObjectRequest(&PresignObjectInput{
+		DataPlaneInput: DataPlaneInput{AccessKey: "access-key"},
+		Method:         http.MethodDelete,
+		Path:           "/object",
+	}, time.Now())
+	suite.Require().Error(err)
+}
+
+func TestPresignSuite(t *testing.T) {
+	suite.Run(t, new(presignSuite))
+}
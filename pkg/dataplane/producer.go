@@ -0,0 +1,440 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type NewProducerInput struct {
+	Container Container
+
+	// a batch is sent once it holds this many records (defaults to 1000, the most a single put records accepts)
+	MaxRecords int
+
+	// a batch is sent once its records' data adds up to this many bytes (defaults to 1MB)
+	MaxBytes int
+
+	// the longest a record waits for its batch to fill before the batch is sent anyway (defaults to 5ms)
+	Linger time.Duration
+
+	// the number of times records which failed to be put are retried (defaults to 3)
+	MaxRetries int
+
+	// the time to wait before retrying failed records (defaults to 100ms)
+	RetryInterval time.Duration
+
+	// Produce blocks while this many batches are being sent (defaults to 64)
+	MaxInflightBatches int
+
+	// called once per produced record with the outcome of putting it. called from the producer's goroutines
+	OnDelivery func(*ProducerDelivery)
+
+	// if set, records are stamped with sequence numbers in their headers, so that consumers can drop
+	// records put more than once with a RecordDeduplicator
+	Idempotent bool
+
+	// identifies the producer in the sequence numbers of its records (defaults to a random ID). a producer
+	// reusing the ID of a previous one would have its records dropped as duplicates
+	ProducerID string
+
+	// the compression of record data. consumer groups decompress records transparently, other readers can
+	// use DecompressRecordData
+	Compression RecordCompression
+}
+
+// ProducerDelivery is the outcome of putting a produced record
+type ProducerDelivery struct {
+	StreamPath     string
+	Record         *StreamRecord
+	Context        interface{}
+	ShardID        int
+	SequenceNumber uint64
+	Error          error
+}
+
+// Producer puts records into streams asynchronously. records are batched per stream and shard, such that
+// each batch is sent with a single put records once it's full or has lingered long enough. records which
+// the stream failed to put are retried, so a retried record may land after records produced after it
+type Producer struct {
+	lock               sync.Mutex
+	inflightCond       *sync.Cond
+	container          Container
+	maxRecords         int
+	maxBytes           int
+	linger             time.Duration
+	maxRetries         int
+	retryInterval      time.Duration
+	maxInflightBatches int
+	onDelivery         func(*ProducerDelivery)
+	batches            map[producerBatchKey]*producerBatch
+	sendingBatches     map[producerBatchKey][]*producerBatch // the batches queued behind the one being sent
+	numInflightBatches int
+	closed             bool
+	idempotent         bool
+	producerID         string
+	sequenceNumbers    map[producerSequenceKey]uint64
+	compression        RecordCompression
+}
+
+type producerSequenceKey struct {
+	streamPath   string
+	partitionKey string
+}
+
+// records with no shard ID are batched under shard ID -1, leaving the choice of shard to the stream
+type producerBatchKey struct {
+	streamPath string
+	shardID    int
+}
+
+type producerBatch struct {
+	key         producerBatchKey
+	records     []*producerRecord
+	numBytes    int
+	lingerTimer *time.Timer
+}
+
+type producerRecord struct {
+	record     *StreamRecord
+	context    interface{}
+	numRetries int
+}
+
+func NewProducer(newProducerInput *NewProducerInput) (*Producer, error) {
+	if newProducerInput.Container == nil {
+		return nil, errors.New("Container must be set")
+	}
+
+	newProducer := Producer{
+		container:          newProducerInput.Container,
+		maxRecords:         newProducerInput.MaxRecords,
+		maxBytes:           newProducerInput.MaxBytes,
+		linger:             newProducerInput.Linger,
+		maxRetries:         newProducerInput.MaxRetries,
+		retryInterval:      newProducerInput.RetryInterval,
+		maxInflightBatches: newProducerInput.MaxInflightBatches,
+		onDelivery:         newProducerInput.OnDelivery,
+		batches:            map[producerBatchKey]*producerBatch{},
+		sendingBatches:     map[producerBatchKey][]*producerBatch{},
+		idempotent:         newProducerInput.Idempotent,
+		producerID:         newProducerInput.ProducerID,
+		sequenceNumbers:    map[producerSequenceKey]uint64{},
+		compression:        newProducerInput.Compression,
+	}
+
+	if err := newProducer.compression.Validate(); err != nil {
+		return nil, err
+	}
+
+	newProducer.inflightCond = sync.NewCond(&newProducer.lock)
+
+	if newProducer.maxRecords <= 0 {
+		newProducer.maxRecords = 1000
+	}
+
+	if newProducer.maxBytes <= 0 {
+		newProducer.maxBytes = 1024 * 1024
+	}
+
+	if newProducer.linger <= 0 {
+		newProducer.linger = 5 * time.Millisecond
+	}
+
+	if newProducer.maxRetries <= 0 {
+		newProducer.maxRetries = 3
+	}
+
+	if newProducer.retryInterval <= 0 {
+		newProducer.retryInterval = 100 * time.Millisecond
+	}
+
+	if newProducer.maxInflightBatches <= 0 {
+		newProducer.maxInflightBatches = 64
+	}
+
+	if newProducer.producerID == "" {
+		randomBytes := make([]byte, 8)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, errors.Wrap(err, "Failed to generate producer ID")
+		}
+
+		newProducer.producerID = hex.EncodeToString(randomBytes)
+	}
+
+	return &newProducer, nil
+}
+
+// Produce adds a record to the batch of its stream and shard. the outcome is passed to OnDelivery along
+// with context
+func (p *Producer) Produce(streamPath string, record *StreamRecord, context interface{}) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return errors.Wrap(v3ioerrors.ErrStopped, "Producer is closed")
+	}
+
+	if p.idempotent {
+		if err := validateIdempotentRecord(record); err != nil {
+			return err
+		}
+	}
+
+	// records are compressed and stamped as copies, leaving the caller's record as is
+	if p.compression != "" || p.idempotent {
+		producedRecord := *record
+
+		if p.compression != "" {
+			compressedData, err := CompressRecordData(record.Data, p.compression)
+			if err != nil {
+				return err
+			}
+
+			producedRecord.Data = compressedData
+		}
+
+		if p.idempotent {
+			sequenceKey := producerSequenceKey{
+				streamPath:   streamPath,
+				partitionKey: record.PartitionKey,
+			}
+
+			headers, err := getRecordHeadersForUpdate(record)
+			if err != nil {
+				return err
+			}
+
+			p.sequenceNumbers[sequenceKey]++
+
+			headers[ProducerSequenceHeader] = (&ProducerSequence{
+				ProducerID:     p.producerID,
+				SequenceNumber: p.sequenceNumbers[sequenceKey],
+			}).encode()
+
+			if err := producedRecord.SetHeaders(headers); err != nil {
+				return err
+			}
+		}
+
+		record = &producedRecord
+	}
+
+	batchKey := producerBatchKey{
+		streamPath: streamPath,
+		shardID:    -1,
+	}
+
+	if record.ShardID != nil {
+		batchKey.shardID = *record.ShardID
+	}
+
+	recordSize := len(record.Data) + len(record.ClientInfo) + len(record.PartitionKey)
+
+	// a record which would overflow the current batch starts the next one
+	batch := p.batches[batchKey]
+	if batch != nil && batch.numBytes+recordSize > p.maxBytes {
+		p.send(batch)
+		batch = nil
+	}
+
+	if batch == nil {
+		batch = &producerBatch{key: batchKey}
+		batch.lingerTimer = time.AfterFunc(p.linger, func() {
+			p.lingerExpired(batch)
+		})
+
+		p.batches[batchKey] = batch
+	}
+
+	batch.records = append(batch.records, &producerRecord{
+		record:  record,
+		context: context,
+	})
+	batch.numBytes += recordSize
+
+	if len(batch.records) >= p.maxRecords || batch.numBytes >= p.maxBytes {
+		p.send(batch)
+	}
+
+	return nil
+}
+
+// Flush sends all batches and waits until all records produced so far were delivered
+func (p *Producer) Flush() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.flush()
+}
+
+// Close flushes the producer. records produced after Close are rejected
+func (p *Producer) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	p.flush()
+}
+
+// must be called with the lock held
+func (p *Producer) flush() {
+	for _, batch := range p.batches {
+		p.send(batch)
+	}
+
+	for p.numInflightBatches > 0 {
+		p.inflightCond.Wait()
+	}
+}
+
+func (p *Producer) lingerExpired(batch *producerBatch) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// the batch may have already been sent
+	if p.batches[batch.key] == batch {
+		p.send(batch)
+	}
+}
+
+// sends the batch in the background. batches of a stream shard are sent one at a time, so that its records
+// keep their order. must be called with the lock held
+func (p *Producer) send(batch *producerBatch) {
+	batch.lingerTimer.Stop()
+	delete(p.batches, batch.key)
+
+	for p.numInflightBatches >= p.maxInflightBatches {
+		p.inflightCond.Wait()
+	}
+
+	p.numInflightBatches++
+
+	if queuedBatches, sending := p.sendingBatches[batch.key]; sending {
+		p.sendingBatches[batch.key] = append(queuedBatches, batch)
+		return
+	}
+
+	p.sendingBatches[batch.key] = nil
+
+	go p.sendBatches(batch)
+}
+
+// sends the batch, followed by the batches queued behind it
+func (p *Producer) sendBatches(batch *producerBatch) {
+	for batch != nil {
+		p.put(batch.key.streamPath, batch.records)
+
+		p.lock.Lock()
+
+		p.numInflightBatches--
+		p.inflightCond.Broadcast()
+
+		batchKey := batch.key
+		batch = nil
+
+		if queuedBatches := p.sendingBatches[batchKey]; len(queuedBatches) > 0 {
+			batch = queuedBatches[0]
+			p.sendingBatches[batchKey] = queuedBatches[1:]
+		} else {
+			delete(p.sendingBatches, batchKey)
+		}
+
+		p.lock.Unlock()
+	}
+}
+
+// puts the records, retrying those which failed until they succeed or run out of retries
+func (p *Producer) put(streamPath string, records []*producerRecord) {
+	for {
+		records = p.putOnce(streamPath, records)
+		if len(records) == 0 {
+			return
+		}
+
+		time.Sleep(p.retryInterval)
+	}
+}
+
+// puts the records and returns those which should be retried
+func (p *Producer) putOnce(streamPath string, records []*producerRecord) []*producerRecord {
+	putRecordsInput := PutRecordsInput{
+		Path:    streamPath,
+		Records: make([]*StreamRecord, len(records)),
+	}
+
+	for recordIdx, record := range records {
+		putRecordsInput.Records[recordIdx] = record.record
+	}
+
+	response, err := p.container.PutRecordsSync(&putRecordsInput)
+	if err == nil {
+		defer response.Release()
+
+		if putRecordsOutput := response.Output.(*PutRecordsOutput); len(putRecordsOutput.Records) != len(records) {
+			err = errors.Errorf("Expected %d record results, got %d", len(records), len(putRecordsOutput.Records))
+		}
+	}
+
+	var retryRecords []*producerRecord
+
+	for recordIdx, record := range records {
+		delivery := ProducerDelivery{
+			StreamPath: streamPath,
+			Record:     record.record,
+			Context:    record.context,
+			Error:      err,
+		}
+
+		if err == nil {
+			putRecordResult := response.Output.(*PutRecordsOutput).Records[recordIdx]
+
+			if putRecordResult.ErrorCode != 0 {
+				delivery.Error = errors.Errorf("Failed to put record (error code %d): %s",
+					putRecordResult.ErrorCode,
+					putRecordResult.ErrorMessage)
+			} else {
+				delivery.ShardID = putRecordResult.ShardID
+				delivery.SequenceNumber = putRecordResult.SequenceNumber
+			}
+		}
+
+		if delivery.Error != nil && record.numRetries < p.maxRetries {
+			record.numRetries++
+			retryRecords = append(retryRecords, record)
+
+			continue
+		}
+
+		if p.onDelivery != nil {
+			p.onDelivery(&delivery)
+		}
+	}
+
+	return retryRecords
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// records the batches put, failing records whose data is "bad" and the first attempt of records whose
+// data is "flaky"
+type fakeStreamContainer struct {
+	Container
+	lock           sync.Mutex
+	batchSizes     []int
+	records        []*StreamRecord
+	numFlakyPuts   int
+	sequenceNumber uint64
+}
+
+func (fsc *fakeStreamContainer) PutRecordsSync(putRecordsInput *PutRecordsInput) (*Response, error) {
+	fsc.lock.Lock()
+	defer fsc.lock.Unlock()
+
+	fsc.batchSizes = append(fsc.batchSizes, len(putRecordsInput.Records))
+	putRecordsOutput := PutRecordsOutput{}
+
+	for _, record := range putRecordsInput.Records {
+		failed := string(record.Data) == "bad"
+		if string(record.Data) == "flaky" {
+			fsc.numFlakyPuts++
+			failed = fsc.numFlakyPuts == 1
+		}
+
+		if failed {
+			putRecordsOutput.FailedRecordCount++
+			putRecordsOutput.Records = append(putRecordsOutput.Records, PutRecordResult{
+				ErrorCode:    -1,
+				ErrorMessage: "Failed",
+			})
+
+			continue
+		}
+
+		fsc.records = append(fsc.records, record)
+		fsc.sequenceNumber++
+		putRecordsOutput.Records = append(putRecordsOutput.Records, PutRecordResult{
+			SequenceNumber: fsc.sequenceNumber,
+		})
+	}
+
+	return &Response{Output: &putRecordsOutput}, nil
+}
+
+type producerSuite struct {
+	suite.Suite
+	container  *fakeStreamContainer
+	lock       sync.Mutex
+	deliveries []*ProducerDelivery
+}
+
+func (suite *producerSuite) SetupTest() {
+	suite.container = &fakeStreamContainer{}
+	suite.deliveries = nil
+}
+
+func (suite *producerSuite) newProducer(newProducerInput *NewProducerInput) *Producer {
+	newProducerInput.Container = suite.container
+	newProducerInput.OnDelivery = func(delivery *ProducerDelivery) {
+		suite.lock.Lock()
+		defer suite.lock.Unlock()
+
+		suite.deliveries = append(suite.deliveries, delivery)
+	}
+
+	producer, err := NewProducer(newProducerInput)
+	suite.Require().NoError(err)
+
+	return producer
+}
+
+func (suite *producerSuite) numDeliveries() int {
+	suite.lock.Lock()
+	defer suite.lock.Unlock()
+
+	return len(suite.deliveries)
+}
+
+func (suite *producerSuite) TestBatchesByRecordsAndShards() {
+	producer := suite.newProducer(&NewProducerInput{MaxRecords: 2, Linger: time.Hour})
+
+	shardIDs := []int{0, 1}
+	for recordIdx := 0; recordIdx < 5; recordIdx++ {
+		err := producer.Produce("stream/", &StreamRecord{Data: []byte("data"), ShardID: &shardIDs[0]}, recordIdx)
+		suite.Require().NoError(err)
+	}
+
+	err := producer.Produce("stream/", &StreamRecord{Data: []byte("data"), ShardID: &shardIDs[1]}, 5)
+	suite.Require().NoError(err)
+
+	producer.Close()
+
+	// two full batches are sent as they fill, then the two partial batches on close
+	suite.Require().ElementsMatch([]int{2, 2, 1, 1}, suite.container.batchSizes)
+
+	// the records of a shard are delivered in order
+	var shardContexts []interface{}
+
+	suite.Require().Len(suite.deliveries, 6)
+	for _, delivery := range suite.deliveries {
+		suite.Require().NoError(delivery.Error)
+		suite.Require().NotZero(delivery.SequenceNumber)
+
+		if *delivery.Record.ShardID == 0 {
+			shardContexts = append(shardContexts, delivery.Context)
+		}
+	}
+
+	suite.Require().Equal([]interface{}{0, 1, 2, 3, 4}, shardContexts)
+
+	err = producer.Produce("stream/", &StreamRecord{Data: []byte("data")}, nil)
+	suite.Require().Equal(v3ioerrors.ErrStopped, errors.Cause(err))
+}
+
+func (suite *producerSuite) TestBatchesByBytes() {
+	producer := suite.newProducer(&NewProducerInput{MaxBytes: 10, Linger: time.Hour})
+
+	for _, data := range []string{"0123", "4567", "89ab"} {
+		suite.Require().NoError(producer.Produce("stream/", &StreamRecord{Data: []byte(data)}, nil))
+	}
+
+	producer.Flush()
+
+	// the third record would overflow the first batch
+	suite.Require().Equal([]int{2, 1}, suite.container.batchSizes)
+}
+
+func (suite *producerSuite) TestLinger() {
+	producer := suite.newProducer(&NewProducerInput{Linger: 10 * time.Millisecond})
+	defer producer.Close()
+
+	suite.Require().NoError(producer.Produce("stream/", &StreamRecord{Data: []byte("data")}, nil))
+
+	// no flush - the batch is sent once it lingered
+	for deadline := time.Now().Add(time.Second); suite.numDeliveries() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	suite.Require().Equal(1, suite.numDeliveries())
+}
+
+func (suite *producerSuite) TestRetriesFailedRecords() {
+	producer := suite.newProducer(&NewProducerInput{
+		MaxRetries:    2,
+		RetryInterval: time.Millisecond,
+		Linger:        time.Hour,
+	})
+
+	for _, data := range []string{"good", "flaky", "bad"} {
+		suite.Require().NoError(producer.Produce("stream/", &StreamRecord{Data: []byte(data)}, data))
+	}
+
+	producer.Close()
+
+	// the flaky and bad records are retried, and the bad record again
+	suite.Require().Equal([]int{3, 2, 1}, suite.container.batchSizes)
+	suite.Require().Len(suite.deliveries, 3)
+
+	for _, delivery := range suite.deliveries {
+		if delivery.Context == "bad" {
+			suite.Require().Error(delivery.Error)
+		} else {
+			suite.Require().NoError(delivery.Error)
+		}
+	}
+}
+
+func (suite *producerSuite) TestIdempotent() {
+	producer := suite.newProducer(&NewProducerInput{
+		Idempotent:    true,
+		ProducerID:    "producer",
+		RetryInterval: time.Millisecond,
+		Linger:        time.Hour,
+	})
+
+	// records must be ordered for their sequence numbers to be meaningful
+	err := producer.Produce("stream/", &StreamRecord{Data: []byte("data")}, nil)
+	suite.Require().Error(err)
+
+	for _, data := range []string{"flaky", "good"} {
+		suite.Require().NoError(producer.Produce("stream/", &StreamRecord{Data: []byte(data), PartitionKey: "key"}, nil))
+	}
+
+	producer.Close()
+
+	// the retried flaky record landed after the good one
+	suite.Require().Len(suite.container.records, 2)
+	for recordIdx, expectedSequenceNumber := range []uint64{2, 1} {
+		producerSequence, found := GetProducerSequence(suite.container.records[recordIdx].ClientInfo)
+		suite.Require().True(found)
+		suite.Require().Equal("producer", producerSequence.ProducerID)
+		suite.Require().Equal(expectedSequenceNumber, producerSequence.SequenceNumber)
+	}
+
+	// the good record is put again, as if the response to putting it was lost
+	recordDeduplicator := NewRecordDeduplicator(0)
+	for recordIdx, record := range append(suite.container.records, suite.container.records[0]) {
+		isDuplicate := recordDeduplicator.IsDuplicate(&GetRecordsResult{
+			ClientInfo:   record.ClientInfo,
+			PartitionKey: record.PartitionKey,
+		})
+
+		suite.Require().Equal(recordIdx == 2, isDuplicate)
+	}
+}
+
+func (suite *producerSuite) TestIdempotentHeaders() {
+	producer := suite.newProducer(&NewProducerInput{Idempotent: true, ProducerID: "producer"})
+
+	// client info which doesn't hold headers is reserved
+	err := producer.Produce("stream/", &StreamRecord{PartitionKey: "key", ClientInfo: []byte("info")}, nil)
+	suite.Require().Error(err)
+
+	record := &StreamRecord{PartitionKey: "key"}
+	suite.Require().NoError(record.SetHeaders(map[string]string{"traceparent": "trace"}))
+	suite.Require().NoError(producer.Produce("stream/", record, nil))
+
+	producer.Close()
+
+	// the sequence is stamped alongside the caller's headers
+	suite.Require().Len(suite.container.records, 1)
+	headers, err := (&GetRecordsResult{ClientInfo: suite.container.records[0].ClientInfo}).GetHeaders()
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]string{
+		"traceparent":          "trace",
+		ProducerSequenceHeader: "producer:1",
+	}, headers)
+}
+
+func (suite *producerSuite) TestCompression() {
+	producer := suite.newProducer(&NewProducerInput{Compression: RecordCompressionSnappy})
+
+	record := &StreamRecord{Data: []byte("data")}
+	suite.Require().NoError(producer.Produce("stream/", record, nil))
+
+	producer.Close()
+
+	// the caller's record is left as is
+	suite.Require().Equal("data", string(record.Data))
+
+	suite.Require().Len(suite.container.records, 1)
+	suite.Require().NotEqual("data", string(suite.container.records[0].Data))
+
+	decompressedData, err := DecompressRecordData(suite.container.records[0].Data)
+	suite.Require().NoError(err)
+	suite.Require().Equal("data", string(decompressedData))
+}
+
+func (suite *producerSuite) TestDeduplicatorWindow() {
+	recordDeduplicator := NewRecordDeduplicator(64)
+
+	for _, testCase := range []struct {
+		sequenceNumber uint64
+		isDuplicate    bool
+	}{
+		{sequenceNumber: 1, isDuplicate: false},
+		{sequenceNumber: 100, isDuplicate: false},
+		{sequenceNumber: 1, isDuplicate: true},
+		{sequenceNumber: 50, isDuplicate: false},
+		{sequenceNumber: 50, isDuplicate: true},
+		{sequenceNumber: 36, isDuplicate: true},
+		{sequenceNumber: 37, isDuplicate: false},
+	} {
+		clientInfo, err := EncodeRecordHeaders(map[string]string{
+			ProducerSequenceHeader: (&ProducerSequence{ProducerID: "producer", SequenceNumber: testCase.sequenceNumber}).encode(),
+		})
+		suite.Require().NoError(err)
+
+		isDuplicate := recordDeduplicator.IsDuplicate(&GetRecordsResult{ClientInfo: clientInfo})
+		suite.Require().Equal(testCase.isDuplicate, isDuplicate, "sequence number %d", testCase.sequenceNumber)
+	}
+
+	// records of other producers are never duplicates
+	suite.Require().False(recordDeduplicator.IsDuplicate(&GetRecordsResult{ClientInfo: []byte("info")}))
+	suite.Require().False(recordDeduplicator.IsDuplicate(&GetRecordsResult{ClientInfo: []byte("info")}))
+}
+
+func TestProducerSuite(t *testing.T) {
+	suite.Run(t, new(producerSuite))
+}
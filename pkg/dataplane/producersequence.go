@@ -0,0 +1,194 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nuclio/errors"
+)
+
+// the header in which idempotent producers stamp the sequence of their records
+const ProducerSequenceHeader = "v3io-seq"
+
+// ProducerSequence identifies a record produced by an idempotent producer. sequence numbers start at 1 and
+// are assigned per stream and partition key
+type ProducerSequence struct {
+	ProducerID     string
+	SequenceNumber uint64
+}
+
+// encodes the sequence as a header value: <producer ID>:<sequence number>
+func (ps *ProducerSequence) encode() string {
+	return ps.ProducerID + ":" + strconv.FormatUint(ps.SequenceNumber, 10)
+}
+
+// GetProducerSequence returns the sequence of a record produced by an idempotent producer, given its client
+// info. returns false for records of other producers
+func GetProducerSequence(clientInfo []byte) (*ProducerSequence, bool) {
+	headers, err := DecodeRecordHeaders(clientInfo)
+	if err != nil {
+		return nil, false
+	}
+
+	encodedProducerSequence, found := headers[ProducerSequenceHeader]
+	if !found {
+		return nil, false
+	}
+
+	separatorIdx := strings.LastIndexByte(encodedProducerSequence, ':')
+	if separatorIdx < 0 {
+		return nil, false
+	}
+
+	sequenceNumber, err := strconv.ParseUint(encodedProducerSequence[separatorIdx+1:], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &ProducerSequence{
+		ProducerID:     encodedProducerSequence[:separatorIdx],
+		SequenceNumber: sequenceNumber,
+	}, true
+}
+
+// RecordDeduplicator drops records which an idempotent producer put more than once, e.g. when it retried a
+// put whose response was lost. it remembers which of the last WindowSize sequence numbers of each producer
+// and partition key were seen, so that records which retries put out of order are still accepted. records
+// older than the window are considered duplicates.
+//
+// a deduplicator should see the records of a single shard, since the sequence numbers of a partition key are
+// only in order within a shard
+type RecordDeduplicator struct {
+	lock       sync.Mutex
+	windowSize uint64
+	windows    map[recordDeduplicatorKey]*sequenceWindow
+}
+
+type recordDeduplicatorKey struct {
+	producerID   string
+	partitionKey string
+}
+
+// the sequence numbers seen out of the last windowSize, as a bitmap indexed by sequence number modulo windowSize
+type sequenceWindow struct {
+	maxSequenceNumber uint64
+	seen              []uint64
+}
+
+// NewRecordDeduplicator creates a deduplicator with the given window size, rounded up to a multiple of 64
+// (defaults to 1024)
+func NewRecordDeduplicator(windowSize int) *RecordDeduplicator {
+	if windowSize <= 0 {
+		windowSize = 1024
+	}
+
+	return &RecordDeduplicator{
+		windowSize: uint64((windowSize + 63) / 64 * 64),
+		windows:    map[recordDeduplicatorKey]*sequenceWindow{},
+	}
+}
+
+// IsDuplicate returns whether the record was seen before, and marks it as seen. records which weren't
+// produced by an idempotent producer are never duplicates
+func (rd *RecordDeduplicator) IsDuplicate(record *GetRecordsResult) bool {
+	producerSequence, found := GetProducerSequence(record.ClientInfo)
+	if !found {
+		return false
+	}
+
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+
+	key := recordDeduplicatorKey{
+		producerID:   producerSequence.ProducerID,
+		partitionKey: record.PartitionKey,
+	}
+
+	window, found := rd.windows[key]
+	if !found {
+		window = &sequenceWindow{seen: make([]uint64, rd.windowSize/64)}
+		rd.windows[key] = window
+	}
+
+	return window.markSeen(producerSequence.SequenceNumber, rd.windowSize)
+}
+
+// marks the sequence number as seen, returning whether it was seen before
+func (sw *sequenceWindow) markSeen(sequenceNumber uint64, windowSize uint64) bool {
+	switch {
+	case sequenceNumber > sw.maxSequenceNumber:
+
+		// forget the sequence numbers which dropped out of the window
+		if sequenceNumber-sw.maxSequenceNumber >= windowSize {
+			for wordIdx := range sw.seen {
+				sw.seen[wordIdx] = 0
+			}
+		} else {
+			for droppedSequenceNumber := sw.maxSequenceNumber + 1; droppedSequenceNumber <= sequenceNumber; droppedSequenceNumber++ {
+				sw.clear(droppedSequenceNumber % windowSize)
+			}
+		}
+
+		sw.maxSequenceNumber = sequenceNumber
+	case sw.maxSequenceNumber-sequenceNumber >= windowSize:
+		return true
+	case sw.isSet(sequenceNumber % windowSize):
+		return true
+	}
+
+	sw.set(sequenceNumber % windowSize)
+
+	return false
+}
+
+func (sw *sequenceWindow) isSet(bit uint64) bool {
+	return sw.seen[bit/64]&(1<<(bit%64)) != 0
+}
+
+func (sw *sequenceWindow) set(bit uint64) {
+	sw.seen[bit/64] |= 1 << (bit % 64)
+}
+
+func (sw *sequenceWindow) clear(bit uint64) {
+	sw.seen[bit/64] &^= 1 << (bit % 64)
+}
+
+// returns an error if the record can't be produced idempotently
+func validateIdempotentRecord(record *StreamRecord) error {
+	headers, err := getRecordHeadersForUpdate(record)
+	if err != nil {
+		return errors.Wrap(err, "Idempotent producers stamp sequence numbers in record headers")
+	}
+
+	if _, found := headers[ProducerSequenceHeader]; found {
+		return errors.Errorf("Header %s is reserved for idempotent producers", ProducerSequenceHeader)
+	}
+
+	// records which are spread round robin may land on any shard, so their order can't be relied upon
+	if record.PartitionKey == "" && record.ShardID == nil {
+		return errors.New("Records of idempotent producers must have a partition key or shard ID")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/nuclio/errors"
+)
+
+type PutRecordsWithRetryInput struct {
+	PutRecordsInput
+
+	// the number of times records which failed to be put are retried (defaults to 3)
+	MaxRetries int
+
+	// the wait between retries (defaults to an exponential backoff from 100ms to 5s)
+	Backoff *common.Backoff
+}
+
+// PutRecordsWithRetry puts the records, retrying the subset which the stream failed to put with backoff.
+// the output holds the result of each record at the index of the record in the input, and counts the
+// records which failed on all attempts. a failed put records request fails the call without retrying, in
+// which case records put by earlier attempts aren't reported
+func PutRecordsWithRetry(container Container,
+	putRecordsWithRetryInput *PutRecordsWithRetryInput) (*PutRecordsOutput, error) {
+	maxRetries := putRecordsWithRetryInput.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+
+	backoff := &common.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    5 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	if putRecordsWithRetryInput.Backoff != nil {
+		backoff = putRecordsWithRetryInput.Backoff.Copy()
+	}
+
+	records := putRecordsWithRetryInput.Records
+	putRecordsOutput := PutRecordsOutput{
+		Records: make([]PutRecordResult, len(records)),
+	}
+
+	// the indexes of the records yet to be put
+	pendingRecordIndexes := make([]int, len(records))
+	for recordIdx := range records {
+		pendingRecordIndexes[recordIdx] = recordIdx
+	}
+
+	for attemptIdx := 0; ; attemptIdx++ {
+		putRecordsInput := putRecordsWithRetryInput.PutRecordsInput
+		putRecordsInput.Records = make([]*StreamRecord, len(pendingRecordIndexes))
+
+		for pendingRecordIdx, recordIdx := range pendingRecordIndexes {
+			putRecordsInput.Records[pendingRecordIdx] = records[recordIdx]
+		}
+
+		failedRecordIndexes, err := putPendingRecords(container, &putRecordsInput, pendingRecordIndexes, &putRecordsOutput)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(failedRecordIndexes) == 0 || attemptIdx == maxRetries {
+			putRecordsOutput.FailedRecordCount = len(failedRecordIndexes)
+			return &putRecordsOutput, nil
+		}
+
+		pendingRecordIndexes = failedRecordIndexes
+
+		if err := sleepWithContext(putRecordsInput.Ctx, backoff.Duration()); err != nil {
+			return nil, errors.Wrap(err, "Context done while waiting to retry failed records")
+		}
+	}
+}
+
+// puts the pending records, setting their results in the output. returns the indexes of those which failed
+func putPendingRecords(container Container,
+	putRecordsInput *PutRecordsInput,
+	pendingRecordIndexes []int,
+	putRecordsOutput *PutRecordsOutput) ([]int, error) {
+	response, err := container.PutRecordsSync(putRecordsInput)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Release()
+
+	attemptOutput := response.Output.(*PutRecordsOutput)
+	if len(attemptOutput.Records) != len(pendingRecordIndexes) {
+		return nil, errors.Errorf("Expected %d record results, got %d",
+			len(pendingRecordIndexes),
+			len(attemptOutput.Records))
+	}
+
+	var failedRecordIndexes []int
+
+	for pendingRecordIdx, putRecordResult := range attemptOutput.Records {
+		recordIdx := pendingRecordIndexes[pendingRecordIdx]
+		putRecordsOutput.Records[recordIdx] = putRecordResult
+
+		if putRecordResult.ErrorCode != 0 {
+			failedRecordIndexes = append(failedRecordIndexes, recordIdx)
+		}
+	}
+
+	return failedRecordIndexes, nil
+}
+
+// sleeps for the duration, or until the context is done if given
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	if ctx == nil {
+		time.Sleep(duration)
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type putRecordsWithRetrySuite struct {
+	suite.Suite
+	container *fakeStreamContainer
+}
+
+func (suite *putRecordsWithRetrySuite) SetupTest() {
+	suite.container = &fakeStreamContainer{}
+}
+
+func (suite *putRecordsWithRetrySuite) TestRetriesFailedSubset() {
+	var records []*StreamRecord
+	for _, data := range []string{"good", "flaky", "bad", "good"} {
+		records = append(records, &StreamRecord{Data: []byte(data)})
+	}
+
+	putRecordsOutput, err := PutRecordsWithRetry(suite.container, &PutRecordsWithRetryInput{
+		PutRecordsInput: PutRecordsInput{Path: "stream/", Records: records},
+		MaxRetries:      2,
+		Backoff:         &common.Backoff{Min: time.Millisecond, Max: time.Millisecond},
+	})
+	suite.Require().NoError(err)
+
+	// only the flaky and bad records are retried, and the bad one again
+	suite.Require().Equal([]int{4, 2, 1}, suite.container.batchSizes)
+
+	suite.Require().Equal(1, putRecordsOutput.FailedRecordCount)
+	suite.Require().Len(putRecordsOutput.Records, 4)
+
+	for recordIdx, putRecordResult := range putRecordsOutput.Records {
+		if recordIdx == 2 {
+			suite.Require().NotZero(putRecordResult.ErrorCode)
+		} else {
+			suite.Require().Zero(putRecordResult.ErrorCode)
+			suite.Require().NotZero(putRecordResult.SequenceNumber)
+		}
+	}
+}
+
+func TestPutRecordsWithRetrySuite(t *testing.T) {
+	suite.Run(t, new(putRecordsWithRetrySuite))
+}
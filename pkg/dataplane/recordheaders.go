@@ -0,0 +1,98 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/nuclio/errors"
+)
+
+// headers are encoded in the client info of records as a marker followed by a JSON object. the marker starts
+// with a zero byte, which client info set by other means is unlikely to
+var recordHeadersMarker = []byte{0, 'v', '3', 'h'}
+
+// EncodeRecordHeaders encodes headers as record client info
+func EncodeRecordHeaders(headers map[string]string) ([]byte, error) {
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode record headers")
+	}
+
+	return append(append([]byte(nil), recordHeadersMarker...), encodedHeaders...), nil
+}
+
+// DecodeRecordHeaders returns the headers encoded in record client info. client info which doesn't hold
+// headers has none
+func DecodeRecordHeaders(clientInfo []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(clientInfo, recordHeadersMarker) {
+		return nil, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(clientInfo[len(recordHeadersMarker):], &headers); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode record headers")
+	}
+
+	return headers, nil
+}
+
+// SetHeaders encodes the headers in the record's client info, replacing it
+func (sr *StreamRecord) SetHeaders(headers map[string]string) error {
+	clientInfo, err := EncodeRecordHeaders(headers)
+	if err != nil {
+		return err
+	}
+
+	sr.ClientInfo = clientInfo
+
+	return nil
+}
+
+// GetHeaders returns the headers encoded in the record's client info
+func (sr *StreamRecord) GetHeaders() (map[string]string, error) {
+	return DecodeRecordHeaders(sr.ClientInfo)
+}
+
+// GetHeaders returns the headers encoded in the record's client info
+func (grr *GetRecordsResult) GetHeaders() (map[string]string, error) {
+	return DecodeRecordHeaders(grr.ClientInfo)
+}
+
+// returns the headers of a record about to be put, failing if its client info was set by other means
+func getRecordHeadersForUpdate(record *StreamRecord) (map[string]string, error) {
+	if record.ClientInfo != nil && !bytes.HasPrefix(record.ClientInfo, recordHeadersMarker) {
+		return nil, errors.New("Record client info must hold headers, or be empty")
+	}
+
+	headers, err := record.GetHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	updatedHeaders := map[string]string{}
+	for headerName, headerValue := range headers {
+		updatedHeaders[headerName] = headerValue
+	}
+
+	return updatedHeaders, nil
+}
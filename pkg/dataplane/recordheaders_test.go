@@ -0,0 +1,63 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type recordHeadersSuite struct {
+	suite.Suite
+}
+
+func (suite *recordHeadersSuite) TestEncodeDecode() {
+	record := &StreamRecord{}
+	suite.Require().NoError(record.SetHeaders(map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"empty":       "",
+	}))
+
+	headers, err := (&GetRecordsResult{ClientInfo: record.ClientInfo}).GetHeaders()
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"empty":       "",
+	}, headers)
+}
+
+func (suite *recordHeadersSuite) TestNoHeaders() {
+	for _, clientInfo := range [][]byte{nil, []byte("info")} {
+		headers, err := DecodeRecordHeaders(clientInfo)
+		suite.Require().NoError(err)
+		suite.Require().Empty(headers)
+	}
+}
+
+func (suite *recordHeadersSuite) TestCorruptHeaders() {
+	_, err := DecodeRecordHeaders(append(append([]byte(nil), recordHeadersMarker...), '{'))
+	suite.Require().Error(err)
+}
+
+func TestRecordHeadersSuite(t *testing.T) {
+	suite.Run(t, new(recordHeadersSuite))
+}
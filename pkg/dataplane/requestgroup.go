@@ -0,0 +1,170 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// RequestGroup submits asynchronous requests to a container and waits for all of their responses. the
+// response channel is sized to the maximum number of requests so that workers never block on it
+type RequestGroup struct {
+	container    Container
+	responseChan chan *Response
+	indexByID    map[uint64]int
+	responses    []*Response
+	numReceived  int
+}
+
+// NewRequestGroup creates a request group which can hold up to maxRequests requests
+func NewRequestGroup(container Container, maxRequests int) *RequestGroup {
+	return &RequestGroup{
+		container:    container,
+		responseChan: make(chan *Response, maxRequests),
+		indexByID:    map[uint64]int{},
+	}
+}
+
+// Submit sends an asynchronous request for the given input (e.g. *GetItemInput). context is passed
+// as is in the response
+func (rg *RequestGroup) Submit(input interface{}, context interface{}) error {
+	if len(rg.responses) == cap(rg.responseChan) {
+		return v3ioerrors.NewErrorWithLimit(errors.Wrap(v3ioerrors.ErrLimitExceeded, "Request group is full"),
+			"maxRequests",
+			len(rg.responses)+1,
+			cap(rg.responseChan))
+	}
+
+	request, err := rg.submit(input, context)
+	if err != nil {
+		return errors.Wrap(err, "Failed to submit request")
+	}
+
+	rg.indexByID[request.ID] = len(rg.responses)
+	rg.responses = append(rg.responses, nil)
+
+	return nil
+}
+
+// Wait waits for the responses of all submitted requests and returns them in submission order. if ctx
+// is done first, the responses received so far are returned (the others are nil) along with ctx's error.
+// the caller must call Release once done with the responses
+func (rg *RequestGroup) Wait(ctx context.Context) ([]*Response, error) {
+	for rg.numReceived < len(rg.responses) {
+		select {
+		case response := <-rg.responseChan:
+			rg.receive(response)
+		case <-ctx.Done():
+			return rg.responses, ctx.Err()
+		}
+	}
+
+	return rg.responses, nil
+}
+
+// Release releases all the responses received so far, including those which arrived after Wait returned
+func (rg *RequestGroup) Release() {
+	for len(rg.responseChan) > 0 {
+		rg.receive(<-rg.responseChan)
+	}
+
+	for responseIdx, response := range rg.responses {
+		if response != nil {
+			response.Release()
+			rg.responses[responseIdx] = nil
+		}
+	}
+}
+
+func (rg *RequestGroup) receive(response *Response) {
+	responseIdx, found := rg.indexByID[response.ID]
+	if !found {
+		return
+	}
+
+	rg.responses[responseIdx] = response
+	rg.numReceived++
+}
+
+func (rg *RequestGroup) submit(input interface{}, context interface{}) (*Request, error) {
+	switch typedInput := input.(type) {
+	case *GetClusterMDInput:
+		return rg.container.GetClusterMD(typedInput, context, rg.responseChan)
+	case *GetContainersInput:
+		return rg.container.GetContainers(typedInput, context, rg.responseChan)
+	case *GetContainerContentsInput:
+		return rg.container.GetContainerContents(typedInput, context, rg.responseChan)
+	case *CheckPathExistsInput:
+		return rg.container.CheckPathExists(typedInput, context, rg.responseChan)
+	case *GetObjectInput:
+		return rg.container.GetObject(typedInput, context, rg.responseChan)
+	case *PutObjectInput:
+		return rg.container.PutObject(typedInput, context, rg.responseChan)
+	case *DeleteObjectInput:
+		return rg.container.DeleteObject(typedInput, context, rg.responseChan)
+	case *GetItemInput:
+		return rg.container.GetItem(typedInput, context, rg.responseChan)
+	case *GetItemsInput:
+		return rg.container.GetItems(typedInput, context, rg.responseChan)
+	case *PutItemInput:
+		return rg.container.PutItem(typedInput, context, rg.responseChan)
+	case *PutItemsInput:
+		return rg.container.PutItems(typedInput, context, rg.responseChan)
+	case *UpdateItemInput:
+		return rg.container.UpdateItem(typedInput, context, rg.responseChan)
+	case *CreateStreamInput:
+		return rg.container.CreateStream(typedInput, context, rg.responseChan)
+	case *DescribeStreamInput:
+		return rg.container.DescribeStream(typedInput, context, rg.responseChan)
+	case *UpdateStreamInput:
+		streamUpdater, ok := rg.container.(StreamUpdater)
+		if !ok {
+			return nil, errors.Wrap(v3ioerrors.ErrNotSupported, "Container can't update streams")
+		}
+
+		return streamUpdater.UpdateStream(typedInput, context, rg.responseChan)
+	case *DeleteStreamInput:
+		return rg.container.DeleteStream(typedInput, context, rg.responseChan)
+	case *SeekShardInput:
+		return rg.container.SeekShard(typedInput, context, rg.responseChan)
+	case *PutRecordsInput:
+		return rg.container.PutRecords(typedInput, context, rg.responseChan)
+	case *PutChunkInput:
+		return rg.container.PutChunk(typedInput, context, rg.responseChan)
+	case *GetRecordsInput:
+		return rg.container.GetRecords(typedInput, context, rg.responseChan)
+	case *PutOOSObjectInput:
+		return rg.container.PutOOSObject(typedInput, context, rg.responseChan)
+	case *GetOOSObjectInput:
+		oosObjectGetter, ok := rg.container.(OOSObjectGetter)
+		if !ok {
+			return nil, errors.Wrap(v3ioerrors.ErrNotSupported, "Container can't get OOS objects")
+		}
+
+		return oosObjectGetter.GetOOSObject(typedInput, context, rg.responseChan)
+	default:
+		return nil, errors.Errorf("Unsupported input type %T", input)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// responds to GetItem requests for keys other than "pending" immediately
+type fakeContainer struct {
+	Container
+	nextID uint64
+}
+
+func (fc *fakeContainer) GetItem(getItemInput *GetItemInput,
+	context interface{},
+	responseChan chan *Response) (*Request, error) {
+	fc.nextID++
+
+	request := &Request{ID: fc.nextID, Input: getItemInput, Context: context}
+	if getItemInput.Path != "pending" {
+		responseChan <- &Response{ID: request.ID, Context: context}
+	}
+
+	return request, nil
+}
+
+// a fake container which can also update streams
+type fakeStreamUpdaterContainer struct {
+	fakeContainer
+}
+
+func (fsuc *fakeStreamUpdaterContainer) UpdateStream(updateStreamInput *UpdateStreamInput,
+	context interface{},
+	responseChan chan *Response) (*Request, error) {
+	fsuc.nextID++
+
+	request := &Request{ID: fsuc.nextID, Input: updateStreamInput, Context: context}
+	responseChan <- &Response{ID: request.ID, Context: context}
+
+	return request, nil
+}
+
+func (fsuc *fakeStreamUpdaterContainer) UpdateStreamSync(updateStreamInput *UpdateStreamInput) error {
+	return nil
+}
+
+type requestGroupSuite struct {
+	suite.Suite
+}
+
+func (suite *requestGroupSuite) TestWait() {
+	requestGroup := NewRequestGroup(&fakeContainer{}, 3)
+
+	for _, path := range []string{"a", "b", "c"} {
+		suite.Require().NoError(requestGroup.Submit(&GetItemInput{Path: path}, path))
+	}
+
+	// the group is full
+	suite.Require().Error(requestGroup.Submit(&GetItemInput{Path: "d"}, nil))
+
+	responses, err := requestGroup.Wait(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Len(responses, 3)
+
+	for responseIdx, path := range []string{"a", "b", "c"} {
+		suite.Require().Equal(path, responses[responseIdx].Context)
+	}
+
+	requestGroup.Release()
+}
+
+func (suite *requestGroupSuite) TestWaitTimeout() {
+	requestGroup := NewRequestGroup(&fakeContainer{}, 2)
+
+	suite.Require().NoError(requestGroup.Submit(&GetItemInput{Path: "a"}, nil))
+	suite.Require().NoError(requestGroup.Submit(&GetItemInput{Path: "pending"}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	responses, err := requestGroup.Wait(ctx)
+	suite.Require().Equal(context.DeadlineExceeded, err)
+	suite.Require().NotNil(responses[0])
+	suite.Require().Nil(responses[1])
+
+	requestGroup.Release()
+}
+
+func (suite *requestGroupSuite) TestUnsupportedInput() {
+	requestGroup := NewRequestGroup(&fakeContainer{}, 1)
+
+	suite.Require().Error(requestGroup.Submit(&UpdateObjectInput{}, nil))
+}
+
+func (suite *requestGroupSuite) TestUpdateStream() {
+	requestGroup := NewRequestGroup(&fakeStreamUpdaterContainer{}, 1)
+
+	suite.Require().NoError(requestGroup.Submit(&UpdateStreamInput{Path: "stream/", ShardCount: 2}, "stream"))
+
+	responses, err := requestGroup.Wait(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal("stream", responses[0].Context)
+
+	requestGroup.Release()
+
+	// containers which can't update streams fail the submission
+	err = NewRequestGroup(&fakeContainer{}, 1).Submit(&UpdateStreamInput{Path: "stream/"}, nil)
+	suite.Require().Equal(v3ioerrors.ErrNotSupported, errors.RootCause(err))
+}
+
+func (suite *requestGroupSuite) TestGetOOSObjectNotSupported() {
+	err := NewRequestGroup(&fakeContainer{}, 1).Submit(&GetOOSObjectInput{}, nil)
+	suite.Require().Equal(v3ioerrors.ErrNotSupported, errors.RootCause(err))
+}
+
+func TestRequestGroupSuite(t *testing.T) {
+	suite.Run(t, new(requestGroupSuite))
+}
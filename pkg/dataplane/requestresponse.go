@@ -16,7 +16,12 @@ limitations under the License.
 
 package v3io
 
-import "github.com/valyala/fasthttp"
+import (
+	"bytes"
+	"io"
+
+	"github.com/valyala/fasthttp"
+)
 
 type Request struct {
 	ID uint64
@@ -35,8 +40,19 @@ type Request struct {
 
 	// Request time
 	SendTimeNanoseconds int64
+
+	// requests with a higher priority are handled before queued requests with a lower one
+	Priority RequestPriority
 }
 
+type RequestPriority int
+
+const (
+	RequestPriorityLow    RequestPriority = -1
+	RequestPriorityNormal RequestPriority = 0
+	RequestPriorityHigh   RequestPriority = 1
+)
+
 type Response struct {
 
 	// hold a decoded output, if any
@@ -54,24 +70,79 @@ type Response struct {
 	// pointer to container
 	RequestResponse *RequestResponse
 
+	// the ID the request was sent with (see DataPlaneInput.RequestID)
+	RequestID string
+
 	// HTTP
 	HTTPResponse *fasthttp.Response
+
+	// called once when the response is released, if set
+	OnRelease func()
+
+	// only tracked in debug builds (see debugResponseOwnership)
+	released bool
 }
 
 func (r *Response) Release() {
+	if debugResponseOwnership {
+		r.checkNotReleased("Release")
+		r.released = true
+	}
+
 	if r.HTTPResponse != nil {
 		fasthttp.ReleaseResponse(r.HTTPResponse)
 	}
+
+	if r.OnRelease != nil {
+		r.OnRelease()
+		r.OnRelease = nil
+	}
 }
 
+// Body returns the body of the response, which is only valid until the response is released. use
+// BodyCopy or TakeBody to keep it
 func (r *Response) Body() []byte {
+	r.checkNotReleased("Body")
+
 	return r.HTTPResponse.Body()
 }
 
+// BodyCopy returns a copy of the body, which remains valid after the response is released
+func (r *Response) BodyCopy() []byte {
+	r.checkNotReleased("BodyCopy")
+
+	return append([]byte(nil), r.HTTPResponse.Body()...)
+}
+
+// BodyReader returns a reader of the body, which is only valid until the response is released
+func (r *Response) BodyReader() io.Reader {
+	r.checkNotReleased("BodyReader")
+
+	return bytes.NewReader(r.HTTPResponse.Body())
+}
+
+// TakeBody transfers ownership of the body to the caller without copying it. the body remains valid
+// after the response is released, and the response's body is empty afterwards
+func (r *Response) TakeBody() []byte {
+	r.checkNotReleased("TakeBody")
+
+	return r.HTTPResponse.SwapBody(nil)
+}
+
 func (r *Response) HeaderPeek(key string) []byte {
+	r.checkNotReleased("HeaderPeek")
+
 	return r.HTTPResponse.Header.Peek(key)
 }
 
+// panics if the response was released, in debug builds. accessing the underlying buffer after it's
+// released returns data of other responses rather than failing
+func (r *Response) checkNotReleased(operation string) {
+	if debugResponseOwnership && r.released {
+		panic("v3io: " + operation + " called on a released response")
+	}
+}
+
 func (r *Response) Request() *Request {
 	return &r.RequestResponse.Request
 }
@@ -0,0 +1,69 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+type responseBodySuite struct {
+	suite.Suite
+	response *Response
+}
+
+func (suite *responseBodySuite) SetupTest() {
+	suite.response = &Response{HTTPResponse: fasthttp.AcquireResponse()}
+	suite.response.HTTPResponse.SetBodyString("body")
+}
+
+func (suite *responseBodySuite) TestBodyCopy() {
+	body := suite.response.BodyCopy()
+	suite.response.HTTPResponse.SetBodyString("other")
+	suite.Require().Equal("body", string(body))
+	suite.response.Release()
+}
+
+func (suite *responseBodySuite) TestBodyReader() {
+	body, err := ioutil.ReadAll(suite.response.BodyReader())
+	suite.Require().NoError(err)
+	suite.Require().Equal("body", string(body))
+	suite.response.Release()
+}
+
+func (suite *responseBodySuite) TestTakeBody() {
+	body := suite.response.TakeBody()
+	suite.Require().Empty(suite.response.Body())
+	suite.response.Release()
+
+	// remains valid once the released buffer is reused
+	otherResponse := fasthttp.AcquireResponse()
+	otherResponse.SetBodyString("other")
+	suite.Require().Equal("body", string(body))
+	fasthttp.ReleaseResponse(otherResponse)
+}
+
+func TestResponseBodySuite(t *testing.T) {
+	suite.Run(t, new(responseBodySuite))
+}
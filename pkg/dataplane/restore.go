@@ -0,0 +1,267 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// ConflictPolicy controls how RestoreTable treats items which already exist in the table
+type ConflictPolicy string
+
+const (
+	// existing items are replaced
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+
+	// existing items are left as is
+	ConflictPolicySkipExisting ConflictPolicy = "skipExisting"
+
+	// the restore fails on the first existing item
+	ConflictPolicyFail ConflictPolicy = "fail"
+
+	// existing items are replaced only if they were modified before the exported item
+	ConflictPolicyMergeByMtime ConflictPolicy = "mergeByMtime"
+)
+
+func (cp ConflictPolicy) Validate() error {
+	switch cp {
+	case "", ConflictPolicyOverwrite, ConflictPolicySkipExisting, ConflictPolicyFail, ConflictPolicyMergeByMtime:
+		return nil
+	default:
+		return errors.Errorf("Invalid conflict policy: %s", cp)
+	}
+}
+
+type RestoreTableInput struct {
+	DataPlaneInput
+	Path           string
+	ConflictPolicy ConflictPolicy // defaults to ConflictPolicyOverwrite
+
+	// the maximum number of items written concurrently (defaults to 16)
+	Parallelism int
+
+	// the number of items to skip from the start of the source, for resuming a restore. set to the
+	// NumProcessedItems of the last progress reported by the interrupted restore
+	ResumeFrom int
+
+	// if set, called after each batch of items is written
+	Progress func(*RestoreTableProgress)
+}
+
+type RestoreTableProgress struct {
+	NumProcessedItems int // includes the items skipped due to ResumeFrom
+	NumRestoredItems  int
+	NumSkippedItems   int // existing items which were left as is, according to the conflict policy
+}
+
+type RestoreTableOutput struct {
+	RestoreTableProgress
+}
+
+// RestoreTable writes the items returned by nextItem (until it returns a nil item) to the table, e.g. items
+// exported with ExportTable. items are identified by their __name attribute, and merging by mtime requires
+// their __mtime_secs and __mtime_nsecs attributes - all of which ExportTable exports by default. other
+// system attributes are not restored
+func RestoreTable(container Container,
+	restoreTableInput *RestoreTableInput,
+	nextItem func() (Item, error)) (*RestoreTableOutput, error) {
+
+	conflictPolicy := restoreTableInput.ConflictPolicy
+	if conflictPolicy == "" {
+		conflictPolicy = ConflictPolicyOverwrite
+	}
+
+	if err := conflictPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
+	parallelism := restoreTableInput.Parallelism
+	if parallelism == 0 {
+		parallelism = 16
+	}
+
+	restoreTableOutput := RestoreTableOutput{}
+
+	for {
+		items, err := readItems(nextItem, parallelism)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read items")
+		}
+
+		if len(items) == 0 {
+			return &restoreTableOutput, nil
+		}
+
+		// skip the items restored before resuming
+		numItemsToSkip := restoreTableInput.ResumeFrom - restoreTableOutput.NumProcessedItems
+		if numItemsToSkip > 0 {
+			if numItemsToSkip > len(items) {
+				numItemsToSkip = len(items)
+			}
+
+			restoreTableOutput.NumProcessedItems += numItemsToSkip
+			items = items[numItemsToSkip:]
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		if err := restoreItems(container, restoreTableInput, conflictPolicy, items, &restoreTableOutput); err != nil {
+			return nil, errors.Wrapf(err, "Failed to restore table %s", restoreTableInput.Path)
+		}
+
+		if restoreTableInput.Progress != nil {
+			progress := restoreTableOutput.RestoreTableProgress
+			restoreTableInput.Progress(&progress)
+		}
+	}
+}
+
+func readItems(nextItem func() (Item, error), maxItems int) ([]Item, error) {
+	var items []Item
+
+	for len(items) < maxItems {
+		item, err := nextItem()
+		if err != nil {
+			return nil, err
+		}
+
+		if item == nil {
+			break
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// writes a batch of items, such that the progress only counts items whose predecessors were all written
+func restoreItems(container Container,
+	restoreTableInput *RestoreTableInput,
+	conflictPolicy ConflictPolicy,
+	items []Item,
+	restoreTableOutput *RestoreTableOutput) error {
+
+	requestGroup := NewRequestGroup(container, len(items))
+	defer requestGroup.Release()
+
+	itemNames := make([]string, len(items))
+
+	for itemIdx, item := range items {
+		putItemInput, err := getRestorePutItemInput(restoreTableInput, conflictPolicy, item)
+		if err != nil {
+			return err
+		}
+
+		itemNames[itemIdx] = putItemInput.Path
+
+		if err := requestGroup.Submit(putItemInput, nil); err != nil {
+			return err
+		}
+	}
+
+	ctx := restoreTableInput.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	responses, err := requestGroup.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	for responseIdx, response := range responses {
+		if response.Error == nil {
+			restoreTableOutput.NumRestoredItems++
+		} else if !isPreconditionFailed(response.Error) {
+			return errors.Wrapf(response.Error, "Failed to restore item %s", itemNames[responseIdx])
+		} else if conflictPolicy == ConflictPolicyFail {
+			return errors.Errorf("Item %s already exists", itemNames[responseIdx])
+		} else {
+			restoreTableOutput.NumSkippedItems++
+		}
+
+		restoreTableOutput.NumProcessedItems++
+	}
+
+	return nil
+}
+
+func getRestorePutItemInput(restoreTableInput *RestoreTableInput,
+	conflictPolicy ConflictPolicy,
+	item Item) (*PutItemInput, error) {
+
+	itemName, err := item.GetName()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get item name")
+	}
+
+	attributes := map[string]interface{}{}
+	for attributeName, attributeValue := range item {
+		if !strings.HasPrefix(attributeName, "__") {
+			attributes[attributeName] = attributeValue
+		}
+	}
+
+	putItemInput := PutItemInput{
+		DataPlaneInput: restoreTableInput.DataPlaneInput,
+		Path:           strings.TrimSuffix(restoreTableInput.Path, "/") + "/" + itemName,
+		Attributes:     attributes,
+		UpdateMode:     UpdateModeOverwrite,
+	}
+
+	switch conflictPolicy {
+	case ConflictPolicySkipExisting, ConflictPolicyFail:
+		putItemInput.Condition = "not(exists(__name))"
+	case ConflictPolicyMergeByMtime:
+		mtimeSecs, err := item.GetFieldInt("__mtime_secs")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get mtime of item %s", itemName)
+		}
+
+		mtimeNSecs, err := item.GetFieldInt("__mtime_nsecs")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get mtime of item %s", itemName)
+		}
+
+		putItemInput.Condition = fmt.Sprintf("not(exists(__name)) OR (__mtime_secs < %d) OR "+
+			"((__mtime_secs == %d) AND (__mtime_nsecs < %d))",
+			mtimeSecs,
+			mtimeSecs,
+			mtimeNSecs)
+	}
+
+	return &putItemInput, nil
+}
+
+func isPreconditionFailed(err error) bool {
+	statusCode, _ := v3ioerrors.GetStatusCode(err)
+	return statusCode == http.StatusPreconditionFailed
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// holds the items written with PutItem, failing conditional puts of existing items
+type fakeTableContainer struct {
+	Container
+	nextID uint64
+	items  map[string]map[string]interface{}
+}
+
+func (ftc *fakeTableContainer) PutItem(putItemInput *PutItemInput,
+	context interface{},
+	responseChan chan *Response) (*Request, error) {
+	ftc.nextID++
+
+	response := &Response{ID: ftc.nextID, Context: context}
+	if _, exists := ftc.items[putItemInput.Path]; exists && putItemInput.Condition != "" {
+		response.Error = v3ioerrors.NewErrorWithStatusCode(errors.New("Precondition failed"),
+			http.StatusPreconditionFailed)
+	} else {
+		ftc.items[putItemInput.Path] = putItemInput.Attributes
+	}
+
+	responseChan <- response
+
+	return &Request{ID: ftc.nextID, Input: putItemInput, Context: context}, nil
+}
+
+type restoreTableSuite struct {
+	suite.Suite
+	container *fakeTableContainer
+}
+
+func (suite *restoreTableSuite) SetupTest() {
+	suite.container = &fakeTableContainer{
+		items: map[string]map[string]interface{}{
+			"table/b": {"value": 0},
+		},
+	}
+}
+
+func (suite *restoreTableSuite) TestSkipExisting() {
+	var progresses []RestoreTableProgress
+
+	restoreTableOutput, err := RestoreTable(suite.container, &RestoreTableInput{
+		Path:           "table/",
+		ConflictPolicy: ConflictPolicySkipExisting,
+		Parallelism:    2,
+		Progress: func(progress *RestoreTableProgress) {
+			progresses = append(progresses, *progress)
+		},
+	}, suite.getItemSource("a", "b", "c"))
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(RestoreTableProgress{NumProcessedItems: 3, NumRestoredItems: 2, NumSkippedItems: 1},
+		restoreTableOutput.RestoreTableProgress)
+	suite.Require().Equal([]RestoreTableProgress{
+		{NumProcessedItems: 2, NumRestoredItems: 1, NumSkippedItems: 1},
+		{NumProcessedItems: 3, NumRestoredItems: 2, NumSkippedItems: 1},
+	}, progresses)
+
+	// system attributes aren't restored, and existing items are left as is
+	suite.Require().Equal(map[string]interface{}{"value": 1}, suite.container.items["table/a"])
+	suite.Require().Equal(map[string]interface{}{"value": 0}, suite.container.items["table/b"])
+}
+
+func (suite *restoreTableSuite) TestFail() {
+	_, err := RestoreTable(suite.container, &RestoreTableInput{
+		Path:           "table",
+		ConflictPolicy: ConflictPolicyFail,
+	}, suite.getItemSource("a", "b"))
+	suite.Require().Error(err)
+}
+
+func (suite *restoreTableSuite) TestResume() {
+	restoreTableOutput, err := RestoreTable(suite.container, &RestoreTableInput{
+		Path:        "table",
+		Parallelism: 2,
+		ResumeFrom:  3,
+	}, suite.getItemSource("a", "b", "c", "d"))
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(RestoreTableProgress{NumProcessedItems: 4, NumRestoredItems: 1},
+		restoreTableOutput.RestoreTableProgress)
+	suite.Require().Len(suite.container.items, 2)
+	suite.Require().Contains(suite.container.items, "table/d")
+}
+
+func (suite *restoreTableSuite) getItemSource(itemNames ...string) func() (Item, error) {
+	return func() (Item, error) {
+		if len(itemNames) == 0 {
+			return nil, nil
+		}
+
+		item := Item{"__name": itemNames[0], "__mtime_secs": 1, "value": 1}
+		itemNames = itemNames[1:]
+
+		return item, nil
+	}
+}
+
+func TestRestoreTableSuite(t *testing.T) {
+	suite.Run(t, new(restoreTableSuite))
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package s3adapter
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+const defaultMaxKeys = 1000
+
+type client struct {
+	session    v3io.Session
+	lock       sync.Mutex
+	containers map[string]v3io.Container
+}
+
+// NewClient creates a client which reaches the containers named by buckets through the session
+func NewClient(session v3io.Session) Client {
+	return &client{
+		session:    session,
+		containers: map[string]v3io.Container{},
+	}
+}
+
+func (c *client) PutObject(putObjectInput *PutObjectInput) (*PutObjectOutput, error) {
+	container, err := c.getContainer(putObjectInput.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := container.PutObjectSync(&v3io.PutObjectInput{
+		Path: keyToPath(putObjectInput.Key),
+		Body: putObjectInput.Body,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &PutObjectOutput{}, nil
+}
+
+func (c *client) GetObject(getObjectInput *GetObjectInput) (*GetObjectOutput, error) {
+	container, err := c.getContainer(getObjectInput.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := container.GetObjectSync(&v3io.GetObjectInput{
+		Path: keyToPath(getObjectInput.Key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Release()
+
+	// the body is released along with the response
+	body := append([]byte(nil), response.Body()...)
+
+	return &GetObjectOutput{
+		Body:          body,
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+func (c *client) DeleteObject(deleteObjectInput *DeleteObjectInput) (*DeleteObjectOutput, error) {
+	container, err := c.getContainer(deleteObjectInput.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := container.DeleteObjectSync(&v3io.DeleteObjectInput{
+		Path: keyToPath(deleteObjectInput.Key),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &DeleteObjectOutput{}, nil
+}
+
+// ListObjectsV2 reads all of the entries under the prefix for each page, as v3io lists directories rather
+// than key ranges
+func (c *client) ListObjectsV2(listObjectsV2Input *ListObjectsV2Input) (*ListObjectsV2Output, error) {
+	if listObjectsV2Input.Delimiter != "" && listObjectsV2Input.Delimiter != "/" {
+		return nil, errors.Errorf("Unsupported delimiter: %s", listObjectsV2Input.Delimiter)
+	}
+
+	container, err := c.getContainer(listObjectsV2Input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	maxKeys := listObjectsV2Input.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+
+	// the directory holding the keys with the prefix
+	prefix := listObjectsV2Input.Prefix
+	dirPath := prefix[:strings.LastIndex(prefix, "/")+1]
+
+	objects := map[string]Object{}
+	commonPrefixes := map[string]bool{}
+
+	if err := c.listDirectory(container,
+		dirPath,
+		prefix,
+		listObjectsV2Input.Delimiter == "",
+		objects,
+		commonPrefixes); err != nil {
+		return nil, err
+	}
+
+	// entries are paged in order of their keys, objects and common prefixes alike
+	var keys []string
+	for key := range objects {
+		keys = append(keys, key)
+	}
+
+	for commonPrefix := range commonPrefixes {
+		keys = append(keys, commonPrefix)
+	}
+
+	sort.Strings(keys)
+
+	startAfter := listObjectsV2Input.StartAfter
+	if listObjectsV2Input.ContinuationToken != "" {
+		startAfter = listObjectsV2Input.ContinuationToken
+	}
+
+	listObjectsV2Output := ListObjectsV2Output{}
+
+	for _, key := range keys {
+		if key <= startAfter {
+			continue
+		}
+
+		if listObjectsV2Output.KeyCount == maxKeys {
+			listObjectsV2Output.IsTruncated = true
+			break
+		}
+
+		if object, found := objects[key]; found {
+			listObjectsV2Output.Contents = append(listObjectsV2Output.Contents, object)
+		} else {
+			listObjectsV2Output.CommonPrefixes = append(listObjectsV2Output.CommonPrefixes, key)
+		}
+
+		listObjectsV2Output.KeyCount++
+		listObjectsV2Output.NextContinuationToken = key
+	}
+
+	if !listObjectsV2Output.IsTruncated {
+		listObjectsV2Output.NextContinuationToken = ""
+	}
+
+	return &listObjectsV2Output, nil
+}
+
+// lists the entries of a directory which start with the prefix, descending into subdirectories if recursive
+func (c *client) listDirectory(container v3io.Container,
+	dirPath string,
+	prefix string,
+	recursive bool,
+	objects map[string]Object,
+	commonPrefixes map[string]bool) error {
+	marker := ""
+
+	for {
+		response, err := container.GetContainerContentsSync(&v3io.GetContainerContentsInput{
+			Path:   v3io.DirectoryPath(keyToPath(dirPath)),
+			Marker: marker,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list directory %s", dirPath)
+		}
+
+		getContainerContentsOutput := response.Output.(*v3io.GetContainerContentsOutput)
+		response.Release()
+
+		for _, content := range getContainerContentsOutput.Contents {
+			if !strings.HasPrefix(content.Key, prefix) {
+				continue
+			}
+
+			object := Object{Key: content.Key}
+			if content.Size != nil {
+				object.Size = int64(*content.Size)
+			}
+
+			object.LastModified, _ = time.Parse(time.RFC3339Nano, content.LastModified)
+			objects[content.Key] = object
+		}
+
+		for _, commonPrefix := range getContainerContentsOutput.CommonPrefixes {
+			subdirPath := commonPrefix.Prefix
+			if !strings.HasPrefix(subdirPath, prefix) {
+				continue
+			}
+
+			if !recursive {
+				commonPrefixes[subdirPath] = true
+				continue
+			}
+
+			if err := c.listDirectory(container, subdirPath, prefix, recursive, objects, commonPrefixes); err != nil {
+				return err
+			}
+		}
+
+		if !getContainerContentsOutput.IsTruncated || getContainerContentsOutput.NextMarker == "" {
+			return nil
+		}
+
+		marker = getContainerContentsOutput.NextMarker
+	}
+}
+
+func (c *client) getContainer(bucket string) (v3io.Container, error) {
+	if bucket == "" {
+		return nil, errors.New("Bucket must be set")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if container, found := c.containers[bucket]; found {
+		return container, nil
+	}
+
+	container, err := c.session.NewContainer(&v3io.NewContainerInput{ContainerName: bucket})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create container for bucket %s", bucket)
+	}
+
+	c.containers[bucket] = container
+
+	return container, nil
+}
+
+// keys are relative to the container root
+func keyToPath(key string) string {
+	return path.Join("/", key)
+}
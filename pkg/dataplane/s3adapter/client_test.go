@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package s3adapter
+
+import (
+	"testing"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iomock "github.com/v3io/v3io-go/pkg/dataplane/mock"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type clientSuite struct {
+	suite.Suite
+	client Client
+}
+
+func (suite *clientSuite) SetupTest() {
+	session, err := v3iomock.NewContext().NewSession(&v3io.NewSessionInput{URL: "http://localhost:8081"})
+	suite.Require().NoError(err)
+
+	suite.client = NewClient(session)
+
+	for _, key := range []string{"dir/a", "dir/b", "dir/sub/c", "dir/sub/deeper/d", "other"} {
+		_, err := suite.client.PutObject(&PutObjectInput{Bucket: "bigdata", Key: key, Body: []byte(key)})
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *clientSuite) TestGetAndDeleteObject() {
+	getObjectOutput, err := suite.client.GetObject(&GetObjectInput{Bucket: "bigdata", Key: "dir/sub/c"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("dir/sub/c", string(getObjectOutput.Body))
+	suite.Require().Equal(int64(9), getObjectOutput.ContentLength)
+
+	_, err = suite.client.DeleteObject(&DeleteObjectInput{Bucket: "bigdata", Key: "dir/sub/c"})
+	suite.Require().NoError(err)
+
+	_, err = suite.client.GetObject(&GetObjectInput{Bucket: "bigdata", Key: "dir/sub/c"})
+	suite.Require().Error(err)
+}
+
+func (suite *clientSuite) TestListObjectsWithDelimiter() {
+	listObjectsV2Output, err := suite.client.ListObjectsV2(&ListObjectsV2Input{
+		Bucket:    "bigdata",
+		Prefix:    "dir/",
+		Delimiter: "/",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"dir/a", "dir/b"}, suite.getKeys(listObjectsV2Output))
+	suite.Require().Equal([]string{"dir/sub/"}, listObjectsV2Output.CommonPrefixes)
+	suite.Require().Equal(3, listObjectsV2Output.KeyCount)
+	suite.Require().False(listObjectsV2Output.IsTruncated)
+}
+
+func (suite *clientSuite) TestListObjectsRecursivePaged() {
+	var keys []string
+	continuationToken := ""
+
+	for {
+		listObjectsV2Output, err := suite.client.ListObjectsV2(&ListObjectsV2Input{
+			Bucket:            "bigdata",
+			Prefix:            "dir/",
+			MaxKeys:           3,
+			ContinuationToken: continuationToken,
+		})
+		suite.Require().NoError(err)
+		suite.Require().Empty(listObjectsV2Output.CommonPrefixes)
+
+		keys = append(keys, suite.getKeys(listObjectsV2Output)...)
+		if !listObjectsV2Output.IsTruncated {
+			break
+		}
+
+		continuationToken = listObjectsV2Output.NextContinuationToken
+	}
+
+	suite.Require().Equal([]string{"dir/a", "dir/b", "dir/sub/c", "dir/sub/deeper/d"}, keys)
+
+	// a prefix which isn't a directory
+	listObjectsV2Output, err := suite.client.ListObjectsV2(&ListObjectsV2Input{Bucket: "bigdata", Prefix: "dir/s"})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"dir/sub/c", "dir/sub/deeper/d"}, suite.getKeys(listObjectsV2Output))
+}
+
+func (suite *clientSuite) getKeys(listObjectsV2Output *ListObjectsV2Output) []string {
+	var keys []string
+	for _, object := range listObjectsV2Output.Contents {
+		keys = append(keys, object.Key)
+	}
+
+	return keys
+}
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(clientSuite))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+// Package s3adapter exposes a minimal S3 shaped object client over v3io, to ease porting code written against
+// S3. buckets are containers and keys are paths relative to the container
+package s3adapter
+
+import (
+	"time"
+)
+
+type Client interface {
+	PutObject(*PutObjectInput) (*PutObjectOutput, error)
+	GetObject(*GetObjectInput) (*GetObjectOutput, error)
+	DeleteObject(*DeleteObjectInput) (*DeleteObjectOutput, error)
+
+	// ListObjectsV2 lists the objects whose keys start with the prefix, in order of their keys. only "/" is
+	// supported as a delimiter
+	ListObjectsV2(*ListObjectsV2Input) (*ListObjectsV2Output, error)
+}
+
+type PutObjectInput struct {
+	Bucket string
+	Key    string
+	Body   []byte
+}
+
+type PutObjectOutput struct{}
+
+type GetObjectInput struct {
+	Bucket string
+	Key    string
+}
+
+type GetObjectOutput struct {
+	Body          []byte
+	ContentLength int64
+}
+
+type DeleteObjectInput struct {
+	Bucket string
+	Key    string
+}
+
+type DeleteObjectOutput struct{}
+
+type ListObjectsV2Input struct {
+	Bucket            string
+	Prefix            string
+	Delimiter         string
+	MaxKeys           int    // defaults to 1000
+	ContinuationToken string // the NextContinuationToken of the previous page
+	StartAfter        string
+}
+
+type ListObjectsV2Output struct {
+	Contents              []Object
+	CommonPrefixes        []string
+	IsTruncated           bool
+	NextContinuationToken string
+	KeyCount              int
+}
+
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+// the object describing a table to frames, spark and the platform UI
+const tableSchemaObjectName = ".#schema"
+
+// the field types of table schemas
+const (
+	TableSchemaFieldTypeLong      = "long"
+	TableSchemaFieldTypeDouble    = "double"
+	TableSchemaFieldTypeString    = "string"
+	TableSchemaFieldTypeBoolean   = "boolean"
+	TableSchemaFieldTypeTimestamp = "timestamp"
+	TableSchemaFieldTypeBinary    = "binary"
+)
+
+// TableSchema is the contents of a table's .#schema object
+type TableSchema struct {
+	Fields           []TableSchemaField `json:"fields"`
+	PartitionBy      []string           `json:"partitionBy"`
+	Key              string             `json:"key"` // the field whose value is the item's name
+	HashingBucketNum int                `json:"hashingBucketNum"`
+	SortingKey       string             `json:"sortingKey,omitempty"`
+}
+
+type TableSchemaField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+func (ts *TableSchema) Validate() error {
+	fieldNames := map[string]bool{}
+
+	for _, field := range ts.Fields {
+		switch field.Type {
+		case TableSchemaFieldTypeLong,
+			TableSchemaFieldTypeDouble,
+			TableSchemaFieldTypeString,
+			TableSchemaFieldTypeBoolean,
+			TableSchemaFieldTypeTimestamp,
+			TableSchemaFieldTypeBinary:
+		default:
+			return errors.Errorf("Invalid type of field %s: %s", field.Name, field.Type)
+		}
+
+		fieldNames[field.Name] = true
+	}
+
+	if !fieldNames[ts.Key] {
+		return errors.Errorf("Key %s isn't a field", ts.Key)
+	}
+
+	if ts.SortingKey != "" && !fieldNames[ts.SortingKey] {
+		return errors.Errorf("Sorting key %s isn't a field", ts.SortingKey)
+	}
+
+	return nil
+}
+
+type CreateSchemaInput struct {
+	DataPlaneInput
+	Path   string // the table's path
+	Schema *TableSchema
+}
+
+type GetSchemaInput struct {
+	DataPlaneInput
+	Path string
+}
+
+type InferSchemaInput struct {
+	DataPlaneInput
+	Path string
+
+	// the key field. if not set, it's the field whose value is the name of all of the sampled items
+	Key string
+
+	// the number of items the field types are inferred from (defaults to 1000)
+	NumSampledItems int
+}
+
+// CreateSchema writes the table's .#schema object, replacing it if it exists
+func CreateSchema(container Container, createSchemaInput *CreateSchemaInput) error {
+	if err := createSchemaInput.Schema.Validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(createSchemaInput.Schema)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode schema")
+	}
+
+	if err := container.PutObjectSync(&PutObjectInput{
+		DataPlaneInput: createSchemaInput.DataPlaneInput,
+		Path:           getTableSchemaPath(createSchemaInput.Path),
+		Body:           body,
+	}); err != nil {
+		return errors.Wrapf(err, "Failed to create schema of table %s", createSchemaInput.Path)
+	}
+
+	return nil
+}
+
+// GetSchema reads the table's .#schema object
+func GetSchema(container Container, getSchemaInput *GetSchemaInput) (*TableSchema, error) {
+	response, err := container.GetObjectSync(&GetObjectInput{
+		DataPlaneInput: getSchemaInput.DataPlaneInput,
+		Path:           getTableSchemaPath(getSchemaInput.Path),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Release()
+
+	tableSchema := TableSchema{}
+	if err := json.Unmarshal(response.Body(), &tableSchema); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode schema of table %s", getSchemaInput.Path)
+	}
+
+	return &tableSchema, nil
+}
+
+// InferSchema derives a schema from the attributes of the table's first items, to be written with
+// CreateSchema. ints and floats of the same attribute are inferred as doubles
+func InferSchema(container Container, inferSchemaInput *InferSchemaInput) (*TableSchema, error) {
+	numSampledItems := inferSchemaInput.NumSampledItems
+	if numSampledItems <= 0 {
+		numSampledItems = 1000
+	}
+
+	fields := map[string]*TableSchemaField{}
+	numItemsWithField := map[string]int{}
+	numItems := 0
+
+	// fields whose value is the name of every item so far
+	var keyCandidates map[string]bool
+
+	itemsCursor, err := NewItemsCursor(container, &GetItemsInput{
+		DataPlaneInput: inferSchemaInput.DataPlaneInput,
+		Path:           inferSchemaInput.Path,
+		AttributeNames: []string{"__name", "*"},
+		Limit:          numSampledItems,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan table %s", inferSchemaInput.Path)
+	}
+
+	defer itemsCursor.Release()
+
+	for numItems < numSampledItems && itemsCursor.NextSync() {
+		item := itemsCursor.GetItem()
+		itemName, _ := item.GetFieldString("__name")
+		itemKeyCandidates := map[string]bool{}
+
+		for attributeName, attributeValue := range item {
+			if strings.HasPrefix(attributeName, "__") || attributeValue == nil {
+				continue
+			}
+
+			fieldType, err := getTableSchemaFieldType(attributeValue)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to infer the type of attribute %s", attributeName)
+			}
+
+			field, found := fields[attributeName]
+			if !found {
+				field = &TableSchemaField{Name: attributeName, Type: fieldType}
+				fields[attributeName] = field
+			} else if field.Type != fieldType {
+				if !isNumericTableSchemaFieldType(field.Type) || !isNumericTableSchemaFieldType(fieldType) {
+					return nil, errors.Errorf("Attribute %s has mixed types %s and %s", attributeName, field.Type, fieldType)
+				}
+
+				field.Type = TableSchemaFieldTypeDouble
+			}
+
+			numItemsWithField[attributeName]++
+
+			if fmt.Sprint(attributeValue) == itemName && (keyCandidates == nil || keyCandidates[attributeName]) {
+				itemKeyCandidates[attributeName] = true
+			}
+		}
+
+		keyCandidates = itemKeyCandidates
+		numItems++
+	}
+
+	if err := itemsCursor.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan table %s", inferSchemaInput.Path)
+	}
+
+	tableSchema := TableSchema{
+		PartitionBy: []string{},
+		Key:         inferSchemaInput.Key,
+	}
+
+	for _, field := range fields {
+		field.Nullable = numItemsWithField[field.Name] < numItems
+		tableSchema.Fields = append(tableSchema.Fields, *field)
+	}
+
+	sort.Slice(tableSchema.Fields, func(i, j int) bool {
+		return tableSchema.Fields[i].Name < tableSchema.Fields[j].Name
+	})
+
+	if tableSchema.Key == "" {
+		for _, field := range tableSchema.Fields {
+			if keyCandidates[field.Name] {
+				tableSchema.Key = field.Name
+				break
+			}
+		}
+
+		if tableSchema.Key == "" {
+			return nil, errors.Errorf("No attribute of table %s holds the item names, so the key must be given",
+				inferSchemaInput.Path)
+		}
+	}
+
+	if err := tableSchema.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &tableSchema, nil
+}
+
+func getTableSchemaFieldType(value interface{}) (string, error) {
+	switch value.(type) {
+	case int, int64, int32, uint32, uint64:
+		return TableSchemaFieldTypeLong, nil
+	case float64:
+		return TableSchemaFieldTypeDouble, nil
+	case string:
+		return TableSchemaFieldTypeString, nil
+	case bool:
+		return TableSchemaFieldTypeBoolean, nil
+	case time.Time:
+		return TableSchemaFieldTypeTimestamp, nil
+	case []byte:
+		return TableSchemaFieldTypeBinary, nil
+	default:
+		return "", errors.Errorf("Unsupported attribute type %T", value)
+	}
+}
+
+func isNumericTableSchemaFieldType(fieldType string) bool {
+	return fieldType == TableSchemaFieldTypeLong || fieldType == TableSchemaFieldTypeDouble
+}
+
+func getTableSchemaPath(tablePath string) string {
+	return DirectoryPath(tablePath) + tableSchemaObjectName
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+// holds the objects written with PutObjectSync
+type fakeObjectContainer struct {
+	Container
+	objects map[string][]byte
+}
+
+func (foc *fakeObjectContainer) PutObjectSync(putObjectInput *PutObjectInput) error {
+	foc.objects[putObjectInput.Path] = putObjectInput.Body
+	return nil
+}
+
+func (foc *fakeObjectContainer) GetObjectSync(getObjectInput *GetObjectInput) (*Response, error) {
+	httpResponse := fasthttp.AcquireResponse()
+	httpResponse.SetBody(foc.objects[getObjectInput.Path])
+
+	return &Response{HTTPResponse: httpResponse}, nil
+}
+
+type schemaSuite struct {
+	suite.Suite
+}
+
+func (suite *schemaSuite) TestInferSchema() {
+	container := &fakeTableContainer{
+		items: map[string]map[string]interface{}{
+			"table/1": {"id": 1, "name": "a", "score": 1},
+			"table/2": {"id": 2, "name": "b", "score": 2.5, "ok": true},
+		},
+	}
+
+	tableSchema, err := InferSchema(container, &InferSchemaInput{Path: "table/"})
+	suite.Require().NoError(err)
+
+	// the key is the attribute holding the item names
+	suite.Require().Equal(&TableSchema{
+		Fields: []TableSchemaField{
+			{Name: "id", Type: TableSchemaFieldTypeLong},
+			{Name: "name", Type: TableSchemaFieldTypeString},
+			{Name: "ok", Type: TableSchemaFieldTypeBoolean, Nullable: true},
+			{Name: "score", Type: TableSchemaFieldTypeDouble},
+		},
+		PartitionBy: []string{},
+		Key:         "id",
+	}, tableSchema)
+
+	// with no such attribute, the key must be given
+	container.items["table/3"] = map[string]interface{}{"id": 4}
+	_, err = InferSchema(container, &InferSchemaInput{Path: "table/"})
+	suite.Require().Error(err)
+
+	tableSchema, err = InferSchema(container, &InferSchemaInput{Path: "table/", Key: "name"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("name", tableSchema.Key)
+}
+
+func (suite *schemaSuite) TestCreateAndGetSchema() {
+	container := &fakeObjectContainer{objects: map[string][]byte{}}
+	tableSchema := &TableSchema{
+		Fields:      []TableSchemaField{{Name: "id", Type: TableSchemaFieldTypeLong}},
+		PartitionBy: []string{},
+		Key:         "id",
+	}
+
+	err := CreateSchema(container, &CreateSchemaInput{Path: "table", Schema: tableSchema})
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{"fields":[{"name":"id","type":"long","nullable":false}],"partitionBy":[],"key":"id",`+
+		`"hashingBucketNum":0}`, string(container.objects["table/.#schema"]))
+
+	readTableSchema, err := GetSchema(container, &GetSchemaInput{Path: "table/"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(tableSchema, readTableSchema)
+
+	// a key which isn't a field is invalid
+	err = CreateSchema(container, &CreateSchemaInput{Path: "table", Schema: &TableSchema{Key: "id"}})
+	suite.Require().Error(err)
+}
+
+func TestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(schemaSuite))
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+const (
+	seriesValuesAttributeKey = "_values"
+	seriesKeyAttributeKey    = "_series"
+	seriesStartAttributeKey  = "_start"
+)
+
+type NewSeriesAppenderInput struct {
+	Container Container
+
+	// the table the series items are written to
+	Path string
+
+	// the width of a value slot. samples falling in the same slot overwrite each other (defaults to 1 minute)
+	Resolution time.Duration
+
+	// the number of slots in each item, i.e. the time range an item covers (defaults to 1440)
+	SlotsPerItem int
+
+	// pending samples are flushed once there are this many (defaults to 4096)
+	MaxPendingSamples int
+
+	// the maximum number of updates in flight while flushing (defaults to 64)
+	MaxInflightUpdates int
+
+	// the timeout for a single flush (defaults to 1 minute)
+	FlushTimeout time.Duration
+}
+
+// SeriesAppender ingests time series samples into KV items. each item holds a fixed time range of a series
+// as an array attribute with a slot per Resolution, and samples are written with update expressions which
+// set array slots, so that appending to a series never requires reading it first
+type SeriesAppender struct {
+	lock               sync.Mutex
+	container          Container
+	path               string
+	resolutionMs       int64
+	slotsPerItem       int
+	maxPendingSamples  int
+	maxInflightUpdates int
+	flushTimeout       time.Duration
+	pendingUpdates     map[string]*seriesUpdate
+	numPendingSamples  int
+}
+
+type seriesUpdate struct {
+	quotedSeriesKey string // for use in the update expression
+	startMs         int64
+	values          map[int]float64
+}
+
+func NewSeriesAppender(newSeriesAppenderInput *NewSeriesAppenderInput) (*SeriesAppender, error) {
+	if newSeriesAppenderInput.Container == nil {
+		return nil, errors.New("Container must be set")
+	}
+
+	newSeriesAppender := SeriesAppender{
+		container:          newSeriesAppenderInput.Container,
+		path:               newSeriesAppenderInput.Path,
+		resolutionMs:       newSeriesAppenderInput.Resolution.Milliseconds(),
+		slotsPerItem:       newSeriesAppenderInput.SlotsPerItem,
+		maxPendingSamples:  newSeriesAppenderInput.MaxPendingSamples,
+		maxInflightUpdates: newSeriesAppenderInput.MaxInflightUpdates,
+		flushTimeout:       newSeriesAppenderInput.FlushTimeout,
+		pendingUpdates:     map[string]*seriesUpdate{},
+	}
+
+	if newSeriesAppender.resolutionMs <= 0 {
+		newSeriesAppender.resolutionMs = time.Minute.Milliseconds()
+	}
+
+	if newSeriesAppender.slotsPerItem <= 0 {
+		newSeriesAppender.slotsPerItem = 1440
+	}
+
+	if newSeriesAppender.maxPendingSamples <= 0 {
+		newSeriesAppender.maxPendingSamples = 4096
+	}
+
+	if newSeriesAppender.maxInflightUpdates <= 0 {
+		newSeriesAppender.maxInflightUpdates = 64
+	}
+
+	if newSeriesAppender.flushTimeout <= 0 {
+		newSeriesAppender.flushTimeout = time.Minute
+	}
+
+	return &newSeriesAppender, nil
+}
+
+// Append adds a sample to a series. samples are buffered and flushed once enough are pending, or when
+// Flush is called
+func (sa *SeriesAppender) Append(seriesKey string, timestampMs int64, value float64) error {
+	if seriesKey == "" || strings.Contains(seriesKey, "/") {
+		return errors.Errorf("Invalid series key: %s", seriesKey)
+	}
+
+	quotedSeriesKey, err := QuoteString(seriesKey)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid series key: %s", seriesKey)
+	}
+
+	if timestampMs < 0 {
+		return errors.Errorf("Invalid timestamp: %d", timestampMs)
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return errors.Errorf("Invalid value: %f", value)
+	}
+
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+
+	itemRangeMs := sa.resolutionMs * int64(sa.slotsPerItem)
+	startMs := timestampMs - timestampMs%itemRangeMs
+	slot := int((timestampMs - startMs) / sa.resolutionMs)
+
+	itemPath := sa.getItemPath(seriesKey, startMs)
+
+	update, found := sa.pendingUpdates[itemPath]
+	if !found {
+		update = &seriesUpdate{
+			quotedSeriesKey: quotedSeriesKey,
+			startMs:         startMs,
+			values:          map[int]float64{},
+		}
+
+		sa.pendingUpdates[itemPath] = update
+	}
+
+	if _, slotFound := update.values[slot]; !slotFound {
+		sa.numPendingSamples++
+	}
+
+	update.values[slot] = value
+
+	if sa.numPendingSamples >= sa.maxPendingSamples {
+		return sa.flush()
+	}
+
+	return nil
+}
+
+// Flush writes all pending samples
+func (sa *SeriesAppender) Flush() error {
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+
+	return sa.flush()
+}
+
+func (sa *SeriesAppender) flush() error {
+	if len(sa.pendingUpdates) == 0 {
+		return nil
+	}
+
+	// sort for a deterministic write order
+	itemPaths := make([]string, 0, len(sa.pendingUpdates))
+	for itemPath := range sa.pendingUpdates {
+		itemPaths = append(itemPaths, itemPath)
+	}
+
+	sort.Strings(itemPaths)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sa.flushTimeout)
+	defer cancel()
+
+	// limit the number of updates in flight so as not to choke the server
+	for batchStart := 0; batchStart < len(itemPaths); batchStart += sa.maxInflightUpdates {
+		batchEnd := batchStart + sa.maxInflightUpdates
+		if batchEnd > len(itemPaths) {
+			batchEnd = len(itemPaths)
+		}
+
+		if err := sa.flushBatch(ctx, itemPaths[batchStart:batchEnd]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sa *SeriesAppender) flushBatch(ctx context.Context, itemPaths []string) error {
+	requestGroup := NewRequestGroup(sa.container, len(itemPaths))
+	defer requestGroup.Release()
+
+	for _, itemPath := range itemPaths {
+		expression := sa.pendingUpdates[itemPath].expression(sa.slotsPerItem)
+
+		if err := requestGroup.Submit(&UpdateItemInput{
+			DataPlaneInput: DataPlaneInput{Ctx: ctx},
+			Path:           itemPath,
+			Expression:     &expression,
+		}, itemPath); err != nil {
+			return errors.Wrapf(err, "Failed to update series item %s", itemPath)
+		}
+	}
+
+	responses, err := requestGroup.Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Failed waiting for series updates")
+	}
+
+	// samples of failed updates remain pending, to be retried by the next flush
+	failedUpdateErrs := map[string]error{}
+	for _, response := range responses {
+		itemPath := response.Context.(string)
+
+		if response.Error != nil {
+			failedUpdateErrs[itemPath] = response.Error
+			continue
+		}
+
+		sa.numPendingSamples -= len(sa.pendingUpdates[itemPath].values)
+		delete(sa.pendingUpdates, itemPath)
+	}
+
+	if len(failedUpdateErrs) > 0 {
+		return v3ioerrors.NewKeyedMultiError(failedUpdateErrs)
+	}
+
+	return nil
+}
+
+func (sa *SeriesAppender) getItemPath(seriesKey string, startMs int64) string {
+	return path.Join(sa.path, fmt.Sprintf("%s_%d", seriesKey, startMs))
+}
+
+// returns an update expression initializing the item's values array if needed and setting the pending slots
+func (su *seriesUpdate) expression(slotsPerItem int) string {
+	slots := make([]int, 0, len(su.values))
+	for slot := range su.values {
+		slots = append(slots, slot)
+	}
+
+	sort.Ints(slots)
+
+	expressionBuilder := strings.Builder{}
+	fmt.Fprintf(&expressionBuilder, "%s=%s;%s=%d;%s=if_not_exists(%s,init_array(%d,'double'));",
+		seriesKeyAttributeKey, su.quotedSeriesKey,
+		seriesStartAttributeKey, su.startMs,
+		seriesValuesAttributeKey, seriesValuesAttributeKey, slotsPerItem)
+
+	for _, slot := range slots {
+		fmt.Fprintf(&expressionBuilder, "%s[%d]=%s;",
+			seriesValuesAttributeKey,
+			slot,
+			strconv.FormatFloat(su.values[slot], 'g', -1, 64))
+	}
+
+	return expressionBuilder.String()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// records update expressions, failing updates of the item at failPath
+type updateItemContainer struct {
+	Container
+	nextID      uint64
+	failPath    string
+	expressions map[string]string
+}
+
+func (uic *updateItemContainer) UpdateItem(updateItemInput *UpdateItemInput,
+	context interface{},
+	responseChan chan *Response) (*Request, error) {
+	uic.nextID++
+
+	response := &Response{ID: uic.nextID, Context: context}
+	if updateItemInput.Path == uic.failPath {
+		response.Error = errors.New("update failed")
+	} else {
+		uic.expressions[updateItemInput.Path] = *updateItemInput.Expression
+	}
+
+	responseChan <- response
+
+	return &Request{ID: uic.nextID}, nil
+}
+
+type seriesAppenderSuite struct {
+	suite.Suite
+	container      *updateItemContainer
+	seriesAppender *SeriesAppender
+}
+
+func (suite *seriesAppenderSuite) SetupTest() {
+	var err error
+
+	suite.container = &updateItemContainer{expressions: map[string]string{}}
+	suite.seriesAppender, err = NewSeriesAppender(&NewSeriesAppenderInput{
+		Container:          suite.container,
+		Path:               "metrics",
+		Resolution:         time.Second,
+		SlotsPerItem:       60,
+		MaxInflightUpdates: 1,
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *seriesAppenderSuite) TestFlush() {
+	suite.Require().NoError(suite.seriesAppender.Append("cpu", 61000, 1.5))
+	suite.Require().NoError(suite.seriesAppender.Append("cpu", 62000, 2))
+	suite.Require().NoError(suite.seriesAppender.Append("cpu", 62500, 3)) // overwrites the previous slot
+	suite.Require().NoError(suite.seriesAppender.Append("cpu", 1000, 4))
+	suite.Require().NoError(suite.seriesAppender.Append("mem", 5000, 5))
+
+	suite.Require().NoError(suite.seriesAppender.Flush())
+
+	suite.Require().Equal(map[string]string{
+		"metrics/cpu_0":     "_series='cpu';_start=0;_values=if_not_exists(_values,init_array(60,'double'));_values[1]=4;",
+		"metrics/cpu_60000": "_series='cpu';_start=60000;_values=if_not_exists(_values,init_array(60,'double'));_values[1]=1.5;_values[2]=3;",
+		"metrics/mem_0":     "_series='mem';_start=0;_values=if_not_exists(_values,init_array(60,'double'));_values[5]=5;",
+	}, suite.container.expressions)
+}
+
+func (suite *seriesAppenderSuite) TestFailedUpdateRemainsPending() {
+	suite.container.failPath = "metrics/cpu_0"
+
+	suite.Require().NoError(suite.seriesAppender.Append("cpu", 1000, 1))
+	suite.Require().Error(suite.seriesAppender.Flush())
+	suite.Require().Equal(1, suite.seriesAppender.numPendingSamples)
+
+	suite.container.failPath = ""
+	suite.Require().NoError(suite.seriesAppender.Flush())
+	suite.Require().Equal(0, suite.seriesAppender.numPendingSamples)
+	suite.Require().Contains(suite.container.expressions, "metrics/cpu_0")
+}
+
+func (suite *seriesAppenderSuite) TestInvalidSamples() {
+	suite.Require().Error(suite.seriesAppender.Append("a/b", 0, 1))
+	suite.Require().Error(suite.seriesAppender.Append("cpu", -1, 1))
+}
+
+func TestSeriesAppenderSuite(t *testing.T) {
+	suite.Run(t, new(seriesAppenderSuite))
+}
@@ -21,3 +21,11 @@ type Session interface {
 	// NewContainer creates a container
 	NewContainer(*NewContainerInput) (Container, error)
 }
+
+// Pinger is a session which can check that a container is reachable with its credentials. it isn't part
+// of Session, so that existing implementations of Session keep compiling - check for it with a type assertion
+type Pinger interface {
+
+	// Ping pings the container of the input with the session's URL and credentials (see PingContext)
+	Ping(*PingInput) (*PingOutput, error)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+// the system attribute holding the sequence number of the last record put to a shard
+const shardLastSequenceNumberAttributeName = "__last_sequence_num"
+
+// ShardDescription describes the records retained in a shard. all fields but ShardID are zero for empty shards
+type ShardDescription struct {
+	ShardID                int
+	Length                 uint64
+	EarliestSequenceNumber uint64
+	LatestSequenceNumber   uint64
+
+	// the last modification time of the shard
+	LastWriteTime time.Time
+}
+
+// ShardDescriber holds the requests DescribeShards sends, which both contexts and containers implement
+type ShardDescriber interface {
+	GetItemSync(*GetItemInput) (*Response, error)
+	SeekShardSync(*SeekShardInput) (*Response, error)
+	GetRecordsSync(*GetRecordsInput) (*Response, error)
+}
+
+// DescribeShards describes the shards of a stream from their attributes and earliest records, sending up
+// to three requests per shard
+func DescribeShards(shardDescriber ShardDescriber,
+	dataPlaneInput *DataPlaneInput,
+	streamPath string,
+	shardCount int) ([]ShardDescription, error) {
+	shardDescriptions := make([]ShardDescription, shardCount)
+
+	for shardID := range shardDescriptions {
+		shardDescription, err := DescribeShard(shardDescriber, dataPlaneInput, streamPath, shardID)
+		if err != nil {
+			return nil, err
+		}
+
+		shardDescriptions[shardID] = *shardDescription
+	}
+
+	return shardDescriptions, nil
+}
+
+// DescribeShard describes a single shard of a stream
+func DescribeShard(shardDescriber ShardDescriber,
+	dataPlaneInput *DataPlaneInput,
+	streamPath string,
+	shardID int) (*ShardDescription, error) {
+	shardDescription := ShardDescription{}
+
+	if err := describeShard(shardDescriber, dataPlaneInput, streamPath, shardID, &shardDescription); err != nil {
+		return nil, errors.Wrapf(err, "Failed to describe shard %d", shardID)
+	}
+
+	return &shardDescription, nil
+}
+
+func describeShard(shardDescriber ShardDescriber,
+	dataPlaneInput *DataPlaneInput,
+	streamPath string,
+	shardID int,
+	shardDescription *ShardDescription) error {
+	shardPath := path.Join(streamPath, strconv.Itoa(shardID))
+	shardDescription.ShardID = shardID
+
+	response, err := shardDescriber.GetItemSync(&GetItemInput{
+		DataPlaneInput: *dataPlaneInput,
+		Path:           shardPath,
+		AttributeNames: []string{shardLastSequenceNumberAttributeName, "__mtime_secs", "__mtime_nsecs"},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get shard attributes")
+	}
+
+	shardAttributes := response.Output.(*GetItemOutput).Item
+	response.Release()
+
+	// shards which were never written to have no last sequence number
+	latestSequenceNumber, err := shardAttributes.GetFieldUint64(shardLastSequenceNumberAttributeName)
+	if err != nil || latestSequenceNumber == 0 {
+		return nil
+	}
+
+	mtimeSecs, _ := shardAttributes.GetFieldInt("__mtime_secs")
+	mtimeNSecs, _ := shardAttributes.GetFieldInt("__mtime_nsecs")
+
+	shardDescription.LatestSequenceNumber = latestSequenceNumber
+	shardDescription.LastWriteTime = time.Unix(int64(mtimeSecs), int64(mtimeNSecs))
+
+	// records past the retention period are dropped, so the earliest record is read rather than assumed
+	response, err = shardDescriber.SeekShardSync(&SeekShardInput{
+		DataPlaneInput: *dataPlaneInput,
+		Path:           shardPath,
+		Type:           SeekShardInputTypeEarliest,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to seek shard")
+	}
+
+	location := response.Output.(*SeekShardOutput).Location
+	response.Release()
+
+	response, err = shardDescriber.GetRecordsSync(&GetRecordsInput{
+		DataPlaneInput: *dataPlaneInput,
+		Path:           shardPath,
+		Location:       location,
+		Limit:          1,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get earliest record")
+	}
+
+	defer response.Release()
+
+	records := response.Output.(*GetRecordsOutput).Records
+	if len(records) == 0 {
+		return nil
+	}
+
+	shardDescription.EarliestSequenceNumber = records[0].SequenceNumber
+	shardDescription.Length = latestSequenceNumber - records[0].SequenceNumber + 1
+
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/nuclio/errors"
+)
+
+type NewShardReaderInput struct {
+	Container  Container
+	StreamPath string
+	ShardID    int
+
+	// the location to start reading from. if empty, the shard is sought according to the seek fields
+	Location string
+
+	// how to seek the shard when no location is given (e.g. SeekShardInputTypeEarliest)
+	SeekType               SeekShardInputType
+	StartingSequenceNumber uint64
+	Timestamp              int
+	TimestampNanoseconds   int
+
+	// the maximum number of records to get per request (defaults to 100)
+	Limit int
+
+	// passed to GetRecords, so that requests at the end of the shard wait for records rather than return
+	// immediately
+	MaxWaitTime time.Duration
+
+	// passed to GetRecords, bounding the size of the batches read
+	MaxBytes int
+
+	// the wait between requests which returned no records. grows while the shard is idle and is reset once
+	// records arrive (defaults to an exponential backoff from 50ms to 2s)
+	PollBackoff *common.Backoff
+}
+
+// ShardReader iterates the records of a single shard, seeking it and getting records in batches
+type ShardReader struct {
+	lock      sync.Mutex
+	container Container
+	shardPath string
+	input     NewShardReaderInput
+	location  string
+	records   []GetRecordsResult
+
+	// the location following the records not yet returned
+	nextLocation string
+	pollBackoff  *common.Backoff
+}
+
+// NewShardReader creates a shard reader. the shard is sought lazily, by the first call to Next
+func NewShardReader(newShardReaderInput *NewShardReaderInput) (*ShardReader, error) {
+	if newShardReaderInput.Container == nil {
+		return nil, errors.New("Container is required")
+	}
+
+	if newShardReaderInput.ShardID < 0 {
+		return nil, errors.Errorf("Invalid shard ID: %d", newShardReaderInput.ShardID)
+	}
+
+	newShardReader := ShardReader{
+		container: newShardReaderInput.Container,
+		shardPath: path.Join(newShardReaderInput.StreamPath, strconv.Itoa(newShardReaderInput.ShardID)),
+		input:     *newShardReaderInput,
+		location:  newShardReaderInput.Location,
+		pollBackoff: &common.Backoff{
+			Min:    50 * time.Millisecond,
+			Max:    2 * time.Second,
+			Factor: 2,
+		},
+	}
+
+	if newShardReader.input.Limit <= 0 {
+		newShardReader.input.Limit = 100
+	}
+
+	if newShardReaderInput.PollBackoff != nil {
+		newShardReader.pollBackoff = newShardReaderInput.PollBackoff.Copy()
+	}
+
+	return &newShardReader, nil
+}
+
+// Next returns the next record of the shard, waiting for one to arrive if the shard has no more records.
+// records compressed by a producer are decompressed, and those which fail to decompress are returned as is.
+// a failed request returns an error, after which Next can be called again to retry from the same location
+func (sr *ShardReader) Next(ctx context.Context) (*GetRecordsResult, error) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	if sr.location == "" {
+		if err := sr.seek(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(sr.records) == 0 {
+		numRecords, err := sr.getRecords(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if numRecords > 0 {
+			sr.pollBackoff.Reset()
+			break
+		}
+
+		if err := sleepWithContext(ctx, sr.pollBackoff.Duration()); err != nil {
+			return nil, errors.Wrap(err, "Context done while waiting for records")
+		}
+	}
+
+	record := sr.records[0]
+	sr.records = sr.records[1:]
+
+	// the location is only advanced once a batch is fully returned, so that the records of a batch aren't
+	// skipped if the location is persisted and later restored
+	if len(sr.records) == 0 {
+		sr.location = sr.nextLocation
+	}
+
+	if data, err := DecompressRecordData(record.Data); err == nil {
+		record.Data = data
+	}
+
+	return &record, nil
+}
+
+// GetLocation returns the location from which the records not yet returned by Next will be read. since
+// records are read in batches, it may precede records which were already returned; it is empty before the
+// shard is sought
+func (sr *ShardReader) GetLocation() string {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	return sr.location
+}
+
+func (sr *ShardReader) seek(ctx context.Context) error {
+	seekShardInput := SeekShardInput{
+		Path:                   sr.shardPath,
+		Type:                   sr.input.SeekType,
+		StartingSequenceNumber: sr.input.StartingSequenceNumber,
+		Timestamp:              sr.input.Timestamp,
+		TimestampNanoseconds:   sr.input.TimestampNanoseconds,
+	}
+	seekShardInput.Ctx = ctx
+
+	response, err := sr.container.SeekShardSync(&seekShardInput)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to seek shard: %s", sr.shardPath)
+	}
+
+	defer response.Release()
+
+	sr.location = response.Output.(*SeekShardOutput).Location
+
+	return nil
+}
+
+// gets the records at the current location, advancing it. returns the number of records read
+func (sr *ShardReader) getRecords(ctx context.Context) (int, error) {
+	getRecordsInput := GetRecordsInput{
+		Path:        sr.shardPath,
+		Location:    sr.location,
+		Limit:       sr.input.Limit,
+		MaxWaitTime: sr.input.MaxWaitTime,
+		MaxBytes:    sr.input.MaxBytes,
+	}
+	getRecordsInput.Ctx = ctx
+
+	response, err := sr.container.GetRecordsSync(&getRecordsInput)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to get records: %s", sr.location)
+	}
+
+	defer response.Release()
+
+	getRecordsOutput := response.Output.(*GetRecordsOutput)
+
+	if len(getRecordsOutput.Records) == 0 {
+		sr.location = getRecordsOutput.NextLocation
+	} else {
+		sr.records = getRecordsOutput.Records
+		sr.nextLocation = getRecordsOutput.NextLocation
+	}
+
+	return len(getRecordsOutput.Records), nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// serves the records of a shard, whose locations are record indexes
+type fakeShardContainer struct {
+	Container
+	records         []GetRecordsResult
+	getRecordsPaths []string
+}
+
+func (fsc *fakeShardContainer) SeekShardSync(seekShardInput *SeekShardInput) (*Response, error) {
+	location := 0
+	if seekShardInput.Type == SeekShardInputTypeLatest {
+		location = len(fsc.records)
+	}
+
+	return &Response{Output: &SeekShardOutput{Location: strconv.Itoa(location)}}, nil
+}
+
+func (fsc *fakeShardContainer) GetRecordsSync(getRecordsInput *GetRecordsInput) (*Response, error) {
+	fsc.getRecordsPaths = append(fsc.getRecordsPaths, getRecordsInput.Path)
+
+	location, err := strconv.Atoi(getRecordsInput.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	nextLocation := location + getRecordsInput.Limit
+	if nextLocation > len(fsc.records) {
+		nextLocation = len(fsc.records)
+	}
+
+	return &Response{Output: &GetRecordsOutput{
+		Records:      fsc.records[location:nextLocation],
+		NextLocation: strconv.Itoa(nextLocation),
+	}}, nil
+}
+
+type shardReaderSuite struct {
+	suite.Suite
+	container *fakeShardContainer
+}
+
+func (suite *shardReaderSuite) SetupTest() {
+	suite.container = &fakeShardContainer{}
+
+	for recordIdx := 0; recordIdx < 5; recordIdx++ {
+		suite.container.records = append(suite.container.records, GetRecordsResult{
+			SequenceNumber: uint64(recordIdx + 1),
+			Data:           []byte("data" + strconv.Itoa(recordIdx)),
+		})
+	}
+}
+
+func (suite *shardReaderSuite) TestNext() {
+	shardReader, err := NewShardReader(&NewShardReaderInput{
+		Container:  suite.container,
+		StreamPath: "stream/",
+		ShardID:    3,
+		SeekType:   SeekShardInputTypeEarliest,
+		Limit:      2,
+	})
+	suite.Require().NoError(err)
+
+	for recordIdx := 0; recordIdx < 5; recordIdx++ {
+		record, err := shardReader.Next(context.Background())
+		suite.Require().NoError(err)
+		suite.Require().Equal("data"+strconv.Itoa(recordIdx), string(record.Data))
+
+		// the location is only advanced once a batch was fully returned
+		expectedLocation := strconv.Itoa((recordIdx + 1) / 2 * 2)
+		if recordIdx == 4 {
+			expectedLocation = "5"
+		}
+
+		suite.Require().Equal(expectedLocation, shardReader.GetLocation())
+	}
+
+	suite.Require().Equal([]string{"stream/3", "stream/3", "stream/3"}, suite.container.getRecordsPaths)
+
+	// with no more records, Next polls until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = shardReader.Next(ctx)
+	suite.Require().Error(err)
+	suite.Require().True(len(suite.container.getRecordsPaths) > 4)
+}
+
+func (suite *shardReaderSuite) TestResumeFromLocation() {
+	shardReader, err := NewShardReader(&NewShardReaderInput{
+		Container:   suite.container,
+		StreamPath:  "stream",
+		Location:    "3",
+		PollBackoff: &common.Backoff{Min: time.Millisecond, Max: time.Millisecond},
+	})
+	suite.Require().NoError(err)
+
+	record, err := shardReader.Next(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(4), record.SequenceNumber)
+}
+
+func TestShardReaderSuite(t *testing.T) {
+	suite.Run(t, new(shardReaderSuite))
+}
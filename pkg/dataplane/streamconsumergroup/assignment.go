@@ -0,0 +1,198 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"math"
+	"strings"
+
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/nuclio/errors"
+)
+
+var errNoFreeShardGroups = errors.New("No free shard groups")
+
+// AssignmentStrategy decides which shards a member joining the group consumes. the shards of the stream are
+// split into one group per replica, and each member claims a group which no other member holds
+type AssignmentStrategy interface {
+
+	// AssignShards returns the shards of the joining member, given the sessions of the members in the
+	// group. it may record information in the state, which is persisted along with the member's session
+	AssignShards(memberID string, maxReplicas int, numShards int, state *State) ([]int, error)
+}
+
+// NewRangeAssignmentStrategy splits the shards into contiguous ranges, e.g. {0, 1}, {2, 3} (the default)
+func NewRangeAssignmentStrategy() AssignmentStrategy {
+	return &shardGroupAssignmentStrategy{getReplicaShardGroups: getRangeReplicaShardGroups}
+}
+
+// NewRoundRobinAssignmentStrategy deals the shards to the replicas in turn, e.g. {0, 2}, {1, 3}
+func NewRoundRobinAssignmentStrategy() AssignmentStrategy {
+	return &shardGroupAssignmentStrategy{getReplicaShardGroups: getRoundRobinReplicaShardGroups}
+}
+
+// NewStickyAssignmentStrategy assigns a restarting member the shard group it last held, if it's still
+// free, so that members which restart (e.g. on deployment) don't move shards between them. members are
+// identified by the name they were created with. others are assigned ranges
+func NewStickyAssignmentStrategy() AssignmentStrategy {
+	return &stickyAssignmentStrategy{
+		shardGroupAssignmentStrategy: shardGroupAssignmentStrategy{getReplicaShardGroups: getRangeReplicaShardGroups},
+	}
+}
+
+type shardGroupAssignmentStrategy struct {
+	getReplicaShardGroups func(maxReplicas int, numShards int) [][]int
+}
+
+func (sgas *shardGroupAssignmentStrategy) AssignShards(memberID string,
+	maxReplicas int,
+	numShards int,
+	state *State) ([]int, error) {
+
+	// per replica index, holds which shards it should handle
+	replicaShardGroups := sgas.getReplicaShardGroups(maxReplicas, numShards)
+
+	// empty shard groups are not unique - therefore simply check whether the number of
+	// empty shard groups allocated to sessions is equal to the number of empty shard groups
+	// required. if not, allocate an empty shard group
+	if getAssignEmptyShardGroup(replicaShardGroups, state) {
+		return []int{}, nil
+	}
+
+	// simply look for the first non-assigned replica shard group which isn't empty
+	for _, replicaShardGroup := range replicaShardGroups {
+
+		// we already checked if we need to allocate an empty shard group
+		if len(replicaShardGroup) == 0 {
+			continue
+		}
+
+		if !isShardGroupAssigned(replicaShardGroup, state) {
+			return replicaShardGroup, nil
+		}
+	}
+
+	return nil, errNoFreeShardGroups
+}
+
+type stickyAssignmentStrategy struct {
+	shardGroupAssignmentStrategy
+}
+
+func (sas *stickyAssignmentStrategy) AssignShards(memberID string,
+	maxReplicas int,
+	numShards int,
+	state *State) ([]int, error) {
+	memberName := getMemberName(memberID)
+
+	// the last group is only reclaimed if it's still one of the groups, since the number of shards or
+	// replicas may have changed since
+	if lastShardGroup, found := state.LastShardGroups[memberName]; found && len(lastShardGroup) > 0 {
+		for _, replicaShardGroup := range sas.getReplicaShardGroups(maxReplicas, numShards) {
+			if common.IntSlicesEqual(replicaShardGroup, lastShardGroup) && !isShardGroupAssigned(replicaShardGroup, state) {
+				return replicaShardGroup, nil
+			}
+		}
+	}
+
+	shards, err := sas.shardGroupAssignmentStrategy.AssignShards(memberID, maxReplicas, numShards, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.LastShardGroups == nil {
+		state.LastShardGroups = map[string][]int{}
+	}
+
+	state.LastShardGroups[memberName] = shards
+
+	return shards, nil
+}
+
+func getRangeReplicaShardGroups(maxReplicas int, numShards int) [][]int {
+	var replicaShardGroups [][]int
+	shards := common.MakeRange(0, numShards)
+
+	step := float64(numShards) / float64(maxReplicas)
+
+	for replicaIndex := 0; replicaIndex < maxReplicas; replicaIndex++ {
+		replicaIndexFloat := float64(replicaIndex)
+		startShard := int(math.Floor(replicaIndexFloat*step + 0.5))
+		endShard := int(math.Floor((replicaIndexFloat+1)*step + 0.5))
+
+		replicaShardGroups = append(replicaShardGroups, shards[startShard:endShard])
+	}
+
+	return replicaShardGroups
+}
+
+func getRoundRobinReplicaShardGroups(maxReplicas int, numShards int) [][]int {
+	replicaShardGroups := make([][]int, maxReplicas)
+
+	for replicaIndex := range replicaShardGroups {
+		replicaShardGroups[replicaIndex] = []int{}
+	}
+
+	for shardID := 0; shardID < numShards; shardID++ {
+		replicaIndex := shardID % maxReplicas
+		replicaShardGroups[replicaIndex] = append(replicaShardGroups[replicaIndex], shardID)
+	}
+
+	return replicaShardGroups
+}
+
+func getAssignEmptyShardGroup(replicaShardGroups [][]int, state *State) bool {
+	numEmptyShardGroupRequired := 0
+	for _, replicaShardGroup := range replicaShardGroups {
+		if len(replicaShardGroup) == 0 {
+			numEmptyShardGroupRequired++
+		}
+	}
+
+	numEmptyShardGroupAssigned := 0
+	for _, sessionState := range state.SessionStates {
+		if len(sessionState.Shards) == 0 {
+			numEmptyShardGroupAssigned++
+		}
+	}
+
+	return numEmptyShardGroupRequired != numEmptyShardGroupAssigned
+}
+
+func isShardGroupAssigned(shardGroup []int, state *State) bool {
+	for _, sessionState := range state.SessionStates {
+		if common.IntSlicesEqual(shardGroup, sessionState.Shards) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// member IDs are the name the member was created with, followed by a unique suffix
+func getMemberName(memberID string) string {
+	if separatorIdx := strings.LastIndexByte(memberID, '-'); separatorIdx >= 0 {
+		return memberID[:separatorIdx]
+	}
+
+	return memberID
+}
@@ -26,6 +26,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/v3io/v3io-go/pkg/common"
@@ -42,6 +43,7 @@ type claim struct {
 	shardID                  int
 	recordBatchChan          chan *RecordBatch
 	stopRecordBatchFetchChan chan struct{}
+	consumeDoneChan          chan struct{}
 	currentShardLocation     string
 
 	// get shard location configuration
@@ -56,6 +58,7 @@ func newClaim(member *member, shardID int) (*claim, error) {
 		shardID:                  shardID,
 		recordBatchChan:          make(chan *RecordBatch, member.streamConsumerGroup.config.Claim.RecordBatchChanSize),
 		stopRecordBatchFetchChan: make(chan struct{}, 1),
+		consumeDoneChan:          make(chan struct{}),
 		getShardLocationAttempts: member.streamConsumerGroup.config.Claim.GetShardLocationRetry.Attempts,
 		getShardLocationBackoff:  member.streamConsumerGroup.config.Claim.GetShardLocationRetry.Backoff,
 	}, nil
@@ -74,6 +77,7 @@ func (c *claim) start() error {
 	}()
 
 	go func() {
+		defer close(c.consumeDoneChan)
 
 		// tell the consumer group handler to consume the claim
 		c.logger.DebugWith("Calling ConsumeClaim on handler")
@@ -101,6 +105,11 @@ func (c *claim) stop() error {
 	return nil
 }
 
+// closed once the handler's ConsumeClaim returns
+func (c *claim) getConsumeDoneChan() <-chan struct{} {
+	return c.consumeDoneChan
+}
+
 func (c *claim) GetStreamPath() string {
 	return c.member.streamConsumerGroup.streamPath
 }
@@ -119,6 +128,7 @@ func (c *claim) GetRecordBatchChan() <-chan *RecordBatch {
 
 func (c *claim) fetchRecordBatches(stopChannel chan struct{}, fetchInterval time.Duration) error {
 	var err error
+	stopped := false
 
 	// read initial location. use config if error. might need to wait until shard actually exists
 	if err := common.RetryFunc(context.TODO(),
@@ -131,6 +141,7 @@ func (c *claim) fetchRecordBatches(stopChannel chan struct{}, fetchInterval time
 
 				// requested for an immediate stop
 				if err == v3ioerrors.ErrStopped {
+					stopped = true
 					return false, nil
 				}
 
@@ -154,9 +165,21 @@ func (c *claim) fetchRecordBatches(stopChannel chan struct{}, fetchInterval time
 			c.shardID)
 	}
 
+	// the stop request was consumed while getting the location
+	if stopped {
+		close(c.recordBatchChan)
+		return nil
+	}
+
 	for {
 		select {
-		case <-time.After(fetchInterval):
+		case <-c.member.streamConsumerGroup.clock.After(fetchInterval):
+
+			// paused shards keep their location, fetching once resumed
+			if c.member.isShardPaused(c.shardID) {
+				continue
+			}
+
 			location, err := c.fetchRecordBatch(c.currentShardLocation)
 			if err != nil {
 				c.logger.WarnWith("Failed fetching record batch",
@@ -215,9 +238,22 @@ func (c *claim) fetchRecordBatch(location string) (string, error) {
 	records := make([]v3io.StreamRecord, len(getRecordsOutput.Records))
 
 	for receivedRecordIndex, receivedRecord := range getRecordsOutput.Records {
+
+		// records compressed by the producer are decompressed transparently. records which fail to
+		// decompress are passed as is, rather than blocking the shard
+		data, err := v3io.DecompressRecordData(receivedRecord.Data)
+		if err != nil {
+			c.logger.WarnWith("Failed to decompress record data",
+				"shardId", c.shardID,
+				"sequenceNumber", receivedRecord.SequenceNumber,
+				"err", errors.GetErrorStackString(err, 10))
+
+			data = receivedRecord.Data
+		}
+
 		record := v3io.StreamRecord{
 			ShardID:        &c.shardID,
-			Data:           receivedRecord.Data,
+			Data:           data,
 			ClientInfo:     receivedRecord.ClientInfo,
 			PartitionKey:   receivedRecord.PartitionKey,
 			SequenceNumber: receivedRecord.SequenceNumber,
@@ -235,6 +271,7 @@ func (c *claim) fetchRecordBatch(location string) (string, error) {
 
 	// write into chunks channel, blocking if there's no space
 	c.recordBatchChan <- &recordBatch
+	atomic.AddUint64(&c.member.numRecordsConsumed, uint64(len(records)))
 
 	return getRecordsOutput.NextLocation, nil
 }
@@ -257,9 +294,11 @@ func (c *claim) getCurrentShardLocation(shardID int) (string, error) {
 			initialLocation = v3io.SeekShardInputTypeEarliest
 		}
 
+		shardWaitInterval := c.member.streamConsumerGroup.config.SequenceNumber.ShardWaitInterval
+
 		for {
 			select {
-			case <-time.After(c.member.streamConsumerGroup.config.SequenceNumber.ShardWaitInterval):
+			case <-c.member.streamConsumerGroup.clock.After(shardWaitInterval):
 
 				// get the location from persistency
 				currentShardLocation, err = c.member.streamConsumerGroup.getShardLocationFromPersistency(shardID, initialLocation)
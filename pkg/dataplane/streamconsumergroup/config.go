@@ -24,14 +24,46 @@ import (
 
 	"github.com/v3io/v3io-go/pkg/common"
 	"github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+// CommitMode determines when the sequence numbers marked by handlers are committed
+type CommitMode string
+
+const (
+
+	// marked sequence numbers are committed every CommitInterval and when the member stops
+	CommitModeInterval CommitMode = "interval"
+
+	// marked sequence numbers are only committed when the handler calls Session.Commit or CommitRecord,
+	// letting the application control its checkpoints
+	CommitModeManual CommitMode = "manual"
 )
 
 type Config struct {
+
+	// the clock intervals and heartbeats are measured by (defaults to common.NewClock). tests may set a
+	// common.MockClock to move time deterministically
+	Clock common.Clock `json:"-"`
+
 	Session struct {
 		Timeout           time.Duration `json:"timeout,omitempty"`
 		HeartbeatInterval time.Duration
+
+		// how long past the timeout the sessions of static members are kept, so that a restarting static member
+		// reclaims its shards rather than have them assigned to another member (see NewStaticMember)
+		RejoinGracePeriod time.Duration `json:"rejoinGracePeriod,omitempty"`
+
+		// how shards are assigned to members (defaults to NewRangeAssignmentStrategy)
+		AssignmentStrategy AssignmentStrategy `json:"-"`
 	} `json:"session,omitempty"`
 	State struct {
+
+		// where the state is persisted (defaults to NewItemStateStore, an item in the stream). a store must
+		// not be shared by different consumer groups
+		Store StateStore `json:"-"`
+
 		ModifyRetry struct {
 			Attempts int            `json:"attempts,omitempty"`
 			Backoff  common.Backoff `json:"backoff,omitempty"`
@@ -40,19 +72,38 @@ type Config struct {
 	SequenceNumber struct {
 		CommitInterval    time.Duration `json:"commitInterval,omitempty"`
 		ShardWaitInterval time.Duration `json:"shardWaitInterval,omitempty"`
+		CommitMode        CommitMode    `json:"commitMode,omitempty"`
 	}
+	Stream struct {
+
+		// how often the stream is described to detect shards added to it. members abort once shards are
+		// added, so that the shards are reassigned when they restart (0 disables detection)
+		ShardCountRefreshInterval time.Duration `json:"shardCountRefreshInterval,omitempty"`
+	} `json:"stream,omitempty"`
 	Claim struct {
 		RecordBatchChanSize int `json:"recordBatchChanSize,omitempty"`
 		RecordBatchFetch    struct {
 			Interval          time.Duration           `json:"interval,omitempty"`
 			NumRecordsInBatch int                     `json:"numRecordsInBatch,omitempty"`
 			InitialLocation   v3io.SeekShardInputType `json:"initialLocation,omitempty"`
+
+			// where shards with no committed sequence number are sought from when InitialLocation is
+			// SeekShardInputTypeTime - the first record which arrived at or after it
+			InitialTimestamp time.Time `json:"initialTimestamp,omitempty"`
 		} `json:"recordBatchFetch,omitempty"`
 		GetShardLocationRetry struct {
 			Attempts int            `json:"attempts,omitempty"`
 			Backoff  common.Backoff `json:"backoff,omitempty"`
 		} `json:"getShardLocationRetry,omitempty"`
 	} `json:"claim,omitempty"`
+	DeadLetter struct {
+
+		// the stream records which keep failing are written to, via Session.FailRecord (empty disables it)
+		StreamPath string `json:"streamPath,omitempty"`
+
+		// how many times in a row a record fails before it's written to the dead-letter stream
+		MaxAttempts int `json:"maxAttempts,omitempty"`
+	} `json:"deadLetter,omitempty"`
 }
 
 // NewConfig returns a new configuration instance with sane defaults.
@@ -60,6 +111,7 @@ func NewConfig() *Config {
 	c := &Config{}
 	c.Session.Timeout = 10 * time.Second
 	c.Session.HeartbeatInterval = 3 * time.Second
+	c.Session.RejoinGracePeriod = 30 * time.Second
 	c.State.ModifyRetry.Attempts = 100
 	c.State.ModifyRetry.Backoff = common.Backoff{
 		Min:    50 * time.Millisecond,
@@ -68,6 +120,8 @@ func NewConfig() *Config {
 	}
 	c.SequenceNumber.CommitInterval = 10 * time.Second
 	c.SequenceNumber.ShardWaitInterval = 1 * time.Second
+	c.SequenceNumber.CommitMode = CommitModeInterval
+	c.Stream.ShardCountRefreshInterval = 30 * time.Second
 	c.Claim.RecordBatchChanSize = 100
 	c.Claim.RecordBatchFetch.Interval = 250 * time.Millisecond
 	c.Claim.RecordBatchFetch.NumRecordsInBatch = 10
@@ -78,6 +132,108 @@ func NewConfig() *Config {
 		Max:    1 * time.Second,
 		Factor: 2,
 	}
+	c.DeadLetter.MaxAttempts = 3
 
 	return c
 }
+
+// Validate returns an error describing the first nonsensical setting found, if any
+func (c *Config) Validate() error {
+	if c.Session.Timeout <= 0 {
+		return errors.Errorf("Session timeout must be positive, got %s", c.Session.Timeout)
+	}
+
+	// members heartbeating once per timeout or less would time out between heartbeats
+	if c.Session.HeartbeatInterval <= 0 || c.Session.HeartbeatInterval >= c.Session.Timeout {
+		return errors.Errorf("Session heartbeat interval must be positive and shorter than the session timeout (%s), got %s",
+			c.Session.Timeout,
+			c.Session.HeartbeatInterval)
+	}
+
+	if c.Session.RejoinGracePeriod < 0 {
+		return errors.Errorf("Session rejoin grace period must not be negative, got %s", c.Session.RejoinGracePeriod)
+	}
+
+	if c.State.ModifyRetry.Attempts <= 0 {
+		return errors.Errorf("State modify retry attempts must be positive, got %d", c.State.ModifyRetry.Attempts)
+	}
+
+	if err := validateBackoff(&c.State.ModifyRetry.Backoff); err != nil {
+		return errors.Wrap(err, "Invalid state modify retry backoff")
+	}
+
+	switch c.SequenceNumber.CommitMode {
+	case CommitModeInterval:
+		if c.SequenceNumber.CommitInterval <= 0 {
+			return errors.Errorf("Sequence number commit interval must be positive, got %s",
+				c.SequenceNumber.CommitInterval)
+		}
+	case CommitModeManual:
+	default:
+		return errors.Errorf("Invalid sequence number commit mode: %s", c.SequenceNumber.CommitMode)
+	}
+
+	if c.SequenceNumber.ShardWaitInterval <= 0 {
+		return errors.Errorf("Shard wait interval must be positive, got %s", c.SequenceNumber.ShardWaitInterval)
+	}
+
+	if c.Stream.ShardCountRefreshInterval < 0 {
+		return errors.Errorf("Shard count refresh interval must not be negative, got %s",
+			c.Stream.ShardCountRefreshInterval)
+	}
+
+	if c.Claim.RecordBatchChanSize < 0 {
+		return errors.Errorf("Record batch channel size must not be negative, got %d", c.Claim.RecordBatchChanSize)
+	}
+
+	if c.Claim.RecordBatchFetch.Interval < 0 {
+		return errors.Errorf("Record batch fetch interval must not be negative, got %s", c.Claim.RecordBatchFetch.Interval)
+	}
+
+	if c.Claim.RecordBatchFetch.NumRecordsInBatch <= 0 {
+		return errors.Errorf("Number of records in batch must be positive, got %d",
+			c.Claim.RecordBatchFetch.NumRecordsInBatch)
+	}
+
+	switch c.Claim.RecordBatchFetch.InitialLocation {
+	case v3io.SeekShardInputTypeEarliest, v3io.SeekShardInputTypeLatest:
+	case v3io.SeekShardInputTypeTime:
+		if c.Claim.RecordBatchFetch.InitialTimestamp.IsZero() {
+			return errors.New("Initial timestamp is required when seeking shards by time")
+		}
+	default:
+		return errors.Errorf("Invalid initial location: %d", c.Claim.RecordBatchFetch.InitialLocation)
+	}
+
+	if c.Claim.GetShardLocationRetry.Attempts <= 0 {
+		return errors.Errorf("Get shard location retry attempts must be positive, got %d",
+			c.Claim.GetShardLocationRetry.Attempts)
+	}
+
+	if err := validateBackoff(&c.Claim.GetShardLocationRetry.Backoff); err != nil {
+		return errors.Wrap(err, "Invalid get shard location retry backoff")
+	}
+
+	if c.DeadLetter.StreamPath != "" && c.DeadLetter.MaxAttempts <= 0 {
+		return errors.Errorf("Dead-letter max attempts must be positive, got %d", c.DeadLetter.MaxAttempts)
+	}
+
+	return nil
+}
+
+// zero backoff values are replaced by defaults, but negative ones are a mistake
+func validateBackoff(backoff *common.Backoff) error {
+	if backoff.Min < 0 || backoff.Max < 0 {
+		return errors.Errorf("Backoff durations must not be negative, got min %s and max %s", backoff.Min, backoff.Max)
+	}
+
+	if backoff.Min > 0 && backoff.Max > 0 && backoff.Min > backoff.Max {
+		return errors.Errorf("Backoff min (%s) must not exceed max (%s)", backoff.Min, backoff.Max)
+	}
+
+	if backoff.Factor < 0 {
+		return errors.Errorf("Backoff factor must not be negative, got %v", backoff.Factor)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+// headers of records written to the dead-letter stream, describing where the record came from and why it failed
+const (
+	DeadLetterConsumerGroupHeader  = "v3io-dlq-consumer-group"
+	DeadLetterStreamHeader         = "v3io-dlq-stream"
+	DeadLetterShardHeader          = "v3io-dlq-shard"
+	DeadLetterSequenceNumberHeader = "v3io-dlq-sequence-number"
+	DeadLetterAttemptsHeader       = "v3io-dlq-attempts"
+	DeadLetterErrorHeader          = "v3io-dlq-error"
+
+	// client info of the original record which doesn't hold headers, base64 encoded
+	DeadLetterClientInfoHeader = "v3io-dlq-client-info"
+)
+
+// the record of a shard which is currently failing, and how many times it failed
+type recordFailure struct {
+	sequenceNumber uint64
+	attempts       int
+}
+
+// FailRecord reports that the handler failed processing the record permanently. once a record fails
+// DeadLetter.MaxAttempts times in a row, it is written to the dead-letter stream along with the error and
+// marked, and FailRecord returns true - the handler should move on to the next record. until then it returns
+// false and the handler may retry the record
+func (s *session) FailRecord(record *v3io.StreamRecord, cause error) (bool, error) {
+	deadLetterConfig := &s.member.streamConsumerGroup.config.DeadLetter
+	if deadLetterConfig.StreamPath == "" {
+		return false, errors.New("Dead-letter stream is not configured")
+	}
+
+	attempts := s.countRecordFailure(record)
+	if attempts < deadLetterConfig.MaxAttempts {
+		s.logger.DebugWith("Record failed",
+			"shardID", *record.ShardID,
+			"sequenceNumber", record.SequenceNumber,
+			"attempts", attempts,
+			"err", cause)
+
+		return false, nil
+	}
+
+	if err := s.writeDeadLetterRecord(record, attempts, cause); err != nil {
+		return false, errors.Wrap(err, "Failed writing record to dead-letter stream")
+	}
+
+	s.logger.InfoWith("Record written to dead-letter stream",
+		"shardID", *record.ShardID,
+		"sequenceNumber", record.SequenceNumber,
+		"attempts", attempts,
+		"deadLetterStreamPath", deadLetterConfig.StreamPath)
+
+	s.clearRecordFailure(*record.ShardID)
+
+	if err := s.MarkRecord(record); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// returns how many times in a row the record failed, including this time
+func (s *session) countRecordFailure(record *v3io.StreamRecord) int {
+	s.recordFailuresLock.Lock()
+	defer s.recordFailuresLock.Unlock()
+
+	if s.recordFailures == nil {
+		s.recordFailures = map[int]*recordFailure{}
+	}
+
+	// records of a shard are processed in order, so a failure of another record restarts the count
+	failure, found := s.recordFailures[*record.ShardID]
+	if !found || failure.sequenceNumber != record.SequenceNumber {
+		failure = &recordFailure{sequenceNumber: record.SequenceNumber}
+		s.recordFailures[*record.ShardID] = failure
+	}
+
+	failure.attempts++
+
+	return failure.attempts
+}
+
+func (s *session) clearRecordFailure(shardID int) {
+	s.recordFailuresLock.Lock()
+	defer s.recordFailuresLock.Unlock()
+
+	delete(s.recordFailures, shardID)
+}
+
+func (s *session) writeDeadLetterRecord(record *v3io.StreamRecord, attempts int, cause error) error {
+	streamConsumerGroup := s.member.streamConsumerGroup
+
+	headers, err := record.GetHeaders()
+	if err != nil {
+		return errors.Wrap(err, "Failed getting record headers")
+	}
+
+	if headers == nil {
+		headers = map[string]string{}
+
+		if len(record.ClientInfo) > 0 {
+			headers[DeadLetterClientInfoHeader] = base64.StdEncoding.EncodeToString(record.ClientInfo)
+		}
+	}
+
+	headers[DeadLetterConsumerGroupHeader] = streamConsumerGroup.name
+	headers[DeadLetterStreamHeader] = streamConsumerGroup.streamPath
+	headers[DeadLetterShardHeader] = strconv.Itoa(*record.ShardID)
+	headers[DeadLetterSequenceNumberHeader] = strconv.FormatUint(record.SequenceNumber, 10)
+	headers[DeadLetterAttemptsHeader] = strconv.Itoa(attempts)
+
+	if cause != nil {
+		headers[DeadLetterErrorHeader] = cause.Error()
+	}
+
+	deadLetterRecord := v3io.StreamRecord{
+		Data:         record.Data,
+		PartitionKey: record.PartitionKey,
+	}
+
+	if err := deadLetterRecord.SetHeaders(headers); err != nil {
+		return err
+	}
+
+	response, err := streamConsumerGroup.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path:    streamConsumerGroup.config.DeadLetter.StreamPath,
+		Records: []*v3io.StreamRecord{&deadLetterRecord},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed putting record")
+	}
+
+	defer response.Release()
+
+	putRecordsOutput := response.Output.(*v3io.PutRecordsOutput)
+	if putRecordsOutput.FailedRecordCount > 0 {
+		return errors.Errorf("Failed putting record, error code: %d", putRecordsOutput.Records[0].ErrorCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+// RecordHandlerFunc processes a single record of a claim
+type RecordHandlerFunc func(*v3io.StreamRecord) error
+
+// ConsumeClaimConcurrently is a helper for handlers' ConsumeClaim, processing the records of the claim by a pool
+// of numWorkers goroutines. records with the same partition key are processed by the same worker, in order.
+// each batch is marked once all of its records are processed, so a batch is only as fast as its slowest
+// record. returns once the claim stops, or with the first error returned by handleRecord
+func ConsumeClaimConcurrently(session Session, claim Claim, numWorkers int, handleRecord RecordHandlerFunc) error {
+	if numWorkers < 1 {
+		return errors.Errorf("Invalid number of workers: %d", numWorkers)
+	}
+
+	var recordsWaitGroup sync.WaitGroup
+	var handleErrLock sync.Mutex
+	var handleErr error
+
+	workerChans := make([]chan *v3io.StreamRecord, numWorkers)
+	for workerIdx := range workerChans {
+		workerChans[workerIdx] = make(chan *v3io.StreamRecord, claimWorkerChanSize)
+
+		go func(workerChan chan *v3io.StreamRecord) {
+			for record := range workerChan {
+				if err := handleRecord(record); err != nil {
+					handleErrLock.Lock()
+					if handleErr == nil {
+						handleErr = errors.Wrapf(err, "Failed handling record %d", record.SequenceNumber)
+					}
+					handleErrLock.Unlock()
+				}
+
+				recordsWaitGroup.Done()
+			}
+		}(workerChans[workerIdx])
+	}
+
+	defer func() {
+		for _, workerChan := range workerChans {
+			close(workerChan)
+		}
+	}()
+
+	for recordBatch := range claim.GetRecordBatchChan() {
+		if len(recordBatch.Records) == 0 {
+			continue
+		}
+
+		recordsWaitGroup.Add(len(recordBatch.Records))
+
+		for recordIdx := range recordBatch.Records {
+			record := &recordBatch.Records[recordIdx]
+			workerChans[getRecordWorkerIdx(record, recordIdx, numWorkers)] <- record
+		}
+
+		recordsWaitGroup.Wait()
+
+		if handleErr != nil {
+			return handleErr
+		}
+
+		if err := session.MarkRecord(&recordBatch.Records[len(recordBatch.Records)-1]); err != nil {
+			return errors.Wrap(err, "Failed marking record batch")
+		}
+	}
+
+	return nil
+}
+
+// the number of records which may be queued for each worker
+const claimWorkerChanSize = 16
+
+// records with the same partition key go to the same worker. records without one have no order to preserve
+func getRecordWorkerIdx(record *v3io.StreamRecord, recordIdx int, numWorkers int) int {
+	if record.PartitionKey == "" {
+		return recordIdx % numWorkers
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(record.PartitionKey)) // nolint: errcheck
+
+	return int(hash.Sum32() % uint32(numWorkers))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"sort"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type MemberState struct {
+	MemberID      string
+	Shards        []int
+	LastHeartbeat time.Time
+	Static        bool
+}
+
+// GroupState is the state of a consumer group, as persisted by its members
+type GroupState struct {
+	Members []MemberState
+
+	// shards which aren't assigned to any member
+	UnassignedShards []int
+
+	// the committed sequence number of each shard, omitting shards which nothing was committed to
+	ShardSequenceNumbers map[int]uint64
+}
+
+// ReadState returns the members of the group, their shards and the committed sequence numbers. members may
+// have timed out without being removed from the state yet - their last heartbeat tells them apart
+func (scg *streamConsumerGroup) ReadState() (*GroupState, error) {
+	groupState := GroupState{}
+
+	state, _, err := scg.stateStore.GetState()
+	if err != nil && err != v3ioerrors.ErrNotFound {
+		return nil, errors.Wrap(err, "Failed getting state")
+	}
+
+	assignedShards := map[int]bool{}
+
+	if state != nil {
+		for _, sessionState := range state.SessionStates {
+			groupState.Members = append(groupState.Members, MemberState{
+				MemberID:      sessionState.MemberID,
+				Shards:        sessionState.Shards,
+				LastHeartbeat: sessionState.LastHeartbeat,
+				Static:        sessionState.Static,
+			})
+
+			for _, shardID := range sessionState.Shards {
+				assignedShards[shardID] = true
+			}
+		}
+
+		sort.Slice(groupState.Members, func(i, j int) bool {
+			return groupState.Members[i].MemberID < groupState.Members[j].MemberID
+		})
+	}
+
+	for shardID := 0; shardID < scg.getTotalNumShards(); shardID++ {
+		if !assignedShards[shardID] {
+			groupState.UnassignedShards = append(groupState.UnassignedShards, shardID)
+		}
+	}
+
+	groupState.ShardSequenceNumbers, err = scg.GetShardSequenceNumbers()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed getting shard sequence numbers")
+	}
+
+	return &groupState, nil
+}
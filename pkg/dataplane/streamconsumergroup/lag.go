@@ -0,0 +1,112 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type ShardLag struct {
+	ShardID int
+
+	// the member the shard is assigned to, empty if none is
+	MemberID string
+
+	CommittedSequenceNumber uint64
+	LatestSequenceNumber    uint64
+
+	// the number of retained records which were put after the committed sequence number
+	Lag uint64
+}
+
+type StreamLag struct {
+	Shards []ShardLag
+
+	// the lag of the shards assigned to each member
+	MemberLags map[string]uint64
+
+	TotalLag uint64
+}
+
+// GetStreamLag compares the sequence numbers committed by the consumer group with the latest sequence
+// number of each shard
+func (scg *streamConsumerGroup) GetStreamLag() (*StreamLag, error) {
+	state, err := scg.GetState()
+	if err != nil && err != v3ioerrors.ErrNotFound {
+		return nil, errors.Wrap(err, "Failed getting state")
+	}
+
+	shardMemberIDs := map[int]string{}
+	if state != nil {
+		for _, sessionState := range state.SessionStates {
+			for _, shardID := range sessionState.Shards {
+				shardMemberIDs[shardID] = sessionState.MemberID
+			}
+		}
+	}
+
+	shardDescriptions, err := v3io.DescribeShards(scg.container,
+		&v3io.DataPlaneInput{},
+		scg.streamPath,
+		scg.getTotalNumShards())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed describing shards of stream: %s", scg.streamPath)
+	}
+
+	streamLag := StreamLag{
+		MemberLags: map[string]uint64{},
+	}
+
+	for _, shardDescription := range shardDescriptions {
+		committedSequenceNumber, err := scg.getShardSequenceNumberFromPersistency(shardDescription.ShardID)
+		if err != nil && err != ErrShardNotFound && err != ErrShardSequenceNumberAttributeNotFound {
+			return nil, errors.Wrapf(err, "Failed getting committed sequence number of shard %d",
+				shardDescription.ShardID)
+		}
+
+		shardLag := ShardLag{
+			ShardID:                 shardDescription.ShardID,
+			MemberID:                shardMemberIDs[shardDescription.ShardID],
+			CommittedSequenceNumber: committedSequenceNumber,
+			LatestSequenceNumber:    shardDescription.LatestSequenceNumber,
+		}
+
+		// records which expired before being consumed no longer count
+		if shardDescription.Length > 0 && shardDescription.LatestSequenceNumber > committedSequenceNumber {
+			shardLag.Lag = shardDescription.LatestSequenceNumber - committedSequenceNumber
+			if shardLag.Lag > shardDescription.Length {
+				shardLag.Lag = shardDescription.Length
+			}
+		}
+
+		streamLag.Shards = append(streamLag.Shards, shardLag)
+		streamLag.TotalLag += shardLag.Lag
+
+		if shardLag.MemberID != "" {
+			streamLag.MemberLags[shardLag.MemberID] += shardLag.Lag
+		}
+	}
+
+	return &streamLag, nil
+}
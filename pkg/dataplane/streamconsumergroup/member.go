@@ -20,7 +20,11 @@ such restriction.
 package streamconsumergroup
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
@@ -37,9 +41,35 @@ type member struct {
 	session               Session
 	retainShards          bool
 	shardGroupToRetain    []int
+	static                bool
+	pausedShards          map[int]bool
+	pausedShardsLock      sync.Mutex
+
+	// statistics, accessed atomically
+	numRecordsConsumed uint64
+	numCommits         uint64
+	commitDuration     int64
+	numHeartbeatMisses uint64
 }
 
 func NewMember(streamConsumerGroupInterface StreamConsumerGroup, name string) (Member, error) {
+
+	// add uniqueness
+	return newMember(streamConsumerGroupInterface, fmt.Sprintf("%s-%s", name, xid.New().String()), false)
+}
+
+// NewStaticMember creates a member with the given ID, which must be unique in the group and stable across
+// restarts (e.g. a stateful set pod name). a static member which restarts within the session timeout and
+// rejoin grace period resumes its previous session, consuming the same shards
+func NewStaticMember(streamConsumerGroupInterface StreamConsumerGroup, id string) (Member, error) {
+	if id == "" {
+		return nil, errors.New("Static member ID is required")
+	}
+
+	return newMember(streamConsumerGroupInterface, id, true)
+}
+
+func newMember(streamConsumerGroupInterface StreamConsumerGroup, id string, static bool) (Member, error) {
 	var err error
 
 	streamConsumerGroupInstance, ok := streamConsumerGroupInterface.(*streamConsumerGroup)
@@ -47,13 +77,12 @@ func NewMember(streamConsumerGroupInterface StreamConsumerGroup, name string) (M
 		return nil, errors.Errorf("Expected streamConsumerGroupInterface of type streamConsumerGroup, got %T", streamConsumerGroupInterface)
 	}
 
-	// add uniqueness
-	id := fmt.Sprintf("%s-%s", name, xid.New().String())
-
 	newMember := member{
 		logger:              streamConsumerGroupInstance.logger.GetChild(id),
 		id:                  id,
 		streamConsumerGroup: streamConsumerGroupInstance,
+		static:              static,
+		pausedShards:        map[int]bool{},
 	}
 
 	// create & start a state handler for the stream
@@ -99,6 +128,11 @@ func (m *member) Consume(handler Handler) error {
 func (m *member) Close() error {
 	m.logger.DebugWith("Closing consumer group")
 
+	// revoke before the sequence number handler stops, so that records marked on revocation are committed
+	if m.session != nil {
+		m.session.revoke()
+	}
+
 	if err := m.stateHandler.stop(); err != nil {
 		return errors.Wrapf(err, "Failed stopping state handler")
 	}
@@ -115,6 +149,103 @@ func (m *member) Close() error {
 	return nil
 }
 
+// Shutdown closes the member gracefully: fetching stops, the handler consumes the records already fetched
+// (until the context is done), the marked sequence numbers are committed and the member's shards are released
+// so that they can be assigned to other members without waiting for the session to time out
+func (m *member) Shutdown(ctx context.Context) error {
+	var drainErr error
+
+	m.logger.DebugWith("Shutting down consumer group")
+
+	if m.session != nil {
+
+		// records marked after the context is done may not be committed, but the shutdown goes on
+		if drainErr = m.session.drain(ctx); drainErr != nil {
+			m.logger.WarnWith("Failed draining session", "err", errors.GetErrorStackString(drainErr, 10))
+		}
+
+		m.session.revoke()
+
+		if err := m.session.stop(); err != nil {
+			return errors.Wrap(err, "Failed stopping member session")
+		}
+	}
+
+	if err := m.stateHandler.stop(); err != nil {
+		return errors.Wrapf(err, "Failed stopping state handler")
+	}
+
+	if m.streamConsumerGroup.config.SequenceNumber.CommitMode != CommitModeManual {
+		if err := m.sequenceNumberHandler.commitMarkedShardSequenceNumbers(); err != nil {
+			return errors.Wrap(err, "Failed committing marked sequence numbers")
+		}
+	}
+
+	if err := m.sequenceNumberHandler.stop(); err != nil {
+		return errors.Wrapf(err, "Failed stopping location handler")
+	}
+
+	if err := m.stateHandler.releaseSessionState(); err != nil {
+		return errors.Wrap(err, "Failed releasing shards")
+	}
+
+	return drainErr
+}
+
+// Pause stops fetching records from the given shards, without releasing them. records already fetched are
+// still delivered to the handler. pausing survives rebalancing, until the shards are resumed
+func (m *member) Pause(shardIDs []int) error {
+	m.pausedShardsLock.Lock()
+	defer m.pausedShardsLock.Unlock()
+
+	for _, shardID := range shardIDs {
+		m.pausedShards[shardID] = true
+	}
+
+	m.logger.DebugWith("Paused shards", "shardIDs", shardIDs)
+
+	return nil
+}
+
+// Resume resumes fetching records from the given shards
+func (m *member) Resume(shardIDs []int) error {
+	m.pausedShardsLock.Lock()
+	defer m.pausedShardsLock.Unlock()
+
+	for _, shardID := range shardIDs {
+		delete(m.pausedShards, shardID)
+	}
+
+	m.logger.DebugWith("Resumed shards", "shardIDs", shardIDs)
+
+	return nil
+}
+
+func (m *member) isShardPaused(shardID int) bool {
+	m.pausedShardsLock.Lock()
+	defer m.pausedShardsLock.Unlock()
+
+	return m.pausedShards[shardID]
+}
+
+func (m *member) Stats() *MemberStats {
+	memberStats := MemberStats{
+		MemberID:           m.id,
+		NumRecordsConsumed: atomic.LoadUint64(&m.numRecordsConsumed),
+		NumCommits:         atomic.LoadUint64(&m.numCommits),
+		CommitDuration:     time.Duration(atomic.LoadInt64(&m.commitDuration)),
+		NumHeartbeatMisses: atomic.LoadUint64(&m.numHeartbeatMisses),
+	}
+
+	if m.session != nil {
+		for _, claim := range m.session.GetClaims() {
+			memberStats.Shards = append(memberStats.Shards, claim.GetShardID())
+		}
+	}
+
+	return &memberStats
+}
+
 func (m *member) Start() error {
 	if err := m.stateHandler.start(); err != nil {
 		return errors.Wrap(err, "Failed starting stream consumer group state handler")
@@ -0,0 +1,305 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nuclio/errors"
+	"github.com/rs/xid"
+)
+
+// MultiStreamMember is a member of consumer groups of several streams, consuming them all with a single handler.
+// the handler tells the streams apart by Claim.GetStreamPath. shard IDs are only unique within a stream, so the
+// Member methods which take or return bare shard IDs apply to all streams - use the per-stream variants to address
+// the shards of a specific stream
+type MultiStreamMember interface {
+	Member
+
+	// returns the member of each stream's consumer group, by stream path
+	GetStreamMembers() map[string]Member
+
+	// pauses the given shards of each stream, by stream path
+	PauseStreamShards(map[string][]int) error
+
+	// resumes the given shards of each stream, by stream path
+	ResumeStreamShards(map[string][]int) error
+
+	// returns the statistics of the member of each stream, by stream path
+	GetStreamStats() map[string]*MemberStats
+
+	// returns the shards retained in each stream, by stream path
+	GetStreamShardsToRetain() map[string][]int
+}
+
+type multiStreamMember struct {
+	id            string
+	streamMembers []Member
+	streamPaths   []string
+}
+
+// NewMultiStreamMember joins a member to the consumer group of each stream, under a single ID. the members share
+// a lifecycle - they consume, pause, resume and close together. the handler's Setup and Cleanup are called
+// once per stream, as each stream's shards are assigned independently
+func NewMultiStreamMember(streamConsumerGroupInterfaces []StreamConsumerGroup, name string) (MultiStreamMember, error) {
+	if len(streamConsumerGroupInterfaces) == 0 {
+		return nil, errors.New("At least one stream consumer group is required")
+	}
+
+	newMultiStreamMember := multiStreamMember{
+
+		// add uniqueness
+		id: fmt.Sprintf("%s-%s", name, xid.New().String()),
+	}
+
+	for _, streamConsumerGroupInterface := range streamConsumerGroupInterfaces {
+		streamMember, err := newMember(streamConsumerGroupInterface, newMultiStreamMember.id, false)
+		if err != nil {
+
+			// don't leave the members already created running
+			newMultiStreamMember.Close() // nolint: errcheck
+
+			return nil, errors.Wrap(err, "Failed creating stream member")
+		}
+
+		newMultiStreamMember.streamMembers = append(newMultiStreamMember.streamMembers, streamMember)
+		newMultiStreamMember.streamPaths = append(newMultiStreamMember.streamPaths,
+			streamMember.(*member).streamConsumerGroup.streamPath)
+	}
+
+	return &newMultiStreamMember, nil
+}
+
+// Consume starts consuming all streams. if a stream fails to start consuming, the members of the streams
+// which already started are closed
+func (msm *multiStreamMember) Consume(handler Handler) error {
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		if err := streamMember.Consume(handler); err != nil {
+			for _, startedStreamMember := range msm.streamMembers[:streamMemberIdx] {
+				startedStreamMember.Close() // nolint: errcheck
+			}
+
+			return errors.Wrapf(err, "Failed consuming stream: %s", msm.streamPaths[streamMemberIdx])
+		}
+	}
+
+	return nil
+}
+
+// Close closes the members of all streams, returning the first error
+func (msm *multiStreamMember) Close() error {
+	var firstErr error
+
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		if err := streamMember.Close(); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "Failed closing member of stream: %s", msm.streamPaths[streamMemberIdx])
+		}
+	}
+
+	return firstErr
+}
+
+// Shutdown shuts the members of all streams down concurrently, returning the first error
+func (msm *multiStreamMember) Shutdown(ctx context.Context) error {
+	errChan := make(chan error, len(msm.streamMembers))
+
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		go func(streamMember Member, streamPath string) {
+			if err := streamMember.Shutdown(ctx); err != nil {
+				errChan <- errors.Wrapf(err, "Failed shutting down member of stream: %s", streamPath)
+				return
+			}
+
+			errChan <- nil
+		}(streamMember, msm.streamPaths[streamMemberIdx])
+	}
+
+	var firstErr error
+	for range msm.streamMembers {
+		if err := <-errChan; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Pause pauses the given shards in all streams. use PauseStreamShards to pause the shards of specific streams
+func (msm *multiStreamMember) Pause(shardIDs []int) error {
+	for _, streamMember := range msm.streamMembers {
+		if err := streamMember.Pause(shardIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resume resumes the given shards in all streams. use ResumeStreamShards to resume the shards of specific streams
+func (msm *multiStreamMember) Resume(shardIDs []int) error {
+	for _, streamMember := range msm.streamMembers {
+		if err := streamMember.Resume(shardIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats sums the statistics of the members of all streams. shards are listed once, even if assigned in
+// several streams - use GetStreamStats for the shards assigned in each stream
+func (msm *multiStreamMember) Stats() *MemberStats {
+	memberStats := MemberStats{MemberID: msm.id}
+	shards := map[int]bool{}
+
+	for _, streamMember := range msm.streamMembers {
+		streamMemberStats := streamMember.Stats()
+
+		memberStats.NumRecordsConsumed += streamMemberStats.NumRecordsConsumed
+		memberStats.NumCommits += streamMemberStats.NumCommits
+		memberStats.CommitDuration += streamMemberStats.CommitDuration
+		memberStats.NumHeartbeatMisses += streamMemberStats.NumHeartbeatMisses
+
+		for _, shardID := range streamMemberStats.Shards {
+			shards[shardID] = true
+		}
+	}
+
+	for shardID := range shards {
+		memberStats.Shards = append(memberStats.Shards, shardID)
+	}
+
+	sort.Ints(memberStats.Shards)
+
+	return &memberStats
+}
+
+func (msm *multiStreamMember) Start() error {
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		if err := streamMember.Start(); err != nil {
+			return errors.Wrapf(err, "Failed starting member of stream: %s", msm.streamPaths[streamMemberIdx])
+		}
+	}
+
+	return nil
+}
+
+func (msm *multiStreamMember) GetID() string {
+	return msm.id
+}
+
+// GetRetainShardFlag returns whether shards are retained in any of the streams
+func (msm *multiStreamMember) GetRetainShardFlag() bool {
+	for _, streamMember := range msm.streamMembers {
+		if streamMember.GetRetainShardFlag() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetShardsToRetain returns the shards retained in any of the streams. use GetStreamShardsToRetain for the
+// shards retained in each stream
+func (msm *multiStreamMember) GetShardsToRetain() []int {
+	shardsToRetain := map[int]bool{}
+
+	for _, streamMember := range msm.streamMembers {
+		for _, shardID := range streamMember.GetShardsToRetain() {
+			shardsToRetain[shardID] = true
+		}
+	}
+
+	var shardIDs []int
+	for shardID := range shardsToRetain {
+		shardIDs = append(shardIDs, shardID)
+	}
+
+	sort.Ints(shardIDs)
+
+	return shardIDs
+}
+
+func (msm *multiStreamMember) GetStreamMembers() map[string]Member {
+	streamMembers := map[string]Member{}
+
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		streamMembers[msm.streamPaths[streamMemberIdx]] = streamMember
+	}
+
+	return streamMembers
+}
+
+func (msm *multiStreamMember) PauseStreamShards(shardIDsByStreamPath map[string][]int) error {
+	return msm.forEachStreamShards(shardIDsByStreamPath, Member.Pause, "pausing")
+}
+
+func (msm *multiStreamMember) ResumeStreamShards(shardIDsByStreamPath map[string][]int) error {
+	return msm.forEachStreamShards(shardIDsByStreamPath, Member.Resume, "resuming")
+}
+
+func (msm *multiStreamMember) GetStreamStats() map[string]*MemberStats {
+	streamStats := map[string]*MemberStats{}
+
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		streamStats[msm.streamPaths[streamMemberIdx]] = streamMember.Stats()
+	}
+
+	return streamStats
+}
+
+func (msm *multiStreamMember) GetStreamShardsToRetain() map[string][]int {
+	streamShardsToRetain := map[string][]int{}
+
+	for streamMemberIdx, streamMember := range msm.streamMembers {
+		streamShardsToRetain[msm.streamPaths[streamMemberIdx]] = streamMember.GetShardsToRetain()
+	}
+
+	return streamShardsToRetain
+}
+
+// applies an operation to the shards of each stream. all stream paths are checked before any is applied
+func (msm *multiStreamMember) forEachStreamShards(shardIDsByStreamPath map[string][]int,
+	operation func(Member, []int) error,
+	operationName string) error {
+	streamMembers := msm.GetStreamMembers()
+
+	streamPaths := make([]string, 0, len(shardIDsByStreamPath))
+	for streamPath := range shardIDsByStreamPath {
+		if _, found := streamMembers[streamPath]; !found {
+			return errors.Errorf("Member doesn't consume stream: %s", streamPath)
+		}
+
+		streamPaths = append(streamPaths, streamPath)
+	}
+
+	sort.Strings(streamPaths)
+
+	for _, streamPath := range streamPaths {
+		if err := operation(streamMembers[streamPath], shardIDsByStreamPath[streamPath]); err != nil {
+			return errors.Wrapf(err, "Failed %s shards of stream: %s", operationName, streamPath)
+		}
+	}
+
+	return nil
+}
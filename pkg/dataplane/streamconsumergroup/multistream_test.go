@@ -0,0 +1,179 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"testing"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// records the operations applied to a stream's member
+type recordingMember struct {
+	Member
+	shards         []int
+	shardsToRetain []int
+	pausedShards   []int
+	resumedShards  []int
+	consumeErr     error
+	consuming      bool
+	closed         bool
+}
+
+func (rm *recordingMember) Consume(Handler) error {
+	if rm.consumeErr != nil {
+		return rm.consumeErr
+	}
+
+	rm.consuming = true
+	return nil
+}
+
+func (rm *recordingMember) Close() error {
+	rm.closed = true
+	return nil
+}
+
+func (rm *recordingMember) Pause(shardIDs []int) error {
+	rm.pausedShards = append(rm.pausedShards, shardIDs...)
+	return nil
+}
+
+func (rm *recordingMember) Resume(shardIDs []int) error {
+	rm.resumedShards = append(rm.resumedShards, shardIDs...)
+	return nil
+}
+
+func (rm *recordingMember) Stats() *MemberStats {
+	return &MemberStats{Shards: rm.shards, NumRecordsConsumed: uint64(len(rm.shards))}
+}
+
+func (rm *recordingMember) GetShardsToRetain() []int {
+	return rm.shardsToRetain
+}
+
+type multiStreamMemberSuite struct {
+	suite.Suite
+	streamMembers     []*recordingMember
+	multiStreamMember *multiStreamMember
+}
+
+func (suite *multiStreamMemberSuite) SetupTest() {
+	suite.streamMembers = []*recordingMember{
+		{shards: []int{0, 1}, shardsToRetain: []int{1}},
+		{shards: []int{1, 2}, shardsToRetain: []int{2}},
+		{shards: []int{3}},
+	}
+
+	suite.multiStreamMember = &multiStreamMember{
+		id:          "member",
+		streamPaths: []string{"/a/", "/b/", "/c/"},
+	}
+
+	for _, streamMember := range suite.streamMembers {
+		suite.multiStreamMember.streamMembers = append(suite.multiStreamMember.streamMembers, streamMember)
+	}
+}
+
+func (suite *multiStreamMemberSuite) TestPauseAndResumeStreamShards() {
+	err := suite.multiStreamMember.PauseStreamShards(map[string][]int{"/a/": {1}, "/c/": {3}})
+	suite.Require().NoError(err)
+
+	// shard 1 of stream b isn't paused, even though shard 1 of stream a is
+	suite.Require().Equal([]int{1}, suite.streamMembers[0].pausedShards)
+	suite.Require().Empty(suite.streamMembers[1].pausedShards)
+	suite.Require().Equal([]int{3}, suite.streamMembers[2].pausedShards)
+
+	err = suite.multiStreamMember.ResumeStreamShards(map[string][]int{"/b/": {2}})
+	suite.Require().NoError(err)
+
+	suite.Require().Empty(suite.streamMembers[0].resumedShards)
+	suite.Require().Equal([]int{2}, suite.streamMembers[1].resumedShards)
+}
+
+func (suite *multiStreamMemberSuite) TestPauseUnknownStream() {
+	err := suite.multiStreamMember.PauseStreamShards(map[string][]int{"/a/": {1}, "/unknown/": {1}})
+	suite.Require().Error(err)
+
+	// nothing is paused if any of the streams is unknown
+	for _, streamMember := range suite.streamMembers {
+		suite.Require().Empty(streamMember.pausedShards)
+	}
+}
+
+func (suite *multiStreamMemberSuite) TestPauseAllStreams() {
+	suite.Require().NoError(suite.multiStreamMember.Pause([]int{1}))
+
+	for _, streamMember := range suite.streamMembers {
+		suite.Require().Equal([]int{1}, streamMember.pausedShards)
+	}
+}
+
+func (suite *multiStreamMemberSuite) TestStats() {
+	suite.Require().Equal(map[string][]int{
+		"/a/": {0, 1},
+		"/b/": {1, 2},
+		"/c/": {3},
+	}, suite.getStreamShards())
+
+	memberStats := suite.multiStreamMember.Stats()
+	suite.Require().Equal("member", memberStats.MemberID)
+	suite.Require().Equal([]int{0, 1, 2, 3}, memberStats.Shards)
+	suite.Require().Equal(uint64(5), memberStats.NumRecordsConsumed)
+}
+
+func (suite *multiStreamMemberSuite) TestGetShardsToRetain() {
+	suite.Require().Equal(map[string][]int{
+		"/a/": {1},
+		"/b/": {2},
+		"/c/": nil,
+	}, suite.multiStreamMember.GetStreamShardsToRetain())
+
+	suite.Require().Equal([]int{1, 2}, suite.multiStreamMember.GetShardsToRetain())
+}
+
+func (suite *multiStreamMemberSuite) TestConsumeFailureClosesStartedMembers() {
+	suite.streamMembers[1].consumeErr = errors.New("Consume failed")
+
+	err := suite.multiStreamMember.Consume(nil)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "/b/")
+
+	// the member which started consuming is closed, the one after the failure never started
+	suite.Require().True(suite.streamMembers[0].closed)
+	suite.Require().False(suite.streamMembers[2].consuming)
+	suite.Require().False(suite.streamMembers[2].closed)
+}
+
+func (suite *multiStreamMemberSuite) getStreamShards() map[string][]int {
+	streamShards := map[string][]int{}
+
+	for streamPath, memberStats := range suite.multiStreamMember.GetStreamStats() {
+		streamShards[streamPath] = memberStats.Shards
+	}
+
+	return streamShards
+}
+
+func TestMultiStreamMemberSuite(t *testing.T) {
+	suite.Run(t, new(multiStreamMemberSuite))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"github.com/v3io/v3io-go/pkg/dataplane"
+
+	"github.com/nuclio/errors"
+)
+
+type ResetShardSequenceNumbersInput struct {
+
+	// the shards to reset (defaults to all shards)
+	ShardIDs []int
+
+	// where to resume consumption from - the earliest record, the latest or the first record which arrived at
+	// or after Timestamp (in seconds)
+	Type      v3io.SeekShardInputType
+	Timestamp int
+}
+
+// GetShardSequenceNumbers returns the committed sequence number of each shard, omitting shards which
+// nothing was committed to
+func (scg *streamConsumerGroup) GetShardSequenceNumbers() (map[int]uint64, error) {
+	shardSequenceNumbers := map[int]uint64{}
+
+	for shardID := 0; shardID < scg.getTotalNumShards(); shardID++ {
+		sequenceNumber, err := scg.getShardSequenceNumberFromPersistency(shardID)
+		if err != nil {
+			if err == ErrShardNotFound || err == ErrShardSequenceNumberAttributeNotFound {
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "Failed getting sequence number of shard %d", shardID)
+		}
+
+		shardSequenceNumbers[shardID] = sequenceNumber
+	}
+
+	return shardSequenceNumbers, nil
+}
+
+// CommitShardSequenceNumber commits the sequence number of a shard, so that consumption resumes from the
+// record following it. members which are consuming the shard overwrite it once they commit, so offsets
+// should be changed while the group is stopped
+func (scg *streamConsumerGroup) CommitShardSequenceNumber(shardID int, sequenceNumber uint64) error {
+	if shardID < 0 || shardID >= scg.getTotalNumShards() {
+		return errors.Errorf("Invalid shard ID: %d", shardID)
+	}
+
+	return scg.setShardSequenceNumberInPersistency(shardID, sequenceNumber)
+}
+
+// ResetShardSequenceNumbers commits, for each shard, the sequence number preceding the record which the
+// seek resolves to. like CommitShardSequenceNumber, it should be called while the group is stopped
+func (scg *streamConsumerGroup) ResetShardSequenceNumbers(resetShardSequenceNumbersInput *ResetShardSequenceNumbersInput) error {
+	switch resetShardSequenceNumbersInput.Type {
+	case v3io.SeekShardInputTypeEarliest, v3io.SeekShardInputTypeLatest, v3io.SeekShardInputTypeTime:
+	default:
+		return errors.Errorf("Invalid reset type: %d", resetShardSequenceNumbersInput.Type)
+	}
+
+	shardIDs := resetShardSequenceNumbersInput.ShardIDs
+	if len(shardIDs) == 0 {
+		for shardID := 0; shardID < scg.getTotalNumShards(); shardID++ {
+			shardIDs = append(shardIDs, shardID)
+		}
+	}
+
+	for _, shardID := range shardIDs {
+		sequenceNumber, err := scg.resolveShardSequenceNumber(shardID, resetShardSequenceNumbersInput)
+		if err != nil {
+			return errors.Wrapf(err, "Failed resolving sequence number of shard %d", shardID)
+		}
+
+		if err := scg.CommitShardSequenceNumber(shardID, sequenceNumber); err != nil {
+			return errors.Wrapf(err, "Failed committing sequence number of shard %d", shardID)
+		}
+	}
+
+	return nil
+}
+
+// returns the sequence number preceding the first record at the seek's location. if there are no records
+// there, the shard's latest sequence number is returned so that consumption resumes with the next record put
+func (scg *streamConsumerGroup) resolveShardSequenceNumber(shardID int,
+	resetShardSequenceNumbersInput *ResetShardSequenceNumbersInput) (uint64, error) {
+	shardPath, err := scg.getShardPath(shardID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed getting shard path: %v", shardID)
+	}
+
+	location, err := scg.getShardLocationWithSeek(&v3io.SeekShardInput{
+		Path:      shardPath,
+		Type:      resetShardSequenceNumbersInput.Type,
+		Timestamp: resetShardSequenceNumbersInput.Timestamp,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed seeking shard")
+	}
+
+	response, err := scg.container.GetRecordsSync(&v3io.GetRecordsInput{
+		Path:     shardPath,
+		Location: location,
+		Limit:    1,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed getting records")
+	}
+
+	records := response.Output.(*v3io.GetRecordsOutput).Records
+	response.Release()
+
+	if len(records) > 0 {
+		return records[0].SequenceNumber - 1, nil
+	}
+
+	shardDescription, err := v3io.DescribeShard(scg.container, &v3io.DataPlaneInput{}, scg.streamPath, shardID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed describing shard")
+	}
+
+	return shardDescription.LatestSequenceNumber, nil
+}
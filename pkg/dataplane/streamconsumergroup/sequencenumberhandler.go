@@ -20,6 +20,8 @@ such restriction.
 package streamconsumergroup
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/v3io/v3io-go/pkg/common"
@@ -36,6 +38,7 @@ type sequenceNumberHandler struct {
 	member                                     *member
 	markedShardSequenceNumbers                 []uint64
 	stopMarkedShardSequenceNumberCommitterChan chan struct{}
+	commitLock                                 sync.Mutex
 	lastCommittedShardSequenceNumbers          []uint64
 }
 
@@ -44,7 +47,7 @@ func newSequenceNumberHandler(member *member) (*sequenceNumberHandler, error) {
 	return &sequenceNumberHandler{
 		logger:                     member.logger.GetChild("sequenceNumberHandler"),
 		member:                     member,
-		markedShardSequenceNumbers: make([]uint64, member.streamConsumerGroup.totalNumShards),
+		markedShardSequenceNumbers: make([]uint64, member.streamConsumerGroup.getTotalNumShards()),
 		stopMarkedShardSequenceNumberCommitterChan: make(chan struct{}, 1),
 	}, nil
 }
@@ -52,6 +55,11 @@ func newSequenceNumberHandler(member *member) (*sequenceNumberHandler, error) {
 func (snh *sequenceNumberHandler) start() error {
 	snh.logger.DebugWith("Starting sequenceNumber handler")
 
+	// the handler commits explicitly
+	if snh.member.streamConsumerGroup.config.SequenceNumber.CommitMode == CommitModeManual {
+		return nil
+	}
+
 	// stopped on stop()
 	go snh.markedShardSequenceNumbersCommitter(snh.member.streamConsumerGroup.config.SequenceNumber.CommitInterval,
 		snh.stopMarkedShardSequenceNumberCommitterChan)
@@ -79,7 +87,7 @@ func (snh *sequenceNumberHandler) markShardSequenceNumber(shardID int, sequenceN
 func (snh *sequenceNumberHandler) markedShardSequenceNumbersCommitter(interval time.Duration, stopChan chan struct{}) {
 	for {
 		select {
-		case <-time.After(interval):
+		case <-snh.member.streamConsumerGroup.clock.After(interval):
 			if err := snh.commitMarkedShardSequenceNumbers(); err != nil {
 				snh.logger.WarnWith("Failed committing marked shard sequenceNumbers", "err", errors.GetErrorStackString(err, 10))
 				continue
@@ -99,6 +107,10 @@ func (snh *sequenceNumberHandler) markedShardSequenceNumbersCommitter(interval t
 func (snh *sequenceNumberHandler) commitMarkedShardSequenceNumbers() error {
 	var markedShardSequenceNumbersCopy []uint64
 
+	// commits may be triggered by the handlers as well as by the committer
+	snh.commitLock.Lock()
+	defer snh.commitLock.Unlock()
+
 	// create a copy of the marked shard sequenceNumbers
 	markedShardSequenceNumbersCopy = append(markedShardSequenceNumbersCopy, snh.markedShardSequenceNumbers...)
 
@@ -109,6 +121,8 @@ func (snh *sequenceNumberHandler) commitMarkedShardSequenceNumbers() error {
 
 	snh.logger.DebugWith("Committing marked shard sequenceNumbers", "markedShardSequenceNumbersCopy", markedShardSequenceNumbersCopy)
 
+	commitStartTime := time.Now()
+
 	var failedShardIDs []int
 	for shardID, sequenceNumber := range markedShardSequenceNumbersCopy {
 
@@ -127,10 +141,14 @@ func (snh *sequenceNumberHandler) commitMarkedShardSequenceNumbers() error {
 		}
 	}
 
+	atomic.AddInt64(&snh.member.commitDuration, int64(time.Since(commitStartTime)))
+
 	if len(failedShardIDs) > 0 {
 		return errors.Errorf("Failed committing marked shard sequenceNumbers in shards: %v", failedShardIDs)
 	}
 
+	atomic.AddUint64(&snh.member.numCommits, 1)
+
 	snh.lastCommittedShardSequenceNumbers = markedShardSequenceNumbersCopy
 
 	return nil
@@ -20,6 +20,9 @@ such restriction.
 package streamconsumergroup
 
 import (
+	"context"
+	"sync"
+
 	v3io "github.com/v3io/v3io-go/pkg/dataplane"
 
 	"github.com/nuclio/errors"
@@ -27,10 +30,15 @@ import (
 )
 
 type session struct {
-	logger logger.Logger
-	member *member
-	state  *SessionState
-	claims []Claim
+	logger     logger.Logger
+	member     *member
+	state      *SessionState
+	claims     []Claim
+	revokeOnce sync.Once
+
+	// the record each shard is failing on (see FailRecord)
+	recordFailuresLock sync.Mutex
+	recordFailures     map[int]*recordFailure
 }
 
 func newSession(member *member,
@@ -63,6 +71,12 @@ func (s *session) start() error {
 		return errors.Wrap(err, "Failed to set up session")
 	}
 
+	if rebalanceHandler, ok := s.member.handler.(RebalanceHandler); ok {
+		if err := rebalanceHandler.OnAssign(s, s.state.Shards); err != nil {
+			return errors.Wrap(err, "Failed handling shard assignment")
+		}
+	}
+
 	s.logger.DebugWith("Starting claim consumption")
 	for _, claim := range s.claims {
 		if err := claim.start(); err != nil {
@@ -93,6 +107,43 @@ func (s *session) stop() error {
 	return nil
 }
 
+// stops fetching records and waits for the handler to consume those already fetched, until the context is done
+func (s *session) drain(ctx context.Context) error {
+	s.logger.DebugWith("Draining session")
+
+	for _, claim := range s.claims {
+		if err := claim.stop(); err != nil {
+			return errors.Wrap(err, "Failed stopping stream consumer group claim")
+		}
+	}
+
+	for _, claim := range s.claims {
+		select {
+		case <-claim.getConsumeDoneChan():
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "Context done while waiting for claims to be consumed")
+		}
+	}
+
+	return nil
+}
+
+// tells the handler that the session's shards are revoked, once
+func (s *session) revoke() {
+	rebalanceHandler, ok := s.member.handler.(RebalanceHandler)
+	if !ok {
+		return
+	}
+
+	s.revokeOnce.Do(func() {
+		s.logger.DebugWith("Triggering given handler OnRevoke", "shards", s.state.Shards)
+
+		if err := rebalanceHandler.OnRevoke(s, s.state.Shards); err != nil {
+			s.logger.WarnWith("Failed handling shard revocation", "err", errors.GetErrorStackString(err, 10))
+		}
+	})
+}
+
 func (s *session) GetClaims() []Claim {
 	return s.claims
 }
@@ -109,3 +160,21 @@ func (s *session) MarkRecord(record *v3io.StreamRecord) error {
 
 	return nil
 }
+
+// CommitRecord marks the record and commits the marked sequence numbers
+func (s *session) CommitRecord(record *v3io.StreamRecord) error {
+	if err := s.MarkRecord(record); err != nil {
+		return err
+	}
+
+	return s.Commit()
+}
+
+// Commit commits the sequence numbers marked so far, returning once they're persisted
+func (s *session) Commit() error {
+	if err := s.member.sequenceNumberHandler.commitMarkedShardSequenceNumbers(); err != nil {
+		return errors.Wrap(err, "Failed committing marked records")
+	}
+
+	return nil
+}
@@ -26,6 +26,9 @@ import (
 type State struct {
 	SchemasVersion string          `json:"schema_version"`
 	SessionStates  []*SessionState `json:"session_states"`
+
+	// the shard group each member name was last assigned, kept by the sticky assignment strategy
+	LastShardGroups map[string][]int `json:"last_shard_groups,omitempty"`
 }
 
 func newState() (*State, error) {
@@ -52,6 +55,13 @@ func (s *State) deepCopy() *State {
 		stateCopy.SessionStates = append(stateCopy.SessionStates, stateSessionCopy)
 	}
 
+	if s.LastShardGroups != nil {
+		stateCopy.LastShardGroups = map[string][]int{}
+		for memberName, shardGroup := range s.LastShardGroups {
+			stateCopy.LastShardGroups[memberName] = shardGroup
+		}
+	}
+
 	return &stateCopy
 }
 
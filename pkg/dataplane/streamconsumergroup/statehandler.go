@@ -20,8 +20,7 @@ such restriction.
 package streamconsumergroup
 
 import (
-	"math"
-	"time"
+	"sync/atomic"
 
 	"github.com/v3io/v3io-go/pkg/common"
 
@@ -32,8 +31,8 @@ import (
 const stateContentsAttributeKey string = "state"
 
 var (
-	errNoFreeShardGroups = errors.New("No free shard groups")
 	errShardRetention    = errors.New("Could not retain shard group")
+	errShardCountChanged = errors.New("Shards were added to the stream")
 )
 
 type stateHandler struct {
@@ -57,10 +56,15 @@ func (sh *stateHandler) start() error {
 	// stops on stop()
 	go func() {
 		if err := sh.refreshStatePeriodically(); err != nil {
-			if errors.RootCause(err) == errShardRetention {
+			if errors.RootCause(err) == errShardRetention || errors.RootCause(err) == errShardCountChanged {
 
 				// signal that the Handler needs to be restarted
 				sh.logger.ErrorWith("Aborting member", "memberID", sh.member.id)
+
+				if sh.member.session != nil {
+					sh.member.session.revoke()
+				}
+
 				sh.member.handler.Abort(sh.member.session) // nolint: errcheck
 			}
 		}
@@ -114,6 +118,9 @@ func (sh *stateHandler) refreshStatePeriodically() error {
 	// it points to a read only state object
 	var lastState *State
 
+	clock := sh.member.streamConsumerGroup.clock
+	lastShardCountRefresh := clock.Now()
+
 	for {
 		select {
 
@@ -139,9 +146,10 @@ func (sh *stateHandler) refreshStatePeriodically() error {
 			}
 
 		// periodically get the state
-		case <-time.After(sh.member.streamConsumerGroup.config.Session.HeartbeatInterval):
+		case <-clock.After(sh.member.streamConsumerGroup.config.Session.HeartbeatInterval):
 			lastState, err = sh.refreshState()
 			if err != nil {
+				atomic.AddUint64(&sh.member.numHeartbeatMisses, 1)
 
 				// in case of shard retention error we want to signal the member to restart
 				if errors.RootCause(err) == errShardRetention {
@@ -153,6 +161,15 @@ func (sh *stateHandler) refreshStatePeriodically() error {
 				continue
 			}
 
+			shardCountRefreshInterval := sh.member.streamConsumerGroup.config.Stream.ShardCountRefreshInterval
+			if shardCountRefreshInterval > 0 && clock.Since(lastShardCountRefresh) >= shardCountRefreshInterval {
+				lastShardCountRefresh = clock.Now()
+
+				if err := sh.handleAddedShards(); err != nil {
+					return err
+				}
+			}
+
 		// if we're told to stop, exit the loop
 		case <-sh.stopChan:
 			sh.logger.Debug("Stopping")
@@ -174,7 +191,12 @@ func (sh *stateHandler) refreshState() (*State, error) {
 
 		// session already exists - just set the last heartbeat
 		if sessionState != nil {
-			sessionState.LastHeartbeat = time.Now()
+			sessionState.LastHeartbeat = sh.member.streamConsumerGroup.clock.Now()
+
+			// a static member resuming its session after a restart retains its shards from now on
+			if sh.member.shardGroupToRetain == nil {
+				sh.member.shardGroupToRetain = sessionState.Shards
+			}
 
 			// we're done
 			return state, nil
@@ -197,6 +219,51 @@ func (sh *stateHandler) refreshState() (*State, error) {
 	})
 }
 
+// if shards were added to the stream, releases the member's shards so that all shards are reassigned once
+// members restart, and returns errShardCountChanged
+func (sh *stateHandler) handleAddedShards() error {
+	shardsAdded, err := sh.member.streamConsumerGroup.refreshTotalNumShards()
+	if err != nil {
+		sh.logger.WarnWith("Failed refreshing shard count", "err", errors.GetErrorStackString(err, 10))
+		return nil
+	}
+
+	if !shardsAdded {
+		return nil
+	}
+
+	if err := sh.releaseSessionState(); err != nil {
+		sh.logger.WarnWith("Failed releasing shards", "err", errors.GetErrorStackString(err, 10))
+	}
+
+	return errShardCountChanged
+}
+
+// removes the member's session from the state, freeing its shards for other members
+func (sh *stateHandler) releaseSessionState() error {
+	_, err := sh.member.streamConsumerGroup.setState(func(state *State) (*State, error) {
+		var sessionStates []*SessionState
+
+		for _, sessionState := range state.SessionStates {
+			if sessionState.MemberID != sh.member.id {
+				sessionStates = append(sessionStates, sessionState)
+			}
+		}
+
+		state.SessionStates = sessionStates
+
+		return state, nil
+	}, func() error {
+
+		// the shards were given up, there's nothing to retain
+		sh.member.retainShards = false
+
+		return nil
+	})
+
+	return err
+}
+
 func (sh *stateHandler) createSessionState(state *State) error {
 	if state.SessionStates == nil {
 		state.SessionStates = []*SessionState{}
@@ -223,8 +290,9 @@ func (sh *stateHandler) createSessionState(state *State) error {
 	} else {
 
 		// assign shards
-		shards, err = sh.assignShards(sh.member.streamConsumerGroup.maxReplicas,
-			sh.member.streamConsumerGroup.totalNumShards,
+		shards, err = sh.getAssignmentStrategy().AssignShards(sh.member.id,
+			sh.member.streamConsumerGroup.maxReplicas,
+			sh.member.streamConsumerGroup.getTotalNumShards(),
 			state)
 		if err != nil {
 			return errors.Wrap(err, "Failed resolving shards for session")
@@ -240,51 +308,20 @@ func (sh *stateHandler) createSessionState(state *State) error {
 
 	state.SessionStates = append(state.SessionStates, &SessionState{
 		MemberID:      sh.member.id,
-		LastHeartbeat: time.Now(),
+		LastHeartbeat: sh.member.streamConsumerGroup.clock.Now(),
 		Shards:        shards,
+		Static:        sh.member.static,
 	})
 
 	return nil
 }
 
-func (sh *stateHandler) assignShards(maxReplicas int, numShards int, state *State) ([]int, error) {
-
-	// per replica index, holds which shards it should handle
-	replicaShardGroups, err := sh.getReplicaShardGroups(maxReplicas, numShards)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get replica shard group")
-	}
-
-	// empty shard groups are not unique - therefore simply check whether the number of
-	// empty shard groups allocated to sessions is equal to the number of empty shard groups
-	// required. if not, allocate an empty shard group
-	if sh.getAssignEmptyShardGroup(replicaShardGroups, state) {
-		return []int{}, nil
-	}
-
-	// simply look for the first non-assigned replica shard group which isn't empty
-	for _, replicaShardGroup := range replicaShardGroups {
-
-		// we already checked if we need to allocate an empty shard group
-		if len(replicaShardGroup) == 0 {
-			continue
-		}
-
-		foundReplicaShardGroup := false
-
-		for _, sessionState := range state.SessionStates {
-			if common.IntSlicesEqual(replicaShardGroup, sessionState.Shards) {
-				foundReplicaShardGroup = true
-				break
-			}
-		}
-
-		if !foundReplicaShardGroup {
-			return replicaShardGroup, nil
-		}
+func (sh *stateHandler) getAssignmentStrategy() AssignmentStrategy {
+	if assignmentStrategy := sh.member.streamConsumerGroup.config.Session.AssignmentStrategy; assignmentStrategy != nil {
+		return assignmentStrategy
 	}
 
-	return nil, errNoFreeShardGroups
+	return NewRangeAssignmentStrategy()
 }
 
 func (sh *stateHandler) retainShards(memberShardGroup []int, memberID string, state *State) ([]int, error) {
@@ -304,56 +341,27 @@ func (sh *stateHandler) retainShards(memberShardGroup []int, memberID string, st
 	return memberShardGroup, nil
 }
 
-func (sh *stateHandler) getReplicaShardGroups(maxReplicas int, numShards int) ([][]int, error) {
-	var replicaShardGroups [][]int
-	shards := common.MakeRange(0, numShards)
-
-	step := float64(numShards) / float64(maxReplicas)
-
-	for replicaIndex := 0; replicaIndex < maxReplicas; replicaIndex++ {
-		replicaIndexFloat := float64(replicaIndex)
-		startShard := int(math.Floor(replicaIndexFloat*step + 0.5))
-		endShard := int(math.Floor((replicaIndexFloat+1)*step + 0.5))
-
-		replicaShardGroups = append(replicaShardGroups, shards[startShard:endShard])
-	}
-
-	return replicaShardGroups, nil
-}
-
-func (sh *stateHandler) getAssignEmptyShardGroup(replicaShardGroups [][]int, state *State) bool {
-	numEmptyShardGroupRequired := 0
-	for _, replicaShardGroup := range replicaShardGroups {
-		if len(replicaShardGroup) == 0 {
-			numEmptyShardGroupRequired++
-		}
-	}
-
-	numEmptyShardGroupAssigned := 0
-	for _, sessionState := range state.SessionStates {
-		if len(sessionState.Shards) == 0 {
-			numEmptyShardGroupAssigned++
-		}
-	}
-
-	return numEmptyShardGroupRequired != numEmptyShardGroupAssigned
-
-}
-
 func (sh *stateHandler) removeStaleSessionStates(state *State) error {
 
 	// clear out the sessions since we only want the valid sessions
 	var activeSessionStates []*SessionState
 
 	for _, sessionState := range state.SessionStates {
+		sessionTimeout := sh.member.streamConsumerGroup.config.Session.Timeout
+
+		// static members are given time to restart and rejoin their session
+		if sessionState.Static {
+			sessionTimeout += sh.member.streamConsumerGroup.config.Session.RejoinGracePeriod
+		}
 
 		// check if the last heartbeat happened prior to the session timeout
-		if time.Since(sessionState.LastHeartbeat) < sh.member.streamConsumerGroup.config.Session.Timeout {
+		sinceLastHeartbeat := sh.member.streamConsumerGroup.clock.Since(sessionState.LastHeartbeat)
+		if sinceLastHeartbeat < sessionTimeout {
 			activeSessionStates = append(activeSessionStates, sessionState)
 		} else {
 			sh.logger.DebugWith("Removing stale member",
 				"memberID", sessionState.MemberID,
-				"lastHeartbeat", time.Since(sessionState.LastHeartbeat))
+				"lastHeartbeat", sinceLastHeartbeat)
 		}
 	}
 
@@ -22,7 +22,11 @@ package streamconsumergroup
 import (
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/v3io/v3io-go/pkg/common"
+
+	"github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -91,12 +95,79 @@ func (suite *stateHandlerSuite) TestAssignShards() {
 			})
 		}
 
-		assignedShardGroup, err := suite.stateHandler.assignShards(testCase.maxReplicas, testCase.numShards, &state)
+		assignedShardGroup, err := NewRangeAssignmentStrategy().AssignShards("member",
+			testCase.maxReplicas,
+			testCase.numShards,
+			&state)
 		suite.Require().NoError(err)
 		suite.Require().Equal(testCase.expectedShardGroup, assignedShardGroup, testCase.name)
 	}
 }
 
+func (suite *stateHandlerSuite) TestRoundRobinAssignShards() {
+	state := State{SessionStates: []*SessionState{{Shards: []int{0, 3, 6}}}}
+
+	assignedShardGroup, err := NewRoundRobinAssignmentStrategy().AssignShards("member", 3, 8, &state)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]int{1, 4, 7}, assignedShardGroup)
+}
+
+func (suite *stateHandlerSuite) TestStickyAssignShards() {
+	assignmentStrategy := NewStickyAssignmentStrategy()
+	state := State{}
+
+	for _, memberName := range []string{"a", "b", "c"} {
+		shards, err := assignmentStrategy.AssignShards(memberName+"-1", 3, 6, &state)
+		suite.Require().NoError(err)
+
+		state.SessionStates = append(state.SessionStates, &SessionState{MemberID: memberName + "-1", Shards: shards})
+	}
+
+	// a and b restart, b rejoining first. each reclaims its own group
+	state.SessionStates = state.SessionStates[2:]
+
+	for _, memberName := range []string{"b", "a"} {
+		shards, err := assignmentStrategy.AssignShards(memberName+"-2", 3, 6, &state)
+		suite.Require().NoError(err)
+		suite.Require().Equal(state.LastShardGroups[memberName], shards)
+
+		state.SessionStates = append(state.SessionStates, &SessionState{MemberID: memberName + "-2", Shards: shards})
+	}
+
+	suite.Require().Equal(map[string][]int{"a": {0, 1}, "b": {2, 3}, "c": {4, 5}}, state.LastShardGroups)
+}
+
+func (suite *stateHandlerSuite) TestRemoveStaleStaticSessionStates() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	clock := common.NewMockClock(time.Now())
+	config := NewConfig()
+	stateHandler := &stateHandler{
+		logger: logger,
+		member: &member{streamConsumerGroup: &streamConsumerGroup{config: config, clock: clock}},
+	}
+
+	state := State{
+		SessionStates: []*SessionState{
+			{MemberID: "dynamic", LastHeartbeat: clock.Now(), Shards: []int{0}},
+			{MemberID: "static", LastHeartbeat: clock.Now(), Shards: []int{1}, Static: true},
+		},
+	}
+
+	// both members stopped heartbeating past the session timeout, but within the rejoin grace period
+	clock.Add(config.Session.Timeout + time.Second)
+
+	suite.Require().NoError(stateHandler.removeStaleSessionStates(&state))
+	suite.Require().Len(state.SessionStates, 1)
+	suite.Require().Equal("static", state.SessionStates[0].MemberID)
+
+	clock.Add(config.Session.RejoinGracePeriod)
+
+	suite.Require().NoError(stateHandler.removeStaleSessionStates(&state))
+	suite.Require().Empty(state.SessionStates)
+}
+
 func (suite *stateHandlerSuite) TestRetainShards() {
 	for _, testCase := range []struct {
 		name                string
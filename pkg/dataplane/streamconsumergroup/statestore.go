@@ -0,0 +1,204 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/v3io/v3io-go/pkg/dataplane"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+// StateStore persists the state of a consumer group. members modify the state concurrently, so stores set it
+// conditionally on its version - a state set by another member since it was got must not be overwritten
+type StateStore interface {
+
+	// GetState returns the state and its version, or v3ioerrors.ErrNotFound if no state was set
+	GetState() (*State, string, error)
+
+	// SetState sets the state, failing if the stored state's version isn't the given one (an empty version
+	// creates the state, failing if one exists)
+	SetState(state *State, version string) error
+}
+
+// the default store, keeping the state in an item of the stream (or of any other path)
+type itemStateStore struct {
+	container v3io.Container
+	path      string
+}
+
+// NewItemStateStore creates a store which keeps the state in a KV item, versioned by its mtime
+func NewItemStateStore(container v3io.Container, path string) StateStore {
+	return &itemStateStore{
+		container: container,
+		path:      path,
+	}
+}
+
+func (iss *itemStateStore) GetState() (*State, string, error) {
+	response, err := iss.container.GetItemSync(&v3io.GetItemInput{
+		Path: iss.path,
+		AttributeNames: []string{
+			"__mtime_nsecs",
+			"__mtime_secs",
+			stateContentsAttributeKey,
+		},
+	})
+
+	if err != nil {
+		errWithStatusCode, errHasStatusCode := err.(v3ioerrors.ErrorWithStatusCode)
+		if !errHasStatusCode {
+			return nil, "", errors.Wrap(err, "Got error without status code")
+		}
+
+		if errWithStatusCode.StatusCode() != 404 {
+			return nil, "", errors.Wrap(err, "Failed getting state item")
+		}
+
+		return nil, "", v3ioerrors.ErrNotFound
+	}
+
+	defer response.Release()
+
+	getItemOutput := response.Output.(*v3io.GetItemOutput)
+
+	stateContents, err := getItemOutput.Item.GetFieldString(stateContentsAttributeKey)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed getting state attribute")
+	}
+
+	var state State
+
+	if err := json.Unmarshal([]byte(stateContents), &state); err != nil {
+		return nil, "", errors.Wrapf(err, "Failed unmarshalling state contents: %s", stateContents)
+	}
+
+	stateMtimeNanoSeconds, err := getItemOutput.Item.GetFieldInt("__mtime_nsecs")
+	if err != nil {
+		return nil, "", errors.New("Failed getting mtime attribute")
+	}
+
+	stateMtimeSeconds, err := getItemOutput.Item.GetFieldInt("__mtime_secs")
+	if err != nil {
+		return nil, "", errors.New("Failed getting mtime attribute")
+	}
+
+	return &state, fmt.Sprintf("%d.%d", stateMtimeSeconds, stateMtimeNanoSeconds), nil
+}
+
+func (iss *itemStateStore) SetState(state *State, version string) error {
+	stateContents, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "Failed marshaling state file contents")
+	}
+
+	var condition string
+	if version != "" {
+		var stateMtimeSeconds, stateMtimeNanoSeconds int
+		if _, err := fmt.Sscanf(version, "%d.%d", &stateMtimeSeconds, &stateMtimeNanoSeconds); err != nil {
+			return errors.Wrapf(err, "Invalid state version: %s", version)
+		}
+
+		condition = fmt.Sprintf("(__mtime_nsecs == %v) AND (__mtime_secs == %v)",
+			stateMtimeNanoSeconds,
+			stateMtimeSeconds)
+	} else {
+
+		// mtime does not exist => file does not exist => create it
+		// we want the file to be created by one replica only and thus
+		// we condition the creation of it by checking if the state attribute
+		// does not exist
+		condition = fmt.Sprintf("not(exists(%s))", stateContentsAttributeKey)
+	}
+
+	response, err := iss.container.UpdateItemSync(&v3io.UpdateItemInput{
+		Path:      iss.path,
+		Condition: condition,
+		Attributes: map[string]interface{}{
+			stateContentsAttributeKey: string(stateContents),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed setting state in persistency")
+	}
+
+	response.Release()
+
+	return nil
+}
+
+// a store keeping the state in memory, for tests and single process deployments
+type memoryStateStore struct {
+	lock          sync.Mutex
+	stateContents []byte
+	version       int
+}
+
+// NewMemoryStateStore creates a store which keeps the state in memory. members must share the store instance
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{}
+}
+
+func (mss *memoryStateStore) GetState() (*State, string, error) {
+	mss.lock.Lock()
+	defer mss.lock.Unlock()
+
+	if mss.stateContents == nil {
+		return nil, "", v3ioerrors.ErrNotFound
+	}
+
+	// unmarshal a copy, so that modifying it doesn't modify the stored state
+	var state State
+	if err := json.Unmarshal(mss.stateContents, &state); err != nil {
+		return nil, "", errors.Wrap(err, "Failed unmarshalling state contents")
+	}
+
+	return &state, strconv.Itoa(mss.version), nil
+}
+
+func (mss *memoryStateStore) SetState(state *State, version string) error {
+	mss.lock.Lock()
+	defer mss.lock.Unlock()
+
+	currentVersion := ""
+	if mss.stateContents != nil {
+		currentVersion = strconv.Itoa(mss.version)
+	}
+
+	if version != currentVersion {
+		return errors.Errorf("State version mismatch, expected %s, got %s", currentVersion, version)
+	}
+
+	stateContents, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "Failed marshaling state contents")
+	}
+
+	mss.stateContents = stateContents
+	mss.version++
+
+	return nil
+}
@@ -21,11 +21,11 @@ package streamconsumergroup
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"path"
 	"strconv"
+	"sync"
 
 	"github.com/v3io/v3io-go/pkg/common"
 	"github.com/v3io/v3io-go/pkg/dataplane"
@@ -36,13 +36,18 @@ import (
 )
 
 type streamConsumerGroup struct {
-	logger         logger.Logger
-	name           string
-	config         *Config
-	container      v3io.Container
-	streamPath     string
-	maxReplicas    int
-	totalNumShards int
+	logger      logger.Logger
+	name        string
+	config      *Config
+	container   v3io.Container
+	streamPath  string
+	maxReplicas int
+	stateStore  StateStore
+	clock       common.Clock
+
+	// may grow at runtime as shards are added to the stream
+	totalNumShardsLock sync.Mutex
+	totalNumShards     int
 }
 
 func NewStreamConsumerGroup(parentLogger logger.Logger,
@@ -57,6 +62,10 @@ func NewStreamConsumerGroup(parentLogger logger.Logger,
 		config = NewConfig()
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid configuration")
+	}
+
 	newStreamConsumerGroup := streamConsumerGroup{
 		logger:      parentLogger.GetChild(name),
 		name:        name,
@@ -66,6 +75,17 @@ func NewStreamConsumerGroup(parentLogger logger.Logger,
 		maxReplicas: maxReplicas,
 	}
 
+	newStreamConsumerGroup.clock = config.Clock
+	if newStreamConsumerGroup.clock == nil {
+		newStreamConsumerGroup.clock = common.NewClock()
+	}
+
+	// the state is kept in an item of the stream, unless configured otherwise
+	newStreamConsumerGroup.stateStore = config.State.Store
+	if newStreamConsumerGroup.stateStore == nil {
+		newStreamConsumerGroup.stateStore = NewItemStateStore(container, newStreamConsumerGroup.getStateFilePath())
+	}
+
 	// get the total number of shards for this stream
 	newStreamConsumerGroup.totalNumShards, err = newStreamConsumerGroup.getTotalNumberOfShards()
 	if err != nil {
@@ -76,7 +96,7 @@ func NewStreamConsumerGroup(parentLogger logger.Logger,
 }
 
 func (scg *streamConsumerGroup) GetState() (*State, error) {
-	state, _, _, err := scg.getStateFromPersistency()
+	state, _, err := scg.stateStore.GetState()
 	return state, err
 }
 
@@ -85,7 +105,7 @@ func (scg *streamConsumerGroup) GetShardSequenceNumber(shardID int) (uint64, err
 }
 
 func (scg *streamConsumerGroup) GetNumShards() (int, error) {
-	return scg.totalNumShards, nil
+	return scg.getTotalNumShards(), nil
 }
 
 func (scg *streamConsumerGroup) getShardPath(shardID int) (string, error) {
@@ -105,6 +125,36 @@ func (scg *streamConsumerGroup) getTotalNumberOfShards() (int, error) {
 	return response.Output.(*v3io.DescribeStreamOutput).ShardCount, nil
 }
 
+func (scg *streamConsumerGroup) getTotalNumShards() int {
+	scg.totalNumShardsLock.Lock()
+	defer scg.totalNumShardsLock.Unlock()
+
+	return scg.totalNumShards
+}
+
+// describes the stream, returning whether shards were added to it since it was last described
+func (scg *streamConsumerGroup) refreshTotalNumShards() (bool, error) {
+	totalNumShards, err := scg.getTotalNumberOfShards()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get total number of shards")
+	}
+
+	scg.totalNumShardsLock.Lock()
+	defer scg.totalNumShardsLock.Unlock()
+
+	if totalNumShards <= scg.totalNumShards {
+		return false, nil
+	}
+
+	scg.logger.InfoWith("Shards were added to the stream",
+		"previousNumShards", scg.totalNumShards,
+		"numShards", totalNumShards)
+
+	scg.totalNumShards = totalNumShards
+
+	return true, nil
+}
+
 func (scg *streamConsumerGroup) setState(modifier stateModifier,
 	handlePostSetStateInPersistency postSetStateInPersistencyHandler) (*State, error) {
 	var previousState, modifiedState *State
@@ -113,7 +163,7 @@ func (scg *streamConsumerGroup) setState(modifier stateModifier,
 	attempts := scg.config.State.ModifyRetry.Attempts
 
 	err := common.RetryFunc(context.TODO(), scg.logger, attempts, nil, &backoff, func(attempt int) (bool, error) {
-		state, stateMtimeNanoSeconds, stateMtimeSeconds, err := scg.getStateFromPersistency()
+		state, stateVersion, err := scg.stateStore.GetState()
 		if err != nil && err != v3ioerrors.ErrNotFound {
 			return true, errors.Wrap(err, "Failed getting current state from persistency")
 		}
@@ -141,13 +191,12 @@ func (scg *streamConsumerGroup) setState(modifier stateModifier,
 		// log only on change
 		if !scg.statesEqual(previousState, modifiedState) {
 			scg.logger.DebugWith("Modified state, saving",
-				"stateMtimeNanoSeconds", stateMtimeNanoSeconds,
-				"stateMtimeSeconds", stateMtimeSeconds,
+				"stateVersion", stateVersion,
 				"previousState", previousState,
 				"modifiedState", modifiedState)
 		}
 
-		if err := scg.setStateInPersistency(modifiedState, stateMtimeNanoSeconds, stateMtimeSeconds); err != nil {
+		if err := scg.stateStore.SetState(modifiedState, stateVersion); err != nil {
 			if attempt%10 == 0 {
 				scg.logger.DebugWith("Failed to set state in persistency",
 					"attempt", attempt,
@@ -170,93 +219,6 @@ func (scg *streamConsumerGroup) setState(modifier stateModifier,
 	return modifiedState, nil
 }
 
-func (scg *streamConsumerGroup) setStateInPersistency(
-	state *State,
-	stateMtimeNanoSeconds *int,
-	stateMtimeSeconds *int) error {
-	stateContents, err := json.Marshal(state)
-	if err != nil {
-		return errors.Wrap(err, "Failed marshaling state file contents")
-	}
-
-	var condition string
-	if stateMtimeNanoSeconds != nil && stateMtimeSeconds != nil {
-		condition = fmt.Sprintf("(__mtime_nsecs == %v) AND (__mtime_secs == %v)",
-			*stateMtimeNanoSeconds,
-			*stateMtimeSeconds)
-	} else {
-
-		// mtime does not exist => file does not exist => create it
-		// we want the file to be created by one replica only and thus
-		// we condition the creation of it by checking if the state attribute
-		// does not exist
-		condition = fmt.Sprintf("not(exists(%s))", stateContentsAttributeKey)
-	}
-
-	if _, err := scg.container.UpdateItemSync(&v3io.UpdateItemInput{
-		Path:      scg.getStateFilePath(),
-		Condition: condition,
-		Attributes: map[string]interface{}{
-			stateContentsAttributeKey: string(stateContents),
-		},
-	}); err != nil {
-		return errors.Wrap(err, "Failed setting state in persistency")
-	}
-
-	return nil
-}
-
-func (scg *streamConsumerGroup) getStateFromPersistency() (*State, *int, *int, error) {
-	response, err := scg.container.GetItemSync(&v3io.GetItemInput{
-		Path: scg.getStateFilePath(),
-		AttributeNames: []string{
-			"__mtime_nsecs",
-			"__mtime_secs",
-			stateContentsAttributeKey,
-		},
-	})
-
-	if err != nil {
-		errWithStatusCode, errHasStatusCode := err.(v3ioerrors.ErrorWithStatusCode)
-		if !errHasStatusCode {
-			return nil, nil, nil, errors.Wrap(err, "Got error without status code")
-		}
-
-		if errWithStatusCode.StatusCode() != 404 {
-			return nil, nil, nil, errors.Wrap(err, "Failed getting state item")
-		}
-
-		return nil, nil, nil, v3ioerrors.ErrNotFound
-	}
-
-	defer response.Release()
-
-	getItemOutput := response.Output.(*v3io.GetItemOutput)
-
-	stateContents, err := getItemOutput.Item.GetFieldString(stateContentsAttributeKey)
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "Failed getting state attribute")
-	}
-
-	var state State
-
-	if err := json.Unmarshal([]byte(stateContents), &state); err != nil {
-		return nil, nil, nil, errors.Wrapf(err, "Failed unmarshalling state contents: %s", stateContents)
-	}
-
-	stateMtimeNanoSeconds, err := getItemOutput.Item.GetFieldInt("__mtime_nsecs")
-	if err != nil {
-		return nil, nil, nil, errors.New("Failed getting mtime attribute")
-	}
-
-	stateMtimeSeconds, err := getItemOutput.Item.GetFieldInt("__mtime_secs")
-	if err != nil {
-		return nil, nil, nil, errors.New("Failed getting mtime attribute")
-	}
-
-	return &state, &stateMtimeNanoSeconds, &stateMtimeSeconds, nil
-}
-
 func (scg *streamConsumerGroup) getStateFilePath() string {
 	return path.Join(scg.streamPath, fmt.Sprintf("%s-state.json", scg.name))
 }
@@ -278,6 +240,9 @@ func (scg *streamConsumerGroup) getShardLocationFromPersistency(shardID int,
 		}
 
 		seekShardInput.Type = initialLocation
+		if initialLocation == v3io.SeekShardInputTypeTime {
+			seekShardInput.SetTime(scg.config.Claim.RecordBatchFetch.InitialTimestamp)
+		}
 	} else {
 
 		// use sequence number
@@ -365,13 +330,19 @@ func (scg *streamConsumerGroup) setShardSequenceNumberInPersistency(shardID int,
 		return errors.Wrapf(err, "Failed getting shard path: %v", shardID)
 	}
 
-	_, err = scg.container.UpdateItemSync(&v3io.UpdateItemInput{
+	response, err := scg.container.UpdateItemSync(&v3io.UpdateItemInput{
 		Path: shardPath,
 		Attributes: map[string]interface{}{
 			scg.getShardCommittedSequenceNumberAttributeName(): sequenceNumber,
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	response.Release()
+
+	return nil
 }
 
 // returns true if the states are equal, ignoring heartbeat times
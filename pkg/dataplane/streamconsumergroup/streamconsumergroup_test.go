@@ -0,0 +1,654 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package streamconsumergroup
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	v3io "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iomock "github.com/v3io/v3io-go/pkg/dataplane/mock"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+// records the shards it's assigned and revoked
+type rebalanceRecordingHandler struct {
+	assignedShards [][]int
+	revokedShards  [][]int
+}
+
+func (rrh *rebalanceRecordingHandler) Setup(Session) error               { return nil }
+func (rrh *rebalanceRecordingHandler) Cleanup(Session) error             { return nil }
+func (rrh *rebalanceRecordingHandler) ConsumeClaim(Session, Claim) error { return nil }
+func (rrh *rebalanceRecordingHandler) Abort(Session) error               { return nil }
+
+func (rrh *rebalanceRecordingHandler) OnAssign(session Session, shards []int) error {
+	rrh.assignedShards = append(rrh.assignedShards, shards)
+	return nil
+}
+
+func (rrh *rebalanceRecordingHandler) OnRevoke(session Session, shards []int) error {
+	rrh.revokedShards = append(rrh.revokedShards, shards)
+	return nil
+}
+
+// marks the records it consumes
+type markingHandler struct {
+	rebalanceRecordingHandler
+	lock      sync.Mutex
+	numMarked int
+}
+
+func (mh *markingHandler) ConsumeClaim(session Session, claim Claim) error {
+	for recordBatch := range claim.GetRecordBatchChan() {
+		for recordIdx := range recordBatch.Records {
+			if err := session.MarkRecord(&recordBatch.Records[recordIdx]); err != nil {
+				return err
+			}
+
+			mh.lock.Lock()
+			mh.numMarked++
+			mh.lock.Unlock()
+		}
+	}
+
+	return nil
+}
+
+func (mh *markingHandler) getNumMarked() int {
+	mh.lock.Lock()
+	defer mh.lock.Unlock()
+
+	return mh.numMarked
+}
+
+// a claim whose record batches are given by the test
+type channelClaim struct {
+	recordBatchChan chan *RecordBatch
+}
+
+func (cc *channelClaim) GetStreamPath() string                   { return "/stream/" }
+func (cc *channelClaim) GetShardID() int                         { return 0 }
+func (cc *channelClaim) GetCurrentLocation() string              { return "" }
+func (cc *channelClaim) GetRecordBatchChan() <-chan *RecordBatch { return cc.recordBatchChan }
+func (cc *channelClaim) start() error                            { return nil }
+func (cc *channelClaim) stop() error                             { return nil }
+func (cc *channelClaim) getConsumeDoneChan() <-chan struct{}     { return nil }
+
+type streamConsumerGroupSuite struct {
+	suite.Suite
+	container v3io.Container
+}
+
+func (suite *streamConsumerGroupSuite) SetupTest() {
+	session, err := v3iomock.NewContext().NewSession(&v3io.NewSessionInput{URL: "http://localhost:8081"})
+	suite.Require().NoError(err)
+
+	suite.container, err = session.NewContainer(&v3io.NewContainerInput{ContainerName: "bigdata"})
+	suite.Require().NoError(err)
+
+	err = suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/stream/", ShardCount: 2})
+	suite.Require().NoError(err)
+}
+
+func (suite *streamConsumerGroupSuite) TestRefreshTotalNumShards() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", nil, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	streamConsumerGroup := streamConsumerGroupInstance.(*streamConsumerGroup)
+
+	shardsAdded, err := streamConsumerGroup.refreshTotalNumShards()
+	suite.Require().NoError(err)
+	suite.Require().False(shardsAdded)
+
+	err = suite.container.(v3io.StreamUpdater).UpdateStreamSync(&v3io.UpdateStreamInput{Path: "/stream/", ShardCount: 3})
+	suite.Require().NoError(err)
+
+	shardsAdded, err = streamConsumerGroup.refreshTotalNumShards()
+	suite.Require().NoError(err)
+	suite.Require().True(shardsAdded)
+
+	numShards, err := streamConsumerGroup.GetNumShards()
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, numShards)
+}
+
+func (suite *streamConsumerGroupSuite) TestGetStreamLag() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	streamConsumerGroup, err := NewStreamConsumerGroup(logger, "group", nil, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	shardID := 0
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{ShardID: &shardID, Data: []byte("a")},
+			{ShardID: &shardID, Data: []byte("b")},
+			{ShardID: &shardID, Data: []byte("c")},
+		},
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	// the first record was consumed by a member holding shard 0
+	_, err = suite.container.UpdateItemSync(&v3io.UpdateItemInput{
+		Path:       "/stream/0",
+		Attributes: map[string]interface{}{"__group_committed_sequence_number": 1},
+	})
+	suite.Require().NoError(err)
+
+	state := State{SessionStates: []*SessionState{{MemberID: "member", Shards: []int{0}}}}
+	_, err = suite.container.UpdateItemSync(&v3io.UpdateItemInput{
+		Path:       "/stream/group-state.json",
+		Attributes: map[string]interface{}{stateContentsAttributeKey: state.String()},
+	})
+	suite.Require().NoError(err)
+
+	streamLag, err := streamConsumerGroup.GetStreamLag()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]ShardLag{
+		{ShardID: 0, MemberID: "member", CommittedSequenceNumber: 1, LatestSequenceNumber: 3, Lag: 2},
+		{ShardID: 1},
+	}, streamLag.Shards)
+	suite.Require().Equal(map[string]uint64{"member": 2}, streamLag.MemberLags)
+	suite.Require().Equal(uint64(2), streamLag.TotalLag)
+}
+
+func (suite *streamConsumerGroupSuite) TestShardSequenceNumbers() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	streamConsumerGroup, err := NewStreamConsumerGroup(logger, "group", nil, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	shardID := 0
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{ShardID: &shardID, Data: []byte("a")},
+			{ShardID: &shardID, Data: []byte("b")},
+			{ShardID: &shardID, Data: []byte("c")},
+		},
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	shardSequenceNumbers, err := streamConsumerGroup.GetShardSequenceNumbers()
+	suite.Require().NoError(err)
+	suite.Require().Empty(shardSequenceNumbers)
+
+	err = streamConsumerGroup.ResetShardSequenceNumbers(&ResetShardSequenceNumbersInput{
+		Type: v3io.SeekShardInputTypeLatest,
+	})
+	suite.Require().NoError(err)
+
+	shardSequenceNumbers, err = streamConsumerGroup.GetShardSequenceNumbers()
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[int]uint64{0: 3, 1: 0}, shardSequenceNumbers)
+
+	err = streamConsumerGroup.ResetShardSequenceNumbers(&ResetShardSequenceNumbersInput{
+		ShardIDs: []int{0},
+		Type:     v3io.SeekShardInputTypeEarliest,
+	})
+	suite.Require().NoError(err)
+
+	sequenceNumber, err := streamConsumerGroup.GetShardSequenceNumber(0)
+	suite.Require().NoError(err)
+	suite.Require().Zero(sequenceNumber)
+
+	suite.Require().NoError(streamConsumerGroup.CommitShardSequenceNumber(0, 2))
+	suite.Require().Error(streamConsumerGroup.CommitShardSequenceNumber(2, 2))
+
+	sequenceNumber, err = streamConsumerGroup.GetShardSequenceNumber(0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(2), sequenceNumber)
+}
+
+func (suite *streamConsumerGroupSuite) TestInitialTimestamp() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	// a shard added after the consumer group was created, with records from before and after the timestamp
+	err = suite.container.(v3io.StreamUpdater).UpdateStreamSync(&v3io.UpdateStreamInput{Path: "/stream/", ShardCount: 3})
+	suite.Require().NoError(err)
+
+	shardID := 2
+	suite.putRecords(shardID, "a")
+	time.Sleep(2 * time.Millisecond)
+	initialTimestamp := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	suite.putRecords(shardID, "b", "c")
+
+	config := NewConfig()
+	config.Claim.RecordBatchFetch.InitialLocation = v3io.SeekShardInputTypeTime
+	config.Claim.RecordBatchFetch.InitialTimestamp = initialTimestamp
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	// the shard has no committed sequence number, so it's sought from the first record at or after the timestamp
+	location, err := streamConsumerGroupInstance.(*streamConsumerGroup).getShardLocationFromPersistency(shardID,
+		config.Claim.RecordBatchFetch.InitialLocation)
+	suite.Require().NoError(err)
+
+	response, err := suite.container.GetRecordsSync(&v3io.GetRecordsInput{Path: "/stream/2", Location: location})
+	suite.Require().NoError(err)
+	defer response.Release()
+
+	var data []string
+	for _, record := range response.Output.(*v3io.GetRecordsOutput).Records {
+		data = append(data, string(record.Data))
+	}
+
+	suite.Require().Equal([]string{"b", "c"}, data)
+}
+
+func (suite *streamConsumerGroupSuite) TestManualCommit() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.SequenceNumber.CommitMode = CommitModeManual
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	member := &member{
+		logger:              logger,
+		streamConsumerGroup: streamConsumerGroupInstance.(*streamConsumerGroup),
+	}
+
+	member.sequenceNumberHandler, err = newSequenceNumberHandler(member)
+	suite.Require().NoError(err)
+	suite.Require().NoError(member.sequenceNumberHandler.start())
+
+	session := &session{member: member}
+	shardID := 1
+
+	// marked records are only committed explicitly
+	suite.Require().NoError(session.MarkRecord(&v3io.StreamRecord{ShardID: &shardID, SequenceNumber: 2}))
+	suite.Require().NoError(member.sequenceNumberHandler.stop())
+
+	_, err = streamConsumerGroupInstance.GetShardSequenceNumber(shardID)
+	suite.Require().Error(err)
+
+	suite.Require().NoError(session.CommitRecord(&v3io.StreamRecord{ShardID: &shardID, SequenceNumber: 3}))
+
+	sequenceNumber, err := streamConsumerGroupInstance.GetShardSequenceNumber(shardID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(3), sequenceNumber)
+}
+
+func (suite *streamConsumerGroupSuite) TestRebalanceHandler() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	handler := &rebalanceRecordingHandler{}
+	member := &member{logger: logger, handler: handler}
+
+	session, err := newSession(member, &SessionState{Shards: []int{}})
+	suite.Require().NoError(err)
+	suite.Require().NoError(session.start())
+	suite.Require().Equal([][]int{{}}, handler.assignedShards)
+
+	// shards are revoked once, whether the member closes or aborts
+	session.revoke()
+	session.revoke()
+	suite.Require().Equal([][]int{{}}, handler.revokedShards)
+}
+
+func (suite *streamConsumerGroupSuite) TestDrainSession() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.Claim.RecordBatchFetch.Interval = time.Millisecond
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	shardID := 0
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path: "/stream/",
+		Records: []*v3io.StreamRecord{
+			{ShardID: &shardID, Data: []byte("a")},
+			{ShardID: &shardID, Data: []byte("b")},
+		},
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	handler := &markingHandler{}
+	member := &member{
+		logger:              logger,
+		streamConsumerGroup: streamConsumerGroupInstance.(*streamConsumerGroup),
+		handler:             handler,
+	}
+
+	member.sequenceNumberHandler, err = newSequenceNumberHandler(member)
+	suite.Require().NoError(err)
+
+	member.session, err = newSession(member, &SessionState{Shards: []int{0}})
+	suite.Require().NoError(err)
+	suite.Require().NoError(member.session.start())
+
+	for handler.getNumMarked() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the handler returns once fetching stops
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	suite.Require().NoError(member.session.drain(ctx))
+
+	suite.Require().NoError(member.sequenceNumberHandler.commitMarkedShardSequenceNumbers())
+	sequenceNumber, err := streamConsumerGroupInstance.GetShardSequenceNumber(0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(2), sequenceNumber)
+
+	memberStats := member.Stats()
+	suite.Require().Equal([]int{0}, memberStats.Shards)
+	suite.Require().Equal(uint64(2), memberStats.NumRecordsConsumed)
+	suite.Require().Equal(uint64(1), memberStats.NumCommits)
+}
+
+func (suite *streamConsumerGroupSuite) TestFailRecord() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	err = suite.container.CreateStreamSync(&v3io.CreateStreamInput{Path: "/dlq/", ShardCount: 1})
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.SequenceNumber.CommitMode = CommitModeManual
+	config.DeadLetter.StreamPath = "/dlq/"
+	config.DeadLetter.MaxAttempts = 2
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	member := &member{
+		logger:              logger,
+		streamConsumerGroup: streamConsumerGroupInstance.(*streamConsumerGroup),
+	}
+
+	member.sequenceNumberHandler, err = newSequenceNumberHandler(member)
+	suite.Require().NoError(err)
+
+	session := &session{logger: logger, member: member}
+	shardID := 1
+	record := v3io.StreamRecord{ShardID: &shardID, SequenceNumber: 5, Data: []byte("a"), ClientInfo: []byte("info")}
+
+	deadLettered, err := session.FailRecord(&record, errors.New("first"))
+	suite.Require().NoError(err)
+	suite.Require().False(deadLettered)
+
+	deadLettered, err = session.FailRecord(&record, errors.New("second"))
+	suite.Require().NoError(err)
+	suite.Require().True(deadLettered)
+
+	// the record is marked, so consumption moves past it
+	suite.Require().NoError(session.Commit())
+	sequenceNumber, err := streamConsumerGroupInstance.GetShardSequenceNumber(shardID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(5), sequenceNumber)
+
+	shardReader, err := v3io.NewShardReader(&v3io.NewShardReaderInput{
+		Container:  suite.container,
+		StreamPath: "/dlq/",
+		ShardID:    0,
+		SeekType:   v3io.SeekShardInputTypeEarliest,
+	})
+	suite.Require().NoError(err)
+
+	records, err := shardReader.Next(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal("a", string(records.Data))
+
+	headers, err := records.GetHeaders()
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]string{
+		DeadLetterConsumerGroupHeader:  "group",
+		DeadLetterStreamHeader:         "/stream/",
+		DeadLetterShardHeader:          "1",
+		DeadLetterSequenceNumberHeader: "5",
+		DeadLetterAttemptsHeader:       "2",
+		DeadLetterErrorHeader:          "second",
+		DeadLetterClientInfoHeader:     "aW5mbw==",
+	}, headers)
+}
+
+func (suite *streamConsumerGroupSuite) TestMemoryStateStore() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.State.Store = NewMemoryStateStore()
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	_, err = streamConsumerGroupInstance.GetState()
+	suite.Require().Error(err)
+
+	_, err = streamConsumerGroupInstance.(*streamConsumerGroup).setState(func(state *State) (*State, error) {
+		state.SessionStates = append(state.SessionStates, &SessionState{MemberID: "a", Shards: []int{0, 1}})
+		return state, nil
+	}, func() error { return nil })
+	suite.Require().NoError(err)
+
+	state, err := streamConsumerGroupInstance.GetState()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]int{0, 1}, state.findSessionStateByMemberID("a").Shards)
+
+	// a state set since it was got isn't overwritten
+	_, version, err := config.State.Store.GetState()
+	suite.Require().NoError(err)
+	suite.Require().NoError(config.State.Store.SetState(state, version))
+	suite.Require().Error(config.State.Store.SetState(state, version))
+	suite.Require().Error(config.State.Store.SetState(state, ""))
+}
+
+func (suite *streamConsumerGroupSuite) TestReadState() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.State.Store = NewMemoryStateStore()
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	groupState, err := streamConsumerGroupInstance.ReadState()
+	suite.Require().NoError(err)
+	suite.Require().Empty(groupState.Members)
+	suite.Require().Equal([]int{0, 1}, groupState.UnassignedShards)
+
+	state, err := newState()
+	suite.Require().NoError(err)
+	state.SessionStates = []*SessionState{{MemberID: "b", Shards: []int{1}, Static: true}}
+	suite.Require().NoError(config.State.Store.SetState(state, ""))
+	suite.Require().NoError(streamConsumerGroupInstance.CommitShardSequenceNumber(1, 10))
+
+	groupState, err = streamConsumerGroupInstance.ReadState()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]MemberState{{MemberID: "b", Shards: []int{1}, Static: true}}, groupState.Members)
+	suite.Require().Equal([]int{0}, groupState.UnassignedShards)
+	suite.Require().Equal(map[int]uint64{1: 10}, groupState.ShardSequenceNumbers)
+}
+
+func (suite *streamConsumerGroupSuite) TestConsumeClaimConcurrently() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.SequenceNumber.CommitMode = CommitModeManual
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	member := &member{
+		logger:              logger,
+		streamConsumerGroup: streamConsumerGroupInstance.(*streamConsumerGroup),
+	}
+
+	member.sequenceNumberHandler, err = newSequenceNumberHandler(member)
+	suite.Require().NoError(err)
+
+	session := &session{logger: logger, member: member}
+	claim := &channelClaim{recordBatchChan: make(chan *RecordBatch, 2)}
+
+	shardID := 0
+	var records []v3io.StreamRecord
+	for recordIdx := 0; recordIdx < 20; recordIdx++ {
+		records = append(records, v3io.StreamRecord{
+			ShardID:        &shardID,
+			SequenceNumber: uint64(recordIdx + 1),
+			PartitionKey:   []string{"a", "b", "c"}[recordIdx%3],
+		})
+	}
+
+	claim.recordBatchChan <- &RecordBatch{Records: records[:10]}
+	claim.recordBatchChan <- &RecordBatch{Records: records[10:]}
+	close(claim.recordBatchChan)
+
+	var lock sync.Mutex
+	sequenceNumbersByPartitionKey := map[string][]uint64{}
+
+	err = ConsumeClaimConcurrently(session, claim, 4, func(record *v3io.StreamRecord) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		sequenceNumbersByPartitionKey[record.PartitionKey] = append(sequenceNumbersByPartitionKey[record.PartitionKey],
+			record.SequenceNumber)
+
+		return nil
+	})
+	suite.Require().NoError(err)
+
+	// records of each partition key are processed in order
+	suite.Require().Equal([]uint64{1, 4, 7, 10, 13, 16, 19}, sequenceNumbersByPartitionKey["a"])
+	suite.Require().Equal([]uint64{2, 5, 8, 11, 14, 17, 20}, sequenceNumbersByPartitionKey["b"])
+	suite.Require().Equal([]uint64{3, 6, 9, 12, 15, 18}, sequenceNumbersByPartitionKey["c"])
+
+	suite.Require().NoError(session.Commit())
+	sequenceNumber, err := streamConsumerGroupInstance.GetShardSequenceNumber(shardID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(uint64(20), sequenceNumber)
+}
+
+func (suite *streamConsumerGroupSuite) TestConfigValidate() {
+	suite.Require().NoError(NewConfig().Validate())
+
+	for _, testCase := range []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"heartbeatNotShorterThanTimeout", func(c *Config) { c.Session.HeartbeatInterval = c.Session.Timeout }},
+		{"zeroBatchSize", func(c *Config) { c.Claim.RecordBatchFetch.NumRecordsInBatch = 0 }},
+		{"negativeBackoff", func(c *Config) { c.State.ModifyRetry.Backoff.Min = -time.Second }},
+		{"timeWithoutTimestamp", func(c *Config) { c.Claim.RecordBatchFetch.InitialLocation = v3io.SeekShardInputTypeTime }},
+		{"unknownCommitMode", func(c *Config) { c.SequenceNumber.CommitMode = "sometimes" }},
+	} {
+		suite.Run(testCase.name, func() {
+			config := NewConfig()
+			testCase.modify(config)
+			suite.Require().Error(config.Validate())
+		})
+	}
+}
+
+func (suite *streamConsumerGroupSuite) TestPauseShards() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	config := NewConfig()
+	config.Claim.RecordBatchFetch.Interval = time.Millisecond
+
+	streamConsumerGroupInstance, err := NewStreamConsumerGroup(logger, "group", config, suite.container, "/stream/", 2)
+	suite.Require().NoError(err)
+
+	shardID := 1
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{
+		Path:    "/stream/",
+		Records: []*v3io.StreamRecord{{ShardID: &shardID, Data: []byte("a")}},
+	})
+	suite.Require().NoError(err)
+	response.Release()
+
+	handler := &markingHandler{}
+	member := &member{
+		logger:              logger,
+		streamConsumerGroup: streamConsumerGroupInstance.(*streamConsumerGroup),
+		handler:             handler,
+		pausedShards:        map[int]bool{},
+	}
+
+	member.sequenceNumberHandler, err = newSequenceNumberHandler(member)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(member.Pause([]int{shardID}))
+
+	member.session, err = newSession(member, &SessionState{Shards: []int{shardID}})
+	suite.Require().NoError(err)
+	suite.Require().NoError(member.session.start())
+
+	// nothing is fetched while paused
+	time.Sleep(50 * time.Millisecond)
+	suite.Require().Equal(0, handler.getNumMarked())
+
+	suite.Require().NoError(member.Resume([]int{shardID}))
+
+	for handler.getNumMarked() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	suite.Require().NoError(member.session.drain(ctx))
+}
+
+func (suite *streamConsumerGroupSuite) putRecords(shardID int, data ...string) {
+	var records []*v3io.StreamRecord
+	for _, recordData := range data {
+		records = append(records, &v3io.StreamRecord{ShardID: &shardID, Data: []byte(recordData)})
+	}
+
+	response, err := suite.container.PutRecordsSync(&v3io.PutRecordsInput{Path: "/stream/", Records: records})
+	suite.Require().NoError(err)
+	response.Release()
+}
+
+func TestStreamConsumerGroupSuite(t *testing.T) {
+	suite.Run(t, new(streamConsumerGroupSuite))
+}
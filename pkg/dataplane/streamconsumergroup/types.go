@@ -20,6 +20,7 @@ such restriction.
 package streamconsumergroup
 
 import (
+	"context"
 	"time"
 
 	v3io "github.com/v3io/v3io-go/pkg/dataplane"
@@ -33,6 +34,7 @@ type SessionState struct {
 	MemberID      string    `json:"member_id"`
 	LastHeartbeat time.Time `json:"last_heartbeat_time"`
 	Shards        []int     `json:"shards"`
+	Static        bool      `json:"static,omitempty"`
 }
 
 type Handler interface {
@@ -53,6 +55,17 @@ type Handler interface {
 	Abort(Session) error
 }
 
+// RebalanceHandler may be implemented by handlers to be told which shards they are assigned and revoked
+type RebalanceHandler interface {
+
+	// OnAssign is run once the session's shards are assigned, after Setup and before ConsumeClaim
+	OnAssign(Session, []int) error
+
+	// OnRevoke is run before the session's shards are released, when the member closes or aborts. records
+	// marked by OnRevoke are committed, so it's the place to flush buffers and mark their records
+	OnRevoke(Session, []int) error
+}
+
 type RecordBatch struct {
 	Records      []v3io.StreamRecord
 	Location     string
@@ -64,24 +77,47 @@ type StreamConsumerGroup interface {
 	GetState() (*State, error)
 	GetShardSequenceNumber(int) (uint64, error)
 	GetNumShards() (int, error)
+	GetStreamLag() (*StreamLag, error)
+	GetShardSequenceNumbers() (map[int]uint64, error)
+	CommitShardSequenceNumber(int, uint64) error
+	ResetShardSequenceNumbers(*ResetShardSequenceNumbersInput) error
+	ReadState() (*GroupState, error)
 }
 
 type Member interface {
 	Consume(Handler) error
 	Close() error
+	Shutdown(context.Context) error
+	Pause([]int) error
+	Resume([]int) error
+	Stats() *MemberStats
 	Start() error
 	GetID() string
 	GetRetainShardFlag() bool
 	GetShardsToRetain() []int
 }
 
+type MemberStats struct {
+	MemberID           string
+	Shards             []int         // shards of the member's current session
+	NumRecordsConsumed uint64        // records delivered to the handler
+	NumCommits         uint64        // commits of marked sequence numbers
+	CommitDuration     time.Duration // total time spent committing
+	NumHeartbeatMisses uint64        // heartbeats which failed to update the state
+}
+
 type Session interface {
 	GetClaims() []Claim
 	GetMemberID() string
 	MarkRecord(*v3io.StreamRecord) error
+	CommitRecord(*v3io.StreamRecord) error
+	Commit() error
+	FailRecord(*v3io.StreamRecord, error) (bool, error)
 
 	start() error
 	stop() error
+	revoke()
+	drain(context.Context) error
 }
 
 type Claim interface {
@@ -92,4 +128,5 @@ type Claim interface {
 
 	start() error
 	stop() error
+	getConsumeDoneChan() <-chan struct{}
 }
@@ -0,0 +1,373 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/common"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+)
+
+type TableChangeType string
+
+const (
+	TableChangeTypeCreated TableChangeType = "created"
+	TableChangeTypeUpdated TableChangeType = "updated"
+)
+
+// TableChange is an item which was created or updated since the previous poll. an item modified several
+// times between polls is reported once, with its latest attributes
+type TableChange struct {
+	Type       TableChangeType
+	ItemName   string
+	Item       Item
+	MtimeSecs  int
+	MtimeNSecs int
+}
+
+type NewTableWatcherInput struct {
+	DataPlaneInput
+	Container      Container
+	Path           string
+	AttributeNames []string // defaults to all user attributes
+	Filter         string   // watches only the items matching the filter
+
+	// the time between polls (defaults to 1s)
+	PollInterval time.Duration
+
+	// items may become visible to scans after items with later mtimes (e.g. while they're being written), so
+	// each poll scans back this far before the latest mtime seen (defaults to 5s)
+	SafetyWindow time.Duration
+
+	// the item the watcher's checkpoint is persisted in, so that a restarted watcher resumes where it
+	// stopped. if not set, the watcher starts from the beginning of the table
+	CheckpointPath string
+
+	// called with the errors of failed polls, which are retried on the next poll
+	OnError func(error)
+
+	// defaults to the wall clock
+	Clock common.Clock
+}
+
+// the watcher's position: the latest mtime seen, and the mtimes of the items seen within the safety window
+// before it, so that they aren't reported again
+type tableWatcherCheckpoint struct {
+	MtimeNanoseconds int64            `json:"mtime_nsecs"`
+	ItemMtimes       map[string]int64 `json:"item_mtimes"`
+}
+
+// TableWatcher reports the items of a table which are created or updated, by polling the table for items
+// modified since the previous poll. deleted items aren't reported. changes are delivered at least once: a
+// watcher which stops before persisting its checkpoint reports the changes since the last checkpoint again
+type TableWatcher struct {
+	input       NewTableWatcherInput
+	changesChan chan *TableChange
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+	checkpoint  tableWatcherCheckpoint
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	started     bool
+}
+
+const tableWatcherCheckpointAttribute = "table_watcher_checkpoint"
+
+func NewTableWatcher(newTableWatcherInput *NewTableWatcherInput) (*TableWatcher, error) {
+	if newTableWatcherInput.Container == nil {
+		return nil, errors.New("Container must be set")
+	}
+
+	newTableWatcher := TableWatcher{
+		input:       *newTableWatcherInput,
+		changesChan: make(chan *TableChange),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+		checkpoint: tableWatcherCheckpoint{
+			ItemMtimes: map[string]int64{},
+		},
+	}
+
+	if newTableWatcher.input.PollInterval <= 0 {
+		newTableWatcher.input.PollInterval = time.Second
+	}
+
+	if newTableWatcher.input.SafetyWindow <= 0 {
+		newTableWatcher.input.SafetyWindow = 5 * time.Second
+	}
+
+	if len(newTableWatcher.input.AttributeNames) == 0 {
+		newTableWatcher.input.AttributeNames = []string{"*"}
+	}
+
+	if newTableWatcher.input.Clock == nil {
+		newTableWatcher.input.Clock = common.NewClock()
+	}
+
+	return &newTableWatcher, nil
+}
+
+// Start loads the checkpoint and starts polling
+func (tw *TableWatcher) Start() error {
+	var err error
+
+	tw.startOnce.Do(func() {
+		if err = tw.loadCheckpoint(); err != nil {
+			err = errors.Wrap(err, "Failed to load checkpoint")
+			return
+		}
+
+		tw.started = true
+		go tw.pollPeriodically()
+	})
+
+	return err
+}
+
+// Changes returns the channel changes are delivered on. it's closed once the watcher stops
+func (tw *TableWatcher) Changes() <-chan *TableChange {
+	return tw.changesChan
+}
+
+// Stop stops polling, waiting for the current poll to end. changes which weren't received are dropped
+func (tw *TableWatcher) Stop() error {
+	tw.stopOnce.Do(func() {
+		close(tw.stopChan)
+	})
+
+	if tw.started {
+		<-tw.stoppedChan
+	}
+
+	return nil
+}
+
+func (tw *TableWatcher) pollPeriodically() {
+	defer close(tw.stoppedChan)
+	defer close(tw.changesChan)
+
+	for {
+		if err := tw.poll(); err != nil {
+			if errors.Cause(err) == v3ioerrors.ErrStopped {
+				return
+			}
+
+			if tw.input.OnError != nil {
+				tw.input.OnError(err)
+			}
+		}
+
+		select {
+		case <-tw.input.Clock.After(tw.input.PollInterval):
+		case <-tw.stopChan:
+			return
+		}
+	}
+}
+
+// scans the items modified since the safety window before the checkpoint, reporting those which weren't
+// seen with their current mtime, and persists the advanced checkpoint
+func (tw *TableWatcher) poll() error {
+	lowWatermark := tw.checkpoint.MtimeNanoseconds - tw.input.SafetyWindow.Nanoseconds()
+	if lowWatermark < 0 {
+		lowWatermark = 0
+	}
+
+	modifiedFilter := fmt.Sprintf("(__mtime_secs > %d) OR ((__mtime_secs == %d) AND (__mtime_nsecs >= %d))",
+		lowWatermark/int64(time.Second),
+		lowWatermark/int64(time.Second),
+		lowWatermark%int64(time.Second))
+
+	attributeNames := append([]string{"__name", "__mtime_secs", "__mtime_nsecs", "__ctime_secs", "__ctime_nsecs"},
+		tw.input.AttributeNames...)
+
+	checkpoint := tableWatcherCheckpoint{
+		MtimeNanoseconds: tw.checkpoint.MtimeNanoseconds,
+		ItemMtimes:       map[string]int64{},
+	}
+
+	for itemName, mtime := range tw.checkpoint.ItemMtimes {
+		checkpoint.ItemMtimes[itemName] = mtime
+	}
+
+	err := scanTable(tw.input.Container, &GetItemsInput{
+		DataPlaneInput: tw.input.DataPlaneInput,
+		Path:           tw.input.Path,
+		AttributeNames: attributeNames,
+		Filter:         combineFilters(tw.input.Filter, modifiedFilter),
+	}, func(item Item) error {
+		tableChange, err := tw.getTableChange(item, lowWatermark, checkpoint.ItemMtimes)
+		if err != nil || tableChange == nil {
+			return err
+		}
+
+		select {
+		case tw.changesChan <- tableChange:
+		case <-tw.stopChan:
+			return v3ioerrors.ErrStopped
+		}
+
+		mtime := getNanoseconds(tableChange.MtimeSecs, tableChange.MtimeNSecs)
+		checkpoint.ItemMtimes[tableChange.ItemName] = mtime
+
+		if mtime > checkpoint.MtimeNanoseconds {
+			checkpoint.MtimeNanoseconds = mtime
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to poll table %s", tw.input.Path)
+	}
+
+	// forget the items which the next poll won't scan
+	nextLowWatermark := checkpoint.MtimeNanoseconds - tw.input.SafetyWindow.Nanoseconds()
+	for itemName, mtime := range checkpoint.ItemMtimes {
+		if mtime < nextLowWatermark {
+			delete(checkpoint.ItemMtimes, itemName)
+		}
+	}
+
+	tw.checkpoint = checkpoint
+
+	return tw.saveCheckpoint()
+}
+
+// returns the change the item represents, or nil if it was already reported
+func (tw *TableWatcher) getTableChange(item Item, lowWatermark int64, itemMtimes map[string]int64) (*TableChange, error) {
+	itemName, err := item.GetFieldString("__name")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get item name")
+	}
+
+	mtimeSecs, err := item.GetFieldInt("__mtime_secs")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get mtime of item %s", itemName)
+	}
+
+	mtimeNSecs, err := item.GetFieldInt("__mtime_nsecs")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get mtime of item %s", itemName)
+	}
+
+	mtime := getNanoseconds(mtimeSecs, mtimeNSecs)
+	if reportedMtime, reported := itemMtimes[itemName]; (reported && reportedMtime >= mtime) || mtime < lowWatermark {
+		return nil, nil
+	}
+
+	tableChange := TableChange{
+		Type:       TableChangeTypeUpdated,
+		ItemName:   itemName,
+		Item:       Item{},
+		MtimeSecs:  mtimeSecs,
+		MtimeNSecs: mtimeNSecs,
+	}
+
+	// items created since the previous poll are new to the watcher, even if they were updated since
+	ctimeSecs, ctimeErr := item.GetFieldInt("__ctime_secs")
+	ctimeNSecs, _ := item.GetFieldInt("__ctime_nsecs")
+	if _, reported := itemMtimes[itemName]; !reported && ctimeErr == nil && getNanoseconds(ctimeSecs, ctimeNSecs) >= lowWatermark {
+		tableChange.Type = TableChangeTypeCreated
+	}
+
+	for attributeName, attributeValue := range item {
+		switch attributeName {
+		case "__mtime_secs", "__mtime_nsecs", "__ctime_secs", "__ctime_nsecs":
+		default:
+			tableChange.Item[attributeName] = attributeValue
+		}
+	}
+
+	return &tableChange, nil
+}
+
+func (tw *TableWatcher) loadCheckpoint() error {
+	if tw.input.CheckpointPath == "" {
+		return nil
+	}
+
+	response, err := tw.input.Container.GetItemSync(&GetItemInput{
+		DataPlaneInput: tw.input.DataPlaneInput,
+		Path:           tw.input.CheckpointPath,
+		AttributeNames: []string{tableWatcherCheckpointAttribute},
+	})
+	if err != nil {
+
+		// no checkpoint was persisted yet
+		if statusCode, _ := v3ioerrors.GetStatusCode(err); statusCode == http.StatusNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	defer response.Release()
+
+	encodedCheckpoint, err := response.Output.(*GetItemOutput).Item.GetFieldString(tableWatcherCheckpointAttribute)
+	if err != nil {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(encodedCheckpoint), &tw.checkpoint); err != nil {
+		return errors.Wrap(err, "Failed to decode checkpoint")
+	}
+
+	if tw.checkpoint.ItemMtimes == nil {
+		tw.checkpoint.ItemMtimes = map[string]int64{}
+	}
+
+	return nil
+}
+
+func (tw *TableWatcher) saveCheckpoint() error {
+	if tw.input.CheckpointPath == "" {
+		return nil
+	}
+
+	encodedCheckpoint, err := json.Marshal(&tw.checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode checkpoint")
+	}
+
+	response, err := tw.input.Container.UpdateItemSync(&UpdateItemInput{
+		DataPlaneInput: tw.input.DataPlaneInput,
+		Path:           tw.input.CheckpointPath,
+		Attributes:     map[string]interface{}{tableWatcherCheckpointAttribute: string(encodedCheckpoint)},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to save checkpoint")
+	}
+
+	response.Release()
+
+	return nil
+}
+
+func getNanoseconds(secs int, nsecs int) int64 {
+	return int64(secs)*int64(time.Second) + int64(nsecs)
+}
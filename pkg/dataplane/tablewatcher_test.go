@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeWatchedTableContainer struct {
+	fakeTableContainer
+}
+
+func (fwtc *fakeWatchedTableContainer) UpdateItemSync(updateItemInput *UpdateItemInput) (*Response, error) {
+	fwtc.items[updateItemInput.Path] = updateItemInput.Attributes
+
+	return &Response{Output: &UpdateItemOutput{}}, nil
+}
+
+func (fwtc *fakeWatchedTableContainer) GetItemSync(getItemInput *GetItemInput) (*Response, error) {
+	attributes, exists := fwtc.items[getItemInput.Path]
+	if !exists {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.New("Not found"), http.StatusNotFound)
+	}
+
+	return &Response{Output: &GetItemOutput{Item: attributes}}, nil
+}
+
+type tableWatcherSuite struct {
+	suite.Suite
+	container *fakeWatchedTableContainer
+}
+
+func (suite *tableWatcherSuite) SetupTest() {
+	suite.container = &fakeWatchedTableContainer{
+		fakeTableContainer: fakeTableContainer{
+			items: map[string]map[string]interface{}{},
+		},
+	}
+}
+
+func (suite *tableWatcherSuite) TestCreatedAndUpdated() {
+	tableWatcher := suite.createTableWatcher()
+
+	suite.putItem("a", 10, 10, 1)
+	suite.putItem("b", 11, 11, 2)
+
+	tableChanges := suite.poll(tableWatcher)
+	suite.Require().Len(tableChanges, 2)
+	suite.Require().Equal(TableChangeTypeCreated, tableChanges[0].Type)
+	suite.Require().Equal(Item{"__name": "a", "value": 1}, tableChanges[0].Item)
+	suite.Require().Equal("b", tableChanges[1].ItemName)
+
+	// nothing changed - items within the safety window aren't reported again
+	suite.Require().Empty(suite.poll(tableWatcher))
+
+	suite.putItem("a", 10, 12, 3)
+
+	tableChanges = suite.poll(tableWatcher)
+	suite.Require().Len(tableChanges, 1)
+	suite.Require().Equal(TableChangeTypeUpdated, tableChanges[0].Type)
+	suite.Require().Equal(Item{"__name": "a", "value": 3}, tableChanges[0].Item)
+
+	// an item which becomes visible late, but within the safety window, is reported
+	suite.putItem("c", 9, 9, 4)
+
+	tableChanges = suite.poll(tableWatcher)
+	suite.Require().Len(tableChanges, 1)
+	suite.Require().Equal("c", tableChanges[0].ItemName)
+}
+
+func (suite *tableWatcherSuite) TestResumeFromCheckpoint() {
+	suite.putItem("a", 10, 10, 1)
+	suite.putItem("b", 20, 20, 2)
+	suite.Require().Len(suite.poll(suite.createTableWatcher()), 2)
+
+	suite.putItem("c", 21, 21, 3)
+
+	// a new watcher doesn't report the items reported before
+	tableChanges := suite.poll(suite.createTableWatcher())
+	suite.Require().Len(tableChanges, 1)
+	suite.Require().Equal("c", tableChanges[0].ItemName)
+}
+
+func (suite *tableWatcherSuite) createTableWatcher() *TableWatcher {
+	tableWatcher, err := NewTableWatcher(&NewTableWatcherInput{
+		Container:      suite.container,
+		Path:           "table/",
+		SafetyWindow:   5 * time.Second,
+		CheckpointPath: "checkpoints/watcher",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(tableWatcher.loadCheckpoint())
+
+	return tableWatcher
+}
+
+func (suite *tableWatcherSuite) putItem(name string, ctimeSecs int, mtimeSecs int, value int) {
+	suite.container.items["table/"+name] = map[string]interface{}{
+		"__ctime_secs":  ctimeSecs,
+		"__ctime_nsecs": 0,
+		"__mtime_secs":  mtimeSecs,
+		"__mtime_nsecs": 0,
+		"value":         value,
+	}
+}
+
+func (suite *tableWatcherSuite) poll(tableWatcher *TableWatcher) []*TableChange {
+	var tableChanges []*TableChange
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tableWatcher.poll()
+	}()
+
+	for {
+		select {
+		case tableChange := <-tableWatcher.changesChan:
+			tableChanges = append(tableChanges, tableChange)
+		case err := <-errChan:
+			suite.Require().NoError(err)
+			return tableChanges
+		}
+	}
+}
+
+func TestTableWatcherSuite(t *testing.T) {
+	suite.Run(t, new(tableWatcherSuite))
+}
@@ -0,0 +1,109 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+
+	"github.com/nuclio/errors"
+)
+
+// GetItemAs gets the item at path into a T, which must be a struct (see UnmarshalItem). only the
+// attributes of T's fields are fetched
+func GetItemAs[T any](ctx context.Context, container Container, path string) (T, error) {
+	var value T
+
+	response, err := container.GetItemSync(&GetItemInput{
+		DataPlaneInput: DataPlaneInput{Ctx: ctx},
+		Path:           path,
+		AttributeNames: ItemAttributeNames(value),
+	})
+	if err != nil {
+		return value, errors.Wrapf(err, "Failed to get item %s", path)
+	}
+
+	defer response.Release()
+
+	if err := UnmarshalItem(response.Output.(*GetItemOutput).Item, &value); err != nil {
+		return value, errors.Wrapf(err, "Failed to unmarshal item %s", path)
+	}
+
+	return value, nil
+}
+
+// ScanAs gets all the items matching getItemsInput into Ts. if getItemsInput doesn't specify attribute
+// names, the attributes of T's fields are fetched
+func ScanAs[T any](ctx context.Context, container Container, getItemsInput *GetItemsInput) ([]T, error) {
+	scanInput := *getItemsInput
+	scanInput.Ctx = ctx
+
+	if len(scanInput.AttributeNames) == 0 {
+		var value T
+		scanInput.AttributeNames = ItemAttributeNames(value)
+	}
+
+	itemsCursor, err := NewItemsCursor(container, &scanInput)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan %s", getItemsInput.Path)
+	}
+
+	defer itemsCursor.Release()
+
+	var values []T
+	for itemsCursor.NextSync() {
+		var value T
+		if err := UnmarshalItem(itemsCursor.GetItem(), &value); err != nil {
+			return nil, errors.Wrapf(err, "Failed to unmarshal item in %s", getItemsInput.Path)
+		}
+
+		values = append(values, value)
+	}
+
+	if err := itemsCursor.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan %s", getItemsInput.Path)
+	}
+
+	return values, nil
+}
+
+// PutItemFrom puts value, which must be a struct (see MarshalItem), as the item at path
+func PutItemFrom[T any](ctx context.Context, container Container, path string, value T) error {
+	attributes, err := MarshalItem(value)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to marshal item %s", path)
+	}
+
+	response, err := container.PutItemSync(&PutItemInput{
+		DataPlaneInput: DataPlaneInput{Ctx: ctx},
+		Path:           path,
+		Attributes:     attributes,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to put item %s", path)
+	}
+
+	response.Release()
+
+	return nil
+}
@@ -0,0 +1,174 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type contextKey string
+
+// holds items by path, returning only the requested attributes
+type fakeItemsContainer struct {
+	Container
+	items map[string]map[string]interface{}
+
+	// the inputs of the last requests
+	getItemInput  *GetItemInput
+	getItemsInput *GetItemsInput
+	putItemInput  *PutItemInput
+}
+
+func (fic *fakeItemsContainer) GetItemSync(getItemInput *GetItemInput) (*Response, error) {
+	fic.getItemInput = getItemInput
+
+	attributes, found := fic.items[getItemInput.Path]
+	if !found {
+		return nil, v3ioerrors.NewErrorWithStatusCode(errors.New("Not found"), http.StatusNotFound)
+	}
+
+	return &Response{Output: &GetItemOutput{Item: fic.getItem(attributes, getItemInput.AttributeNames)}}, nil
+}
+
+func (fic *fakeItemsContainer) GetItemsSync(getItemsInput *GetItemsInput) (*Response, error) {
+	fic.getItemsInput = getItemsInput
+
+	var paths []string
+	for path := range fic.items {
+		if strings.HasPrefix(path, getItemsInput.Path) {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	items := []Item{}
+	for _, path := range paths {
+		items = append(items, fic.getItem(fic.items[path], getItemsInput.AttributeNames))
+	}
+
+	return &Response{Output: &GetItemsOutput{Items: items, Last: true}}, nil
+}
+
+func (fic *fakeItemsContainer) PutItemSync(putItemInput *PutItemInput) (*Response, error) {
+	fic.putItemInput = putItemInput
+	fic.items[putItemInput.Path] = putItemInput.Attributes
+
+	return &Response{Output: &PutItemOutput{}}, nil
+}
+
+func (fic *fakeItemsContainer) getItem(attributes map[string]interface{}, attributeNames []string) Item {
+	item := Item{}
+	for _, attributeName := range attributeNames {
+		if attributeValue, found := attributes[attributeName]; found {
+			item[attributeName] = attributeValue
+		}
+	}
+
+	return item
+}
+
+type typedSuite struct {
+	suite.Suite
+	container *fakeItemsContainer
+	ctx       context.Context
+}
+
+func (suite *typedSuite) SetupTest() {
+	suite.container = &fakeItemsContainer{
+		items: map[string]map[string]interface{}{
+			"table/a": {"name": "a", "age": 3, "Score": 1.5, "other": true},
+			"table/b": {"name": "b", "age": 4.0, "Score": 2},
+		},
+	}
+
+	suite.ctx = context.WithValue(context.Background(), contextKey("test"), "value")
+}
+
+func (suite *typedSuite) TestGetItemAs() {
+	value, err := GetItemAs[testItem](suite.ctx, suite.container, "table/a")
+	suite.Require().NoError(err)
+	suite.Require().Equal(testItem{Name: "a", Age: 3, Score: 1.5}, value)
+
+	// only the attributes of the fields are fetched, with the given context
+	suite.Require().Equal([]string{"name", "age", "Score"}, suite.container.getItemInput.AttributeNames)
+	suite.Require().Equal(suite.ctx, suite.container.getItemInput.Ctx)
+
+	_, err = GetItemAs[testItem](suite.ctx, suite.container, "table/missing")
+	statusCode, _ := v3ioerrors.GetStatusCode(err)
+	suite.Require().Equal(http.StatusNotFound, statusCode)
+}
+
+func (suite *typedSuite) TestGetItemAsUnmarshalError() {
+	suite.container.items["table/a"]["name"] = 5
+
+	_, err := GetItemAs[testItem](suite.ctx, suite.container, "table/a")
+	suite.Require().Error(err)
+}
+
+func (suite *typedSuite) TestScanAs() {
+	values, err := ScanAs[testItem](suite.ctx, suite.container, &GetItemsInput{Path: "table/"})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]testItem{
+		{Name: "a", Age: 3, Score: 1.5},
+		{Name: "b", Age: 4, Score: 2},
+	}, values)
+
+	suite.Require().Equal([]string{"name", "age", "Score"}, suite.container.getItemsInput.AttributeNames)
+	suite.Require().Equal(suite.ctx, suite.container.getItemsInput.Ctx)
+
+	// given attribute names are kept, and the input isn't modified
+	getItemsInput := GetItemsInput{Path: "table/", AttributeNames: []string{"name"}}
+	values, err = ScanAs[testItem](suite.ctx, suite.container, &getItemsInput)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]testItem{{Name: "a"}, {Name: "b"}}, values)
+	suite.Require().Nil(getItemsInput.Ctx)
+}
+
+func (suite *typedSuite) TestPutItemFrom() {
+	err := PutItemFrom(suite.ctx, suite.container, "table/c", testItem{Name: "c", Age: 5, Ignored: "x"})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(suite.ctx, suite.container.putItemInput.Ctx)
+
+	value, err := GetItemAs[testItem](suite.ctx, suite.container, "table/c")
+	suite.Require().NoError(err)
+	suite.Require().Equal(testItem{Name: "c", Age: 5}, value)
+
+	err = PutItemFrom(suite.ctx, suite.container, "table/d", "not a struct")
+	suite.Require().Error(err)
+}
+
+func TestTypedSuite(t *testing.T) {
+	suite.Run(t, new(typedSuite))
+}
@@ -19,11 +19,15 @@ package v3io
 import (
 	"context"
 	"encoding/xml"
+	"net/textproto"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 )
 
@@ -36,30 +40,141 @@ type NewSessionInput struct {
 	Username  string
 	Password  string
 	AccessKey string
+
+	// if set, called when the server rejects the session's credentials (401). the rejected request
+	// is retried once with the returned credentials, which are used by the session from then on
+	RefreshCredentials RefreshCredentialsFunc
+
+	// headers added to every request of the session, overriding those of the context
+	Headers map[string]string
+}
+
+// Credentials authenticate a session - either an access key or a username and password
+type Credentials struct {
+	Username  string
+	Password  string
+	AccessKey string
+}
+
+// RefreshCredentialsFunc returns fresh credentials (e.g. after an access key was rotated)
+type RefreshCredentialsFunc func() (*Credentials, error)
+
+// CredentialsRefresher updates the credentials of an input whose credentials were rejected by the server
+type CredentialsRefresher interface {
+	RefreshCredentials(dataPlaneInput *DataPlaneInput) error
 }
 
 type NewContainerInput struct {
 	ContainerName string
 }
 
+//
+// Context
+//
+
+type ContextStats struct {
+	NumWorkers          int
+	NumPendingRequests  int // requests waiting in the request channel for a worker
+	RequestChanCapacity int
+	NumRequests         uint64 // requests sent to the server
+	NumFailedRequests   uint64 // requests which failed, including non 2xx responses
+	NumWorkerPanics     uint64 // panics recovered while workers handled requests
+	NumExpiredRequests  uint64 // requests whose timeout expired before a worker picked them up
+	NumRetries          uint64 // attempts retried per NewContextInput.RetryPolicy
+	NumRetriesExhausted uint64 // requests which failed after exhausting their attempts or retry budget
+	NumFailedProbes     uint64 // connection probes which failed (see NewContextInput.ConnectionWarmupPolicy)
+
+	// connection pool state. connections are only tracked if the context created its own http client
+	NumOpenConnsByHost         map[string]int
+	NumPendingConnAcquisitions int    // requests waiting for a connection slot (see NewContextInput.MaxConns)
+	NumConnsClosedOnError      uint64 // connections closed after a read or write error
+
+	// bytes of response bodies not yet released (only tracked with NewContextInput.ResponseMemoryPolicy)
+	NumResponseBytes int64
+
+	// responses not yet released (only tracked with NewContextInput.LeakDetectionPolicy)
+	NumUnreleasedResponses int
+}
+
+type PingInput struct {
+	DataPlaneInput
+}
+
+type PingOutput struct {
+	Latency    time.Duration
+	StatusCode int // zero if the server wasn't reachable
+}
+
 //
 // Data plane
 //
 
 type DataPlaneInput struct {
-	Ctx                    context.Context
-	URL                    string
-	ContainerName          string
-	AuthenticationToken    string
-	AccessKey              string
-	MtimeSec               string
-	MtimeNsec              string
-	Timeout                time.Duration
+	Ctx                 context.Context
+	URL                 string
+	ContainerName       string
+	AuthenticationToken string
+	AccessKey           string
+	MtimeSec            string // see SetConditionalMtime
+	MtimeNsec           string
+	Timeout             time.Duration // for asynchronous requests, includes the time spent waiting for a worker
+
+	// only honoured by transports which can time these phases (see v3iohttp.TimeoutsTransport, implemented by
+	// the net/http transport). the default fasthttp transport rejects requests setting them with ErrInvalidInput
+	ConnectTimeout         time.Duration // if a connection is made for the request (see v3iohttp.Timeouts)
+	ResponseHeaderTimeout  time.Duration // from sending the request until the response starts arriving
 	IncludeResponseInError bool
+
+	// if set, requests rejected with a 401 are retried once after refreshing the credentials
+	CredentialsRefresher CredentialsRefresher
+
+	// the priority of the request in the context's queue, for asynchronous requests
+	Priority RequestPriority
+
+	// headers added to the request, overriding those of the session and context
+	Headers map[string]string
+
+	// identifies the request to the server (X-v3io-request-id), to correlate client and server logs. if not
+	// set, a unique ID is generated per request
+	RequestID string
+}
+
+// DataPlaneInputGetter is implemented by all inputs embedding a DataPlaneInput
+type DataPlaneInputGetter interface {
+	GetDataPlaneInput() *DataPlaneInput
+}
+
+func (dpi *DataPlaneInput) GetDataPlaneInput() *DataPlaneInput {
+	return dpi
+}
+
+// SetConditionalMtime conditions reads and updates (GetItem, GetItems, GetObject and UpdateItem) on the
+// mtime, e.g. that of a preceding write (see PutItemOutput), so that they don't observe older versions
+func (dpi *DataPlaneInput) SetConditionalMtime(mtimeSecs int, mtimeNSecs int) {
+	dpi.MtimeSec = strconv.Itoa(mtimeSecs)
+	dpi.MtimeNsec = strconv.Itoa(mtimeNSecs)
 }
 
 type DataPlaneOutput struct {
 	ctx context.Context
+
+	// the headers of the response the output was parsed from, which remain available after the response
+	// is released (see ResponseHeader)
+	ResponseHeaders map[string]string `json:"-" xml:"-"`
+}
+
+// DataPlaneOutputGetter is implemented by all outputs embedding a DataPlaneOutput
+type DataPlaneOutputGetter interface {
+	GetDataPlaneOutput() *DataPlaneOutput
+}
+
+func (dpo *DataPlaneOutput) GetDataPlaneOutput() *DataPlaneOutput {
+	return dpo
+}
+
+// ResponseHeader returns the value of a header of the response, regardless of the case of its name
+func (dpo *DataPlaneOutput) ResponseHeader(name string) string {
+	return dpo.ResponseHeaders[textproto.CanonicalMIMEHeaderKey(name)]
 }
 
 //
@@ -152,6 +267,7 @@ func mode(v3ioFileMode FileMode) (os.FileMode, error) {
 }
 
 type GetContainerContentsOutput struct {
+	DataPlaneOutput
 	Name           string         `xml:"Name"`           // Bucket name
 	NextMarker     string         `xml:"NextMarker"`     // if not empty and isTruncated="true" - has more children (need another fetch to get them)
 	MaxKeys        string         `xml:"MaxKeys"`        // max number of entries in single batch
@@ -198,21 +314,24 @@ type GetObjectInput struct {
 
 type PutObjectInput struct {
 	DataPlaneInput
-	Path   string
-	Offset int
-	Body   []byte
-	Append bool
+	Path        string
+	Offset      int
+	Body        []byte
+	Append      bool
+	IsDirectory bool // if "true" the path is sent as a directory path (see DirectoryPath)
 }
 
 type DeleteObjectInput struct {
 	DataPlaneInput
-	Path string
+	Path        string
+	IsDirectory bool // if "true" the path is sent as a directory path (see DirectoryPath)
 }
 
 type UpdateObjectInput struct {
 	DataPlaneInput
 	Path          string
 	DirAttributes *DirAttributes
+	IsDirectory   bool // if "true" the path is sent as a directory path (see DirectoryPath)
 }
 
 type DirAttributes struct {
@@ -231,16 +350,69 @@ type DirAttributes struct {
 // KV
 //
 
+// UpdateMode controls how put and update operations treat existing attributes. empty means the server default
+type UpdateMode string
+
+const (
+	UpdateModeCreateOrReplace UpdateMode = "CreateOrReplaceAttributes"
+	UpdateModeOverwrite       UpdateMode = "OverWriteAttributes"
+)
+
+// Validate returns an error for modes other than the ones above
+func (um UpdateMode) Validate() error {
+	switch um {
+	case "", UpdateModeCreateOrReplace, UpdateModeOverwrite:
+		return nil
+	default:
+		return errors.Errorf("Invalid update mode: %s", um)
+	}
+}
+
+// ScatterMode controls whether GetItems may return objects scattered across multiple responses
+type ScatterMode string
+
+const (
+	ScatterAllowed    ScatterMode = "true"
+	ScatterNotAllowed ScatterMode = "false"
+)
+
+func (sm ScatterMode) Validate() error {
+	switch sm {
+	case "", ScatterAllowed, ScatterNotAllowed:
+		return nil
+	default:
+		return errors.Errorf("Invalid scatter mode: %s", sm)
+	}
+}
+
+// ReturnDataMode controls whether GetItems returns object data along with the attributes
+type ReturnDataMode string
+
+const (
+	ReturnDataEnabled  ReturnDataMode = "true"
+	ReturnDataDisabled ReturnDataMode = "false"
+)
+
+func (rdm ReturnDataMode) Validate() error {
+	switch rdm {
+	case "", ReturnDataEnabled, ReturnDataDisabled:
+		return nil
+	default:
+		return errors.Errorf("Invalid return data mode: %s", rdm)
+	}
+}
+
 type PutItemInput struct {
 	DataPlaneInput
 	Path       string
 	Condition  string
 	Attributes map[string]interface{}
-	UpdateMode string
+	UpdateMode UpdateMode
 }
 
 type PutItemOutput struct {
 	DataPlaneInput
+	DataPlaneOutput
 	MtimeSecs  int
 	MtimeNSecs int
 }
@@ -258,17 +430,27 @@ type PutItemsOutput struct {
 	Errors  map[string]error
 }
 
+// Err returns a v3ioerrors.MultiError keyed by the names of the items which failed, or nil if none did
+func (o *PutItemsOutput) Err() error {
+	if len(o.Errors) == 0 {
+		return nil
+	}
+
+	return v3ioerrors.NewKeyedMultiError(o.Errors)
+}
+
 type UpdateItemInput struct {
 	DataPlaneInput
 	Path       string
 	Attributes map[string]interface{}
 	Expression *string
 	Condition  string
-	UpdateMode string
+	UpdateMode UpdateMode
 }
 
 type UpdateItemOutput struct {
 	DataPlaneInput
+	DataPlaneOutput
 	MtimeSecs  int
 	MtimeNSecs int
 }
@@ -297,9 +479,10 @@ type GetItemsInput struct {
 	TotalSegments       int
 	SortKeyRangeStart   string
 	SortKeyRangeEnd     string
-	AllowObjectScatter  string
-	ReturnData          string
+	AllowObjectScatter  ScatterMode
+	ReturnData          ReturnDataMode
 	ReturnAllInodes     bool
+	ReturnItemName      bool // if set, the name of each item is returned as well (see Item.GetName)
 	DataMaxSize         int
 	RequestJSONResponse bool `json:"RequestJsonResponse"`
 	ChokeGetItemsMS     int
@@ -343,27 +526,51 @@ type CreateStreamInput struct {
 	Path                 string
 	ShardCount           int
 	RetentionPeriodHours int
+
+	// the retention period at a finer granularity. mutually exclusive with RetentionPeriodHours
+	RetentionPeriodSeconds int
+
+	// additional fields of the request body, for options this client doesn't model yet. they must not
+	// collide with the fields above
+	Options map[string]interface{}
+}
+
+// UpdateStreamInput changes the shard count and/or retention period of an existing stream. zero fields are
+// left unchanged. the shard count can only be increased
+type UpdateStreamInput struct {
+	DataPlaneInput
+	Path                 string
+	ShardCount           int
+	RetentionPeriodHours int
 }
 
 type CheckPathExistsInput struct {
 	DataPlaneInput
-	Path string
+	Path        string
+	IsDirectory bool // if "true" the path is sent as a directory path (see DirectoryPath)
 }
 
 type DescribeStreamInput struct {
 	DataPlaneInput
 	Path string
+
+	// if set, the output describes each shard (see DescribeShards)
+	IncludeShards bool
 }
 
 type DescribeStreamOutput struct {
 	DataPlaneOutput
 	ShardCount           int
 	RetentionPeriodHours int
+	Shards               []ShardDescription `json:"-"`
 }
 
 type DeleteStreamInput struct {
 	DataPlaneInput
 	Path string
+
+	// the number of shards deleted concurrently (defaults to 16)
+	Concurrency int
 }
 
 type PutRecordsInput struct {
@@ -417,7 +624,14 @@ type SeekShardInput struct {
 	Path                   string
 	Type                   SeekShardInputType
 	StartingSequenceNumber uint64
-	Timestamp              int
+	Timestamp              int // seconds
+	TimestampNanoseconds   int // the sub-second part of the timestamp
+}
+
+// SetTime sets the timestamp seeks of type SeekShardInputTypeTime seek to, with nanosecond precision
+func (ssi *SeekShardInput) SetTime(t time.Time) {
+	ssi.Timestamp = int(t.Unix())
+	ssi.TimestampNanoseconds = t.Nanosecond()
 }
 
 type SeekShardOutput struct {
@@ -430,6 +644,14 @@ type GetRecordsInput struct {
 	Path     string
 	Location string
 	Limit    int
+
+	// if set, a request at the end of the shard waits up to this long for records to arrive before
+	// returning an empty batch (millisecond precision)
+	MaxWaitTime time.Duration
+
+	// if set, bounds the total data size of the records returned. at least one record is returned
+	// regardless of its size
+	MaxBytes int
 }
 
 type GetRecordsResult struct {
@@ -456,6 +678,19 @@ type PutOOSObjectInput struct {
 	Data   [][]byte
 }
 
+type GetOOSObjectInput struct {
+	DataPlaneInput
+	Path string
+}
+
+// the header and data io-vecs of an OOS object, as written with PutOOSObject. the slices reference the
+// response's body, so they're only valid until the response is released
+type GetOOSObjectOutput struct {
+	DataPlaneOutput
+	Header []byte
+	Data   [][]byte
+}
+
 type ItemChunkMetadata struct {
 	OSSID                uint32
 	OSDID                uint32
@@ -0,0 +1,271 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+)
+
+// InputValidator is implemented by inputs which can be checked before they're sent, so that invalid
+// inputs fail with a v3ioerrors.ErrorWithField rather than deep inside the client or on the server. all
+// data plane inputs implement it, at least through DataPlaneInput
+type InputValidator interface {
+	Validate() error
+}
+
+// ValidateInput validates the input if it implements InputValidator
+func ValidateInput(input interface{}) error {
+	if inputValidator, ok := input.(InputValidator); ok {
+		return inputValidator.Validate()
+	}
+
+	return nil
+}
+
+func (dpi *DataPlaneInput) Validate() error {
+	if dpi.ContainerName == "" {
+		return v3ioerrors.NewErrorWithField("ContainerName", "must not be empty")
+	}
+
+	if dpi.MtimeSec == "" && dpi.MtimeNsec != "" {
+		return v3ioerrors.NewErrorWithField("MtimeSec", "must be set along with MtimeNsec")
+	}
+
+	// checked in the order of the fields, so that the reported field is deterministic
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"MtimeSec", dpi.MtimeSec},
+		{"MtimeNsec", dpi.MtimeNsec},
+	} {
+		if _, err := strconv.Atoi(field.value); field.value != "" && err != nil {
+			return v3ioerrors.NewErrorWithField(field.name, "must be an integer")
+		}
+	}
+
+	for _, field := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"Timeout", dpi.Timeout},
+		{"ConnectTimeout", dpi.ConnectTimeout},
+		{"ResponseHeaderTimeout", dpi.ResponseHeaderTimeout},
+	} {
+		if field.value < 0 {
+			return v3ioerrors.NewErrorWithField(field.name, "must not be negative")
+		}
+	}
+
+	return nil
+}
+
+func (gcci *GetContainerContentsInput) Validate() error {
+	if gcci.Limit < 0 {
+		return v3ioerrors.NewErrorWithField("Limit", "must not be negative")
+	}
+
+	return gcci.DataPlaneInput.Validate()
+}
+
+func (goi *GetObjectInput) Validate() error {
+	if err := validateItemPath(goi.Path); err != nil {
+		return err
+	}
+
+	if goi.Offset < 0 {
+		return v3ioerrors.NewErrorWithField("Offset", "must not be negative")
+	}
+
+	if goi.NumBytes < 0 {
+		return v3ioerrors.NewErrorWithField("NumBytes", "must not be negative")
+	}
+
+	return goi.DataPlaneInput.Validate()
+}
+
+func (poi *PutObjectInput) Validate() error {
+	if err := validatePath(poi.Path); err != nil {
+		return err
+	}
+
+	if poi.Offset < 0 {
+		return v3ioerrors.NewErrorWithField("Offset", "must not be negative")
+	}
+
+	return poi.DataPlaneInput.Validate()
+}
+
+func (pii *PutItemInput) Validate() error {
+	if err := validateItemPath(pii.Path); err != nil {
+		return err
+	}
+
+	if err := pii.UpdateMode.Validate(); err != nil {
+		return v3ioerrors.NewErrorWithField("UpdateMode", err.Error())
+	}
+
+	return pii.DataPlaneInput.Validate()
+}
+
+func (uii *UpdateItemInput) Validate() error {
+	if err := validateItemPath(uii.Path); err != nil {
+		return err
+	}
+
+	if uii.Attributes == nil && uii.Expression == nil {
+		return v3ioerrors.NewErrorWithField("Attributes", "or Expression must be set")
+	}
+
+	if err := uii.UpdateMode.Validate(); err != nil {
+		return v3ioerrors.NewErrorWithField("UpdateMode", err.Error())
+	}
+
+	return uii.DataPlaneInput.Validate()
+}
+
+func (gii *GetItemInput) Validate() error {
+	if err := validateItemPath(gii.Path); err != nil {
+		return err
+	}
+
+	return gii.DataPlaneInput.Validate()
+}
+
+func (gii *GetItemsInput) Validate() error {
+	if err := validatePath(gii.Path); err != nil {
+		return err
+	}
+
+	if gii.Limit < 0 {
+		return v3ioerrors.NewErrorWithField("Limit", "must not be negative")
+	}
+
+	if gii.TotalSegments < 0 {
+		return v3ioerrors.NewErrorWithField("TotalSegments", "must not be negative")
+	}
+
+	if gii.Segment < 0 || (gii.TotalSegments > 0 && gii.Segment >= gii.TotalSegments) {
+		return v3ioerrors.NewErrorWithField("Segment", "must be between 0 and TotalSegments")
+	}
+
+	if gii.DataMaxSize < 0 {
+		return v3ioerrors.NewErrorWithField("DataMaxSize", "must not be negative")
+	}
+
+	if err := gii.AllowObjectScatter.Validate(); err != nil {
+		return v3ioerrors.NewErrorWithField("AllowObjectScatter", err.Error())
+	}
+
+	if err := gii.ReturnData.Validate(); err != nil {
+		return v3ioerrors.NewErrorWithField("ReturnData", err.Error())
+	}
+
+	return gii.DataPlaneInput.Validate()
+}
+
+func (cis *CreateStreamInput) Validate() error {
+	if err := validatePath(cis.Path); err != nil {
+		return err
+	}
+
+	// the shard count and retention are checked for sanity when the stream is created (see ValidateCreateStreamInput)
+	return cis.DataPlaneInput.Validate()
+}
+
+func (pri *PutRecordsInput) Validate() error {
+	if err := validatePath(pri.Path); err != nil {
+		return err
+	}
+
+	if len(pri.Records) == 0 {
+		return v3ioerrors.NewErrorWithField("Records", "must not be empty")
+	}
+
+	for _, record := range pri.Records {
+		if record == nil {
+			return v3ioerrors.NewErrorWithField("Records", "must not contain nil records")
+		}
+	}
+
+	return pri.DataPlaneInput.Validate()
+}
+
+func (ssi *SeekShardInput) Validate() error {
+	if err := validatePath(ssi.Path); err != nil {
+		return err
+	}
+
+	if ssi.Type < SeekShardInputTypeTime || ssi.Type > SeekShardInputTypeEarliest {
+		return v3ioerrors.NewErrorWithField("Type", "is not a known seek type")
+	}
+
+	return ssi.DataPlaneInput.Validate()
+}
+
+func (gri *GetRecordsInput) Validate() error {
+	if err := validatePath(gri.Path); err != nil {
+		return err
+	}
+
+	if gri.Location == "" {
+		return v3ioerrors.NewErrorWithField("Location", "must not be empty")
+	}
+
+	if gri.Limit < 0 {
+		return v3ioerrors.NewErrorWithField("Limit", "must not be negative")
+	}
+
+	if gri.MaxWaitTime < 0 {
+		return v3ioerrors.NewErrorWithField("MaxWaitTime", "must not be negative")
+	}
+
+	return gri.DataPlaneInput.Validate()
+}
+
+func validatePath(path string) error {
+	if path == "" {
+		return v3ioerrors.NewErrorWithField("Path", "must not be empty")
+	}
+
+	if strings.ContainsRune(path, 0) {
+		return v3ioerrors.NewErrorWithField("Path", "must not contain null characters")
+	}
+
+	return nil
+}
+
+// items and objects are files - their paths can't be directory paths
+func validateItemPath(path string) error {
+	if err := validatePath(path); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, "/") {
+		return v3ioerrors.NewErrorWithField("Path", "must not end with a slash")
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"errors"
+	"testing"
+
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type validateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *validateTestSuite) TestInputs() {
+	dataPlaneInput := DataPlaneInput{ContainerName: "bigdata"}
+
+	for _, testCase := range []struct {
+		name          string
+		input         interface{}
+		expectedField string
+	}{
+		{name: "valid", input: &GetItemsInput{DataPlaneInput: dataPlaneInput, Path: "table/"}},
+		{name: "no container", input: &GetItemsInput{Path: "table/"}, expectedField: "ContainerName"},
+		{name: "inherited", input: &DeleteStreamInput{Path: "stream/"}, expectedField: "ContainerName"},
+		{
+			name:          "negative limit",
+			input:         &GetItemsInput{DataPlaneInput: dataPlaneInput, Path: "table/", Limit: -1},
+			expectedField: "Limit",
+		},
+		{
+			name:          "segment out of range",
+			input:         &GetItemsInput{DataPlaneInput: dataPlaneInput, Path: "table/", Segment: 2, TotalSegments: 2},
+			expectedField: "Segment",
+		},
+		{
+			name:          "directory item path",
+			input:         &GetItemInput{DataPlaneInput: dataPlaneInput, Path: "table/"},
+			expectedField: "Path",
+		},
+		{
+			name:          "no records",
+			input:         &PutRecordsInput{DataPlaneInput: dataPlaneInput, Path: "stream/"},
+			expectedField: "Records",
+		},
+		{
+			name: "first invalid field",
+			input: &GetItemsInput{
+				DataPlaneInput: DataPlaneInput{
+					ContainerName:         "bigdata",
+					MtimeSec:              "1",
+					MtimeNsec:             "x",
+					Timeout:               -1,
+					ConnectTimeout:        -1,
+					ResponseHeaderTimeout: -1,
+				},
+				Path: "table/",
+			},
+			expectedField: "MtimeNsec",
+		},
+		{
+			name: "first negative timeout",
+			input: &GetItemsInput{
+				DataPlaneInput: DataPlaneInput{ContainerName: "bigdata", ConnectTimeout: -1, ResponseHeaderTimeout: -1},
+				Path:           "table/",
+			},
+			expectedField: "ConnectTimeout",
+		},
+		{
+			name:          "unknown update mode",
+			input:         &PutItemInput{DataPlaneInput: dataPlaneInput, Path: "table/a", UpdateMode: "CreateOrReplace"},
+			expectedField: "UpdateMode",
+		},
+		{
+			name: "unknown update mode on update",
+			input: &UpdateItemInput{
+				DataPlaneInput: dataPlaneInput,
+				Path:           "table/a",
+				Attributes:     map[string]interface{}{"a": 1},
+				UpdateMode:     "Overwrite",
+			},
+			expectedField: "UpdateMode",
+		},
+		{
+			name:          "invalid scatter mode",
+			input:         &GetItemsInput{DataPlaneInput: dataPlaneInput, Path: "table/", AllowObjectScatter: "yes"},
+			expectedField: "AllowObjectScatter",
+		},
+		{
+			name:          "invalid return data mode",
+			input:         &GetItemsInput{DataPlaneInput: dataPlaneInput, Path: "table/", ReturnData: "1"},
+			expectedField: "ReturnData",
+		},
+		{
+			name: "valid modes",
+			input: &GetItemsInput{
+				DataPlaneInput:     dataPlaneInput,
+				Path:               "table/",
+				AllowObjectScatter: ScatterAllowed,
+				ReturnData:         ReturnDataDisabled,
+			},
+		},
+		{name: "not validated", input: &struct{}{}},
+	} {
+		err := ValidateInput(testCase.input)
+		if testCase.expectedField == "" {
+			suite.Require().NoError(err, testCase.name)
+			continue
+		}
+
+		suite.Require().True(errors.Is(err, v3ioerrors.ErrInvalidInput), testCase.name)
+
+		errWithField, ok := err.(v3ioerrors.ErrorWithField)
+		suite.Require().True(ok, testCase.name)
+		suite.Require().Equal(testCase.expectedField, errWithField.Field(), testCase.name)
+	}
+}
+
+func (suite *validateTestSuite) TestModes() {
+	for _, updateMode := range []UpdateMode{"", UpdateModeCreateOrReplace, UpdateModeOverwrite} {
+		suite.Require().NoError(updateMode.Validate())
+	}
+
+	suite.Require().Error(UpdateMode("CreateOrReplace").Validate())
+	suite.Require().NoError(ScatterMode(ScatterNotAllowed).Validate())
+	suite.Require().Error(ScatterMode("True").Validate())
+	suite.Require().NoError(ReturnDataMode(ReturnDataEnabled).Validate())
+	suite.Require().Error(ReturnDataMode("no").Validate())
+}
+
+func TestValidateTestSuite(t *testing.T) {
+	suite.Run(t, new(validateTestSuite))
+}
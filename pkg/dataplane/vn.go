@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3io
+
+import (
+	"hash/fnv"
+
+	"github.com/nuclio/errors"
+)
+
+// ShardingKeyToVN maps a sharding key to one of the cluster's VNs (see GetClusterMDOutput.NumberOfVNs) by
+// its FNV-1a hash, so that work on the same sharding keys can be consistently partitioned by VN
+func ShardingKeyToVN(shardingKey string, numVNs int) int {
+	if numVNs <= 1 {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(shardingKey)) // nolint: errcheck
+
+	return int(hash.Sum32() % uint32(numVNs))
+}
+
+// GroupShardingKeysByVN groups the sharding keys by the VN they map to (see ShardingKeyToVN), according
+// to the number of VNs the cluster reports
+func GroupShardingKeysByVN(container Container, shardingKeys []string) (map[int][]string, error) {
+	response, err := container.GetClusterMDSync(&GetClusterMDInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get cluster metadata")
+	}
+
+	numVNs := response.Output.(*GetClusterMDOutput).NumberOfVNs
+	response.Release()
+
+	shardingKeysByVN := map[int][]string{}
+	for _, shardingKey := range shardingKeys {
+		vn := ShardingKeyToVN(shardingKey, numVNs)
+		shardingKeysByVN[vn] = append(shardingKeysByVN[vn], shardingKey)
+	}
+
+	return shardingKeysByVN, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioerrors
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/valyala/fasthttp"
+)
+
+// GetStatusCode returns the HTTP status code of the response err was created from, looking through errors
+// which wrap it
+func GetStatusCode(err error) (int, bool) {
+	statusCode := 0
+
+	found := findError(err, func(err error) bool {
+		errWithStatusCode, ok := err.(interface{ StatusCode() int })
+		if ok {
+			statusCode = errWithStatusCode.StatusCode()
+		}
+
+		return ok
+	})
+
+	return statusCode, found
+}
+
+// IsRetryable returns whether the request which failed with err may succeed if sent again - i.e. it was
+// throttled, the server failed (5xx) or timed out, or the connection failed. errors aggregated by a
+// MultiError aren't classified
+func IsRetryable(err error) bool {
+	if statusCode, found := GetStatusCode(err); found {
+		switch statusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	return findError(err, isRetryableTransportError)
+}
+
+// IsThrottle returns whether err is the platform rejecting a request due to load
+func IsThrottle(err error) bool {
+	statusCode, _ := GetStatusCode(err)
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// IsAuth returns whether err is the platform rejecting a request's credentials or their permissions
+func IsAuth(err error) bool {
+	statusCode, _ := GetStatusCode(err)
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+// returns whether err is a failure to send a request or receive its response
+func isRetryableTransportError(err error) bool {
+	switch err {
+	case ErrTimeout,
+		fasthttp.ErrTimeout,
+		fasthttp.ErrDialTimeout,
+		fasthttp.ErrConnectionClosed,
+		fasthttp.ErrNoFreeConns,
+		io.ErrUnexpectedEOF,
+		syscall.ECONNRESET,
+		syscall.ECONNREFUSED,
+		syscall.ECONNABORTED,
+		syscall.EPIPE:
+		return true
+	}
+
+	netErr, isNetErr := err.(net.Error)
+	return isNetErr && netErr.Timeout()
+}
+
+// returns whether match holds for err or any error it wraps
+func findError(err error, match func(error) bool) bool {
+	for err != nil {
+		if match(err) {
+			return true
+		}
+
+		switch typedErr := err.(type) {
+		case interface{ Unwrap() error }:
+			err = typedErr.Unwrap()
+		case interface{ Cause() error }:
+			err = typedErr.Cause()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
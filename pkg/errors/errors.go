@@ -21,12 +21,41 @@ package v3ioerrors
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
 )
 
 var ErrInvalidTypeConversion = errors.New("Invalid type conversion")
 var ErrNotFound = errors.New("Not found")
 var ErrStopped = errors.New("Stopped")
 var ErrTimeout = errors.New("Timed out")
+var ErrLimitExceeded = errors.New("Limit exceeded")
+var ErrPanic = errors.New("Panic")
+var ErrLocked = errors.New("Locked")
+var ErrNotSupported = errors.New("Not supported")
+var ErrInvalidInput = errors.New("Invalid input")
+var ErrConflict = errors.New("Conflict")
+var ErrUnauthorized = errors.New("Unauthorized")
+var ErrForbidden = errors.New("Forbidden")
+var ErrPreconditionFailed = errors.New("Precondition failed")
+var ErrThrottled = errors.New("Throttled")
+
+// the sentinel errors an ErrorWithStatusCode matches in errors.Is, by status code
+var statusCodeSentinels = map[int]error{
+	http.StatusBadRequest:         ErrInvalidInput,
+	http.StatusUnauthorized:       ErrUnauthorized,
+	http.StatusForbidden:          ErrForbidden,
+	http.StatusNotFound:           ErrNotFound,
+	http.StatusRequestTimeout:     ErrTimeout,
+	http.StatusConflict:           ErrConflict,
+	http.StatusPreconditionFailed: ErrPreconditionFailed,
+	http.StatusLocked:             ErrLocked,
+	http.StatusTooManyRequests:    ErrThrottled,
+	http.StatusServiceUnavailable: ErrThrottled,
+	http.StatusGatewayTimeout:     ErrTimeout,
+}
 
 type ErrorWithStatusCode struct {
 	error
@@ -53,6 +82,21 @@ func (e ErrorWithStatusCode) Error() string {
 	return e.error.Error()
 }
 
+func (e ErrorWithStatusCode) Unwrap() error {
+	return e.error
+}
+
+// Sentinel returns the sentinel error of the status code (e.g. ErrNotFound for 404), or nil if it has none
+func (e ErrorWithStatusCode) Sentinel() error {
+	return statusCodeSentinels[e.statusCode]
+}
+
+// Is returns whether target is the sentinel error of the status code, so that e.g.
+// errors.Is(err, ErrNotFound) holds for a 404
+func (e ErrorWithStatusCode) Is(target error) bool {
+	return target != nil && target == e.Sentinel()
+}
+
 func NewErrorWithStatusCodeAndResponse(err error,
 	statusCode int,
 	response interface{}) ErrorWithStatusCodeAndResponse {
@@ -66,3 +110,240 @@ func NewErrorWithStatusCodeAndResponse(err error,
 func (e ErrorWithStatusCodeAndResponse) Response() interface{} {
 	return e.response
 }
+
+type ErrorWithLimit struct {
+	error
+	field string
+	value int
+	limit int
+}
+
+func NewErrorWithLimit(err error, field string, value int, limit int) ErrorWithLimit {
+	return ErrorWithLimit{
+		error: err,
+		field: field,
+		value: value,
+		limit: limit,
+	}
+}
+
+// Field returns the name of the input field which violated the limit
+func (e ErrorWithLimit) Field() string {
+	return e.field
+}
+
+// Value returns the value given for the field
+func (e ErrorWithLimit) Value() int {
+	return e.value
+}
+
+// Limit returns the limit that was violated
+func (e ErrorWithLimit) Limit() int {
+	return e.limit
+}
+
+func (e ErrorWithLimit) Error() string {
+	return e.error.Error()
+}
+
+func (e ErrorWithLimit) Unwrap() error {
+	return e.error
+}
+
+// Is returns whether target is ErrLimitExceeded, which every ErrorWithLimit describes
+func (e ErrorWithLimit) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ErrorWithField describes an input field with an invalid value, detected before the request was sent
+type ErrorWithField struct {
+	field  string
+	reason string
+}
+
+func NewErrorWithField(field string, reason string) ErrorWithField {
+	return ErrorWithField{
+		field:  field,
+		reason: reason,
+	}
+}
+
+// Field returns the name of the invalid input field
+func (e ErrorWithField) Field() string {
+	return e.field
+}
+
+// Reason returns why the value of the field is invalid
+func (e ErrorWithField) Reason() string {
+	return e.reason
+}
+
+func (e ErrorWithField) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidInput.Error(), e.field, e.reason)
+}
+
+// Unwrap returns ErrInvalidInput, so that errors.Is(err, ErrInvalidInput) holds
+func (e ErrorWithField) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// PlatformError holds the details the platform returned in the body of an error response. fields the
+// body didn't include are empty
+type PlatformError struct {
+	error
+	code      string
+	message   string
+	resource  string
+	requestID string
+}
+
+func NewPlatformError(err error, code string, message string, resource string, requestID string) PlatformError {
+	return PlatformError{
+		error:     err,
+		code:      code,
+		message:   message,
+		resource:  resource,
+		requestID: requestID,
+	}
+}
+
+// Code returns the platform's error code (e.g. NoSuchKey)
+func (e PlatformError) Code() string {
+	return e.code
+}
+
+// Message returns the platform's description of the error
+func (e PlatformError) Message() string {
+	return e.message
+}
+
+// Resource returns the resource the error refers to
+func (e PlatformError) Resource() string {
+	return e.resource
+}
+
+// RequestID returns the platform's identifier of the failed request
+func (e PlatformError) RequestID() string {
+	return e.requestID
+}
+
+func (e PlatformError) Error() string {
+	return e.error.Error()
+}
+
+func (e PlatformError) Unwrap() error {
+	return e.error
+}
+
+// GetPlatformError returns the platform error err holds, looking through errors which wrap it
+func GetPlatformError(err error) (PlatformError, bool) {
+	for err != nil {
+		switch typedErr := err.(type) {
+		case PlatformError:
+			return typedErr, true
+		case interface{ Unwrap() error }:
+			err = typedErr.Unwrap()
+		case interface{ Cause() error }:
+			err = typedErr.Cause()
+		default:
+			return PlatformError{}, false
+		}
+	}
+
+	return PlatformError{}, false
+}
+
+// MultiError aggregates the errors of operations which failed independently of one another. errors may be
+// keyed by what failed (e.g. an item name), in which case the key prefixes the error's string
+type MultiError struct {
+	keys   []string
+	errors []error
+}
+
+func NewMultiError(errors []error) MultiError {
+	return MultiError{
+		errors: errors,
+	}
+}
+
+// NewKeyedMultiError returns a MultiError holding the non nil errors of errorsByKey, sorted by key
+func NewKeyedMultiError(errorsByKey map[string]error) MultiError {
+	keys := make([]string, 0, len(errorsByKey))
+	for key, err := range errorsByKey {
+		if err != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	errors := make([]error, len(keys))
+	for keyIdx, key := range keys {
+		errors[keyIdx] = errorsByKey[key]
+	}
+
+	return MultiError{
+		keys:   keys,
+		errors: errors,
+	}
+}
+
+// Errors returns the aggregated errors
+func (e MultiError) Errors() []error {
+	return e.errors
+}
+
+// FailedKeys returns the keys of the aggregated errors, or nil if they aren't keyed
+func (e MultiError) FailedKeys() []string {
+	return e.keys
+}
+
+// ErrorOf returns the error of a key, or nil if the key didn't fail
+func (e MultiError) ErrorOf(key string) error {
+	for keyIdx, failedKey := range e.keys {
+		if failedKey == key {
+			return e.errors[keyIdx]
+		}
+	}
+
+	return nil
+}
+
+// Unwrap returns the aggregated errors, so that errors.Is and errors.As match any of them
+func (e MultiError) Unwrap() []error {
+	return e.errors
+}
+
+func (e MultiError) Error() string {
+	errorStrings := make([]string, len(e.errors))
+	for errIdx, err := range e.errors {
+		errorStrings[errIdx] = err.Error()
+		if e.keys != nil {
+			errorStrings[errIdx] = e.keys[errIdx] + ": " + errorStrings[errIdx]
+		}
+	}
+
+	if len(errorStrings) == 1 {
+		return errorStrings[0]
+	}
+
+	return fmt.Sprintf("%d errors occurred: %s", len(e.errors), strings.Join(errorStrings, "; "))
+}
+
+// GetMultiError returns the multi error err holds, looking through errors which wrap it
+func GetMultiError(err error) (MultiError, bool) {
+	for err != nil {
+		switch typedErr := err.(type) {
+		case MultiError:
+			return typedErr, true
+		case interface{ Unwrap() error }:
+			err = typedErr.Unwrap()
+		case interface{ Cause() error }:
+			err = typedErr.Cause()
+		default:
+			return MultiError{}, false
+		}
+	}
+
+	return MultiError{}, false
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+
+package v3ioerrors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	nuclioerrors "github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+)
+
+type multiErrorSuite struct {
+	suite.Suite
+}
+
+func (suite *multiErrorSuite) TestKeyed() {
+	multiError := NewKeyedMultiError(map[string]error{
+		"b": ErrNotFound,
+		"a": ErrConflict,
+		"c": nil,
+	})
+
+	suite.Require().Equal([]string{"a", "b"}, multiError.FailedKeys())
+	suite.Require().Equal(ErrConflict, multiError.ErrorOf("a"))
+	suite.Require().Nil(multiError.ErrorOf("c"))
+	suite.Require().Equal("2 errors occurred: a: Conflict; b: Not found", multiError.Error())
+	suite.Require().True(errors.Is(multiError, ErrNotFound))
+	suite.Require().False(errors.Is(multiError, ErrTimeout))
+}
+
+func (suite *multiErrorSuite) TestGetMultiError() {
+	err := nuclioerrors.Wrap(NewMultiError([]error{ErrTimeout}), "Failed")
+
+	multiError, found := GetMultiError(err)
+	suite.Require().True(found)
+	suite.Require().Nil(multiError.FailedKeys())
+	suite.Require().Equal("Timed out", multiError.Error())
+
+	_, found = GetMultiError(ErrTimeout)
+	suite.Require().False(found)
+}
+
+func TestMultiErrorSuite(t *testing.T) {
+	suite.Run(t, new(multiErrorSuite))
+}
+
+type wrappingSuite struct {
+	suite.Suite
+}
+
+func (suite *wrappingSuite) TestErrorWithStatusCodeSentinels() {
+	var err error = NewErrorWithStatusCodeAndResponse(errors.New("Failed"), 404, nil)
+	suite.Require().True(errors.Is(err, ErrNotFound))
+	suite.Require().False(errors.Is(err, ErrConflict))
+
+	err = fmt.Errorf("Failed to get item: %w", NewErrorWithStatusCode(errors.New("Failed"), 412))
+	suite.Require().True(errors.Is(err, ErrPreconditionFailed))
+
+	var errWithStatusCode ErrorWithStatusCode
+	suite.Require().True(errors.As(err, &errWithStatusCode))
+	suite.Require().Equal(412, errWithStatusCode.StatusCode())
+
+	// unmapped status codes match no sentinel, but still match the error they wrap
+	errWithStatusCode = NewErrorWithStatusCode(ErrNotSupported, 501)
+	suite.Require().Nil(errWithStatusCode.Sentinel())
+	suite.Require().True(errors.Is(errWithStatusCode, ErrNotSupported))
+}
+
+func (suite *wrappingSuite) TestWrappedErrors() {
+	err := NewPlatformError(NewErrorWithStatusCode(errors.New("Failed"), 409), "Conflict", "", "", "")
+	suite.Require().True(errors.Is(err, ErrConflict))
+
+	limitErr := NewErrorWithLimit(errors.New("Too many"), "Records", 2000, 1000)
+	suite.Require().True(errors.Is(limitErr, ErrLimitExceeded))
+}
+
+func TestWrappingSuite(t *testing.T) {
+	suite.Run(t, new(wrappingSuite))
+}
+
+type classifySuite struct {
+	suite.Suite
+}
+
+func (suite *classifySuite) TestStatusCode() {
+	err := nuclioerrors.Wrap(NewErrorWithStatusCodeAndResponse(errors.New("busy"), 503, nil), "Failed")
+
+	statusCode, found := GetStatusCode(err)
+	suite.Require().True(found)
+	suite.Require().Equal(503, statusCode)
+	suite.Require().True(IsRetryable(err))
+	suite.Require().True(IsThrottle(err))
+	suite.Require().False(IsAuth(err))
+
+	err = NewErrorWithStatusCode(errors.New("forbidden"), 403)
+	suite.Require().False(IsRetryable(err))
+	suite.Require().False(IsThrottle(err))
+	suite.Require().True(IsAuth(err))
+
+	_, found = GetStatusCode(ErrNotFound)
+	suite.Require().False(found)
+}
+
+func (suite *classifySuite) TestTransportErrors() {
+	suite.Require().True(IsRetryable(nuclioerrors.Wrap(fasthttp.ErrConnectionClosed, "Failed")))
+	suite.Require().True(IsRetryable(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	suite.Require().False(IsRetryable(ErrInvalidInput))
+	suite.Require().False(IsRetryable(nil))
+}
+
+func TestClassifySuite(t *testing.T) {
+	suite.Run(t, new(classifySuite))
+}
@@ -0,0 +1,476 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iometrics
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/dataplane"
+	"github.com/v3io/v3io-go/pkg/dataplane/streamconsumergroup"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Handler serves the statistics of registered contexts and stream consumer groups
+// in the Prometheus text exposition format. the metrics of stream consumer groups require reading
+// the consumer group state from the server, so they're refreshed in the background and scrapes are
+// served the last values read
+type Handler struct {
+	logger               logger.Logger
+	refreshInterval      time.Duration
+	lock                 sync.Mutex
+	contexts             map[string]v3io.Context
+	streamConsumerGroups map[string]streamconsumergroup.StreamConsumerGroup
+	members              map[string]streamconsumergroup.Member
+
+	// the rendered metrics of stream consumer groups, as of the last refresh
+	streamConsumerGroupMetricsLock sync.Mutex
+	streamConsumerGroupMetrics     []byte
+
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	started     bool
+}
+
+// NewHandler creates a handler which refreshes the metrics of stream consumer groups every refreshInterval
+// (15 seconds if not set), once started
+func NewHandler(parentLogger logger.Logger, refreshInterval time.Duration) *Handler {
+	if refreshInterval <= 0 {
+		refreshInterval = 15 * time.Second
+	}
+
+	return &Handler{
+		logger:               parentLogger.GetChild("metrics"),
+		refreshInterval:      refreshInterval,
+		contexts:             map[string]v3io.Context{},
+		streamConsumerGroups: map[string]streamconsumergroup.StreamConsumerGroup{},
+		members:              map[string]streamconsumergroup.Member{},
+		stopChan:             make(chan struct{}),
+		stoppedChan:          make(chan struct{}),
+	}
+}
+
+// Start starts refreshing the metrics of stream consumer groups in the background
+func (h *Handler) Start() error {
+	h.startOnce.Do(func() {
+		h.started = true
+		go h.refreshPeriodically()
+	})
+
+	return nil
+}
+
+// Stop stops refreshing, waiting for the current refresh to end
+func (h *Handler) Stop() error {
+	h.stopOnce.Do(func() {
+		close(h.stopChan)
+	})
+
+	if h.started {
+		<-h.stoppedChan
+	}
+
+	return nil
+}
+
+// RegisterContext exposes the statistics of a context, labeled by the given name. contexts which don't
+// implement v3io.StatsContext are ignored
+func (h *Handler) RegisterContext(name string, context v3io.Context) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.contexts[name] = context
+}
+
+// RegisterStreamConsumerGroup exposes the committed sequence number and lag of each shard in a stream
+// consumer group, labeled by the given name
+func (h *Handler) RegisterStreamConsumerGroup(name string, streamConsumerGroup streamconsumergroup.StreamConsumerGroup) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.streamConsumerGroups[name] = streamConsumerGroup
+}
+
+// RegisterStreamConsumerGroupMember exposes the statistics of a stream consumer group member, labeled by the
+// given name
+func (h *Handler) RegisterStreamConsumerGroupMember(name string, member streamconsumergroup.Member) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.members[name] = member
+}
+
+// Register registers the handler on /metrics of the given mux
+func (h *Handler) Register(serveMux *http.ServeMux) {
+	serveMux.Handle("/metrics", h)
+}
+
+func (h *Handler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	writer := newWriter()
+
+	h.lock.Lock()
+	h.writeContextMetrics(writer)
+	h.lock.Unlock()
+
+	h.streamConsumerGroupMetricsLock.Lock()
+	writer.Write(h.streamConsumerGroupMetrics) // nolint: errcheck
+	h.streamConsumerGroupMetricsLock.Unlock()
+
+	h.lock.Lock()
+	h.writeStreamConsumerGroupMemberMetrics(writer)
+	h.lock.Unlock()
+
+	responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	responseWriter.Write(writer.Bytes()) // nolint: errcheck
+}
+
+func (h *Handler) refreshPeriodically() {
+	defer close(h.stoppedChan)
+
+	for {
+		h.refreshStreamConsumerGroupMetrics()
+
+		select {
+		case <-time.After(h.refreshInterval):
+		case <-h.stopChan:
+			return
+		}
+	}
+}
+
+// reads the state of the registered stream consumer groups and renders their metrics. registration isn't
+// blocked while reading
+func (h *Handler) refreshStreamConsumerGroupMetrics() {
+	h.lock.Lock()
+	streamConsumerGroups := make(map[string]streamconsumergroup.StreamConsumerGroup, len(h.streamConsumerGroups))
+	for name, streamConsumerGroup := range h.streamConsumerGroups {
+		streamConsumerGroups[name] = streamConsumerGroup
+	}
+	h.lock.Unlock()
+
+	writer := newWriter()
+	h.writeStreamConsumerGroupMetrics(writer, streamConsumerGroups)
+
+	h.streamConsumerGroupMetricsLock.Lock()
+	h.streamConsumerGroupMetrics = writer.Bytes()
+	h.streamConsumerGroupMetricsLock.Unlock()
+}
+
+func (h *Handler) writeContextMetrics(writer *writer) {
+	names := make([]string, 0, len(h.contexts))
+	stats := map[string]*v3io.ContextStats{}
+
+	for name, context := range h.contexts {
+		if statsContext, ok := context.(v3io.StatsContext); ok {
+			names = append(names, name)
+			stats[name] = statsContext.Stats()
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	sort.Strings(names)
+
+	for _, metric := range []struct {
+		name       string
+		help       string
+		metricType string
+		value      func(*v3io.ContextStats) float64
+	}{
+		{
+			name:       "v3io_context_workers",
+			help:       "Number of workers serving the request channel",
+			metricType: "gauge",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumWorkers) },
+		},
+		{
+			name:       "v3io_context_pending_requests",
+			help:       "Number of requests waiting in the request channel",
+			metricType: "gauge",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumPendingRequests) },
+		},
+		{
+			name:       "v3io_context_request_chan_capacity",
+			help:       "Capacity of the request channel",
+			metricType: "gauge",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.RequestChanCapacity) },
+		},
+		{
+			name:       "v3io_context_requests_total",
+			help:       "Number of requests sent to the server",
+			metricType: "counter",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumRequests) },
+		},
+		{
+			name:       "v3io_context_failed_requests_total",
+			help:       "Number of requests which failed",
+			metricType: "counter",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumFailedRequests) },
+		},
+		{
+			name:       "v3io_context_worker_panics_total",
+			help:       "Number of panics recovered while workers handled requests",
+			metricType: "counter",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumWorkerPanics) },
+		},
+		{
+			name:       "v3io_context_expired_requests_total",
+			help:       "Number of requests whose timeout expired while waiting for a worker",
+			metricType: "counter",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumExpiredRequests) },
+		},
+		{
+			name:       "v3io_context_pending_connection_acquisitions",
+			help:       "Number of requests waiting for a connection slot",
+			metricType: "gauge",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumPendingConnAcquisitions) },
+		},
+		{
+			name:       "v3io_context_connections_closed_on_error_total",
+			help:       "Number of connections closed after a read or write error",
+			metricType: "counter",
+			value:      func(cs *v3io.ContextStats) float64 { return float64(cs.NumConnsClosedOnError) },
+		},
+	} {
+		writer.writeHeader(metric.name, metric.help, metric.metricType)
+		for _, name := range names {
+			writer.writeSample(metric.name, []string{"context", name}, metric.value(stats[name]))
+		}
+	}
+
+	writer.writeHeader("v3io_context_open_connections", "Number of open connections, per host", "gauge")
+	for _, name := range names {
+		hosts := make([]string, 0, len(stats[name].NumOpenConnsByHost))
+		for host := range stats[name].NumOpenConnsByHost {
+			hosts = append(hosts, host)
+		}
+
+		sort.Strings(hosts)
+
+		for _, host := range hosts {
+			writer.writeSample("v3io_context_open_connections",
+				[]string{"context", name, "host", host},
+				float64(stats[name].NumOpenConnsByHost[host]))
+		}
+	}
+}
+
+func (h *Handler) writeStreamConsumerGroupMetrics(writer *writer,
+	streamConsumerGroups map[string]streamconsumergroup.StreamConsumerGroup) {
+	if len(streamConsumerGroups) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(streamConsumerGroups))
+	for name := range streamConsumerGroups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	writer.writeHeader("v3io_stream_consumer_group_committed_sequence_number",
+		"Last sequence number committed by the consumer group, per shard",
+		"gauge")
+
+	for _, name := range names {
+		streamConsumerGroup := streamConsumerGroups[name]
+
+		numShards, err := streamConsumerGroup.GetNumShards()
+		if err != nil {
+			h.logger.WarnWith("Failed getting number of shards", "consumerGroup", name, "err", err.Error())
+			continue
+		}
+
+		for shardID := 0; shardID < numShards; shardID++ {
+			sequenceNumber, err := streamConsumerGroup.GetShardSequenceNumber(shardID)
+			if err != nil {
+
+				// nothing was committed to this shard yet
+				if errors.RootCause(err) == streamconsumergroup.ErrShardNotFound ||
+					errors.RootCause(err) == streamconsumergroup.ErrShardSequenceNumberAttributeNotFound {
+					continue
+				}
+
+				h.logger.WarnWith("Failed getting shard sequence number",
+					"consumerGroup", name,
+					"shardID", shardID,
+					"err", err.Error())
+				continue
+			}
+
+			writer.writeSample("v3io_stream_consumer_group_committed_sequence_number",
+				[]string{"consumer_group", name, "shard", strconv.Itoa(shardID)},
+				float64(sequenceNumber))
+		}
+	}
+
+	writer.writeHeader("v3io_stream_consumer_group_lag",
+		"Number of records put after the sequence number committed by the consumer group, per shard",
+		"gauge")
+
+	for _, name := range names {
+		streamLag, err := streamConsumerGroups[name].GetStreamLag()
+		if err != nil {
+			h.logger.WarnWith("Failed getting stream lag", "consumerGroup", name, "err", err.Error())
+			continue
+		}
+
+		for _, shardLag := range streamLag.Shards {
+			writer.writeSample("v3io_stream_consumer_group_lag",
+				[]string{"consumer_group", name, "shard", strconv.Itoa(shardLag.ShardID)},
+				float64(shardLag.Lag))
+		}
+	}
+}
+
+func (h *Handler) writeStreamConsumerGroupMemberMetrics(writer *writer) {
+	if len(h.members) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(h.members))
+	stats := map[string]*streamconsumergroup.MemberStats{}
+
+	for name, member := range h.members {
+		names = append(names, name)
+		stats[name] = member.Stats()
+	}
+
+	sort.Strings(names)
+
+	for _, metric := range []struct {
+		name       string
+		help       string
+		metricType string
+		value      func(*streamconsumergroup.MemberStats) float64
+	}{
+		{
+			name:       "v3io_stream_consumer_group_member_assigned_shards",
+			help:       "Number of shards assigned to the member",
+			metricType: "gauge",
+			value:      func(ms *streamconsumergroup.MemberStats) float64 { return float64(len(ms.Shards)) },
+		},
+		{
+			name:       "v3io_stream_consumer_group_member_records_total",
+			help:       "Number of records delivered to the member's handler",
+			metricType: "counter",
+			value:      func(ms *streamconsumergroup.MemberStats) float64 { return float64(ms.NumRecordsConsumed) },
+		},
+		{
+			name:       "v3io_stream_consumer_group_member_commits_total",
+			help:       "Number of commits of marked sequence numbers",
+			metricType: "counter",
+			value:      func(ms *streamconsumergroup.MemberStats) float64 { return float64(ms.NumCommits) },
+		},
+		{
+			name:       "v3io_stream_consumer_group_member_commit_seconds_total",
+			help:       "Time spent committing marked sequence numbers",
+			metricType: "counter",
+			value:      func(ms *streamconsumergroup.MemberStats) float64 { return ms.CommitDuration.Seconds() },
+		},
+		{
+			name:       "v3io_stream_consumer_group_member_heartbeat_misses_total",
+			help:       "Number of heartbeats which failed to update the consumer group state",
+			metricType: "counter",
+			value:      func(ms *streamconsumergroup.MemberStats) float64 { return float64(ms.NumHeartbeatMisses) },
+		},
+	} {
+		writer.writeHeader(metric.name, metric.help, metric.metricType)
+		for _, name := range names {
+			writer.writeSample(metric.name,
+				[]string{"member", name, "member_id", stats[name].MemberID},
+				metric.value(stats[name]))
+		}
+	}
+}
+
+// writes metrics in the Prometheus text exposition format
+type writer struct {
+	bytes.Buffer
+}
+
+func newWriter() *writer {
+	return &writer{}
+}
+
+func (w *writer) writeHeader(name string, help string, metricType string) {
+	w.WriteString("# HELP ")
+	w.WriteString(name)
+	w.WriteString(" ")
+	w.WriteString(help)
+	w.WriteString("\n# TYPE ")
+	w.WriteString(name)
+	w.WriteString(" ")
+	w.WriteString(metricType)
+	w.WriteString("\n")
+}
+
+// labels are given as name, value pairs
+func (w *writer) writeSample(name string, labels []string, value float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 {
+		w.WriteString("{")
+		for labelIndex := 0; labelIndex+1 < len(labels); labelIndex += 2 {
+			if labelIndex > 0 {
+				w.WriteString(",")
+			}
+
+			w.WriteString(labels[labelIndex])
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(labels[labelIndex+1]))
+			w.WriteString(`"`)
+		}
+		w.WriteString("}")
+	}
+
+	w.WriteString(" ")
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteString("\n")
+}
+
+func escapeLabelValue(value string) string {
+	var escapedValue bytes.Buffer
+
+	for _, character := range value {
+		switch character {
+		case '\\':
+			escapedValue.WriteString(`\\`)
+		case '"':
+			escapedValue.WriteString(`\"`)
+		case '\n':
+			escapedValue.WriteString(`\n`)
+		default:
+			escapedValue.WriteRune(character)
+		}
+	}
+
+	return escapedValue.String()
+}
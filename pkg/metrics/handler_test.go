@@ -0,0 +1,183 @@
+/*
+Copyright 2019 Iguazio Systems Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License") with
+an addition restriction as set forth herein. You may not use this
+file except in compliance with the License. You may obtain a copy of
+the License at http://www.apache.org/licenses/LICENSE-2.0.
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+implied. See the License for the specific language governing
+permissions and limitations under the License.
+
+In addition, you may not use the software for any purposes that are
+illegal under applicable law, and the grant of the foregoing license
+under the Apache 2.0 license is conditioned upon your compliance with
+such restriction.
+*/
+package v3iometrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v3io/v3io-go/pkg/dataplane"
+	"github.com/v3io/v3io-go/pkg/dataplane/streamconsumergroup"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type writerSuite struct {
+	suite.Suite
+}
+
+func (suite *writerSuite) TestWriteSample() {
+	writer := newWriter()
+
+	writer.writeHeader("v3io_test", "Test metric", "gauge")
+	writer.writeSample("v3io_test", nil, 1)
+	writer.writeSample("v3io_test", []string{"context", "main", "shard", "3"}, 1.5)
+	writer.writeSample("v3io_test", []string{"context", `a "quoted"\name`}, 10000000)
+
+	suite.Require().Equal(`# HELP v3io_test Test metric
+# TYPE v3io_test gauge
+v3io_test 1
+v3io_test{context="main",shard="3"} 1.5
+v3io_test{context="a \"quoted\"\\name"} 1e+07
+`, writer.String())
+}
+
+func TestWriterSuite(t *testing.T) {
+	suite.Run(t, new(writerSuite))
+}
+
+// a consumer group with a single shard, which counts the reads of its state
+type countingStreamConsumerGroup struct {
+	streamconsumergroup.StreamConsumerGroup
+	lock     sync.Mutex
+	numReads int
+	lag      uint64
+}
+
+func (csg *countingStreamConsumerGroup) GetNumShards() (int, error) {
+	csg.lock.Lock()
+	defer csg.lock.Unlock()
+
+	csg.numReads++
+	return 1, nil
+}
+
+func (csg *countingStreamConsumerGroup) GetShardSequenceNumber(shardID int) (uint64, error) {
+	return 10, nil
+}
+
+func (csg *countingStreamConsumerGroup) GetStreamLag() (*streamconsumergroup.StreamLag, error) {
+	csg.lock.Lock()
+	defer csg.lock.Unlock()
+
+	return &streamconsumergroup.StreamLag{
+		Shards: []streamconsumergroup.ShardLag{{ShardID: 0, Lag: csg.lag}},
+	}, nil
+}
+
+func (csg *countingStreamConsumerGroup) setLag(lag uint64) {
+	csg.lock.Lock()
+	defer csg.lock.Unlock()
+
+	csg.lag = lag
+}
+
+func (csg *countingStreamConsumerGroup) getNumReads() int {
+	csg.lock.Lock()
+	defer csg.lock.Unlock()
+
+	return csg.numReads
+}
+
+type contextWithoutStats struct {
+	v3io.Context
+}
+
+type handlerSuite struct {
+	suite.Suite
+	logger              logger.Logger
+	streamConsumerGroup *countingStreamConsumerGroup
+}
+
+func (suite *handlerSuite) SetupTest() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.streamConsumerGroup = &countingStreamConsumerGroup{lag: 5}
+}
+
+func (suite *handlerSuite) TestScrapesServeCachedValues() {
+	handler := NewHandler(suite.logger, time.Hour)
+	handler.RegisterStreamConsumerGroup("group", suite.streamConsumerGroup)
+
+	suite.Require().NoError(handler.Start())
+	defer handler.Stop() // nolint: errcheck
+
+	suite.waitForMetrics(handler, `v3io_stream_consumer_group_lag{consumer_group="group",shard="0"} 5`)
+
+	// scrapes don't read the consumer group state
+	for scrapeIndex := 0; scrapeIndex < 10; scrapeIndex++ {
+		suite.Require().Contains(suite.scrape(handler),
+			`v3io_stream_consumer_group_committed_sequence_number{consumer_group="group",shard="0"} 10`)
+	}
+
+	suite.Require().Equal(1, suite.streamConsumerGroup.getNumReads())
+}
+
+func (suite *handlerSuite) TestRefresh() {
+	handler := NewHandler(suite.logger, 10*time.Millisecond)
+	handler.RegisterStreamConsumerGroup("group", suite.streamConsumerGroup)
+
+	suite.Require().NoError(handler.Start())
+	defer handler.Stop() // nolint: errcheck
+
+	suite.waitForMetrics(handler, `v3io_stream_consumer_group_lag{consumer_group="group",shard="0"} 5`)
+
+	suite.streamConsumerGroup.setLag(7)
+	suite.waitForMetrics(handler, `v3io_stream_consumer_group_lag{consumer_group="group",shard="0"} 7`)
+}
+
+func (suite *handlerSuite) TestContextsWithoutStatsAreIgnored() {
+	handler := NewHandler(suite.logger, time.Hour)
+
+	handler.RegisterContext("context", &contextWithoutStats{})
+
+	suite.Require().NotContains(suite.scrape(handler), "v3io_context_")
+}
+
+func (suite *handlerSuite) scrape(handler *Handler) string {
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	return responseRecorder.Body.String()
+}
+
+func (suite *handlerSuite) waitForMetrics(handler *Handler, sample string) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if strings.Contains(suite.scrape(handler), sample) {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	suite.Failf("Sample not served", "Expected %s", sample)
+}
+
+func TestHandlerSuite(t *testing.T) {
+	suite.Run(t, new(handlerSuite))
+}